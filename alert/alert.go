@@ -0,0 +1,170 @@
+// Package alert evaluates alerting rules against polled server state
+package alert
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// Sample is one poll of a server; Err is set when the poll failed
+type Sample struct {
+	Server string
+	State  *rcon.FullServerState
+	Err    error
+	At     time.Time
+}
+
+// Rule decides whether an alert is firing for a sample
+type Rule interface {
+	Name() string
+	Evaluate(s Sample) (firing bool, message string)
+}
+
+type Alert struct {
+	Rule       string    `json:"rule"`
+	Server     string    `json:"server"`
+	Message    string    `json:"message"`
+	FiredAt    time.Time `json:"fired_at"`
+	Resolved   bool      `json:"resolved"`
+	ResolvedAt time.Time `json:"resolved_at,omitempty"`
+}
+
+// RuleConfig binds a rule to its notification channels
+type RuleConfig struct {
+	Rule     Rule
+	Channels []Channel
+	// Cooldown is the minimum time between repeated notifications of a still firing alert
+	Cooldown time.Duration
+	// NoResolve skips the notification sent when the alert clears
+	NoResolve bool
+	// Suppress skips this rule while it reports true, e.g. a low-population rule outside
+	// prime time (alert.SuppressFunc(calendar.OffPeak))
+	Suppress Suppressor
+}
+
+// Suppressor reports whether automation for a server is paused, e.g. a maintenance.Schedule
+type Suppressor interface {
+	Suppressed(server string, t time.Time) bool
+}
+
+// SuppressFunc adapts a function to Suppressor
+type SuppressFunc func(server string, t time.Time) bool
+
+// Suppressed implements Suppressor
+func (f SuppressFunc) Suppressed(server string, t time.Time) bool { return f(server, t) }
+
+// Suppressors suppress while any of them does, e.g. maintenance windows and off-peak hours
+type Suppressors []Suppressor
+
+// Suppressed implements Suppressor
+func (ss Suppressors) Suppressed(server string, t time.Time) bool {
+	for _, s := range ss {
+		if s != nil && s.Suppressed(server, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Engine evaluates rules on every sample and notifies channels on state changes
+type Engine struct {
+	Rules   []RuleConfig
+	Timeout time.Duration
+	OnError func(Alert, error)
+	// Suppress skips evaluation entirely while a server is in maintenance
+	Suppress Suppressor
+
+	mu     sync.Mutex
+	active map[alertKey]*activeAlert
+}
+
+type alertKey struct {
+	rule   string
+	server string
+}
+
+type activeAlert struct {
+	alert    Alert
+	notified time.Time
+}
+
+// Observe evaluates every rule against a sample and sends the resulting notifications
+func (e *Engine) Observe(s Sample) {
+	if s.At.IsZero() {
+		s.At = time.Now()
+	}
+	if e.Suppress != nil && e.Suppress.Suppressed(s.Server, s.At) {
+		return
+	}
+	for _, rc := range e.Rules {
+		if rc.Suppress != nil && rc.Suppress.Suppressed(s.Server, s.At) {
+			continue
+		}
+		firing, msg := rc.Rule.Evaluate(s)
+		if a, ok := e.transition(rc, s, firing, msg); ok {
+			e.notify(rc, a)
+		}
+	}
+}
+
+// Active returns the alerts that are currently firing
+func (e *Engine) Active() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]Alert, 0, len(e.active))
+	for _, a := range e.active {
+		out = append(out, a.alert)
+	}
+	return out
+}
+
+// transition updates the alert state and reports whether a notification is due
+func (e *Engine) transition(rc RuleConfig, s Sample, firing bool, msg string) (Alert, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.active == nil {
+		e.active = map[alertKey]*activeAlert{}
+	}
+	key := alertKey{rule: rc.Rule.Name(), server: s.Server}
+	cur, ok := e.active[key]
+
+	switch {
+	case firing && !ok:
+		a := &activeAlert{alert: Alert{Rule: key.rule, Server: s.Server, Message: msg, FiredAt: s.At}, notified: s.At}
+		e.active[key] = a
+		return a.alert, true
+	case firing && ok:
+		cur.alert.Message = msg
+		if rc.Cooldown > 0 && s.At.Sub(cur.notified) >= rc.Cooldown {
+			cur.notified = s.At
+			return cur.alert, true
+		}
+	case !firing && ok:
+		delete(e.active, key)
+		if rc.NoResolve {
+			return Alert{}, false
+		}
+		a := cur.alert
+		a.Resolved, a.ResolvedAt = true, s.At
+		return a, true
+	}
+	return Alert{}, false
+}
+
+// notify delivers an alert to every channel of its rule
+func (e *Engine) notify(rc RuleConfig, a Alert) {
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	for _, ch := range rc.Channels {
+		if err := ch.Notify(ctx, a); err != nil && e.OnError != nil {
+			e.OnError(a, err)
+		}
+	}
+}
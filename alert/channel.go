@@ -0,0 +1,36 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/webhook"
+)
+
+// Channel delivers alert notifications
+type Channel interface {
+	Notify(ctx context.Context, a Alert) error
+}
+
+// ChannelFunc adapts a function to Channel
+type ChannelFunc func(ctx context.Context, a Alert) error
+
+// Notify implements Channel
+func (f ChannelFunc) Notify(ctx context.Context, a Alert) error { return f(ctx, a) }
+
+// WebhookChannel POSTs each alert as JSON to URL
+type WebhookChannel struct {
+	URL     string
+	Headers map[string]string
+	Client  *http.Client
+}
+
+// Notify implements Channel
+func (w *WebhookChannel) Notify(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return webhook.PostJSON(ctx, w.Client, w.URL, w.Headers, body)
+}
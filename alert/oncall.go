@@ -0,0 +1,160 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/webhook"
+)
+
+// dedupKey identifies an alert across its trigger and resolve calls
+func dedupKey(a Alert) string {
+	return "plutorcon:" + a.Rule + ":" + a.Server
+}
+
+// PagerDuty sends alerts to the PagerDuty Events API v2: a trigger when an alert fires
+// and a resolve with the same dedup key (rule and server) when it clears
+type PagerDuty struct {
+	// RoutingKey is the integration key of the service
+	RoutingKey string
+	// Severity is critical, error, warning or info (default error); SeverityFor overrides it per rule
+	Severity    string
+	SeverityFor map[string]string
+	// URL defaults to https://events.pagerduty.com/v2/enqueue
+	URL    string
+	Client *http.Client
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string         `json:"summary"`
+	Source        string         `json:"source"`
+	Severity      string         `json:"severity"`
+	Timestamp     time.Time      `json:"timestamp"`
+	Component     string         `json:"component"`
+	CustomDetails map[string]any `json:"custom_details,omitempty"`
+}
+
+// Notify implements Channel
+func (p *PagerDuty) Notify(ctx context.Context, a Alert) error {
+	if p.RoutingKey == "" {
+		return errors.New("pagerduty: missing routing key")
+	}
+	ev := pagerDutyEvent{RoutingKey: p.RoutingKey, EventAction: "resolve", DedupKey: dedupKey(a)}
+	if !a.Resolved {
+		severity := p.SeverityFor[a.Rule]
+		if severity == "" {
+			severity = p.Severity
+		}
+		if severity == "" {
+			severity = "error"
+		}
+		ev.EventAction = "trigger"
+		ev.Payload = &pagerDutyPayload{
+			Summary:   truncate(a.Server+": "+a.Message, 1024),
+			Source:    a.Server,
+			Severity:  severity,
+			Timestamp: a.FiredAt,
+			Component: a.Rule,
+			CustomDetails: map[string]any{
+				"rule": a.Rule, "server": a.Server, "message": a.Message,
+			},
+		}
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	u := p.URL
+	if u == "" {
+		u = "https://events.pagerduty.com/v2/enqueue"
+	}
+	return webhook.PostJSON(ctx, p.Client, u, nil, body)
+}
+
+// Opsgenie creates an Opsgenie alert when an alert fires and closes it when it clears,
+// using rule and server as the alert alias
+type Opsgenie struct {
+	APIKey string
+	// Priority is P1-P5 (default P3); PriorityFor overrides it per rule
+	Priority    string
+	PriorityFor map[string]string
+	// Responders are teams or users as Opsgenie expects them, e.g. {"type": "team", "name": "ops"}
+	Responders []map[string]string
+	Tags       []string
+	// BaseURL defaults to https://api.opsgenie.com (use https://api.eu.opsgenie.com for EU accounts)
+	BaseURL string
+	Client  *http.Client
+}
+
+type opsgenieAlert struct {
+	Message     string              `json:"message"`
+	Alias       string              `json:"alias"`
+	Description string              `json:"description,omitempty"`
+	Priority    string              `json:"priority"`
+	Source      string              `json:"source"`
+	Entity      string              `json:"entity,omitempty"`
+	Responders  []map[string]string `json:"responders,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Details     map[string]string   `json:"details,omitempty"`
+}
+
+// Notify implements Channel
+func (o *Opsgenie) Notify(ctx context.Context, a Alert) error {
+	if o.APIKey == "" {
+		return errors.New("opsgenie: missing api key")
+	}
+	base := strings.TrimSuffix(o.BaseURL, "/")
+	if base == "" {
+		base = "https://api.opsgenie.com"
+	}
+	headers := map[string]string{"Authorization": "GenieKey " + o.APIKey}
+	alias := dedupKey(a)
+
+	if a.Resolved {
+		body, _ := json.Marshal(map[string]string{"source": "plutorcon", "note": "resolved at " + a.ResolvedAt.Format(time.RFC3339)})
+		u := base + "/v2/alerts/" + url.PathEscape(alias) + "/close?identifierType=alias"
+		return webhook.PostJSON(ctx, o.Client, u, headers, body)
+	}
+	priority := o.PriorityFor[a.Rule]
+	if priority == "" {
+		priority = o.Priority
+	}
+	if priority == "" {
+		priority = "P3"
+	}
+	body, err := json.Marshal(opsgenieAlert{
+		Message:     truncate(a.Server+": "+a.Message, 130),
+		Alias:       alias,
+		Description: a.Message,
+		Priority:    priority,
+		Source:      "plutorcon",
+		Entity:      a.Server,
+		Responders:  o.Responders,
+		Tags:        o.Tags,
+		Details:     map[string]string{"rule": a.Rule, "server": a.Server, "fired_at": a.FiredAt.Format(time.RFC3339)},
+	})
+	if err != nil {
+		return err
+	}
+	return webhook.PostJSON(ctx, o.Client, base+"/v2/alerts", headers, body)
+}
+
+// truncate cuts s to at most n runes, the field limits of the on-call APIs
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,137 @@
+package alert
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+)
+
+// ServerOffline fires when polls have been failing for longer than For (default 2m)
+type ServerOffline struct {
+	For time.Duration
+
+	mu    sync.Mutex
+	since map[string]time.Time
+}
+
+func (r *ServerOffline) Name() string { return "server_offline" }
+
+// Evaluate implements Rule
+func (r *ServerOffline) Evaluate(s Sample) (bool, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.since == nil {
+		r.since = map[string]time.Time{}
+	}
+	if s.Err == nil {
+		delete(r.since, s.Server)
+		return false, ""
+	}
+	first, ok := r.since[s.Server]
+	if !ok {
+		first = s.At
+		r.since[s.Server] = first
+	}
+	d := r.For
+	if d <= 0 {
+		d = 2 * time.Minute
+	}
+	down := s.At.Sub(first)
+	if down < d {
+		return false, ""
+	}
+	return true, i18n.Sprintf("%s offline for %s: %v", s.Server, down.Round(time.Second), s.Err)
+}
+
+// EmptyDuring fires when a server has no players inside a daily time window
+type EmptyDuring struct {
+	// Start and End are offsets from midnight, e.g. 18h and 23h; End < Start wraps past midnight
+	Start    time.Duration
+	End      time.Duration
+	Location *time.Location
+	// PrimeTime, when set, replaces the window per server, e.g. primetime.Calendar.PrimeTime
+	PrimeTime func(server string, t time.Time) bool
+}
+
+func (r *EmptyDuring) Name() string { return "empty_prime_time" }
+
+// Evaluate implements Rule
+func (r *EmptyDuring) Evaluate(s Sample) (bool, string) {
+	if s.Err != nil || s.State == nil || s.State.Status == nil {
+		return false, ""
+	}
+	if r.PrimeTime != nil {
+		if !r.PrimeTime(s.Server, s.At) {
+			return false, ""
+		}
+	} else if !inDailyWindow(s.At, r.Start, r.End, r.Location) {
+		return false, ""
+	}
+	if len(s.State.Status.Players) > 0 {
+		return false, ""
+	}
+	return true, i18n.Sprintf("%s has no players during prime time", s.Server)
+}
+
+// PingP95Above fires when the 95th percentile of player pings exceeds Threshold
+type PingP95Above struct {
+	Threshold int
+	// MinPlayers is the number of pinged players needed before evaluating (default 1)
+	MinPlayers int
+}
+
+func (r *PingP95Above) Name() string { return "ping_p95" }
+
+// Evaluate implements Rule
+func (r *PingP95Above) Evaluate(s Sample) (bool, string) {
+	if s.Err != nil || s.State == nil || s.State.Status == nil {
+		return false, ""
+	}
+	var pings []int
+	for _, p := range s.State.Status.Players {
+		if !p.Loading && !p.Zombie {
+			pings = append(pings, p.Ping)
+		}
+	}
+	minPlayers := r.MinPlayers
+	if minPlayers <= 0 {
+		minPlayers = 1
+	}
+	if len(pings) < minPlayers {
+		return false, ""
+	}
+	p95 := percentile(pings, 0.95)
+	if p95 <= r.Threshold {
+		return false, ""
+	}
+	return true, i18n.Sprintf("%s ping p95 is %dms (threshold %dms)", s.Server, p95, r.Threshold)
+}
+
+// percentile returns the nearest-rank percentile of values
+func percentile(values []int, p float64) int {
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	idx := int(float64(len(sorted))*p+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+// inDailyWindow reports whether t falls between start and end offsets from local midnight
+func inDailyWindow(t time.Time, start, end time.Duration, loc *time.Location) bool {
+	if loc != nil {
+		t = t.In(loc)
+	}
+	y, m, d := t.Date()
+	offset := t.Sub(time.Date(y, m, d, 0, 0, 0, 0, t.Location()))
+	if start <= end {
+		return offset >= start && offset < end
+	}
+	return offset >= start || offset < end
+}
@@ -0,0 +1,230 @@
+// Package archive uploads rotated event logs, status history and incident bundles to
+// S3-compatible object storage and expires them after a retention period
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Source is a local directory whose finished files are archived
+type Source struct {
+	Dir string
+	// Pattern selects files by name (default *)
+	Pattern string
+	// Prefix is prepended to the object keys, e.g. "events/"
+	Prefix string
+	// Retention deletes archived objects under Prefix that match Pattern and are older
+	// than this (0 = keep forever). It needs a Prefix, so it can't reach objects other
+	// sources or tools keep in the bucket
+	Retention time.Duration
+	// KeepLocal leaves uploaded files in Dir; otherwise they are removed after upload
+	KeepLocal bool
+}
+
+// Archiver periodically uploads finished files of its sources. Files a Log is still
+// writing (.active) and files changed within MinAge are left for the next run
+type Archiver struct {
+	Store   *S3
+	Sources []Source
+	// Interval between runs (default 10m)
+	Interval time.Duration
+	// MinAge is how long a file must be unchanged before upload (default 1m)
+	MinAge  time.Duration
+	OnError func(error)
+}
+
+// uploadedFile is the state file kept in a KeepLocal source, so files go up once
+const uploadedFile = ".archived.json"
+
+// Run syncs every Interval until ctx is done
+func (a *Archiver) Run(ctx context.Context) error {
+	every := a.Interval
+	if every <= 0 {
+		every = 10 * time.Minute
+	}
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		if err := a.Sync(ctx); err != nil && a.OnError != nil {
+			a.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Sync uploads pending files and applies retention once. It keeps going past failures
+// and returns them joined
+func (a *Archiver) Sync(ctx context.Context) error {
+	if a.Store == nil {
+		return errors.New("archiver has no store")
+	}
+	var errs []error
+	for _, src := range a.Sources {
+		if err := a.upload(ctx, src); err != nil {
+			errs = append(errs, fmt.Errorf("archive %s: %w", src.Dir, err))
+		}
+		if src.Retention > 0 {
+			if err := a.expire(ctx, src); err != nil {
+				errs = append(errs, fmt.Errorf("archive retention %s: %w", src.Prefix, err))
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a *Archiver) upload(ctx context.Context, src Source) error {
+	pattern := src.Pattern
+	if pattern == "" {
+		pattern = "*"
+	}
+	matches, err := filepath.Glob(filepath.Join(src.Dir, pattern))
+	if err != nil {
+		return err
+	}
+	minAge := a.MinAge
+	if minAge <= 0 {
+		minAge = time.Minute
+	}
+	var done map[string]time.Time
+	if src.KeepLocal {
+		if done, err = readUploaded(src.Dir); err != nil {
+			return err
+		}
+	}
+	var errs []error
+	changed := false
+	for _, p := range matches {
+		name := filepath.Base(p)
+		if strings.HasSuffix(name, activeSuffix) || name == uploadedFile || strings.HasPrefix(name, ".") {
+			continue
+		}
+		fi, err := os.Stat(p)
+		if err != nil || !fi.Mode().IsRegular() || time.Since(fi.ModTime()) < minAge {
+			continue
+		}
+		if at, ok := done[name]; ok && at.Equal(fi.ModTime()) {
+			continue
+		}
+		body, err := os.ReadFile(p)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := a.Store.Put(ctx, src.Prefix+name, body, contentType(name)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		if src.KeepLocal {
+			done[name] = fi.ModTime()
+			changed = true
+		} else if err := os.Remove(p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if changed {
+		// forget files that are gone so the state file doesn't grow forever
+		for name := range done {
+			if _, err := os.Stat(filepath.Join(src.Dir, name)); errors.Is(err, os.ErrNotExist) {
+				delete(done, name)
+			}
+		}
+		if err := writeUploaded(src.Dir, done); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// expire deletes the old objects this source uploaded: keys right under Prefix whose
+// name matches Pattern
+func (a *Archiver) expire(ctx context.Context, src Source) error {
+	if src.Prefix == "" {
+		return errors.New("retention needs a Prefix")
+	}
+	pattern := src.Pattern
+	if pattern == "" {
+		pattern = "*"
+	}
+	objs, err := a.Store.List(ctx, src.Prefix)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-src.Retention)
+	var errs []error
+	for _, o := range objs {
+		name, ok := strings.CutPrefix(o.Key, src.Prefix)
+		if !ok || strings.Contains(name, "/") {
+			continue
+		}
+		if match, _ := path.Match(pattern, name); !match {
+			continue
+		}
+		if o.LastModified.Before(cutoff) {
+			if err := a.Store.Delete(ctx, o.Key); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", o.Key, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func contentType(name string) string {
+	switch path.Ext(name) {
+	case ".ndjson", ".jsonl":
+		return "application/x-ndjson"
+	case ".json":
+		return "application/json"
+	case ".zip":
+		return "application/zip"
+	case ".gz":
+		return "application/gzip"
+	case ".txt", ".log":
+		return "text/plain; charset=utf-8"
+	}
+	return "application/octet-stream"
+}
+
+func readUploaded(dir string) (map[string]time.Time, error) {
+	done := map[string]time.Time{}
+	data, err := os.ReadFile(filepath.Join(dir, uploadedFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &done); err != nil {
+		return nil, fmt.Errorf("%s: %w", uploadedFile, err)
+	}
+	return done, nil
+}
+
+func writeUploaded(dir string, done map[string]time.Time) error {
+	data, err := json.MarshalIndent(done, "", "  ")
+	if err != nil {
+		return err
+	}
+	p := filepath.Join(dir, uploadedFile)
+	if err := os.WriteFile(p+".tmp", data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(p+".tmp", p)
+}
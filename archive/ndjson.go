@@ -0,0 +1,162 @@
+package archive
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/events"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// activeSuffix marks the file a Log is still writing; Archiver skips it
+const activeSuffix = ".active"
+
+// Log writes JSON lines into Dir, starting a new <Name>-<time>.ndjson file every Rotate.
+// The file being written carries an extra .active suffix until it is rotated out
+type Log struct {
+	Dir  string
+	Name string
+	// Rotate defaults to an hour
+	Rotate time.Duration
+
+	mu     sync.Mutex
+	f      *os.File
+	w      *bufio.Writer
+	path   string
+	opened time.Time
+}
+
+// Write appends one value as a JSON line
+func (l *Log) Write(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if l.f != nil && now.Sub(l.opened) >= l.rotate() {
+		if err := l.closeLocked(); err != nil {
+			return err
+		}
+	}
+	if l.f == nil {
+		if err := l.openLocked(now); err != nil {
+			return err
+		}
+	}
+	l.w.Write(append(data, '\n'))
+	return l.w.Flush()
+}
+
+func (l *Log) rotate() time.Duration {
+	if l.Rotate <= 0 {
+		return time.Hour
+	}
+	return l.Rotate
+}
+
+func (l *Log) openLocked(now time.Time) error {
+	if err := os.MkdirAll(l.Dir, 0o755); err != nil {
+		return err
+	}
+	name := l.Name
+	if name == "" {
+		name = "log"
+	}
+	stamp := name + "-" + now.UTC().Format("20060102T150405Z")
+	l.path = filepath.Join(l.Dir, stamp+".ndjson")
+	// a second file within the same second must not replace the first
+	for i := 2; exists(l.path) || exists(l.path+activeSuffix); i++ {
+		l.path = filepath.Join(l.Dir, fmt.Sprintf("%s-%d.ndjson", stamp, i))
+	}
+	f, err := os.OpenFile(l.path+activeSuffix, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	l.f, l.w, l.opened = f, bufio.NewWriter(f), now
+	return nil
+}
+
+func exists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
+
+// closeLocked finishes the current file and gives it its final name
+func (l *Log) closeLocked() error {
+	if l.f == nil {
+		return nil
+	}
+	err := l.w.Flush()
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	l.f, l.w = nil, nil
+	if rerr := os.Rename(l.path+activeSuffix, l.path); err == nil {
+		err = rerr
+	}
+	return err
+}
+
+// Close finishes the current file so it can be archived
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closeLocked()
+}
+
+// eventLine is how Follow writes events
+type eventLine struct {
+	Server string       `json:"server"`
+	Type   string       `json:"type"`
+	Event  events.Event `json:"event"`
+}
+
+// Follow writes the events of a stream until ctx is done or the stream closes
+func (l *Log) Follow(ctx context.Context, server string, s *events.Stream, onError func(error)) {
+	evs, cancel := s.Subscribe(256)
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-evs:
+			if !ok {
+				return
+			}
+			name := reflect.TypeOf(ev).String()
+			name = name[strings.LastIndex(name, ".")+1:]
+			if err := l.Write(eventLine{Server: server, Type: name, Event: ev}); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// statusLine is how Attach writes status polls
+type statusLine struct {
+	Server string             `json:"server"`
+	Status *rcon.ServerStatus `json:"status"`
+}
+
+// Attach writes every successful poll of a Monitor, keeping an OnStatus that is already set
+func (l *Log) Attach(server string, m *rcon.Monitor, onError func(error)) {
+	prev := m.OnStatus
+	m.OnStatus = func(st *rcon.ServerStatus) {
+		if prev != nil {
+			prev(st)
+		}
+		if err := l.Write(statusLine{Server: server, Status: st}); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
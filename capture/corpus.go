@@ -0,0 +1,167 @@
+package capture
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"sort"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// Corpus holds the bundled conformance cases, one directory per title
+//
+//go:embed corpus
+var Corpus embed.FS
+
+// Case is one captured reply with the output the parsers are expected to produce.
+// Datagrams get the OOB header prepended unless Raw is set, so cases stay readable
+type Case struct {
+	Description string          `json:"description,omitempty"`
+	Game        string          `json:"game"`
+	As          string          `json:"as"`
+	Raw         bool            `json:"raw,omitempty"`
+	Datagrams   []string        `json:"datagrams"`
+	Truncated   bool            `json:"truncated,omitempty"`
+	Expected    json.RawMessage `json:"expected"`
+}
+
+// CaseResult is the outcome of running one case
+type CaseResult struct {
+	Path string
+	Got  json.RawMessage
+	Err  error
+}
+
+// Run parses the case's datagrams and returns the comparable output
+func (c *Case) Run() (json.RawMessage, bool, error) {
+	g, err := rcon.ParseGame(c.Game)
+	if err != nil {
+		return nil, false, err
+	}
+	cmd := c.As
+	switch c.As {
+	case "info":
+		cmd = "getinfo"
+	case "serverstatus":
+		cmd = "getstatus"
+	}
+	ex := &Exchange{Command: cmd}
+	for _, d := range c.Datagrams {
+		if c.Raw {
+			ex.Replies = append(ex.Replies, []byte(d))
+			continue
+		}
+		ex.Replies = append(ex.Replies, append(append([]byte{}, wire.OOBHeader...), d...))
+	}
+	parse(ex, g)
+	if ex.Err != nil {
+		return nil, ex.Truncated, ex.Err
+	}
+	out, err := comparable(ex.Parsed)
+	return out, ex.Truncated, err
+}
+
+// Check runs the case and compares it with the expected output
+func (c *Case) Check() (json.RawMessage, error) {
+	got, truncated, err := c.Run()
+	if err != nil {
+		return nil, err
+	}
+	if truncated != c.Truncated {
+		return got, fmt.Errorf("truncated = %t, want %t", truncated, c.Truncated)
+	}
+	var want, have any
+	if err := json.Unmarshal(c.Expected, &want); err != nil {
+		return got, fmt.Errorf("bad expected output: %w", err)
+	}
+	if err := json.Unmarshal(got, &have); err != nil {
+		return got, err
+	}
+	if !reflect.DeepEqual(want, have) {
+		return got, fmt.Errorf("parsed output differs from expected")
+	}
+	return got, nil
+}
+
+// RunCorpus checks every *.json case under fsys, sorted by path
+func RunCorpus(fsys fs.FS) ([]CaseResult, error) {
+	var paths []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && path.Ext(p) == ".json" {
+			paths = append(paths, p)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	results := make([]CaseResult, 0, len(paths))
+	for _, p := range paths {
+		res := CaseResult{Path: p}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			res.Err = err
+		} else {
+			var c Case
+			if err := json.Unmarshal(data, &c); err != nil {
+				res.Err = fmt.Errorf("bad case file: %w", err)
+			} else {
+				res.Got, res.Err = c.Check()
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// NewCase builds a case from captured reply datagrams, recording the current parser
+// output as expected so a contributor only has to review and correct it
+func NewCase(game rcon.Game, as, description string, datagrams [][]byte) (*Case, error) {
+	c := &Case{Description: description, Game: game.String(), As: as}
+	for _, d := range datagrams {
+		if !bytes.HasPrefix(d, wire.OOBHeader) {
+			c.Raw = true
+		}
+	}
+	for _, d := range datagrams {
+		if c.Raw {
+			c.Datagrams = append(c.Datagrams, string(d))
+		} else {
+			c.Datagrams = append(c.Datagrams, string(d[len(wire.OOBHeader):]))
+		}
+	}
+	got, truncated, err := c.Run()
+	if err != nil {
+		return nil, err
+	}
+	c.Expected, c.Truncated = got, truncated
+	return c, nil
+}
+
+// comparable marshals parsed output without the fields that change on every run
+func comparable(v any) (json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	if obj, ok := m.(map[string]any); ok {
+		for k := range obj {
+			if k == "retrieved_at" || k == "raw" {
+				delete(obj, k)
+			}
+		}
+	}
+	return json.MarshalIndent(m, "", "  ")
+}
@@ -0,0 +1,82 @@
+package capture
+
+import (
+	"encoding/json"
+	"io/fs"
+	"path"
+	"testing"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+func TestCorpus(t *testing.T) {
+	results, err := RunCorpus(Corpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) == 0 {
+		t.Fatal("corpus has no cases")
+	}
+	for _, res := range results {
+		t.Run(res.Path, func(t *testing.T) {
+			if res.Err != nil {
+				t.Errorf("%v\ngot:\n%s", res.Err, res.Got)
+			}
+		})
+	}
+}
+
+// Every title the corpus covers has a directory named after its game
+func TestCorpusLayout(t *testing.T) {
+	entries, err := fs.ReadDir(Corpus, "corpus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			t.Errorf("corpus/%s: cases belong in a title directory", e.Name())
+			continue
+		}
+		if _, err := rcon.ParseGame(e.Name()); err != nil {
+			t.Errorf("corpus/%s: %v", e.Name(), err)
+		}
+		cases, _ := fs.Glob(Corpus, path.Join("corpus", e.Name(), "*.json"))
+		for _, p := range cases {
+			data, _ := fs.ReadFile(Corpus, p)
+			var c Case
+			if err := json.Unmarshal(data, &c); err != nil {
+				t.Errorf("%s: %v", p, err)
+				continue
+			}
+			if g, _ := rcon.ParseGame(c.Game); g.String() != e.Name() {
+				t.Errorf("%s: game %q in the %s directory", p, c.Game, e.Name())
+			}
+		}
+	}
+}
+
+func TestNewCase(t *testing.T) {
+	reply := []byte("\xFF\xFF\xFF\xFFprint\n\"g_speed\" is:\"190^7\" default:\"190^7\"\n")
+	c, err := NewCase(rcon.GameT6, "g_speed", "speed", [][]byte{reply})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Raw {
+		t.Error("an OOB reply was stored raw")
+	}
+	if _, err := c.Check(); err != nil {
+		t.Errorf("a new case fails its own check: %v", err)
+	}
+	var got DvarValue
+	if err := json.Unmarshal(c.Expected, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != (DvarValue{Name: "g_speed", Value: "190"}) {
+		t.Errorf("expected = %+v", got)
+	}
+
+	c.Expected = json.RawMessage(`{"name":"g_speed","value":"200"}`)
+	if _, err := c.Check(); err == nil {
+		t.Error("a wrong expected output passed the check")
+	}
+}
@@ -0,0 +1,159 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+	"github.com/Yallamaztar/PlutoRCON/v2/query"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// Exchange is a request and every reply datagram the server sent back for it
+type Exchange struct {
+	At      time.Time
+	Client  netip.AddrPort
+	Server  netip.AddrPort
+	Request string
+	// Command is the rcon command (or getinfo/getstatus) with the password left out
+	Command string
+	Replies [][]byte
+	// Lines is what the client would see after reassembling and normalizing the replies
+	Lines     []string
+	Truncated bool
+	// Dropped counts reply datagrams of another type that the client would have ignored
+	Dropped int
+	Parsed  any
+	Err     error
+}
+
+// Decode pairs requests with their replies and runs them through the normal parsers.
+// Parsed holds a *rcon.ServerStatus for status, a *query.ServerInfo or *query.ServerStatusInfo
+// for queries, and a DvarValue for single-word commands that read back as a dvar
+func Decode(dgrams []Datagram, game rcon.Game) []*Exchange {
+	var out []*Exchange
+	open := map[netip.AddrPort]*Exchange{}
+	for _, d := range dgrams {
+		if cmd, ok := requestCommand(d.Payload); ok {
+			ex := &Exchange{At: d.At, Client: d.Src, Server: d.Dst, Request: redact(d.Payload), Command: cmd}
+			open[d.Src] = ex
+			out = append(out, ex)
+			continue
+		}
+		if ex := open[d.Dst]; ex != nil && ex.Server == d.Src {
+			ex.Replies = append(ex.Replies, d.Payload)
+		}
+	}
+	for _, ex := range out {
+		parse(ex, game)
+	}
+	return out
+}
+
+// DvarValue is a dvar read back from a reply
+type DvarValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// DecodeReply normalizes one raw reply payload and parses it as status, info,
+// serverstatus or a dvar name
+func DecodeReply(payload []byte, as string, game rcon.Game) *Exchange {
+	cmd := as
+	switch as {
+	case "info":
+		cmd = "getinfo"
+	case "serverstatus":
+		cmd = "getstatus"
+	}
+	ex := &Exchange{Command: cmd, Replies: [][]byte{payload}}
+	parse(ex, game)
+	return ex
+}
+
+func parse(ex *Exchange, game rcon.Game) {
+	word, _, _ := strings.Cut(ex.Command, " ")
+	a := &wire.Assembler{Expect: "print"}
+	if t := wire.ReplyType(word); t != "" {
+		a.Expect = t
+	}
+	for _, r := range ex.Replies {
+		a.Add(r)
+	}
+	res := a.Response()
+	ex.Truncated, ex.Dropped = res.Truncated, res.Dropped
+	ex.Lines = wire.SplitLines(wire.Normalize(string(res.Data)))
+
+	switch {
+	case word == "getinfo":
+		ex.Parsed, ex.Err = query.ParseInfoResponseKeys(ex.Lines, rcon.Profile(game).InfoKeys)
+	case word == "getstatus":
+		ex.Parsed, ex.Err = query.ParseStatusResponse(ex.Lines)
+	case strings.EqualFold(word, "status"):
+		st := rcon.ParseStatus(ex.Lines, game)
+		st.Truncated = ex.Truncated
+		ex.Parsed = st
+	case !strings.Contains(ex.Command, " "):
+		if v, ok := rcon.ParseDvar(word, ex.Lines); ok {
+			ex.Parsed = DvarValue{Name: word, Value: v}
+		}
+	}
+}
+
+// requestCommand recognizes client requests and returns the command without the password
+func requestCommand(p []byte) (string, bool) {
+	if !bytes.HasPrefix(p, wire.OOBHeader) {
+		return "", false
+	}
+	s := strings.TrimSpace(string(p[len(wire.OOBHeader):]))
+	word, rest, _ := strings.Cut(s, " ")
+	switch strings.ToLower(word) {
+	case "rcon":
+		_, cmd, _ := strings.Cut(rest, " ")
+		return strings.TrimSpace(cmd), true
+	case "getinfo", "getstatus":
+		return strings.ToLower(word), true
+	}
+	return "", false
+}
+
+// redact hides the rcon password of a request
+func redact(p []byte) string {
+	s := strings.TrimSpace(string(bytes.TrimPrefix(p, wire.OOBHeader)))
+	word, rest, _ := strings.Cut(s, " ")
+	if !strings.EqualFold(word, "rcon") {
+		return s
+	}
+	_, cmd, _ := strings.Cut(rest, " ")
+	return "rcon ****** " + cmd
+}
+
+// Redact returns a copy of d with its password masked, for sharing captures: the
+// password of a Quake3 rcon request or of a BattlEye login. ok is false for payloads
+// of neither protocol, which may carry a password in a form Redact can't find and
+// should be dropped
+func Redact(d Datagram) (_ Datagram, ok bool) {
+	if bytes.HasPrefix(d.Payload, wire.OOBHeader) {
+		if s := redact(d.Payload); strings.HasPrefix(s, "rcon ****** ") {
+			d.Payload = wire.Packet(s)
+		}
+		return d, true
+	}
+	// BattlEye: "BE", CRC32, 0xFF, type, payload. A login from the client carries the
+	// password; the server's answer is a single status byte
+	p := d.Payload
+	if len(p) < 8 || p[0] != 'B' || p[1] != 'E' || p[6] != 0xFF || crc32.ChecksumIEEE(p[6:]) != binary.LittleEndian.Uint32(p[2:6]) {
+		return d, false
+	}
+	if body := p[8:]; p[7] == 0x00 && !(len(body) == 1 && body[0] <= 1) {
+		rest := append([]byte{0xFF, 0x00}, "******"...)
+		pkt := []byte{'B', 'E', 0, 0, 0, 0}
+		binary.LittleEndian.PutUint32(pkt[2:6], crc32.ChecksumIEEE(rest))
+		d.Payload = append(pkt, rest...)
+	}
+	return d, true
+}
@@ -0,0 +1,83 @@
+package capture
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// bePacket frames a BattlEye packet the way rcon's codec does
+func bePacket(typ byte, payload string) []byte {
+	rest := append([]byte{0xFF, typ}, payload...)
+	pkt := []byte{'B', 'E', 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(pkt[2:6], crc32.ChecksumIEEE(rest))
+	return append(pkt, rest...)
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		ok      bool
+		hidden  string
+		kept    string
+	}{
+		{"rcon", []byte("\xFF\xFF\xFF\xFFrcon hunter2 kick bob\n"), true, "hunter2", "rcon ****** kick bob"},
+		{"getinfo", []byte("\xFF\xFF\xFF\xFFgetinfo abc\n"), true, "", "getinfo abc"},
+		{"print reply", []byte("\xFF\xFF\xFF\xFFprint\nmap: mp_raid\n"), true, "", "map: mp_raid"},
+		{"battleye login", bePacket(0x00, "hunter2"), true, "hunter2", "******"},
+		{"battleye one character login", bePacket(0x00, "x"), true, "x", "******"},
+		{"battleye login reply", bePacket(0x00, "\x01"), true, "", "\x01"},
+		{"battleye command", bePacket(0x01, "\x00players"), true, "", "players"},
+		{"battleye bad checksum", append(bePacket(0x00, "hunter2")[:2], append([]byte{1, 2, 3, 4}, bePacket(0x00, "hunter2")[6:]...)...), false, "", ""},
+		{"other protocol", []byte("hunter2"), false, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, ok := Redact(Datagram{Payload: tt.payload})
+			if ok != tt.ok {
+				t.Fatalf("ok = %t, want %t", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			got := string(d.Payload)
+			if tt.hidden != "" && strings.Contains(got, tt.hidden) {
+				t.Errorf("payload %q still holds %q", got, tt.hidden)
+			}
+			if !strings.Contains(got, tt.kept) {
+				t.Errorf("payload %q lost %q", got, tt.kept)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	client := netip.MustParseAddrPort("10.0.0.2:50000")
+	server := netip.MustParseAddrPort("10.0.0.1:28960")
+	other := netip.MustParseAddrPort("10.0.0.9:28960")
+	dgrams := []Datagram{
+		{Src: client, Dst: server, Payload: []byte("\xFF\xFF\xFF\xFFrcon hunter2 g_speed\n")},
+		{Src: other, Dst: client, Payload: []byte("\xFF\xFF\xFF\xFFprint\n\"g_speed\" is:\"999^7\"\n")},
+		{Src: server, Dst: client, Payload: []byte("\xFF\xFF\xFF\xFFprint\n\"g_speed\" is:\"190^7\" default:\"190^7\"\n")},
+		{Src: client, Dst: server, Payload: []byte("\xFF\xFF\xFF\xFFgetinfo xyz\n")},
+		{Src: server, Dst: client, Payload: []byte("\xFF\xFF\xFF\xFFinfoResponse\n\\hostname\\test\\mapname\\mp_raid\\clients\\3\\sv_maxclients\\18\n")},
+	}
+	exs := Decode(dgrams, rcon.GameT6)
+	if len(exs) != 2 {
+		t.Fatalf("got %d exchanges, want 2", len(exs))
+	}
+	if exs[0].Request != "rcon ****** g_speed" || exs[0].Command != "g_speed" {
+		t.Errorf("request %q, command %q", exs[0].Request, exs[0].Command)
+	}
+	if v, ok := exs[0].Parsed.(DvarValue); !ok || v.Value != "190" {
+		t.Errorf("dvar parsed as %#v, a reply from another host leaked in", exs[0].Parsed)
+	}
+	if exs[1].Err != nil || exs[1].Parsed == nil {
+		t.Errorf("getinfo: %v, %#v", exs[1].Err, exs[1].Parsed)
+	}
+}
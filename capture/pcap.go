@@ -0,0 +1,240 @@
+// Package capture decodes recorded RCON traffic with the library's own parsers
+package capture
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"time"
+)
+
+// Datagram is one UDP payload from a capture
+type Datagram struct {
+	At      time.Time
+	Src     netip.AddrPort
+	Dst     netip.AddrPort
+	Payload []byte
+}
+
+var errNotPcap = errors.New("not a pcap file")
+
+// IsPcapError reports whether ReadPcap failed on a real pcap file rather than
+// because the input isn't one
+func IsPcapError(err error) bool {
+	return err != nil && !errors.Is(err, errNotPcap)
+}
+
+const (
+	linkNull     = 0
+	linkEthernet = 1
+	linkRaw      = 101
+	linkRawAlt   = 12
+	linkLinuxSLL = 113
+	linkSLL2     = 276
+)
+
+// ReadPcap reads the UDP datagrams of a classic libpcap capture (not pcapng).
+// Ethernet, Linux cooked, loopback and raw IP link types are supported; fragmented
+// IPv4 packets and IPv6 extension headers are skipped. A capture cut off inside a
+// record returns the datagrams before it with io.ErrUnexpectedEOF
+func ReadPcap(r io.Reader) ([]Datagram, error) {
+	var hdr [24]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, errNotPcap
+	}
+	var order binary.ByteOrder
+	nano := false
+	switch binary.LittleEndian.Uint32(hdr[:4]) {
+	case 0xa1b2c3d4:
+		order = binary.LittleEndian
+	case 0xa1b23c4d:
+		order, nano = binary.LittleEndian, true
+	case 0xd4c3b2a1:
+		order = binary.BigEndian
+	case 0x4d3cb2a1:
+		order, nano = binary.BigEndian, true
+	case 0x0a0d0d0a:
+		return nil, fmt.Errorf("pcapng is not supported, convert with: editcap -F pcap in.pcapng out.pcap")
+	default:
+		return nil, errNotPcap
+	}
+	link := order.Uint32(hdr[20:24]) & 0xffff
+
+	var out []Datagram
+	var rec [16]byte
+	for {
+		if _, err := io.ReadFull(r, rec[:]); err != nil {
+			if err == io.EOF {
+				return out, nil
+			}
+			return out, fmt.Errorf("pcap record: %w", err)
+		}
+		sec, frac := order.Uint32(rec[0:4]), order.Uint32(rec[4:8])
+		incl := order.Uint32(rec[8:12])
+		if incl > 1<<20 {
+			return out, fmt.Errorf("packet of %d bytes is too large", incl)
+		}
+		data := make([]byte, incl)
+		if _, err := io.ReadFull(r, data); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return out, fmt.Errorf("pcap record: %w", err)
+		}
+		ns := int64(frac) * 1000
+		if nano {
+			ns = int64(frac)
+		}
+		if d, ok := decodeFrame(link, data); ok {
+			d.At = time.Unix(int64(sec), ns)
+			out = append(out, d)
+		}
+	}
+}
+
+// decodeFrame strips the link layer and decodes IP/UDP
+func decodeFrame(link uint32, b []byte) (Datagram, bool) {
+	switch link {
+	case linkEthernet:
+		if len(b) < 14 {
+			return Datagram{}, false
+		}
+		etype, off := binary.BigEndian.Uint16(b[12:14]), 14
+		for etype == 0x8100 || etype == 0x88a8 {
+			if len(b) < off+4 {
+				return Datagram{}, false
+			}
+			etype, off = binary.BigEndian.Uint16(b[off+2:off+4]), off+4
+		}
+		return decodeIP(b[off:])
+	case linkLinuxSLL:
+		if len(b) < 16 {
+			return Datagram{}, false
+		}
+		return decodeIP(b[16:])
+	case linkSLL2:
+		if len(b) < 20 {
+			return Datagram{}, false
+		}
+		return decodeIP(b[20:])
+	case linkNull:
+		if len(b) < 4 {
+			return Datagram{}, false
+		}
+		return decodeIP(b[4:])
+	case linkRaw, linkRawAlt:
+		return decodeIP(b)
+	}
+	return Datagram{}, false
+}
+
+// decodeIP decodes an IPv4 or IPv6 packet carrying UDP
+func decodeIP(b []byte) (Datagram, bool) {
+	if len(b) < 1 {
+		return Datagram{}, false
+	}
+	var src, dst netip.Addr
+	var udp []byte
+	switch b[0] >> 4 {
+	case 4:
+		if len(b) < 20 {
+			return Datagram{}, false
+		}
+		ihl := int(b[0]&0x0f) * 4
+		flags := binary.BigEndian.Uint16(b[6:8])
+		if b[9] != 17 || len(b) < ihl || flags&0x3fff != 0 {
+			return Datagram{}, false
+		}
+		src = netip.AddrFrom4([4]byte(b[12:16]))
+		dst = netip.AddrFrom4([4]byte(b[16:20]))
+		udp = b[ihl:]
+	case 6:
+		if len(b) < 40 || b[6] != 17 {
+			return Datagram{}, false
+		}
+		src = netip.AddrFrom16([16]byte(b[8:24]))
+		dst = netip.AddrFrom16([16]byte(b[24:40]))
+		udp = b[40:]
+	default:
+		return Datagram{}, false
+	}
+	if len(udp) < 8 {
+		return Datagram{}, false
+	}
+	ulen := int(binary.BigEndian.Uint16(udp[4:6]))
+	payload := udp[8:]
+	if ulen >= 8 && ulen-8 <= len(payload) {
+		payload = payload[:ulen-8]
+	}
+	return Datagram{
+		Src:     netip.AddrPortFrom(src, binary.BigEndian.Uint16(udp[0:2])),
+		Dst:     netip.AddrPortFrom(dst, binary.BigEndian.Uint16(udp[2:4])),
+		Payload: append([]byte(nil), payload...),
+	}, true
+}
+
+// WritePcap writes datagrams as a classic libpcap capture with raw IP framing, readable
+// by ReadPcap, Wireshark and tcpdump. Checksums are left zero
+func WritePcap(w io.Writer, dgrams []Datagram) error {
+	var hdr [24]byte
+	binary.LittleEndian.PutUint32(hdr[0:4], 0xa1b2c3d4)
+	binary.LittleEndian.PutUint16(hdr[4:6], 2)
+	binary.LittleEndian.PutUint16(hdr[6:8], 4)
+	binary.LittleEndian.PutUint32(hdr[16:20], 65535)
+	binary.LittleEndian.PutUint32(hdr[20:24], linkRaw)
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	for _, d := range dgrams {
+		pkt := encodeIP(d)
+		var rec [16]byte
+		binary.LittleEndian.PutUint32(rec[0:4], uint32(d.At.Unix()))
+		binary.LittleEndian.PutUint32(rec[4:8], uint32(d.At.Nanosecond()/1000))
+		binary.LittleEndian.PutUint32(rec[8:12], uint32(len(pkt)))
+		binary.LittleEndian.PutUint32(rec[12:16], uint32(len(pkt)))
+		if _, err := w.Write(rec[:]); err != nil {
+			return err
+		}
+		if _, err := w.Write(pkt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeIP wraps a datagram in IPv4 (or IPv6) and UDP headers
+func encodeIP(d Datagram) []byte {
+	udp := make([]byte, 8+len(d.Payload))
+	binary.BigEndian.PutUint16(udp[0:2], d.Src.Port())
+	binary.BigEndian.PutUint16(udp[2:4], d.Dst.Port())
+	binary.BigEndian.PutUint16(udp[4:6], uint16(len(udp)))
+	copy(udp[8:], d.Payload)
+
+	src, dst := d.Src.Addr().Unmap(), d.Dst.Addr().Unmap()
+	if src.Is4() && dst.Is4() {
+		ip := make([]byte, 20, 20+len(udp))
+		ip[0], ip[8], ip[9] = 0x45, 64, 17
+		binary.BigEndian.PutUint16(ip[2:4], uint16(20+len(udp)))
+		s4, d4 := src.As4(), dst.As4()
+		copy(ip[12:16], s4[:])
+		copy(ip[16:20], d4[:])
+		var sum uint32
+		for i := 0; i < 20; i += 2 {
+			sum += uint32(binary.BigEndian.Uint16(ip[i : i+2]))
+		}
+		for sum > 0xffff {
+			sum = sum&0xffff + sum>>16
+		}
+		binary.BigEndian.PutUint16(ip[10:12], ^uint16(sum))
+		return append(ip, udp...)
+	}
+	ip := make([]byte, 40, 40+len(udp))
+	ip[0], ip[6], ip[7] = 0x60, 17, 64
+	binary.BigEndian.PutUint16(ip[4:6], uint16(len(udp)))
+	s16, d16 := src.As16(), dst.As16()
+	copy(ip[8:24], s16[:])
+	copy(ip[24:40], d16[:])
+	return append(ip, udp...)
+}
@@ -0,0 +1,83 @@
+package capture
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPcapRoundTrip(t *testing.T) {
+	at := time.Unix(1700000000, 250000000)
+	in := []Datagram{
+		{At: at, Src: netip.MustParseAddrPort("10.0.0.2:50000"), Dst: netip.MustParseAddrPort("10.0.0.1:28960"), Payload: []byte("\xFF\xFF\xFF\xFFrcon secret status\n")},
+		{At: at.Add(time.Millisecond), Src: netip.MustParseAddrPort("10.0.0.1:28960"), Dst: netip.MustParseAddrPort("10.0.0.2:50000"), Payload: []byte("\xFF\xFF\xFF\xFFprint\nmap: mp_raid\n")},
+		{At: at.Add(2 * time.Millisecond), Src: netip.MustParseAddrPort("[2001:db8::2]:50001"), Dst: netip.MustParseAddrPort("[2001:db8::1]:28960"), Payload: []byte("\xFF\xFF\xFF\xFFgetinfo xyz\n")},
+	}
+	var buf bytes.Buffer
+	if err := WritePcap(&buf, in); err != nil {
+		t.Fatal(err)
+	}
+	out, err := ReadPcap(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != len(in) {
+		t.Fatalf("read %d datagrams, want %d", len(out), len(in))
+	}
+	for i := range in {
+		if out[i].Src != in[i].Src || out[i].Dst != in[i].Dst || !bytes.Equal(out[i].Payload, in[i].Payload) {
+			t.Errorf("datagram %d = %+v, want %+v", i, out[i], in[i])
+		}
+		if !out[i].At.Equal(in[i].At) {
+			t.Errorf("datagram %d at %v, want %v", i, out[i].At, in[i].At)
+		}
+	}
+}
+
+func TestReadPcapErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		in        string
+		pcapError bool
+	}{
+		{"empty", "", false},
+		{"text", strings.Repeat("not a capture ", 4), false},
+		{"pcapng", "\x0a\x0d\x0d\x0a" + strings.Repeat("\x00", 20), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadPcap(strings.NewReader(tt.in))
+			if err == nil {
+				t.Fatal("no error")
+			}
+			if IsPcapError(err) != tt.pcapError {
+				t.Errorf("IsPcapError(%v) = %t, want %t", err, !tt.pcapError, tt.pcapError)
+			}
+		})
+	}
+}
+
+func TestReadPcapTruncated(t *testing.T) {
+	d := Datagram{At: time.Unix(1700000000, 0), Src: netip.MustParseAddrPort("10.0.0.2:50000"), Dst: netip.MustParseAddrPort("10.0.0.1:28960"), Payload: []byte("\xFF\xFF\xFF\xFFgetstatus\n")}
+	var buf bytes.Buffer
+	if err := WritePcap(&buf, []Datagram{d, d}); err != nil {
+		t.Fatal(err)
+	}
+	full := buf.Bytes()
+	one := 24 + (len(full)-24)/2
+	for name, cut := range map[string]int{"header": one + 8, "data": len(full) - 5} {
+		t.Run(name, func(t *testing.T) {
+			out, err := ReadPcap(bytes.NewReader(full[:cut]))
+			if !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Fatalf("err = %v, want io.ErrUnexpectedEOF", err)
+			}
+			if !IsPcapError(err) || len(out) != 1 {
+				t.Errorf("read %d datagrams, IsPcapError %t", len(out), IsPcapError(err))
+			}
+		})
+	}
+}
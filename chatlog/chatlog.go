@@ -0,0 +1,198 @@
+// Package chatlog indexes the chat of game log streams so moderators can search it
+// by text, player and time instead of grepping raw logs
+package chatlog
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/events"
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+)
+
+// Message is one indexed chat line
+type Message struct {
+	Server string    `json:"server"`
+	At     time.Time `json:"at"`
+	GUID   string    `json:"guid"`
+	Name   string    `json:"name"`
+	Team   bool      `json:"team,omitempty"`
+	Text   string    `json:"text"`
+}
+
+// Query selects messages. Text holds words that must all appear in a message, a
+// trailing * matches a prefix ("camp*"); empty fields don't filter
+type Query struct {
+	Text   string
+	GUID   string
+	Server string
+	From   time.Time
+	To     time.Time
+	// Limit bounds the reply, newest first (default 100)
+	Limit int
+}
+
+// Store keeps and searches messages
+type Store interface {
+	Add(ctx context.Context, msgs ...Message) error
+	Search(ctx context.Context, q Query) ([]Message, error)
+}
+
+// Index feeds chat events into a Store
+type Index struct {
+	// Store keeps the messages. Left nil, they go to an SQLiteStore on DB, or to a
+	// MemoryStore without DB
+	Store Store
+	// DB is an SQLite database opened with a driver built with FTS5, e.g.
+	// modernc.org/sqlite; the chat table is created on first use
+	DB *sql.DB
+	// OnError receives failed writes
+	OnError func(error)
+
+	once sync.Once
+	err  error
+}
+
+// store returns the Store, creating the default one on first use
+func (ix *Index) store(ctx context.Context) (Store, error) {
+	ix.once.Do(func() {
+		switch {
+		case ix.Store != nil:
+		case ix.DB != nil:
+			st := &SQLiteStore{DB: ix.DB}
+			if ix.err = st.Init(ctx); ix.err == nil {
+				ix.Store = st
+			}
+		default:
+			ix.Store = &MemoryStore{}
+		}
+	})
+	return ix.Store, ix.err
+}
+
+// Add indexes one chat event of server
+func (ix *Index) Add(ctx context.Context, server string, ev *events.ChatMessage) error {
+	st, err := ix.store(ctx)
+	if err != nil {
+		return err
+	}
+	return st.Add(ctx, FromEvent(server, ev))
+}
+
+// Follow indexes the chat of a stream until ctx is done or the stream closes
+func (ix *Index) Follow(ctx context.Context, server string, s *events.Stream) {
+	st, err := ix.store(ctx)
+	if err != nil {
+		if ix.OnError != nil {
+			ix.OnError(err)
+		}
+		return
+	}
+	evs, cancel := s.Subscribe(256)
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-evs:
+			if !ok {
+				return
+			}
+			chat, ok := ev.(*events.ChatMessage)
+			if !ok {
+				continue
+			}
+			if err := st.Add(ctx, FromEvent(server, chat)); err != nil && ix.OnError != nil {
+				ix.OnError(err)
+			}
+		}
+	}
+}
+
+// SearchChat returns the messages containing query, said by guid if set, between from
+// and to; zero times leave that end open
+func (ix *Index) SearchChat(ctx context.Context, query, guid string, from, to time.Time) ([]Message, error) {
+	return ix.Search(ctx, Query{Text: query, GUID: guid, From: from, To: to})
+}
+
+// Search runs any query against the store
+func (ix *Index) Search(ctx context.Context, q Query) ([]Message, error) {
+	st, err := ix.store(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return st.Search(ctx, q)
+}
+
+// FromEvent converts a chat event, dropping color codes from the name and text
+func FromEvent(server string, ev *events.ChatMessage) Message {
+	return Message{
+		Server: server,
+		At:     ev.Timestamp(),
+		GUID:   ev.Player.GUID,
+		Name:   wire.StripColors(ev.Player.Name),
+		Team:   ev.Team,
+		Text:   wire.StripColors(ev.Message),
+	}
+}
+
+// term is one word of a query
+type term struct {
+	word   string
+	prefix bool
+}
+
+// parseQuery splits query text into terms, lower-cased like the indexed words
+func parseQuery(s string) []term {
+	var out []term
+	for _, f := range strings.Fields(s) {
+		prefix := strings.HasSuffix(f, "*")
+		for _, w := range tokenize(strings.TrimSuffix(f, "*")) {
+			out = append(out, term{word: w})
+		}
+		if prefix && len(out) > 0 {
+			out[len(out)-1].prefix = true
+		}
+	}
+	return out
+}
+
+// tokenize lower-cases text and splits it into words of letters and digits
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(wire.StripColors(s)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// terms parses Text; ok is false for text without any word, such as "?!", which
+// matches no message rather than every one
+func (q *Query) terms() (terms []term, ok bool) {
+	terms = parseQuery(q.Text)
+	return terms, len(terms) > 0 || strings.TrimSpace(q.Text) == ""
+}
+
+// matches reports whether m passes the filters of q other than Text
+func (q *Query) matches(m *Message) bool {
+	switch {
+	case q.GUID != "" && !strings.EqualFold(m.GUID, q.GUID):
+		return false
+	case q.Server != "" && m.Server != q.Server:
+		return false
+	case !q.From.IsZero() && m.At.Before(q.From):
+		return false
+	case !q.To.IsZero() && m.At.After(q.To):
+		return false
+	}
+	return true
+}
+
+func (q *Query) limit() int {
+	if q.Limit <= 0 {
+		return 100
+	}
+	return q.Limit
+}
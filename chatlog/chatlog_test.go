@@ -0,0 +1,78 @@
+package chatlog
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []term
+	}{
+		{"", nil},
+		{"Hello World", []term{{word: "hello"}, {word: "world"}}},
+		{"^1wall^7hack*", []term{{word: "wallhack", prefix: true}}},
+		{"don't cheat*", []term{{word: "don"}, {word: "t"}, {word: "cheat", prefix: true}}},
+		{"gg-wp", []term{{word: "gg"}, {word: "wp"}}},
+	}
+	for _, tt := range tests {
+		if got := parseQuery(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseQuery(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIndexStores(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	ctx := context.Background()
+	at := time.Unix(1700000000, 0)
+	msgs := []Message{
+		{Server: "tdm-1", At: at, GUID: "abcdef01", Name: "Sniper", Text: "nice wallhack lol"},
+		{Server: "tdm-1", At: at.Add(time.Second), GUID: "12345678", Name: "xy", Text: "gg, wp!"},
+	}
+	for name, ix := range map[string]*Index{"sqlite": {DB: db}, "memory": {}} {
+		t.Run(name, func(t *testing.T) {
+			st, err := ix.store(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := st.(*SQLiteStore); ok != (name == "sqlite") {
+				t.Fatalf("default store %T", st)
+			}
+			if err := st.Add(ctx, msgs...); err != nil {
+				t.Fatal(err)
+			}
+			tests := []struct {
+				q    string
+				want int
+			}{
+				{"", 2},
+				{"wall*", 1},
+				{"GG wp", 1},
+				{"?!", 0},
+				{"*", 0},
+			}
+			for _, tt := range tests {
+				got, err := ix.SearchChat(ctx, tt.q, "", time.Time{}, time.Time{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(got) != tt.want {
+					t.Errorf("SearchChat(%q) found %d, want %d", tt.q, len(got), tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package chatlog
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MemoryStore is an in-memory inverted index of the most recent messages
+type MemoryStore struct {
+	// Max bounds the messages kept (default 100000); the oldest are dropped first
+	Max int
+
+	mu    sync.RWMutex
+	msgs  []Message
+	words map[string][]int
+}
+
+// Add implements Store
+func (s *MemoryStore) Add(_ context.Context, msgs ...Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.words == nil {
+		s.words = map[string][]int{}
+	}
+	for _, m := range msgs {
+		s.indexLocked(len(s.msgs), &m)
+		s.msgs = append(s.msgs, m)
+	}
+	max := s.Max
+	if max <= 0 {
+		max = 100000
+	}
+	// Trim in batches so the rebuild is paid once per max/10 messages
+	if len(s.msgs) > max+max/10 {
+		s.msgs = append(s.msgs[:0:0], s.msgs[len(s.msgs)-max:]...)
+		s.reindexLocked()
+	}
+	return nil
+}
+
+// reindexLocked rebuilds the word index after messages were removed
+func (s *MemoryStore) reindexLocked() {
+	s.words = map[string][]int{}
+	for i := range s.msgs {
+		s.indexLocked(i, &s.msgs[i])
+	}
+}
+
+func (s *MemoryStore) indexLocked(i int, m *Message) {
+	seen := map[string]bool{}
+	for _, w := range tokenize(m.Text) {
+		if !seen[w] {
+			seen[w] = true
+			s.words[w] = append(s.words[w], i)
+		}
+	}
+}
+
+// Search implements Store
+func (s *MemoryStore) Search(ctx context.Context, q Query) ([]Message, error) {
+	terms, ok := q.terms()
+	if !ok {
+		return nil, ctx.Err()
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []Message
+	add := func(i int) bool {
+		if m := &s.msgs[i]; q.matches(m) {
+			out = append(out, *m)
+		}
+		return len(out) < q.limit()
+	}
+	if len(terms) > 0 {
+		hits := s.postingsLocked(terms[0])
+		for _, t := range terms[1:] {
+			hits = intersect(hits, s.postingsLocked(t))
+		}
+		for i := len(hits) - 1; i >= 0 && add(hits[i]); i-- {
+		}
+	} else {
+		for i := len(s.msgs) - 1; i >= 0 && add(i); i-- {
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].At.After(out[j].At) })
+	return out, ctx.Err()
+}
+
+// postingsLocked returns the ascending message indexes containing a term
+func (s *MemoryStore) postingsLocked(t term) []int {
+	if !t.prefix {
+		return s.words[t.word]
+	}
+	var out []int
+	for w, list := range s.words {
+		if strings.HasPrefix(w, t.word) {
+			out = append(out, list...)
+		}
+	}
+	sort.Ints(out)
+	return slices.Compact(out)
+}
+
+func intersect(a, b []int) []int {
+	var out []int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
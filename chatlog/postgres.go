@@ -0,0 +1,133 @@
+package chatlog
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PostgresStore keeps messages in the chat table of a PostgreSQL database (12 or later)
+// through database/sql. Words are indexed with a generated tsvector
+type PostgresStore struct {
+	DB *sql.DB
+}
+
+// PostgresSchema creates the table of PostgresStore. words holds the tokenized text,
+// so color codes and punctuation split words the same way as in the other stores
+var PostgresSchema = []string{
+	`CREATE TABLE IF NOT EXISTS chat (
+		id BIGSERIAL PRIMARY KEY, text TEXT NOT NULL, words TEXT NOT NULL,
+		server TEXT NOT NULL, guid TEXT NOT NULL, name TEXT NOT NULL, team BOOLEAN NOT NULL, at BIGINT NOT NULL,
+		tsv TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', words)) STORED)`,
+	`CREATE INDEX IF NOT EXISTS chat_tsv ON chat USING GIN (tsv)`,
+	`CREATE INDEX IF NOT EXISTS chat_server_at ON chat (server, at)`,
+	`CREATE INDEX IF NOT EXISTS chat_guid_at ON chat (guid, at)`,
+}
+
+// Init creates the table if it doesn't exist
+func (s *PostgresStore) Init(ctx context.Context) error {
+	if s.DB == nil {
+		return errors.New("chat store has no database")
+	}
+	for _, stmt := range PostgresSchema {
+		if _, err := s.DB.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add implements Store
+func (s *PostgresStore) Add(ctx context.Context, msgs ...Message) error {
+	if s.DB == nil {
+		return errors.New("chat store has no database")
+	}
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO chat (text, words, server, guid, name, team, at) VALUES ($1, $2, $3, $4, $5, $6, $7)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, m := range msgs {
+		words := strings.Join(tokenize(m.Text), " ")
+		if _, err := stmt.ExecContext(ctx, m.Text, words, m.Server, strings.ToLower(m.GUID), m.Name, m.Team, m.At.UnixNano()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Search implements Store
+func (s *PostgresStore) Search(ctx context.Context, q Query) ([]Message, error) {
+	if s.DB == nil {
+		return nil, errors.New("chat store has no database")
+	}
+	terms, ok := q.terms()
+	if !ok {
+		return nil, nil
+	}
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if match := tsQuery(terms); match != "" {
+		add("tsv @@ to_tsquery('simple', $%d)", match)
+	}
+	if q.GUID != "" {
+		add("guid = $%d", strings.ToLower(q.GUID))
+	}
+	if q.Server != "" {
+		add("server = $%d", q.Server)
+	}
+	if !q.From.IsZero() {
+		add("at >= $%d", q.From.UnixNano())
+	}
+	if !q.To.IsZero() {
+		add("at <= $%d", q.To.UnixNano())
+	}
+	query := "SELECT server, at, guid, name, team, text FROM chat"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, q.limit())
+	query += fmt.Sprintf(" ORDER BY at DESC LIMIT $%d", len(args))
+
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Message
+	for rows.Next() {
+		var m Message
+		var at int64
+		if err := rows.Scan(&m.Server, &at, &m.GUID, &m.Name, &m.Team, &m.Text); err != nil {
+			return out, err
+		}
+		m.At = time.Unix(0, at)
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// tsQuery renders terms as a tsquery that needs all of them; like ftsQuery, it relies
+// on tokenize leaving only letters and digits
+func tsQuery(terms []term) string {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		parts[i] = t.word
+		if t.prefix {
+			parts[i] += ":*"
+		}
+	}
+	return strings.Join(parts, " & ")
+}
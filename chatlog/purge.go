@@ -0,0 +1,98 @@
+package chatlog
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Purger is a store that can delete messages, for deletion requests and to keep the
+// index bounded. MemoryStore, SQLiteStore and PostgresStore implement it
+type Purger interface {
+	// Purge deletes every message of a player
+	Purge(ctx context.Context, guid string) error
+	// PurgeOlderThan deletes the messages older than d and returns how many
+	PurgeOlderThan(ctx context.Context, d time.Duration) (int, error)
+}
+
+// Purge implements Purger
+func (s *MemoryStore) Purge(_ context.Context, guid string) error {
+	guid = strings.ToLower(strings.TrimSpace(guid))
+	s.removeWhere(func(m *Message) bool { return strings.ToLower(m.GUID) == guid })
+	return nil
+}
+
+// PurgeOlderThan implements Purger
+func (s *MemoryStore) PurgeOlderThan(_ context.Context, d time.Duration) (int, error) {
+	cutoff := time.Now().Add(-d)
+	return s.removeWhere(func(m *Message) bool { return m.At.Before(cutoff) }), nil
+}
+
+// removeWhere drops the matching messages and returns how many
+func (s *MemoryStore) removeWhere(drop func(*Message) bool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keep := s.msgs[:0]
+	for i := range s.msgs {
+		if !drop(&s.msgs[i]) {
+			keep = append(keep, s.msgs[i])
+		}
+	}
+	n := len(s.msgs) - len(keep)
+	if n > 0 {
+		clear(s.msgs[len(keep):])
+		s.msgs = keep
+		s.reindexLocked()
+	}
+	return n
+}
+
+// Purge implements Purger
+func (s *SQLiteStore) Purge(ctx context.Context, guid string) error {
+	_, err := purgeSQL(ctx, s.DB, fmt.Sprintf("DELETE FROM %s WHERE guid = ?", s.table()), strings.ToLower(strings.TrimSpace(guid)))
+	return err
+}
+
+// PurgeTx is Purge inside tx, for callers that delete the player from other tables in
+// the same transaction
+func (s *SQLiteStore) PurgeTx(ctx context.Context, tx *sql.Tx, guid string) error {
+	_, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE guid = ?", s.table()), strings.ToLower(strings.TrimSpace(guid)))
+	return err
+}
+
+// PurgeOlderThan implements Purger
+func (s *SQLiteStore) PurgeOlderThan(ctx context.Context, d time.Duration) (int, error) {
+	return purgeSQL(ctx, s.DB, fmt.Sprintf("DELETE FROM %s WHERE at < ?", s.table()), time.Now().Add(-d).UnixNano())
+}
+
+// Purge implements Purger
+func (s *PostgresStore) Purge(ctx context.Context, guid string) error {
+	_, err := purgeSQL(ctx, s.DB, "DELETE FROM chat WHERE guid = $1", strings.ToLower(strings.TrimSpace(guid)))
+	return err
+}
+
+// PurgeTx is SQLiteStore.PurgeTx
+func (s *PostgresStore) PurgeTx(ctx context.Context, tx *sql.Tx, guid string) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM chat WHERE guid = $1", strings.ToLower(strings.TrimSpace(guid)))
+	return err
+}
+
+// PurgeOlderThan implements Purger
+func (s *PostgresStore) PurgeOlderThan(ctx context.Context, d time.Duration) (int, error) {
+	return purgeSQL(ctx, s.DB, "DELETE FROM chat WHERE at < $1", time.Now().Add(-d).UnixNano())
+}
+
+func purgeSQL(ctx context.Context, db *sql.DB, query string, arg any) (int, error) {
+	if db == nil {
+		return 0, errors.New("chat store has no database")
+	}
+	res, err := db.ExecContext(ctx, query, arg)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,124 @@
+package chatlog
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SQLiteStore keeps messages in an SQLite FTS5 table through database/sql. Open DB
+// with any SQLite driver built with FTS5, e.g. modernc.org/sqlite, and call Init once
+type SQLiteStore struct {
+	DB *sql.DB
+	// Table defaults to chat
+	Table string
+}
+
+func (s *SQLiteStore) table() string {
+	if s.Table == "" {
+		return "chat"
+	}
+	return s.Table
+}
+
+// Init creates the table if it doesn't exist. Only the text is tokenized; the other
+// columns are stored for filtering
+func (s *SQLiteStore) Init(ctx context.Context) error {
+	if s.DB == nil {
+		return errors.New("chat store has no database")
+	}
+	_, err := s.DB.ExecContext(ctx, fmt.Sprintf(`CREATE VIRTUAL TABLE IF NOT EXISTS %s USING fts5(
+	text, server UNINDEXED, guid UNINDEXED, name UNINDEXED, team UNINDEXED, at UNINDEXED,
+	tokenize = 'unicode61 remove_diacritics 2')`, s.table()))
+	return err
+}
+
+// Add implements Store
+func (s *SQLiteStore) Add(ctx context.Context, msgs ...Message) error {
+	if s.DB == nil {
+		return errors.New("chat store has no database")
+	}
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s (text, server, guid, name, team, at) VALUES (?, ?, ?, ?, ?, ?)", s.table()))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, m := range msgs {
+		if _, err := stmt.ExecContext(ctx, m.Text, m.Server, strings.ToLower(m.GUID), m.Name, m.Team, m.At.UnixNano()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Search implements Store
+func (s *SQLiteStore) Search(ctx context.Context, q Query) ([]Message, error) {
+	if s.DB == nil {
+		return nil, errors.New("chat store has no database")
+	}
+	terms, ok := q.terms()
+	if !ok {
+		return nil, nil
+	}
+	var where []string
+	var args []any
+	if match := ftsQuery(terms); match != "" {
+		where, args = append(where, s.table()+" MATCH ?"), append(args, match)
+	}
+	if q.GUID != "" {
+		where, args = append(where, "guid = ?"), append(args, strings.ToLower(q.GUID))
+	}
+	if q.Server != "" {
+		where, args = append(where, "server = ?"), append(args, q.Server)
+	}
+	if !q.From.IsZero() {
+		where, args = append(where, "at >= ?"), append(args, q.From.UnixNano())
+	}
+	if !q.To.IsZero() {
+		where, args = append(where, "at <= ?"), append(args, q.To.UnixNano())
+	}
+	query := fmt.Sprintf("SELECT server, at, guid, name, team, text FROM %s", s.table())
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY at DESC LIMIT ?"
+	args = append(args, q.limit())
+
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Message
+	for rows.Next() {
+		var m Message
+		var at int64
+		if err := rows.Scan(&m.Server, &at, &m.GUID, &m.Name, &m.Team, &m.Text); err != nil {
+			return out, err
+		}
+		m.At = time.Unix(0, at)
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// ftsQuery renders terms as an FTS5 match expression; tokenize leaves only letters
+// and digits, so quoting each word is enough to keep FTS5 syntax out
+func ftsQuery(terms []term) string {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		parts[i] = `"` + t.word + `"`
+		if t.prefix {
+			parts[i] += "*"
+		}
+	}
+	return strings.Join(parts, " ")
+}
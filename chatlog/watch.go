@@ -0,0 +1,265 @@
+package chatlog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/alert"
+	"github.com/Yallamaztar/PlutoRCON/v2/events"
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+)
+
+// Watchlist flags chat lines for moderator review
+type Watchlist struct {
+	Name string
+	// Words match whole words case-insensitively, ignoring color codes; a trailing *
+	// matches a prefix ("cheat*")
+	Words []string
+	// Patterns are matched against the color-stripped text
+	Patterns []*regexp.Regexp
+}
+
+// match returns the first matched word or pattern text of m
+func (wl *Watchlist) match(m *Message) (string, bool) {
+	if len(wl.Words) > 0 {
+		words := tokenize(m.Text)
+		for _, t := range parseQuery(strings.Join(wl.Words, " ")) {
+			for _, w := range words {
+				if w == t.word || t.prefix && strings.HasPrefix(w, t.word) {
+					return w, true
+				}
+			}
+		}
+	}
+	for _, rx := range wl.Patterns {
+		if s := rx.FindString(wire.StripColors(m.Text)); s != "" {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// Hit is a flagged chat line with the lines around it on the same server
+type Hit struct {
+	Watchlist string    `json:"watchlist"`
+	Match     string    `json:"match"`
+	Message   Message   `json:"message"`
+	Before    []Message `json:"before"`
+	After     []Message `json:"after"`
+}
+
+// String renders the hit for a review channel, the flagged line marked with >
+func (h Hit) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s matched %q on %s\n", h.Watchlist, h.Message.Name, h.Match, h.Message.Server)
+	line := func(mark string, m Message) {
+		fmt.Fprintf(&b, "%s %s %s: %s\n", mark, m.At.Format("15:04:05"), m.Name, m.Text)
+	}
+	for _, m := range h.Before {
+		line(" ", m)
+	}
+	line(">", h.Message)
+	for _, m := range h.After {
+		line(" ", m)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Alert converts the hit for alert channels; hits never resolve
+func (h Hit) Alert() alert.Alert {
+	return alert.Alert{Rule: "chat_" + h.Watchlist, Server: h.Message.Server, Message: h.String(), FiredAt: h.Message.At}
+}
+
+// Watcher checks chat against watchlists and reports hits with context. It only
+// reports; what to do about a hit is left to the moderators
+type Watcher struct {
+	Lists []Watchlist
+	// Before and After are how many surrounding lines a hit carries (default 5 each)
+	Before, After int
+	// AfterWait bounds how long a hit waits for its After lines (default 30s)
+	AfterWait time.Duration
+	// Cooldown suppresses repeated hits of one watchlist for the same player (0 = report all)
+	Cooldown time.Duration
+	OnHit    func(Hit)
+
+	mu      sync.Mutex
+	recent  map[string][]Message
+	pending []*pendingHit
+	last    map[string]time.Time
+}
+
+type pendingHit struct {
+	hit      Hit
+	deadline time.Time
+}
+
+func (w *Watcher) before() int {
+	if w.Before <= 0 {
+		return 5
+	}
+	return w.Before
+}
+
+func (w *Watcher) after() int {
+	if w.After <= 0 {
+		return 5
+	}
+	return w.After
+}
+
+// Observe checks one message and delivers the hits whose context is complete
+func (w *Watcher) Observe(m Message) {
+	w.mu.Lock()
+	if w.recent == nil {
+		w.recent, w.last = map[string][]Message{}, map[string]time.Time{}
+	}
+	var done []Hit
+	keep := w.pending[:0]
+	for _, p := range w.pending {
+		if p.hit.Message.Server == m.Server {
+			p.hit.After = append(p.hit.After, m)
+		}
+		if len(p.hit.After) >= w.after() {
+			done = append(done, p.hit)
+		} else {
+			keep = append(keep, p)
+		}
+	}
+	w.pending = keep
+
+	for _, wl := range w.Lists {
+		match, ok := wl.match(&m)
+		if !ok {
+			continue
+		}
+		key := wl.Name + "\x00" + m.Server + "\x00" + strings.ToLower(m.GUID)
+		if w.Cooldown > 0 && m.At.Sub(w.last[key]) < w.Cooldown {
+			continue
+		}
+		w.last[key] = m.At
+		wait := w.AfterWait
+		if wait <= 0 {
+			wait = 30 * time.Second
+		}
+		before := append([]Message(nil), w.recent[m.Server]...)
+		w.pending = append(w.pending, &pendingHit{
+			hit:      Hit{Watchlist: wl.Name, Match: match, Message: m, Before: before},
+			deadline: time.Now().Add(wait),
+		})
+	}
+
+	list := append(w.recent[m.Server], m)
+	if n := len(list) - w.before(); n > 0 {
+		list = append(list[:0:0], list[n:]...)
+	}
+	w.recent[m.Server] = list
+	w.mu.Unlock()
+	w.deliver(done)
+}
+
+// Flush delivers the hits that waited AfterWait for their After lines
+func (w *Watcher) Flush() {
+	w.flush(false)
+}
+
+// flush delivers the overdue hits, or all pending ones with all set
+func (w *Watcher) flush(all bool) {
+	now := time.Now()
+	w.mu.Lock()
+	var done []Hit
+	keep := w.pending[:0]
+	for _, p := range w.pending {
+		if all || now.After(p.deadline) {
+			done = append(done, p.hit)
+		} else {
+			keep = append(keep, p)
+		}
+	}
+	w.pending = keep
+	w.mu.Unlock()
+	w.deliver(done)
+}
+
+func (w *Watcher) deliver(hits []Hit) {
+	if w.OnHit == nil {
+		return
+	}
+	for _, h := range hits {
+		w.OnHit(h)
+	}
+}
+
+// Follow watches the chat of a stream until ctx is done or the stream closes, then
+// delivers the hits still waiting for context
+func (w *Watcher) Follow(ctx context.Context, server string, s *events.Stream) {
+	evs, cancel := s.Subscribe(256)
+	defer cancel()
+	defer w.flush(true)
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			w.Flush()
+		case ev, ok := <-evs:
+			if !ok {
+				return
+			}
+			if chat, ok := ev.(*events.ChatMessage); ok {
+				w.Observe(FromEvent(server, chat))
+			}
+		}
+	}
+}
+
+// watcherState is the JSON form of a Watcher's state
+type watcherState struct {
+	Recent  map[string][]Message `json:"recent,omitempty"`
+	Pending []pendingState       `json:"pending,omitempty"`
+	Last    map[string]time.Time `json:"last,omitempty"`
+}
+
+type pendingState struct {
+	Hit      Hit       `json:"hit"`
+	Deadline time.Time `json:"deadline"`
+}
+
+// Snapshot encodes the recent lines, the hits waiting for context and the cooldowns,
+// e.g. to hand them to a standby instance
+func (w *Watcher) Snapshot() ([]byte, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	st := watcherState{Recent: w.recent, Last: w.last}
+	for _, p := range w.pending {
+		st.Pending = append(st.Pending, pendingState{Hit: p.hit, Deadline: p.deadline})
+	}
+	return json.Marshal(st)
+}
+
+// Restore replaces the state with that of a Snapshot
+func (w *Watcher) Restore(data []byte) error {
+	var st watcherState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return err
+	}
+	if st.Recent == nil {
+		st.Recent = map[string][]Message{}
+	}
+	if st.Last == nil {
+		st.Last = map[string]time.Time{}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.recent, w.last, w.pending = st.Recent, st.Last, nil
+	for _, p := range st.Pending {
+		w.pending = append(w.pending, &pendingHit{hit: p.Hit, deadline: p.Deadline})
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package cluster
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// RedisBackend keeps leases and handed-over state in Redis
+type RedisBackend struct {
+	Redis *Redis
+}
+
+// acquireLease renews the lease of ARGV[1] or takes it when free
+const acquireLease = `local v = redis.call("GET", KEYS[1])
+if v == ARGV[1] then redis.call("PEXPIRE", KEYS[1], ARGV[2]) return 1 end
+if not v then redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2]) return 1 end
+return 0`
+
+// Acquire implements Lease
+func (b *RedisBackend) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	v, err := b.Redis.Do(ctx, "EVAL", acquireLease, 1, name+":lease", holder, ttl.Milliseconds())
+	n, _ := v.(int64)
+	return n == 1, err
+}
+
+// Release implements Lease
+func (b *RedisBackend) Release(ctx context.Context, name, holder string) error {
+	_, err := b.Redis.Do(ctx, "EVAL", compareAndDelete, 1, name+":lease", holder)
+	return err
+}
+
+// SaveState implements StateStore
+func (b *RedisBackend) SaveState(ctx context.Context, key string, data []byte) error {
+	_, err := b.Redis.Do(ctx, "SET", key, data)
+	return err
+}
+
+// LoadState implements StateStore
+func (b *RedisBackend) LoadState(ctx context.Context, key string) ([]byte, error) {
+	s, ok, err := b.Redis.get(ctx, key)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+// PostgresBackend keeps leases and handed-over state in PostgreSQL, e.g. the database
+// of store.Postgres. Expiry uses the database clock, so instance clocks may drift
+type PostgresBackend struct {
+	DB *sql.DB
+}
+
+// Init creates the tables if they don't exist
+func (b *PostgresBackend) Init(ctx context.Context) error {
+	if b.DB == nil {
+		return errors.New("postgres backend has no database")
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE IF NOT EXISTS cluster_leases (name TEXT PRIMARY KEY, holder TEXT NOT NULL, expires TIMESTAMPTZ NOT NULL)`,
+		`CREATE TABLE IF NOT EXISTS cluster_state (key TEXT PRIMARY KEY, data BYTEA NOT NULL, saved TIMESTAMPTZ NOT NULL DEFAULT now())`,
+	} {
+		if _, err := b.DB.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Acquire implements Lease
+func (b *PostgresBackend) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	var got string
+	err := b.DB.QueryRowContext(ctx, `INSERT INTO cluster_leases (name, holder, expires)
+		VALUES ($1, $2, now() + $3::bigint * interval '1 millisecond')
+		ON CONFLICT (name) DO UPDATE SET holder = excluded.holder, expires = excluded.expires
+		WHERE cluster_leases.holder = excluded.holder OR cluster_leases.expires < now()
+		RETURNING holder`, name, holder, ttl.Milliseconds()).Scan(&got)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil && got == holder, err
+}
+
+// Release implements Lease
+func (b *PostgresBackend) Release(ctx context.Context, name, holder string) error {
+	_, err := b.DB.ExecContext(ctx, `DELETE FROM cluster_leases WHERE name = $1 AND holder = $2`, name, holder)
+	return err
+}
+
+// SaveState implements StateStore
+func (b *PostgresBackend) SaveState(ctx context.Context, key string, data []byte) error {
+	_, err := b.DB.ExecContext(ctx, `INSERT INTO cluster_state (key, data) VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET data = excluded.data, saved = now()`, key, data)
+	return err
+}
+
+// LoadState implements StateStore
+func (b *PostgresBackend) LoadState(ctx context.Context, key string) ([]byte, error) {
+	var data []byte
+	err := b.DB.QueryRowContext(ctx, `SELECT data FROM cluster_state WHERE key = $1`, key).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return data, err
+}
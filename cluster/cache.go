@@ -0,0 +1,139 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// Cache shares the responses of read-only commands between instances for TTL. When
+// several instances poll the same server, one of them sends the command and the others
+// wait briefly for its response instead of asking the server as well
+type Cache struct {
+	Redis *Redis
+	// Prefix namespaces the keys (default "plutorcon:")
+	Prefix string
+	// TTL is how long a response is shared (default 2s); keep it below the poll interval
+	TTL time.Duration
+	// Commands are the command lines that may be cached (default status and serverinfo).
+	// Dvar names can be listed to share dvar reads
+	Commands []string
+	// OnError receives Redis failures; the command is then sent to the server as usual
+	OnError func(error)
+}
+
+// defaultCached are the commands cached when Commands is empty
+var defaultCached = []string{"status", "serverinfo"}
+
+func (c *Cache) ttl() time.Duration {
+	if c.TTL <= 0 {
+		return 2 * time.Second
+	}
+	return c.TTL
+}
+
+func (c *Cache) prefix() string {
+	if c.Prefix == "" {
+		return "plutorcon:"
+	}
+	return c.Prefix
+}
+
+// cacheable reports whether a command line is one of Commands
+func (c *Cache) cacheable(line string) bool {
+	list := c.Commands
+	if len(list) == 0 {
+		list = defaultCached
+	}
+	return slices.ContainsFunc(list, func(s string) bool { return strings.EqualFold(strings.TrimSpace(s), line) })
+}
+
+// Middleware caches the commands of one client; server names it in the keys and must
+// be the same on every instance
+func (c *Cache) Middleware(server string) rcon.Middleware {
+	return func(next rcon.CommandFunc) rcon.CommandFunc {
+		return func(cmd rcon.Command) ([]string, error) {
+			line := strings.TrimSpace(cmd.Line())
+			if c.Redis == nil || !c.cacheable(line) {
+				return next(cmd)
+			}
+			key := c.prefix() + "rcon:" + server + ":" + strings.ToLower(line)
+			ctx := context.Background()
+			if res, ok := c.load(ctx, key); ok {
+				return res, nil
+			}
+			// only the instance holding the fetch key asks the server; the rest wait for it
+			ttl := c.ttl()
+			mine, err := c.Redis.setNX(ctx, key+":fetch", "1", ttl)
+			if err != nil {
+				c.report(err)
+				return next(cmd)
+			}
+			if !mine {
+				if res, ok := c.wait(ctx, key, ttl); ok {
+					return res, nil
+				}
+			}
+			res, err := next(cmd)
+			if err == nil {
+				c.store(ctx, key, res, ttl)
+			}
+			if mine {
+				if _, derr := c.Redis.Do(ctx, "DEL", key+":fetch"); derr != nil {
+					c.report(derr)
+				}
+			}
+			return res, err
+		}
+	}
+}
+
+func (c *Cache) load(ctx context.Context, key string) ([]string, bool) {
+	s, ok, err := c.Redis.get(ctx, key)
+	if err != nil {
+		c.report(err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	var res []string
+	if err := json.Unmarshal([]byte(s), &res); err != nil {
+		c.report(err)
+		return nil, false
+	}
+	return res, true
+}
+
+// wait polls for the response another instance is fetching, for at most ttl
+func (c *Cache) wait(ctx context.Context, key string, ttl time.Duration) ([]string, bool) {
+	deadline := time.Now().Add(ttl)
+	for time.Now().Before(deadline) {
+		time.Sleep(25 * time.Millisecond)
+		if res, ok := c.load(ctx, key); ok {
+			return res, true
+		}
+	}
+	return nil, false
+}
+
+func (c *Cache) store(ctx context.Context, key string, res []string, ttl time.Duration) {
+	data, err := json.Marshal(res)
+	if err != nil {
+		c.report(err)
+		return
+	}
+	if _, err := c.Redis.Do(ctx, "SET", key, string(data), "PX", ttl.Milliseconds()); err != nil {
+		c.report(err)
+	}
+}
+
+func (c *Cache) report(err error) {
+	if c.OnError != nil {
+		c.OnError(err)
+	}
+}
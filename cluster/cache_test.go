@@ -0,0 +1,48 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon/rcontest"
+)
+
+func TestCacheSharesResponses(t *testing.T) {
+	f := newFakeRedis(t)
+	srv := rcontest.NewServer("secret")
+	defer srv.Close()
+	srv.SetPlayers("mp_raid", []rcon.Player{{ClientNum: 0, Name: "alice", GUID: "0100000000000001", IP: "203.0.113.10:28960", Ping: 40}})
+
+	// two instances polling the same server through one Redis
+	var clients []*rcon.RCONClient
+	for range 2 {
+		rc, err := srv.Client()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		cache := &Cache{Redis: &Redis{Addr: f.ln.Addr().String()}, OnError: func(err error) { t.Error(err) }}
+		defer cache.Redis.Close()
+		rc.Use(cache.Middleware("tdm-1"))
+		clients = append(clients, rc)
+	}
+
+	for _, rc := range clients {
+		st, err := rc.Status()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(st.Players) != 1 || rcon.StripColors(st.Players[0].Name) != "alice" {
+			t.Errorf("players %+v, want alice", st.Players)
+		}
+	}
+	clients[0].SendCommand("say", nil)
+
+	var sent []string
+	for _, c := range srv.Commands() {
+		sent = append(sent, c.Name)
+	}
+	if len(sent) != 2 || sent[0] != "status" || sent[1] != "say" {
+		t.Errorf("server received %v, want one status and the uncached say", sent)
+	}
+}
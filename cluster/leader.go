@@ -0,0 +1,218 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Lease is a time-limited claim on a name shared by instances
+type Lease interface {
+	// Acquire takes the lease for holder, or renews it if holder has it, and reports
+	// whether holder holds it for the next ttl
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	// Release gives up the lease if holder has it
+	Release(ctx context.Context, name, holder string) error
+}
+
+// StateStore keeps the state a primary hands over to the next one
+type StateStore interface {
+	SaveState(ctx context.Context, key string, data []byte) error
+	// LoadState returns nil when nothing was saved under key
+	LoadState(ctx context.Context, key string) ([]byte, error)
+}
+
+// Stateful is a component whose state moves to the next primary, such as a
+// maintenance.Schedule or a chatlog.Watcher
+type Stateful interface {
+	Snapshot() ([]byte, error)
+	Restore(data []byte) error
+}
+
+// Leader elects one instance of a group as the primary, which runs polling,
+// automation and gateways, while the others stand by and take over when it dies
+type Leader struct {
+	Lease Lease
+	// Name identifies the group of instances, e.g. "plutorcon:eu"
+	Name string
+	// ID identifies this instance (default host-pid)
+	ID string
+	// TTL is how long a dead primary keeps the lease (default 15s); it is renewed every TTL/3
+	TTL time.Duration
+
+	// Store and State hand state over: the primary saves every State entry on each
+	// renewal and when it steps down, and a new primary restores them before starting
+	Store StateStore
+	State map[string]Stateful
+
+	OnElected func()
+	OnDemoted func()
+	OnError   func(error)
+
+	leading atomic.Bool
+}
+
+func (l *Leader) ttl() time.Duration {
+	if l.TTL <= 0 {
+		return 15 * time.Second
+	}
+	return l.TTL
+}
+
+func (l *Leader) id() string {
+	if l.ID == "" {
+		host, _ := os.Hostname()
+		l.ID = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	return l.ID
+}
+
+// IsLeader reports whether this instance is the primary right now
+func (l *Leader) IsLeader() bool {
+	return l.leading.Load()
+}
+
+// Run campaigns until ctx is done and calls primary whenever this instance is elected.
+// primary's context is cancelled when the lease is lost, before another instance can
+// take it over, so it must return promptly then
+func (l *Leader) Run(ctx context.Context, primary func(ctx context.Context)) error {
+	if l.Lease == nil || l.Name == "" {
+		return errors.New("leader needs Lease and Name")
+	}
+	ttl := l.ttl()
+	t := time.NewTicker(ttl / 3)
+	defer t.Stop()
+	for {
+		ok, err := l.Lease.Acquire(ctx, l.Name, l.id(), ttl)
+		if err != nil && ctx.Err() == nil {
+			l.report(err)
+		}
+		if ok {
+			l.lead(ctx, t.C, primary)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// lead runs primary while renewing the lease on every tick
+func (l *Leader) lead(ctx context.Context, tick <-chan time.Time, primary func(ctx context.Context)) {
+	renewed := time.Now()
+	l.restore(ctx)
+	l.leading.Store(true)
+	if l.OnElected != nil {
+		l.OnElected()
+	}
+	pctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		primary(pctx)
+	}()
+
+	stepDown := func(release bool) {
+		cancel()
+		if release {
+			// ctx may be done already; renew without it
+			l.wait(context.WithoutCancel(ctx), tick, done)
+		} else {
+			<-done
+		}
+		l.leading.Store(false)
+		if release {
+			// ctx may be done already; save and release on a fresh one
+			rctx, rcancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer rcancel()
+			l.save(rctx)
+			if err := l.Lease.Release(rctx, l.Name, l.id()); err != nil {
+				l.report(err)
+			}
+		}
+		if l.OnDemoted != nil {
+			l.OnDemoted()
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			stepDown(true)
+			return
+		case <-done:
+			stepDown(true)
+			return
+		case <-tick:
+			ok, err := l.Lease.Acquire(ctx, l.Name, l.id(), l.ttl())
+			switch {
+			case ok:
+				renewed = time.Now()
+				l.save(ctx)
+			case err != nil && time.Since(renewed) < l.ttl()/2:
+				// a failed renewal is retried while the lease surely still holds
+				l.report(err)
+			default:
+				if err != nil {
+					l.report(err)
+				}
+				stepDown(false)
+				return
+			}
+		}
+	}
+}
+
+// wait waits for primary to return, renewing the lease meanwhile so no other
+// instance is elected while a primary that is slow to stop still runs
+func (l *Leader) wait(ctx context.Context, tick <-chan time.Time, done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case <-tick:
+			if _, err := l.Lease.Acquire(ctx, l.Name, l.id(), l.ttl()); err != nil {
+				l.report(err)
+			}
+		}
+	}
+}
+
+func (l *Leader) save(ctx context.Context) {
+	if l.Store == nil {
+		return
+	}
+	for key, s := range l.State {
+		data, err := s.Snapshot()
+		if err == nil {
+			err = l.Store.SaveState(ctx, l.Name+":state:"+key, data)
+		}
+		if err != nil && ctx.Err() == nil {
+			l.report(fmt.Errorf("save state %s: %w", key, err))
+		}
+	}
+}
+
+func (l *Leader) restore(ctx context.Context) {
+	if l.Store == nil {
+		return
+	}
+	for key, s := range l.State {
+		data, err := l.Store.LoadState(ctx, l.Name+":state:"+key)
+		if err == nil && data != nil {
+			err = s.Restore(data)
+		}
+		if err != nil {
+			l.report(fmt.Errorf("restore state %s: %w", key, err))
+		}
+	}
+}
+
+func (l *Leader) report(err error) {
+	if l.OnError != nil {
+		l.OnError(err)
+	}
+}
@@ -0,0 +1,65 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memLease is a Lease held in memory that records when it was acquired
+type memLease struct {
+	mu       sync.Mutex
+	holder   string
+	acquired []time.Time
+}
+
+func (m *memLease) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.holder != "" && m.holder != holder {
+		return false, nil
+	}
+	m.holder = holder
+	m.acquired = append(m.acquired, time.Now())
+	return true, nil
+}
+
+func (m *memLease) Release(ctx context.Context, name, holder string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.holder == holder {
+		m.holder = ""
+	}
+	return nil
+}
+
+func TestLeaderRenewsWhileSteppingDown(t *testing.T) {
+	lease := &memLease{}
+	l := &Leader{Lease: lease, Name: "test", ID: "a", TTL: 30 * time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	var stopped time.Time
+	elected := make(chan struct{})
+	go func() {
+		<-elected
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	l.Run(ctx, func(ctx context.Context) {
+		close(elected)
+		<-ctx.Done()
+		// a primary that is slow to stop
+		time.Sleep(100 * time.Millisecond)
+		stopped = time.Now()
+	})
+
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+	if lease.holder != "" {
+		t.Errorf("lease still held by %q after Run returned", lease.holder)
+	}
+	last := lease.acquired[len(lease.acquired)-1]
+	if gap := stopped.Sub(last); gap > l.TTL {
+		t.Errorf("lease last renewed %v before the primary stopped, past its TTL of %v", gap, l.TTL)
+	}
+}
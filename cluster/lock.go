@@ -0,0 +1,163 @@
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Lock is a distributed lock held in Redis for TTL and kept alive while in use. Only
+// the Lock that took it can refresh or release it
+type Lock struct {
+	Redis *Redis
+	Key   string
+	// TTL bounds how long the lock outlives an instance that died holding it (default 15s)
+	TTL time.Duration
+	// OnError receives Redis failures of Run
+	OnError func(error)
+
+	once  sync.Once
+	token string
+}
+
+// compareAndDelete and compareAndExpire only touch the key while it holds our token
+const (
+	compareAndDelete = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`
+	compareAndExpire = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) else return 0 end`
+)
+
+func (l *Lock) ttl() time.Duration {
+	if l.TTL <= 0 {
+		return 15 * time.Second
+	}
+	return l.TTL
+}
+
+// id returns the random token identifying this Lock's hold of the key
+func (l *Lock) id() string {
+	l.once.Do(func() {
+		var b [16]byte
+		rand.Read(b[:])
+		l.token = hex.EncodeToString(b[:])
+	})
+	return l.token
+}
+
+// TryLock takes the lock if it is free
+func (l *Lock) TryLock(ctx context.Context) (bool, error) {
+	if l.Redis == nil || l.Key == "" {
+		return false, errors.New("lock needs Redis and Key")
+	}
+	return l.Redis.setNX(ctx, l.Key, l.id(), l.ttl())
+}
+
+// Refresh extends a held lock by TTL and reports whether it was still held
+func (l *Lock) Refresh(ctx context.Context) (bool, error) {
+	v, err := l.Redis.Do(ctx, "EVAL", compareAndExpire, 1, l.Key, l.id(), l.ttl().Milliseconds())
+	n, _ := v.(int64)
+	return n == 1, err
+}
+
+// Unlock releases the lock if this Lock holds it
+func (l *Lock) Unlock(ctx context.Context) error {
+	_, err := l.Redis.Do(ctx, "EVAL", compareAndDelete, 1, l.Key, l.id())
+	return err
+}
+
+// Run calls fn whenever this instance holds the lock, until ctx is done. fn's context
+// is cancelled once the lock is lost or refreshes have failed for TTL/2, so it must
+// return promptly then; Run takes the lock again once it is free. Announcer.Run and
+// Schedule loops fit as fn
+func (l *Lock) Run(ctx context.Context, fn func(ctx context.Context)) error {
+	every := l.ttl() / 3
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		ok, err := l.TryLock(ctx)
+		if err != nil && ctx.Err() == nil {
+			l.report(err)
+		}
+		if ok {
+			l.hold(ctx, t.C, fn)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// hold runs fn while refreshing the lock on every tick. The lock is only released
+// once fn has returned, so no other instance starts fn while this one still runs it
+func (l *Lock) hold(ctx context.Context, tick <-chan time.Time, fn func(ctx context.Context)) {
+	refreshed := time.Now()
+	fctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(fctx)
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			// ctx is already done, so refresh and release without it
+			bg := context.WithoutCancel(ctx)
+			l.stop(bg, tick, cancel, done)
+			rctx, rcancel := context.WithTimeout(bg, 5*time.Second)
+			defer rcancel()
+			if err := l.Unlock(rctx); err != nil {
+				l.report(err)
+			}
+			return
+		case <-done:
+			// fn finished on its own; give the lock up for another instance
+			if err := l.Unlock(ctx); err != nil {
+				l.report(err)
+			}
+			return
+		case <-tick:
+			ok, err := l.Refresh(ctx)
+			if err != nil && ctx.Err() == nil {
+				l.report(err)
+			}
+			switch {
+			case ok:
+				refreshed = time.Now()
+			case err != nil && time.Since(refreshed) < l.ttl()/2:
+				// a failed refresh is retried while the lock surely still holds
+			default:
+				// the lock is gone, or may be by the next tick; nothing left to keep
+				cancel()
+				<-done
+				return
+			}
+		}
+	}
+}
+
+// stop cancels fn and waits for it to return, refreshing the lock meanwhile so it
+// doesn't expire under an fn that is slow to stop
+func (l *Lock) stop(ctx context.Context, tick <-chan time.Time, cancel context.CancelFunc, done <-chan struct{}) {
+	cancel()
+	for {
+		select {
+		case <-done:
+			return
+		case <-tick:
+			if _, err := l.Refresh(ctx); err != nil {
+				l.report(err)
+			}
+		}
+	}
+}
+
+func (l *Lock) report(err error) {
+	if l.OnError != nil {
+		l.OnError(err)
+	}
+}
@@ -0,0 +1,124 @@
+package cluster
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeRedis keeps keys for GET, SET and DEL, without expiry, and answers EVAL with 1
+// except for the next fail EVALs, which get an error reply; fail < 0 fails them all
+type fakeRedis struct {
+	ln   net.Listener
+	fail atomic.Int32
+
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{ln: ln, data: map[string]string{}}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(c)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		args := make([]string, n)
+		for i := range args {
+			r.ReadString('\n')
+			arg, _ := r.ReadString('\n')
+			args[i] = strings.TrimSpace(arg)
+		}
+		fmt.Fprint(c, f.reply(args))
+	}
+}
+
+func (f *fakeRedis) reply(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch args[0] {
+	case "EVAL":
+		if f.fail.Load() != 0 {
+			f.fail.Add(-1)
+			return "-LOADING Redis is loading the dataset in memory\r\n"
+		}
+		return ":1\r\n"
+	case "GET":
+		v, ok := f.data[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+	case "SET":
+		if _, ok := f.data[args[1]]; ok && slices.Contains(args, "NX") {
+			return "$-1\r\n"
+		}
+		f.data[args[1]] = args[2]
+	case "DEL":
+		delete(f.data, args[1])
+		return ":1\r\n"
+	}
+	return "+OK\r\n"
+}
+
+func TestLockToleratesFailedRefresh(t *testing.T) {
+	f := newFakeRedis(t)
+	l := &Lock{Redis: &Redis{Addr: f.ln.Addr().String()}, Key: "lock", TTL: 90 * time.Millisecond}
+	defer l.Redis.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runs := make(chan context.Context, 4)
+	go l.Run(ctx, func(ctx context.Context) {
+		runs <- ctx
+		<-ctx.Done()
+	})
+	fctx := <-runs
+
+	// one refresh fails, well within TTL/2 of the last one that worked
+	f.fail.Store(1)
+	time.Sleep(100 * time.Millisecond)
+	if f.fail.Load() != 0 {
+		t.Fatal("lock not refreshed")
+	}
+	if fctx.Err() != nil {
+		t.Fatal("fn cancelled after a single failed refresh")
+	}
+
+	// refreshes keep failing past TTL/2
+	f.fail.Store(-1)
+	select {
+	case <-fctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("fn still running after refreshes failed for longer than TTL/2")
+	}
+}
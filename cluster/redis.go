@@ -0,0 +1,242 @@
+// Package cluster lets several daemon instances share one set of game servers: a
+// Redis-backed response cache that also deduplicates polls, and distributed locks so
+// only one instance runs the announcer or scheduler of a server
+package cluster
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Redis is a minimal client for the commands this package needs, speaking RESP2 to
+// Redis, Valkey, KeyDB or any compatible server
+type Redis struct {
+	Addr string
+	// Username and Password authenticate with AUTH; Username only for ACL users
+	Username, Password string
+	DB                 int
+	// TLS, when set, connects with TLS
+	TLS *tls.Config
+	// Timeout bounds dialing and every command without a context deadline (default 5s)
+	Timeout time.Duration
+
+	mu   sync.Mutex
+	idle []*redisConn
+}
+
+// RedisError is an error reply of the server
+type RedisError string
+
+func (e RedisError) Error() string { return "redis: " + string(e) }
+
+type redisConn struct {
+	c net.Conn
+	r *bufio.Reader
+}
+
+// maxIdle bounds the connections kept open between commands
+const maxIdle = 4
+
+func (r *Redis) timeout() time.Duration {
+	if r.Timeout <= 0 {
+		return 5 * time.Second
+	}
+	return r.Timeout
+}
+
+// Do sends one command and returns its reply: a string for simple and bulk strings,
+// int64, []any, or nil for a nil reply. Error replies are returned as RedisError
+func (r *Redis) Do(ctx context.Context, args ...any) (any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout())
+		defer cancel()
+	}
+	c, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deadline, _ := ctx.Deadline()
+	c.c.SetDeadline(deadline)
+	reply, err := c.do(args)
+	var rerr RedisError
+	if err != nil && !errors.As(err, &rerr) {
+		c.c.Close()
+		return nil, err
+	}
+	r.put(c)
+	return reply, err
+}
+
+// Close closes the idle connections
+func (r *Redis) Close() error {
+	r.mu.Lock()
+	idle := r.idle
+	r.idle = nil
+	r.mu.Unlock()
+	for _, c := range idle {
+		c.c.Close()
+	}
+	return nil
+}
+
+func (r *Redis) conn(ctx context.Context) (*redisConn, error) {
+	r.mu.Lock()
+	if n := len(r.idle); n > 0 {
+		c := r.idle[n-1]
+		r.idle = r.idle[:n-1]
+		r.mu.Unlock()
+		return c, nil
+	}
+	r.mu.Unlock()
+
+	var nc net.Conn
+	var err error
+	if r.TLS != nil {
+		nc, err = (&tls.Dialer{Config: r.TLS}).DialContext(ctx, "tcp", r.Addr)
+	} else {
+		nc, err = (&net.Dialer{}).DialContext(ctx, "tcp", r.Addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &redisConn{c: nc, r: bufio.NewReader(nc)}
+	deadline, _ := ctx.Deadline()
+	nc.SetDeadline(deadline)
+	if r.Password != "" {
+		auth := []any{"AUTH", r.Password}
+		if r.Username != "" {
+			auth = []any{"AUTH", r.Username, r.Password}
+		}
+		if _, err := c.do(auth); err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+	if r.DB != 0 {
+		if _, err := c.do([]any{"SELECT", r.DB}); err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (r *Redis) put(c *redisConn) {
+	c.c.SetDeadline(time.Time{})
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.idle) >= maxIdle {
+		c.c.Close()
+		return
+	}
+	r.idle = append(r.idle, c)
+}
+
+func (c *redisConn) do(args []any) (any, error) {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, "\r\n"...)
+	for _, a := range args {
+		var s string
+		switch v := a.(type) {
+		case string:
+			s = v
+		case []byte:
+			s = string(v)
+		case int:
+			s = strconv.Itoa(v)
+		case int64:
+			s = strconv.FormatInt(v, 10)
+		default:
+			return nil, fmt.Errorf("redis: unsupported argument type %T", a)
+		}
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(s)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, s...)
+		buf = append(buf, "\r\n"...)
+	}
+	if _, err := c.c.Write(buf); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+func (c *redisConn) read() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, RedisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		out := make([]any, n)
+		var firstErr error
+		for i := range out {
+			// an element may be an error reply; keep reading so the connection stays in sync
+			v, err := c.read()
+			var rerr RedisError
+			if err != nil && !errors.As(err, &rerr) {
+				return nil, err
+			}
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			out[i] = v
+		}
+		return out, firstErr
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+}
+
+// get returns the string at key, ok false when it doesn't exist
+func (r *Redis) get(ctx context.Context, key string) (string, bool, error) {
+	v, err := r.Do(ctx, "GET", key)
+	if err != nil || v == nil {
+		return "", false, err
+	}
+	s, ok := v.(string)
+	return s, ok, nil
+}
+
+// setNX sets key for ttl unless it exists and reports whether it did
+func (r *Redis) setNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	v, err := r.Do(ctx, "SET", key, value, "NX", "PX", ttl.Milliseconds())
+	return v != nil, err
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// colors is whether output may carry ANSI colors: stdout is a terminal that takes
+// them, and neither -no-color nor $NO_COLOR is set
+var colors bool
+
+// nameColors maps the ^0-^9 color codes to ANSI; ^7 is the default white, so it
+// resets instead of forcing white on light terminals
+var nameColors = [10]string{"\x1b[30m", "\x1b[31m", "\x1b[32m", "\x1b[33m", "\x1b[34m", "\x1b[36m", "\x1b[35m", "", "\x1b[90m", "\x1b[37m"}
+
+// paint renders ^N color codes as ANSI colors, or strips them when colors are off
+func paint(s string) string {
+	if !colors || !strings.Contains(s, "^") {
+		return cleanName(s)
+	}
+	var b strings.Builder
+	colored := false
+	for i := 0; i < len(s); i++ {
+		if s[i] == '^' && i+1 < len(s) {
+			i++
+			switch c := s[i]; {
+			case c == '7':
+				if colored {
+					b.WriteString(ansiReset)
+				}
+				colored = false
+			case c >= '0' && c <= '9':
+				b.WriteString(nameColors[c-'0'])
+				colored = true
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	if colored {
+		b.WriteString(ansiReset)
+	}
+	return b.String()
+}
+
+// initColors sets colors from the terminal and the -no-color flag
+func initColors(ansi, noColor bool) {
+	colors = ansi && !noColor && os.Getenv("NO_COLOR") == ""
+}
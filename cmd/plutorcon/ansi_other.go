@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import "os"
+
+// setupConsole reports whether stdout is a terminal, which takes ANSI colors
+func setupConsole() (ansi bool, restore func()) {
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0 && os.Getenv("TERM") != "dumb", func() {}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+var (
+	kernel32               = syscall.NewLazyDLL("kernel32.dll")
+	procSetConsoleMode     = kernel32.NewProc("SetConsoleMode")
+	procGetConsoleCP       = kernel32.NewProc("GetConsoleCP")
+	procSetConsoleCP       = kernel32.NewProc("SetConsoleCP")
+	procGetConsoleOutputCP = kernel32.NewProc("GetConsoleOutputCP")
+	procSetConsoleOutputCP = kernel32.NewProc("SetConsoleOutputCP")
+)
+
+const (
+	enableVirtualTerminalProcessing = 0x0004
+	codepageUTF8                    = 65001
+)
+
+// setupConsole switches the console to UTF-8, so names with extended characters
+// don't come out as mojibake under the OEM code page, and turns on ANSI escape
+// processing (Windows 10 and later). It reports whether stdout takes ANSI colors
+// and returns a func that puts the old code pages back
+func setupConsole() (ansi bool, restore func()) {
+	restore = func() {}
+	var mode uint32
+	out := syscall.Handle(os.Stdout.Fd())
+	if syscall.GetConsoleMode(out, &mode) != nil {
+		// redirected to a file or pipe: plain UTF-8 text, no colors
+		return false, restore
+	}
+	in, _, _ := procGetConsoleCP.Call()
+	outCP, _, _ := procGetConsoleOutputCP.Call()
+	procSetConsoleCP.Call(codepageUTF8)
+	procSetConsoleOutputCP.Call(codepageUTF8)
+	restore = func() {
+		if in != 0 {
+			procSetConsoleCP.Call(in)
+		}
+		if outCP != 0 {
+			procSetConsoleOutputCP.Call(outCP)
+		}
+	}
+	if mode&enableVirtualTerminalProcessing != 0 {
+		return true, restore
+	}
+	ok, _, _ := procSetConsoleMode.Call(uintptr(out), uintptr(mode|enableVirtualTerminalProcessing))
+	return ok != 0, restore
+}
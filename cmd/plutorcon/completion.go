@@ -0,0 +1,240 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+func init() {
+	register("completion", "print a bash, zsh or fish completion script", runCompletion)
+	// __complete is called by the completion scripts and is left out of the usage list
+	commands["__complete"] = command{run: runComplete}
+}
+
+const bashCompletion = `_plutorcon() {
+    local IFS=$'\n'
+    COMPREPLY=($(plutorcon __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _plutorcon plutorcon
+`
+
+const zshCompletion = `#compdef plutorcon
+_plutorcon() {
+    local -a suggestions
+    suggestions=("${(@f)$(plutorcon __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    compadd -a suggestions
+}
+compdef _plutorcon plutorcon
+`
+
+const fishCompletion = `function __plutorcon_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    plutorcon __complete $tokens[2..-1] 2>/dev/null
+end
+complete -c plutorcon -f -a '(__plutorcon_complete)'
+`
+
+func runCompletion(args []string) error {
+	if len(args) != 1 {
+		return i18n.Errorf("usage: %s", "plutorcon completion bash|zsh|fish")
+	}
+	switch args[0] {
+	case "bash":
+		fmt.Print(bashCompletion)
+	case "zsh":
+		fmt.Print(zshCompletion)
+	case "fish":
+		fmt.Print(fishCompletion)
+	default:
+		return i18n.Errorf("completion: unsupported shell %q", args[0])
+	}
+	return nil
+}
+
+// runComplete prints suggestions for the last (partial) word, one per line
+func runComplete(words []string) error {
+	words, ok := skipGlobalFlags(words)
+	if !ok {
+		return nil
+	}
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	partial := words[len(words)-1]
+	var out []string
+	if len(words) == 1 {
+		out = subcommandNames()
+	} else {
+		out = suggest(words[0], words[1:len(words)-1])
+	}
+	for _, s := range out {
+		if strings.HasPrefix(strings.ToLower(s), strings.ToLower(partial)) {
+			fmt.Println(s)
+		}
+	}
+	return nil
+}
+
+// skipGlobalFlags applies and drops the flags typed before the subcommand; ok is false while a flag value is being typed
+func skipGlobalFlags(words []string) (rest []string, ok bool) {
+	fs := flag.NewFlagSet("plutorcon", flag.ContinueOnError)
+	globalFlags(fs)
+	for len(words) > 1 && strings.HasPrefix(words[0], "-") {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(words[0], "-"), "=")
+		f := fs.Lookup(name)
+		n := 1
+		if f != nil && name != "json" && !hasValue {
+			if len(words) < 3 {
+				return nil, false
+			}
+			value, hasValue, n = words[1], true, 2
+		}
+		if f != nil && hasValue {
+			f.Value.Set(value)
+		}
+		words = words[n:]
+	}
+	return words, true
+}
+
+func subcommandNames() []string {
+	var names []string
+	for name, c := range commands {
+		if c.summary != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// suggest returns candidates for the next argument of a subcommand
+func suggest(cmd string, done []string) []string {
+	args := positional(done)
+	switch cmd {
+	case "completion":
+		return []string{"bash", "zsh", "fish"}
+	case "sessions":
+		if len(args) == 0 {
+			return []string{"list", "show"}
+		}
+	case "notes":
+		if len(args) == 0 {
+			return []string{"list", "add"}
+		}
+	case "players":
+		if len(args) == 0 {
+			return []string{"lookup", "name"}
+		}
+	case "config":
+		if len(args) == 0 {
+			return []string{"add", "list", "use", "remove"}
+		}
+		if len(args) == 1 && (args[0] == "use" || args[0] == "remove") {
+			return profileNames()
+		}
+	case "kick":
+		if len(args) == 0 {
+			return quickPlayers(profileFlag(done))
+		}
+	case "map":
+		if len(args) == 0 {
+			return quickMaps(profileFlag(done))
+		}
+	case "dvar":
+		if len(args) == 0 {
+			return []string{"get", "set", "list"}
+		}
+	}
+	return nil
+}
+
+// positional drops flags (and the value of -profile) from already typed words
+func positional(words []string) []string {
+	var out []string
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if w == "-profile" || w == "--profile" {
+			i++
+			continue
+		}
+		if strings.HasPrefix(w, "-") {
+			continue
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+func profileFlag(words []string) string {
+	for i, w := range words {
+		if (w == "-profile" || w == "--profile") && i+1 < len(words) {
+			return words[i+1]
+		}
+		if v, ok := strings.CutPrefix(w, "-profile="); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+func profileNames() []string {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// completionClient dials a profile with a short timeout so completion stays snappy
+func completionClient(profile string) *rcon.RCONClient {
+	rc, err := dialProfile(profile)
+	if err != nil {
+		return nil
+	}
+	rc.Timeout = 500 * time.Millisecond
+	return rc
+}
+
+func quickPlayers(profile string) []string {
+	rc := completionClient(profile)
+	if rc == nil {
+		return nil
+	}
+	defer rc.Close()
+	st, err := rc.Status()
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, p := range st.Players {
+		names = append(names, cleanName(p.Name))
+	}
+	return names
+}
+
+func quickMaps(profile string) []string {
+	rc := completionClient(profile)
+	if rc == nil {
+		return nil
+	}
+	defer rc.Close()
+	maps, err := rotationMaps(rc)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil
+	}
+	return maps
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/term"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// Profile is a named server stored in the config file
+type Profile struct {
+	Host string `json:"host"`
+	Port string `json:"port"`
+	Game string `json:"game,omitempty"`
+	// Password is only set when no keychain was available
+	Password string `json:"password,omitempty"`
+	Keychain bool   `json:"keychain,omitempty"`
+}
+
+type config struct {
+	Current  string             `json:"current,omitempty"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+func init() {
+	register("config", "add, list, use or remove server profiles", runConfig)
+}
+
+func configPath() string {
+	return filepath.Join(configDir(), "config.json")
+}
+
+func loadConfig() (*config, error) {
+	cfg := &config{Profiles: map[string]Profile{}}
+	data, err := os.ReadFile(configPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", configPath(), err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]Profile{}
+	}
+	return cfg, nil
+}
+
+func (c *config) save() error {
+	if err := os.MkdirAll(configDir(), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(configPath(), append(data, '\n'), 0o600)
+}
+
+// password returns the stored password of a profile
+func (p Profile) password(name string) (string, error) {
+	if !p.Keychain {
+		return p.Password, nil
+	}
+	pw, err := keychain{}.get(name)
+	if err != nil {
+		return "", i18n.Errorf("profile %s: reading password from keychain: %w", name, err)
+	}
+	return pw, nil
+}
+
+// profileName resolves an empty profile name to the current profile
+func profileName(name string) (string, error) {
+	if name != "" {
+		return name, nil
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return "", err
+	}
+	if cfg.Current == "" {
+		return "", i18n.Errorf("no profile selected: pass -s host:port or run `plutorcon config add` and `plutorcon config use`")
+	}
+	return cfg.Current, nil
+}
+
+// dialProfile connects to a named profile, to -s when no profile is named, or to the current profile
+func dialProfile(name string, opts ...rcon.Option) (*rcon.RCONClient, error) {
+	if name == "" && global.server != "" {
+		return dialServer(global.server, opts...)
+	}
+	name, err := profileName(name)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return nil, i18n.Errorf("unknown profile %q", name)
+	}
+	pw, err := p.password(name)
+	if err != nil {
+		return nil, err
+	}
+	if p.Game != "" {
+		g, err := rcon.ParseGame(p.Game)
+		if err != nil {
+			return nil, i18n.Errorf("profile %s: %w", name, err)
+		}
+		opts = append([]rcon.Option{rcon.WithGame(g)}, opts...)
+	}
+	return rcon.New(p.Host, p.Port, pw, opts...)
+}
+
+// dialServer connects to a host:port given on the command line
+func dialServer(addr string, opts ...rcon.Option) (*rcon.RCONClient, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("-s %s: %w", addr, err)
+	}
+	pw := global.password
+	if pw == "" {
+		pw = os.Getenv("PLUTORCON_PASSWORD")
+	}
+	if global.game != "" {
+		g, err := rcon.ParseGame(global.game)
+		if err != nil {
+			return nil, err
+		}
+		opts = append([]rcon.Option{rcon.WithGame(g)}, opts...)
+	}
+	return rcon.New(host, port, pw, opts...)
+}
+
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		return i18n.Errorf("usage: %s", "plutorcon config add|list|use|remove")
+	}
+	switch args[0] {
+	case "add":
+		return configAdd(args[1:])
+	case "list":
+		return configList()
+	case "use":
+		if len(args) < 2 {
+			return i18n.Errorf("config use: missing profile name")
+		}
+		return configUse(args[1])
+	case "remove", "rm":
+		if len(args) < 2 {
+			return i18n.Errorf("config remove: missing profile name")
+		}
+		return configRemove(args[1])
+	}
+	return i18n.Errorf("config: unknown action %q", args[0])
+}
+
+func configAdd(args []string) error {
+	fs := flag.NewFlagSet("config add", flag.ContinueOnError)
+	host := fs.String("host", "", i18n.T("server address"))
+	port := fs.String("port", "4976", i18n.T("server port"))
+	game := fs.String("game", "", i18n.T("title (t6, iw5, t4, t5, iw6)"))
+	stdin := fs.Bool("password-stdin", false, i18n.T("read the password from stdin instead of prompting"))
+	noKeychain := fs.Bool("no-keychain", false, i18n.T("store the password in the config file even if a keychain is available"))
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), i18n.T("usage:"), "plutorcon config add [flags] <name>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	name := fs.Arg(0)
+	if name == "" || *host == "" {
+		fs.Usage()
+		return i18n.Errorf("config add: profile name and -host are required")
+	}
+	if *game != "" {
+		if _, err := rcon.ParseGame(*game); err != nil {
+			return err
+		}
+	}
+
+	pw, err := readPassword(i18n.Sprintf("RCON password for %s: ", name), *stdin)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	p := Profile{Host: *host, Port: *port, Game: *game}
+	kc := keychain{}
+	if !*noKeychain && kc.available() {
+		if err := kc.set(name, pw); err != nil {
+			return i18n.Errorf("storing password in keychain: %w", err)
+		}
+		p.Keychain = true
+	} else {
+		p.Password = pw
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("warning: no keychain available, password stored in %s", configPath()))
+	}
+	cfg.Profiles[name] = p
+	if cfg.Current == "" {
+		cfg.Current = name
+	}
+	return cfg.save()
+}
+
+func configList() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "\t"+i18n.T("NAME\tADDRESS\tGAME\tPASSWORD"))
+	for _, name := range names {
+		p := cfg.Profiles[name]
+		cur, store := "", i18n.T("config file")
+		if name == cfg.Current {
+			cur = "*"
+		}
+		if p.Keychain {
+			store = i18n.T("keychain")
+		}
+		game := p.Game
+		if game == "" {
+			game = "t6"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s:%s\t%s\t%s\n", cur, name, p.Host, p.Port, game, store)
+	}
+	return tw.Flush()
+}
+
+func configUse(name string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if _, ok := cfg.Profiles[name]; !ok {
+		return i18n.Errorf("unknown profile %q", name)
+	}
+	cfg.Current = name
+	return cfg.save()
+}
+
+func configRemove(name string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return i18n.Errorf("unknown profile %q", name)
+	}
+	if p.Keychain {
+		if err := (keychain{}).delete(name); err != nil {
+			fmt.Fprintln(os.Stderr, i18n.T("warning: removing keychain entry:"), err)
+		}
+	}
+	delete(cfg.Profiles, name)
+	if cfg.Current == name {
+		cfg.Current = ""
+	}
+	return cfg.save()
+}
+
+// readPassword prompts for a password without echoing it. From a pipe, or with fromStdin
+// set, it reads a line of stdin without prompting
+func readPassword(prompt string, fromStdin bool) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if fromStdin || !term.IsTerminal(fd) {
+		return readLine(os.Stdin)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	pw, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", i18n.Errorf("reading password: %w", err)
+	}
+	return string(pw), nil
+}
+
+// readLine reads one line without its line ending
+func readLine(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", i18n.Errorf("reading password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
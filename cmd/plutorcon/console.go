@@ -0,0 +1,375 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+func init() {
+	register("status", "print the map and players", runStatus)
+	register("say", "send a message to all players", runSay)
+	register("dvar", "get, set or list dvars", runDvar)
+	register("send", "send a raw console command and print the reply", runSend)
+	register("console", "open an interactive rcon console", runConsole)
+}
+
+// consoleCommands are completed in the console before the server's cmdlist is known
+var consoleCommands = []string{
+	"banclient", "clientkick", "cmdlist", "dvardump", "dvarlist", "fast_restart",
+	"kick", "map", "map_restart", "map_rotate", "say", "serverinfo", "set", "seta",
+	"status", "tell", "tempbanclient", "unbanuser",
+}
+
+// commandFlags parses the -profile flag of a one-shot command
+func commandFlags(name, usage string, args []string) (profile string, rest []string, err error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	p := serverFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), i18n.T("usage:"), "plutorcon "+usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return "", nil, err
+	}
+	return *p, fs.Args(), nil
+}
+
+func runStatus(args []string) error {
+	profile, _, err := commandFlags("status", "status [-profile name]", args)
+	if err != nil {
+		return err
+	}
+	rc, err := dialProfile(profile)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	st, err := rc.Status()
+	if err != nil {
+		return err
+	}
+	if global.json {
+		return printJSON(st)
+	}
+	writeStatus(os.Stdout, rc.Game(), st)
+	return nil
+}
+
+// writeStatus prints a status reply as a table
+func writeStatus(w io.Writer, g rcon.Game, st *rcon.ServerStatus) {
+	mapName := st.Map
+	if d := rcon.MapDisplayName(g, st.Map); d != st.Map {
+		mapName += " (" + d + ")"
+	}
+	fmt.Fprintln(w, i18n.Sprintf("map: %s  players: %d", mapName, len(st.Players)))
+	if len(st.Players) == 0 {
+		return
+	}
+	var table strings.Builder
+	tw := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, i18n.T("NUM\tNAME\tSCORE\tPING\tGUID\tADDRESS"))
+	for _, p := range st.Players {
+		ping := fmt.Sprint(p.Ping)
+		if p.Loading {
+			ping = "LOAD"
+		} else if p.Zombie {
+			ping = "ZMBI"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\t%s\n", p.ClientNum, cleanName(p.Name), p.Score, ping, p.GUID, p.IP)
+	}
+	tw.Flush()
+	lines := strings.SplitAfter(table.String(), "\n")
+	// color escapes would count as width in the tabwriter, so the colored names go
+	// in after the columns are laid out. The name starts after the client number and
+	// its padding; the header is translated, so it can't be searched for the column
+	if colors {
+		for i, p := range st.Players {
+			line, clean := lines[i+1], cleanName(p.Name)
+			num := strconv.Itoa(p.ClientNum)
+			if !strings.HasPrefix(line, num) {
+				continue
+			}
+			col := len(line) - len(strings.TrimLeft(line[len(num):], " "))
+			if len(line) >= col+len(clean) && line[col:col+len(clean)] == clean {
+				lines[i+1] = line[:col] + paint(p.Name) + line[col+len(clean):]
+			}
+		}
+	}
+	io.WriteString(w, strings.Join(lines, ""))
+	if st.Truncated {
+		fmt.Fprintln(w, i18n.T("(reply was truncated; the player list may be incomplete)"))
+	}
+}
+
+func runSay(args []string) error {
+	profile, rest, err := commandFlags("say", "say [-profile name] <message...>", args)
+	if err != nil {
+		return err
+	}
+	if len(rest) == 0 {
+		return i18n.Errorf("say: missing message")
+	}
+	rc, err := dialProfile(profile)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	end, err := recordSession(rc, "cli", profile)
+	if err != nil {
+		return err
+	}
+	defer end()
+	return rc.Say(strings.Join(rest, " "))
+}
+
+func runDvar(args []string) error {
+	profile, rest, err := commandFlags("dvar", "dvar [-profile name] get <name> | set <name> <value...> | list [prefix]", args)
+	if err != nil {
+		return err
+	}
+	if len(rest) == 0 {
+		return i18n.Errorf("usage: %s", "plutorcon dvar get|set|list")
+	}
+	rc, err := dialProfile(profile)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	switch rest[0] {
+	case "get":
+		if len(rest) != 2 {
+			return i18n.Errorf("usage: %s", "plutorcon dvar get <name>")
+		}
+		val, err := rc.GetDvar(rest[1])
+		if err != nil {
+			return err
+		}
+		if global.json {
+			return printJSON(map[string]string{"name": rest[1], "value": val})
+		}
+		fmt.Println(val)
+		return nil
+	case "set":
+		if len(rest) < 3 {
+			return i18n.Errorf("usage: %s", "plutorcon dvar set <name> <value...>")
+		}
+		return rc.SetDvar(rest[1], strings.Join(rest[2:], " "))
+	case "list":
+		prefix := ""
+		if len(rest) > 1 {
+			prefix = rest[1]
+		}
+		dvars, err := rc.DumpDvars(prefix)
+		if dvars == nil && err != nil {
+			return err
+		}
+		if global.json {
+			if perr := printJSON(dvars); perr != nil {
+				return perr
+			}
+			return err
+		}
+		names := make([]string, 0, len(dvars))
+		for name := range dvars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, name := range names {
+			fmt.Fprintf(tw, "%s\t%s\n", name, dvars[name].Value)
+		}
+		tw.Flush()
+		return err
+	}
+	return i18n.Errorf("dvar: unknown action %q", rest[0])
+}
+
+func runSend(args []string) error {
+	profile, rest, err := commandFlags("send", "send [-profile name] <command> [args...]", args)
+	if err != nil {
+		return err
+	}
+	if len(rest) == 0 {
+		return i18n.Errorf("send: missing command")
+	}
+	rc, err := dialProfile(profile)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	end, err := recordSession(rc, "cli", profile)
+	if err != nil {
+		return err
+	}
+	defer end()
+	return sendLine(os.Stdout, rc, strings.Join(rest, " "))
+}
+
+// sendLine sends one console line and prints the reply
+func sendLine(w io.Writer, rc *rcon.RCONClient, line string) error {
+	name, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+	var argp *string
+	if args = strings.TrimSpace(args); args != "" {
+		argp = &args
+	}
+	res, err := rc.SendCommand(name, argp)
+	if err != nil {
+		return err
+	}
+	if global.json {
+		if res == nil {
+			res = []string{}
+		}
+		return printJSON(map[string]any{"command": strings.TrimSpace(line), "response": res})
+	}
+	for _, l := range res {
+		fmt.Fprintln(w, paint(l))
+	}
+	return nil
+}
+
+func runConsole(args []string) error {
+	profile, _, err := commandFlags("console", "console [-profile name]", args)
+	if err != nil {
+		return err
+	}
+	rc, err := dialProfile(profile)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	end, err := recordSession(rc, "repl", profile)
+	if err != nil {
+		return err
+	}
+	defer end()
+
+	c := &console{game: rc.Game(), historyPath: filepath.Join(configDir(), "history"), commands: consoleCommands}
+	go c.load(profile)
+	ed := newLineEditor(os.Stdin, os.Stdout, c.complete)
+	ed.history = loadHistory(c.historyPath)
+
+	fmt.Fprintln(os.Stdout, i18n.T("connected; type a console command, \"help\" or \"exit\""))
+	for {
+		line, err := ed.readLine("rcon> ")
+		if err == io.EOF {
+			fmt.Fprintln(os.Stdout)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		ed.remember(line)
+		appendHistory(c.historyPath, line)
+
+		switch line {
+		case "exit", "quit":
+			return nil
+		case "help":
+			fmt.Fprintln(os.Stdout, i18n.T("any console command is sent as typed; Tab completes commands and dvars, Up/Down walk the history"))
+			continue
+		case "status":
+			st, err := rc.Status()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, i18n.T("error:"), err)
+				continue
+			}
+			if global.json {
+				printJSON(st)
+			} else {
+				writeStatus(os.Stdout, rc.Game(), st)
+			}
+			continue
+		}
+		if err := sendLine(os.Stdout, rc, line); err != nil {
+			fmt.Fprintln(os.Stderr, i18n.T("error:"), err)
+		}
+	}
+}
+
+// console holds the completion state of an interactive session
+type console struct {
+	game        rcon.Game
+	historyPath string
+
+	mu       sync.Mutex
+	commands []string
+	dvars    []string
+}
+
+// complete returns the candidates for the last word of line
+func (c *console) complete(line string) []string {
+	words := strings.Fields(line)
+	if strings.HasSuffix(line, " ") || len(words) == 0 {
+		words = append(words, "")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var pool []string
+	switch first := strings.ToLower(words[0]); {
+	case len(words) == 1:
+		pool = append(append([]string{"exit", "help"}, c.commands...), c.dvars...)
+	case len(words) == 2 && (first == "set" || first == "seta" || first == "reset" || first == "toggle"):
+		pool = c.dvars
+	case len(words) == 2 && first == "map":
+		pool = rcon.KnownMaps(c.game)
+	}
+	partial := strings.ToLower(words[len(words)-1])
+	var out []string
+	seen := map[string]bool{}
+	for _, s := range pool {
+		if strings.HasPrefix(strings.ToLower(s), partial) && !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// load fetches the server's command and dvar names over a second connection,
+// so the console is usable while a large dvar list is still arriving
+func (c *console) load(profile string) {
+	rc := completionClient(profile)
+	if rc == nil {
+		return
+	}
+	defer rc.Close()
+	commands := append([]string(nil), consoleCommands...)
+	if res, err := rc.SendCommand("cmdlist", nil); err == nil {
+		for _, l := range res {
+			if f := strings.Fields(cleanName(l)); len(f) == 1 {
+				commands = append(commands, f[0])
+			}
+		}
+	}
+	c.mu.Lock()
+	c.commands = commands
+	c.mu.Unlock()
+
+	all, _ := rc.DumpDvars("")
+	dvars := make([]string, 0, len(all))
+	for name := range all {
+		dvars = append(dvars, name)
+	}
+	c.mu.Lock()
+	c.dvars = dvars
+	c.mu.Unlock()
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/capture"
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+func init() {
+	register("corpus", "check the parser conformance corpus or add a captured reply to it", runCorpus)
+}
+
+func runCorpus(args []string) error {
+	if len(args) == 0 {
+		return i18n.Errorf("usage: %s", "plutorcon corpus check [dir] | add [flags] <capture> <case.json> | update <case.json>...")
+	}
+	switch args[0] {
+	case "check":
+		return corpusCheck(args[1:])
+	case "add":
+		return corpusAdd(args[1:])
+	case "update":
+		return corpusUpdate(args[1:])
+	}
+	return i18n.Errorf("corpus: unknown action %q", args[0])
+}
+
+// corpusCheck runs the bundled corpus, or a directory of cases
+func corpusCheck(args []string) error {
+	fsys := capture.Corpus
+	var results []capture.CaseResult
+	var err error
+	if len(args) > 0 {
+		results, err = capture.RunCorpus(os.DirFS(args[0]))
+	} else {
+		results, err = capture.RunCorpus(fsys)
+	}
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, r := range results {
+		if r.Err == nil {
+			fmt.Println("ok  ", r.Path)
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL %s: %v\n", r.Path, r.Err)
+		if len(r.Got) > 0 {
+			fmt.Println("    ", i18n.T("got:"), string(r.Got))
+		}
+	}
+	if failed > 0 {
+		return i18n.Errorf("%d of %d corpus cases failed", failed, len(results))
+	}
+	return nil
+}
+
+// corpusAdd turns a raw reply dump or a single-exchange pcap into a new case
+func corpusAdd(args []string) error {
+	fs := flag.NewFlagSet("corpus add", flag.ContinueOnError)
+	game := fs.String("game", "t6", i18n.T("title the capture came from"))
+	as := fs.String("as", "status", i18n.T("status, info, serverstatus or a dvar name"))
+	desc := fs.String("desc", "", i18n.T("what the case covers"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return i18n.Errorf("usage: %s", "plutorcon corpus add [flags] <capture.pcap | reply.bin> <case.json>")
+	}
+	g, err := rcon.ParseGame(*game)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	datagrams := [][]byte{data}
+	if dgrams, err := capture.ReadPcap(bytes.NewReader(data)); err == nil {
+		exs := capture.Decode(dgrams, g)
+		if len(exs) != 1 {
+			return i18n.Errorf("capture holds %d exchanges, trim it to the one reply you want to add", len(exs))
+		}
+		datagrams = exs[0].Replies
+	} else if capture.IsPcapError(err) {
+		return err
+	}
+
+	c, err := capture.NewCase(g, *as, *desc, datagrams)
+	if err != nil {
+		return err
+	}
+	if err := writeCase(fs.Arg(1), c); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, i18n.Sprintf("wrote %s: review the expected output, correct it where the parser is wrong, and send it in", fs.Arg(1)))
+	return nil
+}
+
+// corpusUpdate rewrites the expected output of cases from the current parsers
+func corpusUpdate(paths []string) error {
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		var c capture.Case
+		if err := json.Unmarshal(data, &c); err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		got, truncated, err := c.Run()
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		c.Expected, c.Truncated = got, truncated
+		if err := writeCase(p, &c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCase(path string, c *capture.Case) error {
+	out, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(out, '\n'), 0o644)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/capture"
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+func init() {
+	register("decode", "parse a pcap or raw reply dump the way the library would", runDecode)
+}
+
+func runDecode(args []string) error {
+	fs := flag.NewFlagSet("decode", flag.ContinueOnError)
+	game := fs.String("game", "t6", i18n.T("title whose parsing rules to use"))
+	as := fs.String("as", "status", i18n.T("for raw dumps: status, info, serverstatus or a dvar name"))
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), i18n.T("usage:"), "plutorcon decode [flags] <capture.pcap | reply.bin>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return i18n.Errorf("decode: missing file")
+	}
+	g, err := rcon.ParseGame(*game)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var exchanges []*capture.Exchange
+	if dgrams, err := capture.ReadPcap(bytes.NewReader(data)); err == nil {
+		exchanges = capture.Decode(dgrams, g)
+	} else if capture.IsPcapError(err) {
+		return err
+	} else {
+		exchanges = []*capture.Exchange{capture.DecodeReply(data, *as, g)}
+	}
+
+	for _, ex := range exchanges {
+		printExchange(ex)
+	}
+	return nil
+}
+
+func printExchange(ex *capture.Exchange) {
+	if ex.Request != "" {
+		fmt.Println("==", i18n.Sprintf("%s %s -> %s: %s (%d reply datagrams)", ex.At.Format("15:04:05.000"), ex.Client, ex.Server, ex.Request, len(ex.Replies)))
+	} else {
+		fmt.Println("==", i18n.Sprintf("raw reply as %s", ex.Command))
+	}
+	fmt.Println("--", i18n.T("normalized lines:"))
+	for _, l := range ex.Lines {
+		fmt.Printf("   %q\n", l)
+	}
+	if ex.Truncated {
+		fmt.Println("--", i18n.T("reply looks truncated"))
+	}
+	if ex.Dropped > 0 {
+		fmt.Println("--", i18n.Sprintf("%d datagram(s) of another reply type dropped", ex.Dropped))
+	}
+	if ex.Err != nil {
+		fmt.Println("--", i18n.T("parse error:"), ex.Err)
+	}
+	if ex.Parsed != nil {
+		fmt.Println("--", i18n.T("parsed:"))
+		out, _ := json.MarshalIndent(ex.Parsed, "   ", "  ")
+		fmt.Println("   " + string(out))
+	}
+	fmt.Println()
+}
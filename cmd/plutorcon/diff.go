@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/drift"
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+)
+
+func init() {
+	register("diff", "compare live dvars and rotation against a spec file", runDiff)
+}
+
+const (
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiReset = "\x1b[0m"
+)
+
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	profile := serverFlags(fs)
+	specPath := fs.String("spec", "server.yaml", i18n.T("desired state file"))
+	noColor := fs.Bool("no-color", !colors, i18n.T("disable colored output"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	spec, err := drift.LoadSpec(*specPath)
+	if err != nil {
+		return err
+	}
+	rc, err := dialProfile(*profile)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	rep, err := drift.Detect(rc, spec)
+	if err != nil {
+		return err
+	}
+	if !rep.Drifted() {
+		fmt.Println(i18n.T("no drift"))
+		return nil
+	}
+
+	color := func(code, s string) string {
+		if *noColor {
+			return s
+		}
+		return code + s + ansiReset
+	}
+	for _, d := range rep.Dvars {
+		fmt.Println(d.Name)
+		fmt.Println(color(ansiRed, fmt.Sprintf("  - %q", d.Want)))
+		fmt.Println(color(ansiGreen, fmt.Sprintf("  + %q", d.Got)))
+	}
+	if r := rep.Rotation; r != nil {
+		fmt.Println(i18n.T("rotation"))
+		fmt.Println(color(ansiRed, "  - "+strings.Join(r.Want, ", ")))
+		fmt.Println(color(ansiGreen, "  + "+strings.Join(r.Got, ", ")))
+	}
+
+	for _, name := range rep.MissingConfigs {
+		fmt.Println(color(ansiRed, i18n.Sprintf("missing config %s", name)))
+	}
+
+	n := len(rep.Dvars) + len(rep.MissingConfigs)
+	if rep.Rotation != nil {
+		n++
+	}
+	return i18n.Errorf("drift detected: %d setting(s) differ", n)
+}
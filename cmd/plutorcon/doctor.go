@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/events"
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+func init() {
+	register("doctor", "check connectivity, auth, parsing, log access and storage and suggest fixes", runDoctor)
+}
+
+func runDoctor(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	profile := serverFlags(fs)
+	logPath := fs.String("log", "", i18n.T("game log file to check (games_mp.log)"))
+	timeout := fs.Duration("timeout", 15*time.Second, i18n.T("bound for all checks"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	var probes []rcon.Probe
+	if *logPath != "" {
+		probes = append(probes, logProbe(*logPath))
+	}
+	probes = append(probes, storageProbe)
+
+	rc, err := dialProfile(*profile)
+	var d *rcon.Diagnosis
+	if err != nil {
+		d = &rcon.Diagnosis{At: time.Now(), Checks: []rcon.Check{{
+			Name: "config", Status: rcon.CheckFail, Detail: err.Error(),
+			Hint: "add a profile with plutorcon config add, or pass -s host:port -p password",
+		}}}
+		for _, p := range probes {
+			d.Checks = append(d.Checks, p(ctx))
+		}
+	} else {
+		defer rc.Close()
+		rc.Timeout = 3 * time.Second
+		d = rc.Diagnose(ctx, probes...)
+	}
+
+	if global.json {
+		if err := printJSON(d); err != nil {
+			return err
+		}
+	} else {
+		writeDiagnosis(os.Stdout, d)
+	}
+	if !d.OK() {
+		return i18n.Errorf("doctor: some checks failed")
+	}
+	return nil
+}
+
+// writeDiagnosis prints one line per check with its hint underneath. Details and hints
+// are translated here rather than in the checks, so -json output stays stable
+func writeDiagnosis(w io.Writer, d *rcon.Diagnosis) {
+	if d.Server != "" {
+		fmt.Fprintln(w, i18n.T("server:"), d.Server)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range d.Checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", i18n.T(strings.ToUpper(string(c.Status))), c.Name, i18n.T(c.Detail))
+		if c.Hint != "" && c.Status != rcon.CheckOK {
+			fmt.Fprintf(tw, "\t\t-> %s\n", i18n.T(c.Hint))
+		}
+	}
+	tw.Flush()
+}
+
+// logProbe checks that a game log exists, is being written and holds parseable lines
+func logProbe(path string) rcon.Probe {
+	return func(ctx context.Context) rcon.Check {
+		c := rcon.Check{Name: "log"}
+		f, err := os.Open(path)
+		if err != nil {
+			c.Status, c.Detail = rcon.CheckFail, err.Error()
+			c.Hint = "enable logging with g_log games_mp.log and g_logsync 1, and check the path and file permissions"
+			return c
+		}
+		defer f.Close()
+		fi, err := f.Stat()
+		if err != nil {
+			c.Status, c.Detail = rcon.CheckFail, err.Error()
+			return c
+		}
+		const tail = 64 << 10
+		if fi.Size() > tail {
+			f.Seek(fi.Size()-tail, io.SeekStart)
+		}
+		buf, err := io.ReadAll(io.LimitReader(f, tail))
+		if err != nil {
+			c.Status, c.Detail = rcon.CheckFail, err.Error()
+			return c
+		}
+		parsed := 0
+		for _, line := range strings.Split(string(buf), "\n") {
+			if _, ok := events.ParseLine(line); ok {
+				parsed++
+			}
+		}
+		age := time.Since(fi.ModTime()).Round(time.Second)
+		switch {
+		case fi.Size() == 0:
+			c.Status, c.Detail = rcon.CheckWarn, "the log is empty"
+			c.Hint = "set g_logsync 1 so lines are flushed as they happen"
+		case parsed == 0:
+			c.Status, c.Detail = rcon.CheckWarn, "no recognizable events in the last 64 KiB"
+			c.Hint = "check that this is the game log (games_mp.log), not the console log"
+		case age > 10*time.Minute:
+			c.Status, c.Detail = rcon.CheckWarn, fmt.Sprintf("last written %s ago", age)
+			c.Hint = "the server may be writing another file; check fs_homepath and g_log"
+		default:
+			c.Status, c.Detail = rcon.CheckOK, fmt.Sprintf("%d events in the tail, written %s ago", parsed, age)
+		}
+		return c
+	}
+}
+
+// storageProbe opens the -db database and runs a lookup against it
+func storageProbe(ctx context.Context) rcon.Check {
+	c := rcon.Check{Name: "storage"}
+	db, err := openDB()
+	if err != nil {
+		c.Status, c.Detail = rcon.CheckFail, err.Error()
+		c.Hint = "check the DSN, that the server is up and that this binary was built with the database driver"
+		return c
+	}
+	if db == nil {
+		c.Status, c.Detail = rcon.CheckSkip, "no -db set; players, notes and sessions use files in "+configDir()
+		return c
+	}
+	defer db.Close()
+	if _, err := db.Lookup("doctor"); err != nil {
+		c.Status, c.Detail = rcon.CheckFail, err.Error()
+		c.Hint = "the database opened but queries fail; check permissions and that migrations ran"
+		return c
+	}
+	c.Status, c.Detail = rcon.CheckOK, "queries succeed"
+	return c
+}
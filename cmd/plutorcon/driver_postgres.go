@@ -0,0 +1,6 @@
+//go:build postgres
+
+package main
+
+// Built with -tags postgres, the CLI opens postgres:// URLs through pgx
+import _ "github.com/jackc/pgx/v5/stdlib"
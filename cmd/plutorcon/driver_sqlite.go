@@ -0,0 +1,7 @@
+//go:build !nosqlite
+
+package main
+
+// The CLI opens -db files through the pure Go SQLite driver, so the database works
+// without cgo or extra setup; build with -tags nosqlite to leave it out
+import _ "modernc.org/sqlite"
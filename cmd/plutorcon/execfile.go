@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+func init() {
+	register("exec-file", "send every command of a file (or - for stdin)", runExecFile)
+}
+
+func runExecFile(args []string) error {
+	fs := flag.NewFlagSet("exec-file", flag.ContinueOnError)
+	profile := serverFlags(fs)
+	stop := fs.Bool("stop-on-error", false, i18n.T("stop at the first failing command"))
+	quiet := fs.Bool("quiet", false, i18n.T("only print failures"))
+	pace := fs.Duration("pace", 0, i18n.T("wait between commands"))
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), i18n.T("usage:"), "plutorcon exec-file [flags] <file>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return i18n.Errorf("exec-file: missing file")
+	}
+
+	var r io.Reader = os.Stdin
+	if path := fs.Arg(0); path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	rc, err := dialProfile(*profile)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	lines, err := rcon.ParseScript(r)
+	if err != nil {
+		return err
+	}
+	opts := rcon.ExecOptions{StopOnError: *stop, Pace: *pace}
+	opts.Each = func(res rcon.BatchResult) {
+		if res.Err != nil {
+			fmt.Fprintf(os.Stderr, "[%d/%d] %s: %s: %v\n", res.Index+1, len(lines), i18n.Sprintf("line %d", res.Line), res.Command, res.Err)
+			return
+		}
+		if !*quiet {
+			fmt.Printf("[%d/%d] %s (%s)\n", res.Index+1, len(lines), res.Command, res.Took.Round(time.Millisecond))
+		}
+	}
+	_, err = rc.ExecLines(lines, opts)
+	var execErr *rcon.ExecError
+	if errors.As(err, &execErr) {
+		return i18n.Errorf("%d of %d commands failed", len(execErr.Failed), execErr.Total)
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const keychainService = "plutorcon"
+
+var errNoKeychain = errors.New("no OS keychain available")
+
+// keychain stores secrets through the platform's credential tool: security(1) on macOS
+// and secret-tool(1) from libsecret on Linux. Other platforms fall back to the config file
+type keychain struct{}
+
+func (keychain) available() bool {
+	switch runtime.GOOS {
+	case "darwin":
+		_, err := exec.LookPath("security")
+		return err == nil
+	case "linux", "freebsd", "openbsd":
+		_, err := exec.LookPath("secret-tool")
+		return err == nil
+	}
+	return false
+}
+
+func (k keychain) set(account, secret string) error {
+	switch {
+	case !k.available():
+		return errNoKeychain
+	case runtime.GOOS == "darwin":
+		// security -i reads the command from stdin, which keeps the secret out of the process list
+		return run(strings.NewReader(securityCommand("add-generic-password", "-U", "-s", keychainService, "-a", account, "-w", secret)+"\n"), "security", "-i")
+	}
+	return run(strings.NewReader(secret), "secret-tool", "store", "--label", "plutorcon "+account, "service", keychainService, "account", account)
+}
+
+func (k keychain) get(account string) (string, error) {
+	var out []byte
+	var err error
+	switch {
+	case !k.available():
+		return "", errNoKeychain
+	case runtime.GOOS == "darwin":
+		out, err = exec.Command("security", "find-generic-password", "-s", keychainService, "-a", account, "-w").Output()
+	default:
+		out, err = exec.Command("secret-tool", "lookup", "service", keychainService, "account", account).Output()
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+func (k keychain) delete(account string) error {
+	switch {
+	case !k.available():
+		return errNoKeychain
+	case runtime.GOOS == "darwin":
+		return run(nil, "security", "delete-generic-password", "-s", keychainService, "-a", account)
+	}
+	return run(nil, "secret-tool", "clear", "service", keychainService, "account", account)
+}
+
+// securityCommand quotes args into a line for security -i
+func securityCommand(args ...string) string {
+	q := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	for i, a := range args {
+		args[i] = `"` + q.Replace(a) + `"`
+	}
+	return strings.Join(args, " ")
+}
+
+func run(stdin *strings.Reader, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return errors.New(name + ": " + msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+	"github.com/Yallamaztar/PlutoRCON/v2/loadtest"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon/rcontest"
+)
+
+func init() {
+	register("loadtest", "drive concurrent clients against a mock (or, with -live, a real) server", runLoadtest)
+}
+
+func runLoadtest(args []string) error {
+	fs := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	profile := fs.String("profile", "", i18n.T("load test this server profile instead of a local mock (needs -live)"))
+	live := fs.Bool("live", false, i18n.T("confirm that commands may be sent to a real server"))
+	clients := fs.Int("clients", 8, i18n.T("concurrent clients"))
+	duration := fs.Duration("duration", 0, i18n.T("how long to run (default 10s)"))
+	requests := fs.Int("requests", 0, i18n.T("cap on requests per client (0 = until -duration)"))
+	cmd := fs.String("cmd", "status", i18n.T("command to send"))
+	shared := fs.Bool("shared", false, i18n.T("share one client between all workers"))
+	queue := fs.Bool("queue", false, i18n.T("send through the async command queue"))
+	players := fs.Int("players", 12, i18n.T("players on the mock server"))
+	fragment := fs.Int("fragment", 0, i18n.T("split mock replies into datagrams of this many bytes"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := loadtest.Config{
+		Clients:      *clients,
+		Duration:     *duration,
+		Requests:     *requests,
+		Command:      *cmd,
+		SharedClient: *shared,
+		Queue:        *queue,
+	}
+	if *profile != "" {
+		if !*live {
+			return i18n.Errorf("loadtest: refusing to load a real server without -live")
+		}
+		cfg.Dial = func() (*rcon.RCONClient, error) { return dialProfile(*profile) }
+	} else {
+		srv := rcontest.NewServer("loadtest")
+		defer srv.Close()
+		srv.FragmentSize = *fragment
+		sim := srv.Simulate(rcontest.Churn{JoinChance: 1, MaxPlayers: *players})
+		for i := 0; i < *players; i++ {
+			sim.Step()
+		}
+		cfg.Dial = func() (*rcon.RCONClient, error) { return srv.Client() }
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	rep, err := loadtest.Run(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	rep.Write(os.Stdout)
+	return nil
+}
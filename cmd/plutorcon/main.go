@@ -0,0 +1,117 @@
+// Command plutorcon is a command line client for Plutonium servers
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+)
+
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]command{}
+
+// global holds the flags given before the subcommand
+var global struct {
+	server   string
+	password string
+	game     string
+	json     bool
+	db       string
+	noColor  bool
+	lang     string
+}
+
+// register adds a subcommand; each subcommand file registers itself in init
+func register(name, summary string, run func(args []string) error) {
+	commands[name] = command{summary: summary, run: run}
+}
+
+func main() {
+	i18n.SetLanguage("")
+	fs := flag.NewFlagSet("plutorcon", flag.ContinueOnError)
+	globalFlags(fs)
+	fs.Usage = usage
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return
+		}
+		os.Exit(2)
+	}
+	if global.lang != "" {
+		i18n.SetLanguage(global.lang)
+	}
+	args := fs.Args()
+	if len(args) == 0 || args[0] == "help" {
+		usage()
+		return
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "plutorcon: %s\n\n", i18n.Sprintf("unknown command %q", args[0]))
+		usage()
+		os.Exit(2)
+	}
+	ansi, restore := setupConsole()
+	initColors(ansi, global.noColor)
+	err := cmd.run(args[1:])
+	restore()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "plutorcon:", err)
+		os.Exit(1)
+	}
+}
+
+// globalFlags adds the flags accepted before any subcommand
+func globalFlags(fs *flag.FlagSet) {
+	fs.StringVar(&global.server, "s", "", "server host:port, instead of a profile")
+	fs.StringVar(&global.server, "server", "", "same as -s")
+	fs.StringVar(&global.password, "p", "", "rcon password for -s (default: $PLUTORCON_PASSWORD)")
+	fs.StringVar(&global.password, "password", "", "same as -p")
+	fs.StringVar(&global.game, "game", "", "game of the -s server (t6, iw5, t4, t5, iw6)")
+	fs.BoolVar(&global.json, "json", false, "print results as JSON")
+	fs.BoolVar(&global.noColor, "no-color", false, "print player names without colors (default: on when $NO_COLOR is set)")
+	fs.StringVar(&global.db, "db", "", "SQLite file or postgres:// URL for players, notes and sessions instead of files (default: $PLUTORCON_DB)")
+	fs.StringVar(&global.lang, "lang", "", "output language: en, de, es, pt or ru (default: $PLUTORCON_LANG, then $LANG)")
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, i18n.T("usage:"), "plutorcon [-s host:port -p password] [-json] [-lang code] <command> [arguments]")
+	fmt.Fprintln(os.Stderr)
+	names := make([]string, 0, len(commands))
+	for name, c := range commands {
+		if c.summary != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, i18n.T(commands[name].summary))
+	}
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// configDir returns the plutorcon config directory, honouring PLUTORCON_HOME
+func configDir() string {
+	if dir := os.Getenv("PLUTORCON_HOME"); dir != "" {
+		return dir
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, "plutorcon")
+	}
+	return ".plutorcon"
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+	"github.com/Yallamaztar/PlutoRCON/v2/metrics"
+)
+
+func init() {
+	register("metrics", "poll once and print metrics (influx, prom or json)", runMetrics)
+}
+
+func runMetrics(args []string) error {
+	fs := flag.NewFlagSet("metrics", flag.ContinueOnError)
+	profile := serverFlags(fs)
+	format := fs.String("format", "prom", i18n.T("output format: influx, prom or json"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	name, err := profileName(*profile)
+	if err != nil {
+		return err
+	}
+	rc, err := dialProfile(name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	snap := metrics.Collect(rc, name)
+	return metrics.Write(os.Stdout, *format, []*metrics.Snapshot{snap})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+	"github.com/Yallamaztar/PlutoRCON/v2/tracker"
+)
+
+func init() {
+	register("notes", "list or add moderator notes on a player", runNotes)
+}
+
+func runNotes(args []string) error {
+	fs := flag.NewFlagSet("notes", flag.ContinueOnError)
+	file := fs.String("file", filepath.Join(configDir(), "players.json"), i18n.T("player store file"))
+	author := fs.String("author", currentUser(), i18n.T("note author"))
+	server := fs.String("server", "", i18n.T("server the note is about"))
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), i18n.T("usage:"), "plutorcon notes [-file path] list <guid> | add [-author name] [-server name] <guid> <text>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	action := fs.Arg(0)
+	// Flags may also follow the action: notes add -server tdm-1 <guid> <text>
+	if err := fs.Parse(fs.Args()[min(1, fs.NArg()):]); err != nil {
+		return err
+	}
+	store, closeStore, err := playerStore(*file)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	t := &tracker.Tracker{Store: store}
+
+	switch action {
+	case "list":
+		if fs.NArg() < 1 {
+			return i18n.Errorf("notes list: missing player guid")
+		}
+		return listNotes(t, fs.Arg(0))
+	case "add":
+		if fs.NArg() < 2 {
+			return i18n.Errorf("notes add: usage: notes add <guid> <text>")
+		}
+		n, err := t.SaveNote(tracker.Note{GUID: fs.Arg(0), Author: *author, Server: *server, Text: strings.Join(fs.Args()[1:], " ")})
+		if err != nil {
+			return err
+		}
+		fmt.Println(i18n.Sprintf("noted on %s by %s", n.GUID, n.Author))
+		return nil
+	}
+	fs.Usage()
+	return i18n.Errorf("notes: unknown action %q", action)
+}
+
+func listNotes(t *tracker.Tracker, guid string) error {
+	notes, err := t.Notes(guid)
+	if err != nil {
+		return err
+	}
+	if len(notes) == 0 {
+		fmt.Println(i18n.Sprintf("no notes on %s", guid))
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, i18n.T("WRITTEN\tAUTHOR\tSERVER\tNOTE"))
+	for _, n := range notes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", n.At.Local().Format(time.DateTime), n.Author, n.Server, n.Text)
+	}
+	return tw.Flush()
+}
+
+// currentUser names the operator for notes
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+	"github.com/Yallamaztar/PlutoRCON/v2/tracker"
+)
+
+func init() {
+	register("players", "look up tracked players by guid or any name they used", runPlayers)
+}
+
+func runPlayers(args []string) error {
+	fs := flag.NewFlagSet("players", flag.ContinueOnError)
+	file := fs.String("file", filepath.Join(configDir(), "players.json"), i18n.T("player store file"))
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), i18n.T("usage:"), "plutorcon players [-file path] lookup <guid> | name <text>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	store, closeStore, err := playerStore(*file)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	t := &tracker.Tracker{Store: store}
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return i18n.Errorf("players: missing action or argument")
+	}
+
+	switch fs.Arg(0) {
+	case "lookup":
+		p, err := t.Lookup(fs.Arg(1))
+		if err != nil {
+			return err
+		}
+		if p == nil {
+			return i18n.Errorf("player %s was never seen", fs.Arg(1))
+		}
+		if global.json {
+			return printJSON(p)
+		}
+		return showPlayer(t, p)
+	case "name":
+		players, err := t.ByNameEver(strings.Join(fs.Args()[1:], " "))
+		if err != nil {
+			return err
+		}
+		if global.json {
+			return printJSON(players)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, i18n.T("GUID\tLAST SEEN\tSERVER\tNAMES"))
+		for _, p := range players {
+			names := make([]string, len(p.Names))
+			for i, n := range p.Names {
+				names[i] = n.Value
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", p.GUID, p.LastSeen.Local().Format(time.DateTime), p.LastServer, strings.Join(names, ", "))
+		}
+		return tw.Flush()
+	}
+	fs.Usage()
+	return i18n.Errorf("players: unknown action %q", fs.Arg(0))
+}
+
+func showPlayer(t *tracker.Tracker, p *tracker.Player) error {
+	fmt.Println(i18n.Sprintf("player %s  first seen %s  last seen %s on %s", p.GUID,
+		p.FirstSeen.Local().Format(time.DateTime), p.LastSeen.Local().Format(time.DateTime), p.LastServer))
+	fmt.Println(i18n.Sprintf("playtime %s", p.Playtime.Round(time.Minute)))
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, group := range []struct {
+		title string
+		list  []tracker.Seen
+	}{{i18n.T("NAME"), p.Names}, {"IP", p.IPs}} {
+		fmt.Fprintf(tw, "\n%s\t%s\n", group.title, i18n.T("FIRST SEEN\tLAST SEEN"))
+		for _, s := range group.list {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Value, s.FirstSeen.Local().Format(time.DateTime), s.LastSeen.Local().Format(time.DateTime))
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	notes, err := t.Notes(p.GUID)
+	if err != nil || len(notes) == 0 {
+		return err
+	}
+	fmt.Println("\n" + i18n.T("NOTES"))
+	for _, n := range notes {
+		fmt.Printf("%s  %s: %s\n", n.At.Local().Format(time.DateTime), n.Author, n.Text)
+	}
+	return nil
+}
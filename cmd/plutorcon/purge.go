@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+	"github.com/Yallamaztar/PlutoRCON/v2/session"
+	"github.com/Yallamaztar/PlutoRCON/v2/store"
+	"github.com/Yallamaztar/PlutoRCON/v2/tracker"
+)
+
+func init() {
+	register("purge", "delete a player's data, or everything older than a retention period", runPurge)
+}
+
+func runPurge(args []string) error {
+	fs := flag.NewFlagSet("purge", flag.ContinueOnError)
+	file := fs.String("file", filepath.Join(configDir(), "players.json"), i18n.T("player store file (without -db)"))
+	dir := fs.String("sessions", filepath.Join(configDir(), "sessions"), i18n.T("transcript directory (without -db)"))
+	older := fs.Duration("older-than", 0, i18n.T("delete what is older than this instead, e.g. 2160h"))
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), i18n.T("usage:"), "plutorcon purge [-file path] [-sessions dir] <guid> | -older-than duration")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if (*older > 0) == (fs.NArg() == 1) {
+		fs.Usage()
+		return i18n.Errorf("purge: give either a player guid or -older-than")
+	}
+	ctx := context.Background()
+	db, err := openDB()
+	if err != nil {
+		return err
+	}
+	if db != nil {
+		defer db.Close()
+		if *older == 0 {
+			if err := db.Purge(ctx, fs.Arg(0)); err != nil {
+				return err
+			}
+			fmt.Println(i18n.Sprintf("purged %s", fs.Arg(0)))
+			return nil
+		}
+		p, err := db.Enforce(ctx, store.Retention{Players: *older, Chat: *older, Stats: *older, Audit: *older})
+		if err != nil {
+			return err
+		}
+		fmt.Println(i18n.Sprintf("deleted %d players, %d chat lines, %d match results and %d audit entries", p.Players, p.Chat, p.Stats, p.Audit))
+		return nil
+	}
+
+	players := &tracker.FileStore{Path: *file}
+	sessions := &session.Store{Dir: *dir}
+	if *older == 0 {
+		// the names are looked up first, so transcripts lose them along with the guid
+		subject := session.Subject{GUID: strings.ToLower(strings.TrimSpace(fs.Arg(0)))}
+		p, err := players.Lookup(subject.GUID)
+		if err != nil {
+			return err
+		}
+		if p != nil {
+			for _, n := range p.Names {
+				subject.Names = append(subject.Names, n.Value)
+			}
+		}
+		if err := players.Purge(subject.GUID); err != nil {
+			return err
+		}
+		if err := sessions.Purge(subject); err != nil {
+			return err
+		}
+		fmt.Println(i18n.Sprintf("purged %s", fs.Arg(0)))
+		return nil
+	}
+	np, err := players.PurgeOlderThan(*older)
+	if err != nil {
+		return err
+	}
+	ns, err := sessions.PurgeOlderThan(*older)
+	if err != nil {
+		return err
+	}
+	fmt.Println(i18n.Sprintf("deleted %d players and %d session transcripts", np, ns))
+	return nil
+}
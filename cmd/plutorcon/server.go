@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+func init() {
+	register("kick", "kick a player by client number or name", runKick)
+	register("map", "change the map", runMap)
+}
+
+// serverFlags adds the flags shared by commands that talk to a server
+func serverFlags(fs *flag.FlagSet) *string {
+	return fs.String("profile", "", i18n.T("server profile (default: the current profile)"))
+}
+
+func runKick(args []string) error {
+	fs := flag.NewFlagSet("kick", flag.ContinueOnError)
+	profile := serverFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), i18n.T("usage:"), "plutorcon kick [-profile name] <player> [reason...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return i18n.Errorf("kick: missing player")
+	}
+	reason := strings.Join(fs.Args()[1:], " ")
+	if reason == "" {
+		reason = "Kicked by admin"
+	}
+
+	rc, err := dialProfile(*profile)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	num, err := resolvePlayer(rc, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return rc.Kick(strconv.Itoa(num), reason)
+}
+
+func runMap(args []string) error {
+	fs := flag.NewFlagSet("map", flag.ContinueOnError)
+	profile := serverFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return i18n.Errorf("usage: %s", "plutorcon map [-profile name] <mapname or display name>")
+	}
+	rc, err := dialProfile(*profile)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	name := fs.Arg(0)
+	if internal, ok := rcon.MapByDisplayName(rc.Game(), name); ok {
+		name = internal
+	}
+	_, err = rc.SendCommand("map", &name)
+	return err
+}
+
+// resolvePlayer maps a client number, GUID, IP or name to a client number
+func resolvePlayer(rc *rcon.RCONClient, who string) (int, error) {
+	if n, err := strconv.Atoi(who); err == nil && len(who) < 3 {
+		return n, nil
+	}
+	p, err := rc.FindPlayer(who)
+	if err != nil {
+		return 0, err
+	}
+	return p.ClientNum, nil
+}
+
+// cleanName strips ^N color codes from a player name
+func cleanName(name string) string {
+	return wire.StripColors(name)
+}
+
+// rotationMaps returns the distinct map names of the rotation
+func rotationMaps(rc *rcon.RCONClient) ([]string, error) {
+	rot, err := rc.GetMapRotation()
+	if err != nil {
+		return nil, err
+	}
+	var maps []string
+	seen := map[string]bool{}
+	for _, e := range rot {
+		if !seen[e.Map] {
+			seen[e.Map] = true
+			maps = append(maps, e.Map)
+		}
+	}
+	return maps, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+	"github.com/Yallamaztar/PlutoRCON/v2/session"
+)
+
+func init() {
+	register("sessions", "list or show recorded admin session transcripts", runSessions)
+}
+
+func runSessions(args []string) error {
+	fs := flag.NewFlagSet("sessions", flag.ContinueOnError)
+	dir := fs.String("dir", filepath.Join(configDir(), "sessions"), i18n.T("transcript directory"))
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), i18n.T("usage:"), "plutorcon sessions [-dir path] list | show <id>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	store := &session.Store{Dir: *dir}
+	db, err := openDB()
+	if err != nil {
+		return err
+	}
+	if db != nil {
+		defer db.Close()
+		store.Backend = db
+	}
+
+	switch fs.Arg(0) {
+	case "", "list":
+		return listSessions(store)
+	case "show":
+		if fs.NArg() < 2 {
+			return i18n.Errorf("sessions show: missing session id")
+		}
+		return showSession(store, fs.Arg(1))
+	}
+	fs.Usage()
+	return i18n.Errorf("sessions: unknown action %q", fs.Arg(0))
+}
+
+// recordSession writes a transcript of every command rc sends to the -db database or the
+// sessions directory, for `plutorcon sessions`. The returned func ends the session
+func recordSession(rc *rcon.RCONClient, source, profile string) (func(), error) {
+	server := global.server
+	if profile != "" || server == "" {
+		if name, err := profileName(profile); err == nil {
+			server = name
+		}
+	}
+	store := &session.Store{Dir: filepath.Join(configDir(), "sessions")}
+	db, err := openDB()
+	if err != nil {
+		return nil, err
+	}
+	if db != nil {
+		store.Backend = db
+	}
+	s, err := store.Open(operatorName(), source, server)
+	if err != nil {
+		if db != nil {
+			db.Close()
+		}
+		return nil, i18n.Errorf("recording session: %w", err)
+	}
+	rc.Use(s.Middleware())
+	return func() {
+		s.Close()
+		if db != nil {
+			db.Close()
+		}
+	}, nil
+}
+
+// operatorName is the local user, who operates the CLI's sessions
+func operatorName() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	for _, env := range []string{"USER", "USERNAME"} {
+		if name := os.Getenv(env); name != "" {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+func listSessions(store *session.Store) error {
+	metas, err := store.List()
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, i18n.T("ID\tOPERATOR\tSOURCE\tSERVER\tSTARTED"))
+	for _, m := range metas {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", m.ID, m.Operator, m.Source, m.Server, m.Started.Local().Format(time.DateTime))
+	}
+	return tw.Flush()
+}
+
+func showSession(store *session.Store, id string) error {
+	t, err := store.Load(id)
+	if err != nil {
+		return err
+	}
+	fmt.Println(i18n.Sprintf("session %s  operator %s  source %s  server %s", t.Meta.ID, t.Meta.Operator, t.Meta.Source, t.Meta.Server))
+	fmt.Print(i18n.Sprintf("started %s, %d commands", t.Meta.Started.Local().Format(time.DateTime), len(t.Entries)), "\n\n")
+	for _, e := range t.Entries {
+		cmd := e.Command
+		if e.Args != "" {
+			cmd += " " + e.Args
+		}
+		fmt.Printf("[%s] > %s\n", e.At.Local().Format(time.TimeOnly), cmd)
+		for _, line := range e.Response {
+			fmt.Println("    " + line)
+		}
+		if e.Error != "" {
+			fmt.Println("    ! " + strings.TrimSpace(e.Error))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+	"github.com/Yallamaztar/PlutoRCON/v2/session"
+	"github.com/Yallamaztar/PlutoRCON/v2/store"
+	"github.com/Yallamaztar/PlutoRCON/v2/tracker"
+)
+
+// database is what the CLI uses of store.SQLite and store.Postgres
+type database interface {
+	tracker.Store
+	session.Backend
+	Purge(ctx context.Context, guid string) error
+	Enforce(ctx context.Context, r store.Retention) (store.Purged, error)
+	Close() error
+}
+
+// openDB opens the -db database, or returns nil when none is set: a postgres:// URL
+// or an SQLite DSN. It needs a binary built with the matching driver imported
+func openDB() (database, error) {
+	dsn := global.db
+	if dsn == "" {
+		dsn = os.Getenv("PLUTORCON_DB")
+	}
+	if dsn == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		db, err := store.OpenPostgres(dsn)
+		if err != nil {
+			return nil, driverHint(err, "postgres")
+		}
+		return db, nil
+	}
+	db, err := store.OpenSQLite(dsn)
+	if err != nil {
+		return nil, driverHint(err, "sqlite")
+	}
+	return db, nil
+}
+
+// playerStore returns the -db database, or the player file at path without one
+func playerStore(path string) (tracker.Store, func(), error) {
+	db, err := openDB()
+	if err != nil {
+		return nil, nil, err
+	}
+	if db == nil {
+		return &tracker.FileStore{Path: path}, func() {}, nil
+	}
+	return db, func() { db.Close() }, nil
+}
+
+// driverHint points at the build tag when the binary was built without the driver
+func driverHint(err error, tag string) error {
+	if !errors.Is(err, store.ErrNoDriver) {
+		return err
+	}
+	if tag == "sqlite" {
+		return i18n.Errorf("%w (plutorcon was built with -tags nosqlite)", err)
+	}
+	return i18n.Errorf("%w (rebuild plutorcon with -tags %s)", err, tag)
+}
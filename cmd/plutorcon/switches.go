@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+	"github.com/Yallamaztar/PlutoRCON/v2/maintenance"
+)
+
+func init() {
+	register("switch", "pause or resume a subsystem (announcer, policies, rules) per server", runSwitch)
+}
+
+func runSwitch(args []string) error {
+	fs := flag.NewFlagSet("switch", flag.ContinueOnError)
+	file := fs.String("file", filepath.Join(configDir(), "switches.json"), i18n.T("switch state file the daemon reads"))
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), i18n.T("usage:"), "plutorcon switch [-file path] list | pause <subsystem> [server] | resume <subsystem> [server]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	sw := &maintenance.Switches{Path: *file}
+	action := fs.Arg(0)
+	switch action {
+	case "", "list":
+		paused, err := sw.List()
+		if err != nil {
+			return err
+		}
+		if len(paused) == 0 {
+			fmt.Println(i18n.T("nothing is paused"))
+			return nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, i18n.T("SUBSYSTEM\tSERVER\tPAUSED"))
+		for _, p := range paused {
+			server := p.Server
+			if server == "" {
+				server = i18n.T("(all)")
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", p.Subsystem, server, p.At.Local().Format(time.DateTime))
+		}
+		return tw.Flush()
+	case "pause", "resume":
+		if fs.NArg() < 2 {
+			return i18n.Errorf("switch %s: missing subsystem", action)
+		}
+		flip := sw.Resume
+		if action == "pause" {
+			flip = sw.Pause
+		}
+		if err := flip(fs.Arg(1), fs.Arg(2)); err != nil {
+			return err
+		}
+		format := i18n.T("%s paused on %s")
+		if action == "resume" {
+			format = i18n.T("%s resumed on %s")
+		}
+		where := i18n.T("every server")
+		if fs.Arg(2) != "" {
+			where = fs.Arg(2)
+		}
+		fmt.Printf(format+"\n", fs.Arg(1), where)
+		return nil
+	}
+	fs.Usage()
+	return i18n.Errorf("switch: unknown action %q", action)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// maxHistory is how many console lines are kept on disk and in memory
+const maxHistory = 1000
+
+// lineEditor reads console lines with history and Tab completion when stdin is a terminal,
+// and plain lines otherwise
+type lineEditor struct {
+	in       *bufio.Reader
+	fd       int
+	out      io.Writer
+	complete func(line string) []string
+	history  []string
+	// terminal is set when stdin is a terminal that can be switched to raw mode
+	terminal bool
+}
+
+func newLineEditor(in *os.File, out io.Writer, complete func(line string) []string) *lineEditor {
+	fd := int(in.Fd())
+	return &lineEditor{in: bufio.NewReader(in), fd: fd, out: out, complete: complete, terminal: term.IsTerminal(fd)}
+}
+
+// remember adds a line to the in-memory history
+func (e *lineEditor) remember(line string) {
+	if n := len(e.history); n > 0 && e.history[n-1] == line {
+		return
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+}
+
+// readLine reads one line; io.EOF is returned on Ctrl-D at an empty prompt or end of input
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	// the terminal is only raw while a line is edited, so replies printed in between keep
+	// their line endings; golang.org/x/term does this on Windows consoles too
+	var state *term.State
+	if e.terminal {
+		state, _ = term.MakeRaw(e.fd)
+	}
+	if state == nil {
+		fmt.Fprint(e.out, prompt)
+		line, err := e.in.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		return strings.TrimRight(line, "\r\n"), err
+	}
+
+	defer term.Restore(e.fd, state)
+
+	var buf []rune
+	pos, draft := len(e.history), ""
+	redraw := func() { fmt.Fprintf(e.out, "\r\x1b[K%s%s", prompt, string(buf)) }
+	redraw()
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case r == '\r' || r == '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(buf), nil
+		case r == 0x03: // Ctrl-C drops the line
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", nil
+		case r == 0x04: // Ctrl-D
+			if len(buf) == 0 {
+				return "", io.EOF
+			}
+		case r == 0x7f || r == 0x08:
+			if len(buf) > 0 {
+				buf = buf[:len(buf)-1]
+			}
+		case r == 0x15: // Ctrl-U
+			buf = buf[:0]
+		case r == 0x17: // Ctrl-W
+			line := strings.TrimRight(string(buf), " ")
+			buf = []rune(line[:strings.LastIndex(line, " ")+1])
+		case r == '\t':
+			buf = e.tab(buf)
+		case r == 0x1b:
+			switch e.escape() {
+			case "[A", "OA":
+				if pos == len(e.history) {
+					draft = string(buf)
+				}
+				if pos > 0 {
+					pos--
+					buf = []rune(e.history[pos])
+				}
+			case "[B", "OB":
+				if pos < len(e.history) {
+					pos++
+					if pos == len(e.history) {
+						buf = []rune(draft)
+					} else {
+						buf = []rune(e.history[pos])
+					}
+				}
+			}
+		case r >= 0x20:
+			buf = append(buf, r)
+		}
+		redraw()
+	}
+}
+
+// escape reads the rest of an escape sequence, such as "[A" for the up arrow
+func (e *lineEditor) escape() string {
+	r, _, err := e.in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return ""
+	}
+	seq := []rune{r}
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return ""
+		}
+		seq = append(seq, r)
+		if r >= 0x40 && r <= 0x7e {
+			return string(seq)
+		}
+	}
+}
+
+// tab completes the last word of buf, listing the candidates when there is more than one
+func (e *lineEditor) tab(buf []rune) []rune {
+	line := string(buf)
+	cands := e.complete(line)
+	if len(cands) == 0 {
+		return buf
+	}
+	start := strings.LastIndex(line, " ") + 1
+	if len(cands) == 1 {
+		return []rune(line[:start] + cands[0] + " ")
+	}
+	prefix := cands[0]
+	for _, c := range cands[1:] {
+		n := 0
+		for n < len(prefix) && n < len(c) && strings.EqualFold(prefix[n:n+1], c[n:n+1]) {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	if len(prefix) > len(line)-start {
+		return []rune(line[:start] + prefix)
+	}
+	shown := cands
+	if len(shown) > 100 {
+		shown = shown[:100]
+	}
+	fmt.Fprintf(e.out, "\r\n%s", strings.Join(shown, "  "))
+	if len(cands) > len(shown) {
+		fmt.Fprintf(e.out, "  (%d more)", len(cands)-len(shown))
+	}
+	fmt.Fprint(e.out, "\r\n")
+	return buf
+}
+
+// loadHistory reads the last maxHistory lines of the history file
+func loadHistory(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return nil
+	}
+	if len(lines) > maxHistory {
+		lines = lines[len(lines)-maxHistory:]
+	}
+	return lines
+}
+
+// appendHistory adds a line to the history file, leaving out anything that sets a password
+func appendHistory(path, line string) {
+	if strings.Contains(strings.ToLower(line), "password") {
+		return
+	}
+	if err := os.MkdirAll(configDir(), 0o700); err != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
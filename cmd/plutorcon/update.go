@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"runtime/debug"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+	"github.com/Yallamaztar/PlutoRCON/v2/update"
+)
+
+// version and releaseKey are set by release builds:
+//
+//	go build -ldflags "-X main.version=v2.1.0 -X main.releaseKey=<base64 ed25519 public key>"
+var (
+	version    = ""
+	releaseKey = ""
+)
+
+func init() {
+	register("version", "print the plutorcon version", runVersion)
+	register("self-update", "check for a signed release and replace this binary with it", runSelfUpdate)
+}
+
+// currentVersion is the -X version, or the module version of a go install build
+func currentVersion() string {
+	if version != "" {
+		return version
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		return bi.Main.Version
+	}
+	return "dev"
+}
+
+func runVersion(args []string) error {
+	fmt.Println("plutorcon", currentVersion())
+	return nil
+}
+
+func runSelfUpdate(args []string) error {
+	fs := flag.NewFlagSet("self-update", flag.ContinueOnError)
+	check := fs.Bool("check", false, i18n.T("only report whether a newer release exists"))
+	url := fs.String("url", update.DefaultURL, i18n.T("release manifest URL"))
+	key := fs.String("key", releaseKey, i18n.T("base64 ed25519 public key the manifest must be signed with"))
+	force := fs.Bool("force", false, i18n.T("replace a dev build too"))
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), i18n.T("usage:"), "plutorcon self-update [-check] [-url manifest] [-key pubkey] [-force]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *key == "" {
+		return i18n.Errorf("self-update: this build has no release key; pass -key or build with -X main.releaseKey")
+	}
+	pub, err := base64.StdEncoding.DecodeString(*key)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return i18n.Errorf("self-update: -key is not a base64 ed25519 public key")
+	}
+	current := currentVersion()
+	c := &update.Checker{URL: *url, Current: current, PublicKey: pub}
+	ctx := context.Background()
+	r, err := c.Check(ctx)
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		fmt.Println(i18n.Sprintf("plutorcon %s is up to date", current))
+		return nil
+	}
+	fmt.Println(i18n.Sprintf("plutorcon %s is available (running %s)", r.Version, current))
+	if r.Notes != "" {
+		fmt.Println(r.Notes)
+	}
+	if *check {
+		return nil
+	}
+	if current == "dev" && !*force {
+		return i18n.Errorf("self-update: not replacing a dev build without -force")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if err := c.Apply(ctx, r, exe); err != nil {
+		return err
+	}
+	fmt.Println(i18n.Sprintf("updated %s to %s; restart running daemons to use it", exe, r.Version))
+	return nil
+}
@@ -0,0 +1,99 @@
+package drift
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// Diff is one setting whose live value differs from the spec
+type Diff struct {
+	Name string
+	Want string
+	Got  string
+}
+
+type Report struct {
+	Dvars    []Diff
+	Rotation *RotationDiff
+	// MissingConfigs are spec configs the server doesn't have
+	MissingConfigs []string
+}
+
+// RotationDiff holds the desired and live rotation when they differ
+type RotationDiff struct {
+	Want []string
+	Got  []string
+}
+
+// Drifted reports whether anything differs
+func (r *Report) Drifted() bool {
+	return len(r.Dvars) > 0 || r.Rotation != nil || len(r.MissingConfigs) > 0
+}
+
+// Detect reads the spec's dvars (and sv_maprotation when a rotation is given) and
+// reports every difference, dvars sorted by name. Configs are only checked on titles
+// that can list files
+func Detect(rc *rcon.RCONClient, spec *Spec) (*Report, error) {
+	names := make([]string, 0, len(spec.Dvars)+1)
+	for name := range spec.Dvars {
+		names = append(names, name)
+	}
+	if len(spec.Rotation) > 0 {
+		names = append(names, "sv_maprotation")
+	}
+	live, err := rc.GetDvars(names...)
+	if err != nil {
+		return nil, err
+	}
+
+	rep := &Report{}
+	for name, want := range spec.Dvars {
+		got := live[name]
+		if !equalValues(want, got) {
+			rep.Dvars = append(rep.Dvars, Diff{Name: name, Want: want, Got: got})
+		}
+	}
+	sort.Slice(rep.Dvars, func(i, j int) bool { return rep.Dvars[i].Name < rep.Dvars[j].Name })
+
+	if len(spec.Rotation) > 0 {
+		got := rotationPairs(live["sv_maprotation"])
+		if !equalRotation(spec.Rotation, got) {
+			rep.Rotation = &RotationDiff{Want: spec.Rotation, Got: got}
+		}
+	}
+	for _, name := range spec.Configs {
+		ok, err := rc.ConfigExists(name)
+		if errors.Is(err, rcon.ErrUnsupported) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			rep.MissingConfigs = append(rep.MissingConfigs, name)
+		}
+	}
+	return rep, nil
+}
+
+// equalValues compares a spec value with the live one. Replies come back without
+// color codes, so the spec's are dropped; anything else must match exactly
+func equalValues(want, got string) bool {
+	return strings.TrimSpace(wire.StripColors(want)) == strings.TrimSpace(got)
+}
+
+func equalRotation(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+// Package drift compares a server's live configuration against a desired spec
+package drift
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is the desired state of a server
+type Spec struct {
+	Dvars map[string]string
+	// Rotation lists "gametype map" pairs, compared against sv_maprotation
+	Rotation []string
+	// Configs are config files that must exist on the server, checked where the title
+	// can list files
+	Configs []string
+}
+
+// LoadSpec reads a spec file
+func LoadSpec(path string) (*Spec, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	spec, err := ParseSpec(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// ParseSpec reads a YAML server spec:
+//
+//	dvars:
+//	  sv_hostname: "^2My Server"
+//	  g_gametype: tdm
+//	rotation:
+//	  - tdm mp_raid
+//	  - dom mp_slums
+//	configs:
+//	  - server.cfg
+//
+// rotation may also be a raw sv_maprotation string. Unknown sections are an error
+func ParseSpec(r io.Reader) (*Spec, error) {
+	var doc struct {
+		Dvars    map[string]string `yaml:"dvars"`
+		Rotation yaml.Node         `yaml:"rotation"`
+		Configs  []string          `yaml:"configs"`
+	}
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	spec := &Spec{Dvars: doc.Dvars, Configs: doc.Configs}
+	if spec.Dvars == nil {
+		spec.Dvars = map[string]string{}
+	}
+	switch rot := doc.Rotation; rot.Kind {
+	case 0:
+	case yaml.ScalarNode:
+		spec.Rotation = rotationPairs(rot.Value)
+	case yaml.SequenceNode:
+		var items []string
+		if err := rot.Decode(&items); err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			spec.Rotation = append(spec.Rotation, strings.Join(strings.Fields(item), " "))
+		}
+	default:
+		return nil, fmt.Errorf("line %d: rotation must be a list or an sv_maprotation string", rot.Line)
+	}
+	return spec, nil
+}
+
+// rotationPairs splits a raw sv_maprotation string into "gametype map" pairs
+func rotationPairs(rot string) []string {
+	var out []string
+	for _, e := range rcon.ParseMapRotation(rot) {
+		out = append(out, strings.TrimSpace(e.GameType+" "+e.Map))
+	}
+	return out
+}
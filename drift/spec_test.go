@@ -0,0 +1,62 @@
+package drift
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSpec(t *testing.T) {
+	spec, err := ParseSpec(strings.NewReader(`# tdm-1
+dvars:
+  sv_hostname: "^2My #1 Server"
+  scr_tdm_scorelimit: 7500
+  g_gametype: tdm # comment
+rotation:
+  - tdm   mp_raid
+  - dom mp_slums
+configs: [server.cfg]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Spec{
+		Dvars:    map[string]string{"sv_hostname": "^2My #1 Server", "scr_tdm_scorelimit": "7500", "g_gametype": "tdm"},
+		Rotation: []string{"tdm mp_raid", "dom mp_slums"},
+		Configs:  []string{"server.cfg"},
+	}
+	if !reflect.DeepEqual(spec, want) {
+		t.Errorf("ParseSpec =\n%+v\nwant\n%+v", spec, want)
+	}
+
+	spec, err = ParseSpec(strings.NewReader(`rotation: "gametype tdm map mp_raid map mp_slums"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"tdm mp_raid", "tdm mp_slums"}; !reflect.DeepEqual(spec.Rotation, want) {
+		t.Errorf("raw rotation = %q, want %q", spec.Rotation, want)
+	}
+
+	for _, in := range []string{"dvar:\n  a: b\n", "dvars: [a, b]\n", "rotation: {a: b}\n"} {
+		if _, err := ParseSpec(strings.NewReader(in)); err == nil {
+			t.Errorf("ParseSpec(%q) accepted", in)
+		}
+	}
+}
+
+func TestEqualValues(t *testing.T) {
+	tests := []struct {
+		want, got string
+		equal     bool
+	}{
+		{"^2My Server", "My Server", true},
+		{"tdm", " tdm", true},
+		{"TDM", "tdm", false},
+		{"1", "1.0", false},
+	}
+	for _, tt := range tests {
+		if got := equalValues(tt.want, tt.got); got != tt.equal {
+			t.Errorf("equalValues(%q, %q) = %t", tt.want, tt.got, got)
+		}
+	}
+}
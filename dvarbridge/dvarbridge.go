@@ -0,0 +1,196 @@
+// Package dvarbridge calls into server-side GSC mods through a pair of dvars: requests
+// are written to an "in" dvar and the mod answers in an "out" dvar that is polled
+package dvarbridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// ErrTimeout is returned when the mod doesn't answer in time
+var ErrTimeout = errors.New("no reply from mod")
+
+// RemoteError is an error the mod answered with
+type RemoteError struct {
+	Method  string
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("dvarbridge %s: %s", e.Method, e.Message)
+}
+
+// Bridge sends calls to a mod. A request is written to In as
+//
+//	<seq>|<method>|<arg>|<arg>...
+//
+// and the mod answers in Out with "<seq>|ok|<value>...", or "<seq>|err|<message>". A
+// long reply is sent in parts: "<seq>|more|<value>..." asks the bridge to write
+// "<seq>|next|<n>" for part n, which the mod answers as "<seq>.<n>|ok|..." (or more).
+// Fields are escaped with Escape. The sequence number lets a reply hold stale answers
+// apart, and calls on one bridge are sent one at a time
+type Bridge struct {
+	Client *rcon.RCONClient
+	// In and Out are the dvar names (default plutorcon_in and plutorcon_out)
+	In, Out string
+	// Timeout bounds one call, or one part of a reply, when ctx has no deadline (default 5s)
+	Timeout time.Duration
+	// Poll is how often Out is read while waiting (default 100ms)
+	Poll time.Duration
+
+	mu  sync.Mutex
+	seq uint32
+}
+
+// New returns a bridge over the default dvars
+func New(rc *rcon.RCONClient) *Bridge {
+	return &Bridge{Client: rc}
+}
+
+func (b *Bridge) in() string {
+	if b.In == "" {
+		return "plutorcon_in"
+	}
+	return b.In
+}
+
+func (b *Bridge) out() string {
+	if b.Out == "" {
+		return "plutorcon_out"
+	}
+	return b.Out
+}
+
+// Call runs method on the mod and returns the values it answered with
+func (b *Bridge) Call(ctx context.Context, method string, args ...string) ([]string, error) {
+	if method == "" {
+		return nil, errors.New("dvarbridge: empty method")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.seq == 0 {
+		// start somewhere a restarted client won't meet its old replies
+		b.seq = uint32(time.Now().UnixNano()>>20) | 1
+	}
+	b.seq++
+	seq := strconv.FormatUint(uint64(b.seq), 10)
+
+	fields := []string{seq, Escape(method)}
+	for _, a := range args {
+		fields = append(fields, Escape(a))
+	}
+	var values []string
+	request := strings.Join(fields, "|")
+	for part := 0; ; part++ {
+		id := seq
+		if part > 0 {
+			id += "." + strconv.Itoa(part)
+			request = seq + "|next|" + strconv.Itoa(part)
+		}
+		if err := b.Client.SetDvar(b.in(), request); err != nil {
+			return nil, err
+		}
+		status, vals, err := b.wait(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("dvarbridge %s: %w", method, err)
+		}
+		switch status {
+		case "ok":
+			return append(values, vals...), nil
+		case "more":
+			values = append(values, vals...)
+		case "err":
+			return nil, &RemoteError{Method: method, Message: strings.Join(vals, "|")}
+		default:
+			return nil, fmt.Errorf("dvarbridge %s: unknown reply status %q", method, status)
+		}
+	}
+}
+
+// wait polls Out until it carries the reply id
+func (b *Bridge) wait(ctx context.Context, id string) (string, []string, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		timeout := b.Timeout
+		if timeout <= 0 {
+			timeout = 5 * time.Second
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	every := b.Poll
+	if every <= 0 {
+		every = 100 * time.Millisecond
+	}
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		b.Client.InvalidateDvar(b.out())
+		v, err := b.Client.GetDvar(b.out())
+		if err == nil {
+			fields := strings.Split(v, "|")
+			if len(fields) >= 2 && fields[0] == id {
+				vals := fields[2:]
+				for i, f := range vals {
+					vals[i] = Unescape(f)
+				}
+				return fields[1], vals, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return "", nil, ErrTimeout
+			}
+			return "", nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// escaped are the bytes Escape encodes: the field separator, the escape itself, and
+// what the console or GetDvar would mangle (quotes, ;, color carets and control bytes)
+func escaped(c byte) bool {
+	return c < 0x20 || c == 0x7f || strings.IndexByte(`%|";^`, c) >= 0
+}
+
+// Escape encodes a field as %XX for the bytes the frame or the console can't carry.
+// Mods decode it by replacing each %XX with its byte
+func Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if escaped(c) {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// Unescape decodes a field encoded by Escape; malformed escapes are kept as they are
+func Unescape(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// resetBackstep is how far an offset may go backwards before the log is taken to have restarted
+const resetBackstep = 5 * time.Second
+
+// Clock maps the uptime stamps of log lines onto the local clock. The local time of
+// offset 0 is estimated as the earliest receive time minus offset seen so far, since
+// delivery only ever adds delay; times it returns keep a monotonic reading, so events
+// order correctly against RetrievedAt of RCON polls even across wall clock jumps
+type Clock struct {
+	mu     sync.Mutex
+	epoch  time.Time
+	last   time.Duration
+	lag    time.Duration
+	resets int
+}
+
+// Observe records a line stamped offset that was read at received and returns its local time
+func (c *Clock) Observe(offset time.Duration, received time.Time) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	start := received.Add(-offset)
+	switch {
+	case c.epoch.IsZero():
+		c.epoch = start
+	case offset < c.last-resetBackstep:
+		// The server restarted or reopened its log at 0:00
+		c.epoch = start
+		c.resets++
+	case start.Before(c.epoch):
+		c.epoch = start
+	}
+	c.last = offset
+	at := c.epoch.Add(offset)
+	c.lag = received.Sub(at)
+	return at
+}
+
+// Time returns the local time of a log offset, or the zero time before anything was observed
+func (c *Clock) Time(offset time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.epoch.IsZero() {
+		return time.Time{}
+	}
+	return c.epoch.Add(offset)
+}
+
+// Offset returns the log offset of a local time, e.g. a poll's RetrievedAt
+func (c *Clock) Offset(t time.Time) (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.epoch.IsZero() {
+		return 0, false
+	}
+	return t.Sub(c.epoch), true
+}
+
+// Skew returns how far the last line arrived after its stamp; it grows when the server
+// clock runs slow against the local one or log delivery backs up
+func (c *Clock) Skew() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lag
+}
+
+// Resets returns how often the log clock went back to an earlier offset
+func (c *Clock) Resets() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.resets
+}
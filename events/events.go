@@ -0,0 +1,212 @@
+// Package events parses games_mp.log lines into typed game events
+package events
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is one parsed log line
+type Event interface {
+	// Offset is the server uptime stamped on the log line
+	Offset() time.Duration
+	// Timestamp is the event time on the local clock, for ordering against RCON polls
+	Timestamp() time.Time
+}
+
+// Base holds the fields every event shares
+type Base struct {
+	At  time.Duration
+	Raw string
+	// Received is when the line was read, with a monotonic reading
+	Received time.Time
+	// Time is At mapped through the stream's Clock; it equals Received without one
+	Time time.Time
+}
+
+func (b Base) Offset() time.Duration { return b.At }
+
+func (b Base) Timestamp() time.Time {
+	if b.Time.IsZero() {
+		return b.Received
+	}
+	return b.Time
+}
+
+func (b *Base) base() *Base { return b }
+
+// Actor is a player as written in log lines
+type Actor struct {
+	GUID      string
+	ClientNum int
+	Name      string
+	Team      string
+}
+
+type PlayerJoin struct {
+	Base
+	Player Actor
+}
+
+type PlayerQuit struct {
+	Base
+	Player Actor
+}
+
+type Kill struct {
+	Base
+	Victim       Actor
+	Attacker     Actor
+	Weapon       string
+	Damage       int
+	MeansOfDeath string
+	HitLocation  string
+}
+
+// Suicide reports whether the victim killed themself or died to the world
+func (k *Kill) Suicide() bool {
+	return k.Attacker.ClientNum < 0 || k.Attacker.GUID == k.Victim.GUID
+}
+
+type ChatMessage struct {
+	Base
+	Player  Actor
+	Team    bool
+	Message string
+}
+
+// MapChange is emitted on InitGame with the server info string of the new map
+type MapChange struct {
+	Base
+	Map      string
+	GameType string
+	Vars     map[string]string
+}
+
+// Downed is logged by the zombies script (match.ZombiesScript) when a player goes
+// down: "ZD;guid;num;name"
+type Downed struct {
+	Base
+	Player Actor
+}
+
+// Revived is logged by the zombies script when a downed player is picked up:
+// "ZR;guid;num;name;reviver guid;reviver num;reviver name". Reviver is the world
+// (client number -1) when nobody revived them, e.g. with Quick Revive solo
+type Revived struct {
+	Base
+	Player  Actor
+	Reviver Actor
+}
+
+// RoundStart is logged by the zombies script as a round begins: "ZRND;round"
+type RoundStart struct {
+	Base
+	Round int
+}
+
+// ParseLine parses one games_mp.log line, returning false for lines that aren't one of the known events
+func ParseLine(line string) (Event, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	at, body := splitOffset(line)
+	base := Base{At: at, Raw: line}
+
+	if rest, ok := strings.CutPrefix(body, "InitGame:"); ok {
+		vars := infoVars(strings.TrimSpace(rest))
+		return &MapChange{Base: base, Map: vars["mapname"], GameType: vars["g_gametype"], Vars: vars}, true
+	}
+
+	f := strings.Split(body, ";")
+	switch f[0] {
+	case "J":
+		if len(f) < 4 {
+			return nil, false
+		}
+		return &PlayerJoin{Base: base, Player: actor(f[1], f[2], "", strings.Join(f[3:], ";"))}, true
+	case "Q":
+		if len(f) < 4 {
+			return nil, false
+		}
+		return &PlayerQuit{Base: base, Player: actor(f[1], f[2], "", strings.Join(f[3:], ";"))}, true
+	case "K":
+		if len(f) < 13 {
+			return nil, false
+		}
+		dmg, _ := strconv.Atoi(f[10])
+		return &Kill{
+			Base:         base,
+			Victim:       actor(f[1], f[2], f[3], f[4]),
+			Attacker:     actor(f[5], f[6], f[7], f[8]),
+			Weapon:       f[9],
+			Damage:       dmg,
+			MeansOfDeath: f[11],
+			HitLocation:  f[12],
+		}, true
+	case "say", "sayteam":
+		if len(f) < 5 {
+			return nil, false
+		}
+		msg := strings.Join(f[4:], ";")
+		msg = strings.TrimPrefix(msg, "\x15")
+		return &ChatMessage{Base: base, Player: actor(f[1], f[2], "", f[3]), Team: f[0] == "sayteam", Message: msg}, true
+	case "ZD":
+		if len(f) < 4 {
+			return nil, false
+		}
+		return &Downed{Base: base, Player: actor(f[1], f[2], "", strings.Join(f[3:], ";"))}, true
+	case "ZR":
+		if len(f) < 7 {
+			return nil, false
+		}
+		return &Revived{Base: base, Player: actor(f[1], f[2], "", f[3]), Reviver: actor(f[4], f[5], "", f[6])}, true
+	case "ZRND":
+		if len(f) < 2 {
+			return nil, false
+		}
+		round, err := strconv.Atoi(strings.TrimSpace(f[1]))
+		if err != nil {
+			return nil, false
+		}
+		return &RoundStart{Base: base, Round: round}, true
+	}
+	return nil, false
+}
+
+// splitOffset splits the leading "mmm:ss" uptime stamp off a log line
+func splitOffset(line string) (time.Duration, string) {
+	s := strings.TrimLeft(line, " ")
+	stamp, body, ok := strings.Cut(s, " ")
+	if !ok {
+		return 0, s
+	}
+	mins, secs, ok := strings.Cut(stamp, ":")
+	if !ok {
+		return 0, s
+	}
+	m, err1 := strconv.Atoi(mins)
+	sc, err2 := strconv.Atoi(secs)
+	if err1 != nil || err2 != nil {
+		return 0, s
+	}
+	return time.Duration(m)*time.Minute + time.Duration(sc)*time.Second, strings.TrimSpace(body)
+}
+
+// actor builds an Actor from log fields; a client number of -1 is the world
+func actor(guid, num, team, name string) Actor {
+	n, err := strconv.Atoi(strings.TrimSpace(num))
+	if err != nil {
+		n = -1
+	}
+	return Actor{GUID: guid, ClientNum: n, Name: name, Team: team}
+}
+
+// infoVars parses a \key\value info string
+func infoVars(s string) map[string]string {
+	parts := strings.Split(strings.TrimPrefix(s, `\`), `\`)
+	vars := make(map[string]string, len(parts)/2)
+	for i := 0; i+1 < len(parts); i += 2 {
+		vars[parts[i]] = parts[i+1]
+	}
+	return vars
+}
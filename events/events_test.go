@@ -0,0 +1,100 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want Event
+	}{
+		{
+			name: "join",
+			line: "  1:05 J;0110000100000001;3;Some;Name\r\n",
+			want: &PlayerJoin{Base: Base{At: 65 * time.Second, Raw: "  1:05 J;0110000100000001;3;Some;Name"},
+				Player: Actor{GUID: "0110000100000001", ClientNum: 3, Name: "Some;Name"}},
+		},
+		{
+			name: "quit",
+			line: "12:00 Q;0110000100000001;3;Bob",
+			want: &PlayerQuit{Base: Base{At: 12 * time.Minute, Raw: "12:00 Q;0110000100000001;3;Bob"},
+				Player: Actor{GUID: "0110000100000001", ClientNum: 3, Name: "Bob"}},
+		},
+		{
+			name: "kill",
+			line: "0:30 K;111;1;allies;Vic;222;2;axis;Att;ak47_mp;100;MOD_HEAD_SHOT;head",
+			want: &Kill{Base: Base{At: 30 * time.Second, Raw: "0:30 K;111;1;allies;Vic;222;2;axis;Att;ak47_mp;100;MOD_HEAD_SHOT;head"},
+				Victim:   Actor{GUID: "111", ClientNum: 1, Team: "allies", Name: "Vic"},
+				Attacker: Actor{GUID: "222", ClientNum: 2, Team: "axis", Name: "Att"},
+				Weapon:   "ak47_mp", Damage: 100, MeansOfDeath: "MOD_HEAD_SHOT", HitLocation: "head"},
+		},
+		{
+			name: "team chat",
+			line: "0:31 sayteam;111;1;Vic;\x15hold; B",
+			want: &ChatMessage{Base: Base{At: 31 * time.Second, Raw: "0:31 sayteam;111;1;Vic;\x15hold; B"},
+				Player: Actor{GUID: "111", ClientNum: 1, Name: "Vic"}, Team: true, Message: "hold; B"},
+		},
+		{
+			name: "map change",
+			line: `0:00 InitGame: \g_gametype\tdm\mapname\mp_raid`,
+			want: &MapChange{Base: Base{Raw: `0:00 InitGame: \g_gametype\tdm\mapname\mp_raid`},
+				Map: "mp_raid", GameType: "tdm", Vars: map[string]string{"g_gametype": "tdm", "mapname": "mp_raid"}},
+		},
+		{
+			name: "downed",
+			line: "3:10 ZD;111;1;Vic",
+			want: &Downed{Base: Base{At: 190 * time.Second, Raw: "3:10 ZD;111;1;Vic"}, Player: Actor{GUID: "111", ClientNum: 1, Name: "Vic"}},
+		},
+		{
+			name: "revived",
+			line: "3:20 ZR;111;1;Vic;222;2;Med",
+			want: &Revived{Base: Base{At: 200 * time.Second, Raw: "3:20 ZR;111;1;Vic;222;2;Med"},
+				Player: Actor{GUID: "111", ClientNum: 1, Name: "Vic"}, Reviver: Actor{GUID: "222", ClientNum: 2, Name: "Med"}},
+		},
+		{
+			name: "revived alone",
+			line: "3:20 ZR;111;1;Vic;;-1;",
+			want: &Revived{Base: Base{At: 200 * time.Second, Raw: "3:20 ZR;111;1;Vic;;-1;"},
+				Player: Actor{GUID: "111", ClientNum: 1, Name: "Vic"}, Reviver: Actor{ClientNum: -1}},
+		},
+		{
+			name: "round",
+			line: "4:00 ZRND;5",
+			want: &RoundStart{Base: Base{At: 4 * time.Minute, Raw: "4:00 ZRND;5"}, Round: 5},
+		},
+		{name: "bad round", line: "4:00 ZRND;x"},
+		{name: "short kill", line: "0:30 K;111;1;allies"},
+		{name: "unknown", line: "0:30 ShutdownGame:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseLine(tt.line)
+			if ok != (tt.want != nil) {
+				t.Fatalf("ParseLine ok = %t", ok)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLine =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKillSuicide(t *testing.T) {
+	tests := []struct {
+		k    Kill
+		want bool
+	}{
+		{Kill{Victim: Actor{GUID: "1", ClientNum: 1}, Attacker: Actor{GUID: "2", ClientNum: 2}}, false},
+		{Kill{Victim: Actor{GUID: "1", ClientNum: 1}, Attacker: Actor{GUID: "1", ClientNum: 1}}, true},
+		{Kill{Victim: Actor{GUID: "1", ClientNum: 1}, Attacker: Actor{ClientNum: -1}}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.k.Suicide(); got != tt.want {
+			t.Errorf("Suicide(%+v) = %t, want %t", tt.k, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+)
+
+// TailFile follows a games_mp.log from its current end, publishing new lines until ctx is done.
+// A truncated or rotated file is reopened from the start
+func TailFile(ctx context.Context, path string, s *Stream, poll time.Duration) error {
+	if poll <= 0 {
+		poll = 250 * time.Millisecond
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	r := bufio.NewReader(f)
+	var partial string
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		for {
+			line, err := r.ReadString('\n')
+			offset += int64(len(line))
+			if err != nil {
+				partial += line
+				break
+			}
+			s.PublishLine(partial + line)
+			partial = ""
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		st, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		cur, err := f.Stat()
+		if err == nil && os.SameFile(st, cur) && st.Size() >= offset {
+			continue
+		}
+		nf, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		f.Close()
+		f, offset, partial = nf, 0, ""
+		r.Reset(f)
+	}
+}
+
+// ListenUDP receives forwarded log lines on addr (e.g. ":27500") and publishes them until ctx is done.
+// Datagrams may carry the OOB "print" header and several lines
+func ListenUDP(ctx context.Context, addr string, s *Stream) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, 65535)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		pkt := bytes.TrimPrefix(buf[:n], wire.OOBHeader)
+		text := strings.TrimPrefix(string(pkt), "print\n")
+		for _, line := range strings.Split(text, "\n") {
+			if strings.TrimSpace(line) != "" {
+				s.PublishLine(line)
+			}
+		}
+	}
+}
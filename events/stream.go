@@ -0,0 +1,107 @@
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+)
+
+// Stream fans parsed events out to subscribers
+type Stream struct {
+	// Clock, when set, maps the uptime stamp of every published line to local time
+	Clock *Clock
+	// Codepage is the encoding log lines are converted to UTF-8 from, e.g. rcon.UTF8 (default CP1252)
+	Codepage wire.Codepage
+
+	mu      sync.Mutex
+	subs    map[int]chan Event
+	next    int
+	closed  bool
+	dropped atomic.Uint64
+}
+
+// Subscribe returns a channel of events and a func that unsubscribes and closes it.
+// Events are dropped for subscribers whose buffer is full rather than stalling the log reader
+func (s *Stream) Subscribe(buffer int) (<-chan Event, func()) {
+	if buffer <= 0 {
+		buffer = 64
+	}
+	ch := make(chan Event, buffer)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if s.subs == nil {
+		s.subs = map[int]chan Event{}
+	}
+	id := s.next
+	s.next++
+	s.subs[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if c, ok := s.subs[id]; ok {
+				delete(s.subs, id)
+				close(c)
+			}
+		})
+	}
+}
+
+// Publish sends an event to every subscriber
+func (s *Stream) Publish(ev Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ch := range s.subs {
+		select {
+		case ch <- ev:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// PublishLine parses a log line and publishes it if it is a known event, stamping it with the receive time
+func (s *Stream) PublishLine(line string) {
+	received := time.Now()
+	ev, ok := ParseLine(wire.ToUTF8(line, s.Codepage))
+	if !ok {
+		return
+	}
+	if b, ok := ev.(interface{ base() *Base }); ok {
+		base := b.base()
+		base.Received = received
+		base.Time = received
+		if s.Clock != nil {
+			base.Time = s.Clock.Observe(base.At, received)
+		}
+	}
+	s.Publish(ev)
+}
+
+// Dropped returns how many events were dropped for slow subscribers
+func (s *Stream) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close closes every subscriber channel
+func (s *Stream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	for id, ch := range s.subs {
+		delete(s.subs, id)
+		close(ch)
+	}
+}
@@ -0,0 +1,63 @@
+package examples_test
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/manager"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon/rcontest"
+)
+
+// Announce a restart on a fleet with one server down, find a player across the fleet
+// and set a dvar everywhere
+func Example_broadcast() {
+	fleet := manager.New()
+	defer fleet.Close()
+
+	servers := map[string]*rcontest.Server{}
+	for _, name := range []string{"tdm-1", "tdm-2", "snd-1"} {
+		s := rcontest.NewServer("secret")
+		defer s.Close()
+		// against a real server: rcon.New(ip, port, password)
+		rc, err := s.Client()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		servers[name] = s
+		fleet.Add(name, rc)
+	}
+	servers["tdm-2"].SetPlayers("mp_hijacked", []rcon.Player{
+		{ClientNum: 3, Name: "alpha", GUID: "0100000000000001", IP: "203.0.113.10:28960"},
+	})
+	servers["snd-1"].Close()
+
+	var failed *manager.Error
+	if err := fleet.Say("^3Restarting in 5 minutes"); errors.As(err, &failed) {
+		for name := range failed.Errors {
+			fmt.Println("say failed on", name)
+		}
+	}
+
+	if server, p, ok := fleet.FindGUID("0100000000000001"); ok {
+		fmt.Printf("%s is on %s\n", rcon.StripColors(p.Name), server)
+	}
+
+	fleet.Remove("snd-1").Close()
+	if err := fleet.SetDvar("g_motd", "Restart at 04:00 UTC"); err != nil {
+		fmt.Println(err)
+	}
+	names := fleet.Names()
+	sort.Strings(names)
+	for _, name := range names {
+		v, _ := servers[name].Dvar("g_motd")
+		fmt.Printf("%s g_motd = %q\n", name, v)
+	}
+	// Output:
+	// say failed on snd-1
+	// alpha is on tdm-2
+	// tdm-1 g_motd = "Restart at 04:00 UTC"
+	// tdm-2 g_motd = "Restart at 04:00 UTC"
+}
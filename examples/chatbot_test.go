@@ -0,0 +1,121 @@
+package examples_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/events"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon/rcontest"
+)
+
+// admins may change the map
+var admins = map[string]bool{"01000000000000ad": true}
+
+// handleChat runs one chat command and returns the reply for the sender
+func handleChat(rc *rcon.RCONClient, chat *events.ChatMessage) (string, bool) {
+	cmd, arg, _ := strings.Cut(strings.TrimSpace(chat.Message), " ")
+	switch strings.ToLower(cmd) {
+	case "!help":
+		return "commands: !players, !map <name>", true
+	case "!players":
+		st, err := rc.Status()
+		if err != nil {
+			return "status failed: " + err.Error(), true
+		}
+		names := make([]string, len(st.Players))
+		for i, p := range st.Players {
+			names[i] = rcon.StripColors(p.Name)
+		}
+		return fmt.Sprintf("%d on %s: %s", len(names), rcon.MapDisplayName(rc.Game(), st.Map), strings.Join(names, ", ")), true
+	case "!map":
+		if !admins[strings.ToLower(chat.Player.GUID)] {
+			return "only admins can change the map", true
+		}
+		name, ok := rcon.MapByDisplayName(rc.Game(), arg)
+		if !ok {
+			name = arg
+		}
+		if err := rc.ChangeMap(name, ""); err != nil {
+			return err.Error(), true
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// runChatbot answers the chat commands of evs until the stream closes
+func runChatbot(rc *rcon.RCONClient, evs <-chan events.Event) {
+	for ev := range evs {
+		chat, ok := ev.(*events.ChatMessage)
+		if !ok || !strings.HasPrefix(chat.Message, "!") {
+			continue
+		}
+		if reply, ok := handleChat(rc, chat); ok {
+			rc.Tell(chat.Player.ClientNum, reply)
+		}
+	}
+}
+
+// Answer !help, !players and an admin-only !map in chat. Log lines are published by
+// hand here; on a server, events.TailFile or events.ListenUDP publishes them
+func Example_chatbot() {
+	s := rcontest.NewServer("secret")
+	defer s.Close()
+	s.SetPlayers("mp_raid", []rcon.Player{
+		{ClientNum: 0, Name: "alpha", GUID: "0100000000000001", IP: "203.0.113.10:28960"},
+		{ClientNum: 1, Name: "admin", GUID: "01000000000000ad", IP: "203.0.113.11:28960"},
+	})
+	rc, err := s.Client()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer rc.Close()
+
+	stream := &events.Stream{}
+	evs, cancel := stream.Subscribe(64)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runChatbot(rc, evs)
+	}()
+
+	for _, line := range []string{
+		"  1:02 say;0100000000000001;0;alpha;!players",
+		"  1:05 say;0100000000000001;0;alpha;!map standoff",
+		"  1:09 say;01000000000000ad;1;admin;!map Nuketown 2025",
+	} {
+		stream.PublishLine(line)
+	}
+	// wait for the last command before stopping the bot
+	ctx, stop := context.WithTimeout(context.Background(), 10*time.Second)
+	defer stop()
+	for !sentCommand(s, "map") && ctx.Err() == nil {
+		time.Sleep(20 * time.Millisecond)
+	}
+	stream.Close()
+	<-done
+
+	for _, c := range s.Commands() {
+		if c.Name == "tell" || c.Name == "map" {
+			fmt.Println(c.Name, c.Args)
+		}
+	}
+	// Output:
+	// tell 0 2 on Raid: alpha, admin
+	// tell 0 only admins can change the map
+	// map mp_nuketown_2020
+}
+
+func sentCommand(s *rcontest.Server, name string) bool {
+	for _, c := range s.Commands() {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,4 @@
+// Package examples holds quickstart programs as runnable examples wired to the rcontest
+// mock server, so they need no game server. go test ./examples runs them and checks
+// their output; go doc shows them next to the packages they use
+package examples
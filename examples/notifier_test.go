@@ -0,0 +1,75 @@
+package examples_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/notify"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon/rcontest"
+)
+
+// Post Discord messages for a watched player joining, a player threshold and a map
+// change. The webhook is a local stand-in that records the posts
+func Example_notifier() {
+	var (
+		mu    sync.Mutex
+		posts []string
+	)
+	discord := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Content string }
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		posts = append(posts, body.Content)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer discord.Close()
+
+	s := rcontest.NewServer("secret")
+	defer s.Close()
+	alpha := rcon.Player{ClientNum: 0, Name: "alpha", GUID: "0100000000000001", IP: "203.0.113.10:28960"}
+	admin := rcon.Player{ClientNum: 1, Name: "admin", GUID: "01000000000000ad", IP: "203.0.113.11:28960"}
+	s.SetPlayers("mp_raid", []rcon.Player{alpha})
+
+	rc, err := s.Client()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer rc.Close()
+
+	n := &notify.Notifier{
+		// a channel's webhook URL goes here, from its Integrations settings
+		Webhook:   &notify.Webhook{URL: discord.URL},
+		Server:    "tdm-1",
+		Threshold: 2,
+		Watch:     []string{admin.GUID},
+		OnError:   func(ev notify.Event, err error) { fmt.Println(ev.Kind, err) },
+	}
+	m := &rcon.Monitor{Client: rc}
+	n.Attach(m)
+
+	// with a real server, call m.Run(ctx) instead of stepping the polls by hand
+	m.Poll()
+	s.SetPlayers("mp_raid", []rcon.Player{alpha, admin})
+	m.Poll()
+	s.SetPlayers("mp_nuketown_2020", []rcon.Player{alpha, admin})
+	m.Poll()
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, p := range posts {
+		fmt.Println(p)
+	}
+	// Output:
+	// **admin** (01000000000000ad) joined **tdm-1**
+	// **tdm-1** has 2 players (threshold 2)
+	// **tdm-1** changed map: Raid -> Nuketown 2025
+}
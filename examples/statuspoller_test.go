@@ -0,0 +1,48 @@
+package examples_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/metrics"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon/rcontest"
+)
+
+// Scrape a server into the Prometheus text format. Serve it with an http.HandlerFunc
+// on /metrics that writes the same output on every request
+func Example_statusPoller() {
+	s := rcontest.NewServer("secret")
+	defer s.Close()
+	s.SetInfo("hostname", "Example TDM")
+	s.SetInfo("sv_maxclients", "18")
+	s.SetPlayers("mp_raid", []rcon.Player{
+		{ClientNum: 0, Name: "alpha", GUID: "0100000000000001", Ping: 40, IP: "203.0.113.10:28960"},
+		{ClientNum: 1, Name: "bravo", GUID: "0100000000000002", Ping: 60, IP: "203.0.113.11:28960"},
+	})
+
+	// against a real server: rcon.New(ip, port, password)
+	rc, err := s.Client()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer rc.Close()
+
+	var buf bytes.Buffer
+	if err := metrics.WritePrometheus(&buf, []*metrics.Snapshot{metrics.Collect(rc, "tdm-1")}); err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, line := range strings.Split(buf.String(), "\n") {
+		// poll_ms changes from run to run
+		if strings.HasPrefix(line, "plutorcon_up{") || strings.HasPrefix(line, "plutorcon_players{") || strings.HasPrefix(line, "plutorcon_max_clients{") {
+			fmt.Println(line)
+		}
+	}
+	// Output:
+	// plutorcon_max_clients{server="tdm-1",map="mp_raid",gametype="tdm"} 18
+	// plutorcon_players{server="tdm-1",map="mp_raid",gametype="tdm"} 2
+	// plutorcon_up{server="tdm-1",map="mp_raid",gametype="tdm"} 1
+}
@@ -0,0 +1,211 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+const (
+	bannerWidth = 468
+	// bannerHeight holds two rows of top players; each further row adds a line
+	bannerHeight = 100
+)
+
+var (
+	bannerBG    = color.RGBA{0x1b, 0x1f, 0x27, 0xff}
+	bannerFG    = color.RGBA{0xf0, 0xf0, 0xf0, 0xff}
+	bannerMuted = color.RGBA{0x9a, 0xa4, 0xb5, 0xff}
+	bannerTitle = color.RGBA{0x4f, 0xc3, 0xf7, 0xff}
+)
+
+type Banner struct {
+	// Game picks the display names of Map and Gametype (default T6)
+	Game       rcon.Game
+	Hostname   string
+	Map        string
+	Gametype   string
+	Players    int
+	MaxPlayers int
+	Top        []rcon.Player
+}
+
+// BannerFromState builds banner data from a server state, keeping the top n scorers
+func BannerFromState(st *rcon.FullServerState, n int) Banner {
+	var b Banner
+	if st == nil {
+		return b
+	}
+	if st.Info != nil {
+		b.Hostname, b.Map, b.Gametype, b.MaxPlayers = st.Info.Hostname, st.Info.MapName, st.Info.GameType, st.Info.MaxClients
+	}
+	if st.StatusInfo != nil {
+		if b.Hostname == "" {
+			b.Hostname = st.StatusInfo.SvHostname
+		}
+		if b.MaxPlayers == 0 {
+			b.MaxPlayers = st.StatusInfo.SvMaxClients
+		}
+	}
+	if st.Status != nil {
+		if b.Map == "" {
+			b.Map = st.Status.Map
+		}
+		b.Players = len(st.Status.Players)
+		top := append([]rcon.Player(nil), st.Status.Players...)
+		sort.SliceStable(top, func(i, j int) bool { return top[i].Score > top[j].Score })
+		if len(top) > n {
+			top = top[:n]
+		}
+		b.Top = top
+	}
+	return b
+}
+
+// lines returns the text rows of the banner
+func (b Banner) lines() (title, sub string, top []string) {
+	title = wire.StripColors(b.Hostname)
+	if title == "" {
+		title = "Plutonium server"
+	}
+	sub = fmt.Sprintf("%s  %s  %d/%d players", rcon.MapDisplayName(b.Game, b.Map), rcon.GametypeDisplayName(b.Game, b.Gametype), b.Players, b.MaxPlayers)
+	for i, p := range b.Top {
+		top = append(top, fmt.Sprintf("%d. %s (%d)", i+1, wire.StripColors(p.Name), p.Score))
+	}
+	return
+}
+
+// SVG writes the banner as an SVG image
+func (b Banner) SVG(w io.Writer) error {
+	title, sub, top := b.lines()
+	height := heightFor(top, 16)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, bannerWidth, height, bannerWidth, height)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="%s"/>`, hexColor(bannerBG))
+	svgText(&buf, 12, 26, 18, bannerTitle, title)
+	svgText(&buf, 12, 46, 12, bannerMuted, sub)
+	for i, line := range top {
+		svgText(&buf, 12+float64(i%2)*228, 66+float64(i/2)*16, 12, bannerFG, line)
+	}
+	buf.WriteString(`</svg>`)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// PNG writes the banner as a PNG image using the built-in bitmap font
+func (b Banner) PNG(w io.Writer) error {
+	title, sub, top := b.lines()
+	img := image.NewRGBA(image.Rect(0, 0, bannerWidth, heightFor(top, 14)))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = bannerBG.R, bannerBG.G, bannerBG.B, bannerBG.A
+	}
+	drawText(img, 12, 10, 2, bannerTitle, title)
+	drawText(img, 12, 34, 1, bannerMuted, sub)
+	for i, line := range top {
+		drawText(img, 12+(i%2)*228, 56+(i/2)*14, 1, bannerFG, line)
+	}
+	return png.Encode(w, img)
+}
+
+// heightFor returns the banner height fitting the top rows, two players per row,
+// at the given line pitch
+func heightFor(top []string, pitch int) int {
+	rows := (len(top) + 1) / 2
+	return bannerHeight + max(0, rows-2)*pitch
+}
+
+// svgText writes one escaped text element
+func svgText(buf *bytes.Buffer, x, y, size float64, c color.RGBA, s string) {
+	fmt.Fprintf(buf, `<text x="%g" y="%g" font-family="monospace" font-size="%g" fill="%s">`, x, y, size, hexColor(c))
+	xml.EscapeText(buf, []byte(s))
+	buf.WriteString(`</text>`)
+}
+
+// drawText renders s with the 5x7 font at the given pixel scale, clipping at the image edge
+func drawText(img *image.RGBA, x, y, scale int, c color.RGBA, s string) {
+	for _, r := range s {
+		if x+5*scale > img.Bounds().Dx() {
+			return
+		}
+		if r < 32 || r > 126 {
+			r = '?'
+		}
+		glyph := font5x7[r-32]
+		for col := 0; col < 5; col++ {
+			for row := 0; row < 7; row++ {
+				if glyph[col]&(1<<uint(row)) == 0 {
+					continue
+				}
+				for dx := 0; dx < scale; dx++ {
+					for dy := 0; dy < scale; dy++ {
+						img.SetRGBA(x+col*scale+dx, y+row*scale+dy, c)
+					}
+				}
+			}
+		}
+		x += 6 * scale
+	}
+}
+
+// hexColor formats a color as #rrggbb
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// BannerHandler renders the latest state of a StatusHandler as an image.
+// The format is taken from ?format=png|svg, defaulting to Format
+type BannerHandler struct {
+	Status *StatusHandler
+	Format string
+	// Top is the number of top players shown (default 4); the banner grows a line
+	// for every two beyond that
+	Top int
+	// Game picks the display names of the map and gametype (default T6)
+	Game rcon.Game
+}
+
+// ServeHTTP implements http.Handler
+func (h *BannerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	st := h.Status.State()
+	if st == nil {
+		http.Error(w, "no state yet", http.StatusServiceUnavailable)
+		return
+	}
+	top := h.Top
+	if top <= 0 {
+		top = 4
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = h.Format
+	}
+
+	b := BannerFromState(st, top)
+	b.Game = h.Game
+	var buf bytes.Buffer
+	var err error
+	if format == "png" {
+		w.Header().Set("Content-Type", "image/png")
+		err = b.PNG(&buf)
+	} else {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		err = b.SVG(&buf)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "max-age=30")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.Write(buf.Bytes())
+}
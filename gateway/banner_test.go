@@ -0,0 +1,48 @@
+package gateway
+
+import (
+	"bytes"
+	"image/png"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+func TestBannerFitsTop(t *testing.T) {
+	for _, tt := range []struct {
+		top       int
+		png, svgH int
+	}{
+		{top: 0, png: 100, svgH: 100},
+		{top: 4, png: 100, svgH: 100},
+		{top: 5, png: 114, svgH: 116},
+		{top: 8, png: 128, svgH: 132},
+	} {
+		b := Banner{Hostname: "server", Map: "mp_raid"}
+		for i := range tt.top {
+			b.Top = append(b.Top, rcon.Player{Name: "player" + strconv.Itoa(i), Score: 10 - i})
+		}
+
+		var buf bytes.Buffer
+		if err := b.PNG(&buf); err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h := img.Bounds().Dy(); h != tt.png {
+			t.Errorf("top %d: PNG height %d, want %d", tt.top, h, tt.png)
+		}
+
+		buf.Reset()
+		if err := b.SVG(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if want := `height="` + strconv.Itoa(tt.svgH) + `"`; !strings.Contains(buf.String(), want) {
+			t.Errorf("top %d: SVG without %s", tt.top, want)
+		}
+	}
+}
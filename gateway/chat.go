@@ -0,0 +1,60 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/chatlog"
+)
+
+// ChatHandler searches indexed chat: q holds the words to find (word* for a prefix),
+// guid, server, from and to (same formats as TimelineHandler) narrow it and limit
+// bounds the reply (default 100, at most 1000)
+type ChatHandler struct {
+	Index *chatlog.Index
+	// Location interprets HH:MM times (default time.Local)
+	Location *time.Location
+}
+
+// ServeHTTP implements http.Handler
+func (h *ChatHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v := r.URL.Query()
+	q := chatlog.Query{Text: v.Get("q"), GUID: v.Get("guid"), Server: v.Get("server"), Limit: 100}
+	now := time.Now()
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &q.From}, {"to", &q.To}} {
+		s := v.Get(p.name)
+		if s == "" {
+			continue
+		}
+		t, err := parseTime(s, now, h.Location)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		*p.dst = t
+	}
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		q.Limit = min(n, 1000)
+	}
+
+	msgs, err := h.Index.Search(r.Context(), q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if msgs == nil {
+		msgs = []chatlog.Message{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"query": q.Text, "messages": msgs})
+}
@@ -0,0 +1,77 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/tracker"
+)
+
+// NotesHandler serves moderators' notes on players: GET ?guid=... lists them and POST
+// with a JSON body {"guid", "text", "server"} adds one. A note without a server is about
+// the player on every server. Put it behind the same authentication as the rest of the
+// admin tools
+type NotesHandler struct {
+	Tracker *tracker.Tracker
+	// Identity names the authenticated moderator of a request, who becomes the author
+	// of the note; any author in the body is ignored. POST is refused while it is nil
+	// or returns ""
+	Identity func(r *http.Request) string
+}
+
+// ServeHTTP implements http.Handler
+func (h *NotesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		guid := r.URL.Query().Get("guid")
+		if guid == "" {
+			http.Error(w, "missing guid", http.StatusBadRequest)
+			return
+		}
+		notes, err := h.Tracker.Notes(guid)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if notes == nil {
+			notes = []tracker.Note{}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"guid": guid, "notes": notes})
+	case http.MethodPost:
+		author := ""
+		if h.Identity != nil {
+			author = h.Identity(r)
+		}
+		if author == "" {
+			http.Error(w, "unauthenticated", http.StatusUnauthorized)
+			return
+		}
+		var n tracker.Note
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&n); err != nil {
+			http.Error(w, "invalid note: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		n.Author, n.At = author, time.Time{}
+		n, err := h.Tracker.SaveNote(n)
+		if errors.Is(err, tracker.ErrInvalidNote) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusCreated, n)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
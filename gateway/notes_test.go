@@ -0,0 +1,58 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/tracker"
+)
+
+func TestNotesHandler(t *testing.T) {
+	tr := &tracker.Tracker{Store: &tracker.FileStore{Path: filepath.Join(t.TempDir(), "players.json")}}
+	h := &NotesHandler{Tracker: tr, Identity: func(r *http.Request) string { return r.Header.Get("X-Moderator") }}
+	do := func(method, url, moderator, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		if moderator != "" {
+			req.Header.Set("X-Moderator", moderator)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/notes", "", `{"guid":"abc","text":"spawn camping"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("POST without a moderator: %d, want 401", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/notes", "alice", `{"guid":"abc","text":"  "}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("POST with empty text: %d, want 400", rec.Code)
+	}
+	rec := do(http.MethodPost, "/notes", "alice", `{"guid":"ABC","text":"spawn camping","author":"bob","server":"tdm-1"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST: %d %s", rec.Code, rec.Body)
+	}
+
+	rec = do(http.MethodGet, "/notes?guid=abc", "", "")
+	var got struct {
+		Notes []tracker.Note `json:"notes"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Notes) != 1 {
+		t.Fatalf("notes %+v, want one", got.Notes)
+	}
+	if n := got.Notes[0]; n.GUID != "abc" || n.Author != "alice" || n.Server != "tdm-1" || n.At.IsZero() {
+		t.Errorf("note %+v, want abc by alice on tdm-1 with a time", n)
+	}
+
+	if rec := do(http.MethodGet, "/notes", "", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("GET without guid: %d, want 400", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/notes?guid=abc", "alice", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE: %d, want 405", rec.Code)
+	}
+}
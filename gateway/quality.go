@@ -0,0 +1,38 @@
+package gateway
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/policy"
+)
+
+// QualityHandler serves the connection quality a policy.QualityTracker keeps: GET lists
+// every tracked player, worst median ping first, and GET ?guid=... returns one
+type QualityHandler struct {
+	Tracker *policy.QualityTracker
+}
+
+// ServeHTTP implements http.Handler
+func (h *QualityHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Tracker == nil {
+		http.Error(w, "quality tracking disabled", http.StatusServiceUnavailable)
+		return
+	}
+	// GUIDs are tracked lowercase, while status and the game print them in either case
+	if guid := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("guid"))); guid != "" {
+		q, ok := h.Tracker.PlayerQuality(guid)
+		if !ok {
+			http.Error(w, "player not tracked", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, q)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"players": h.Tracker.Players()})
+}
@@ -0,0 +1,40 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/policy"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+func TestQualityHandler(t *testing.T) {
+	get := func(h http.Handler, url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		return rec
+	}
+	if rec := get(&QualityHandler{}, "/quality"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("without a tracker: %d, want 503", rec.Code)
+	}
+
+	tracker := &policy.QualityTracker{}
+	tracker.Observe(&rcon.ServerStatus{Players: []rcon.Player{{GUID: "AbC123", Ping: 40}}})
+	h := &QualityHandler{Tracker: tracker}
+	if rec := get(h, "/quality?guid=def"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown guid: %d, want 404", rec.Code)
+	}
+	rec := get(h, "/quality?guid=ABC123")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("guid in upper case: %d, want 200", rec.Code)
+	}
+	var q policy.Quality
+	if err := json.NewDecoder(rec.Body).Decode(&q); err != nil {
+		t.Fatal(err)
+	}
+	if q.GUID != "abc123" || q.Samples != 1 {
+		t.Errorf("quality %+v, want one sample of abc123", q)
+	}
+}
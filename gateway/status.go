@@ -0,0 +1,102 @@
+// Package gateway serves server state over HTTP
+package gateway
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// StatusHandler serves the latest server state as JSON marshaled once per update,
+// so scrapes never cause RCON traffic or re-marshaling
+type StatusHandler struct {
+	latest atomic.Pointer[cachedState]
+}
+
+type cachedState struct {
+	state     *rcon.FullServerState
+	body      []byte
+	etag      string
+	updatedAt time.Time
+}
+
+// Update marshals a new state and makes it the one being served
+func (h *StatusHandler) Update(state *rcon.FullServerState) error {
+	body, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	sum := sha1.Sum(body)
+	at := time.Now()
+	if state != nil && !state.RetrievedAt.IsZero() {
+		at = state.RetrievedAt
+	}
+	h.latest.Store(&cachedState{state: state, body: body, etag: `"` + hex.EncodeToString(sum[:8]) + `"`, updatedAt: at})
+	return nil
+}
+
+// State returns the latest state, or nil before the first update
+func (h *StatusHandler) State() *rcon.FullServerState {
+	if st := h.latest.Load(); st != nil {
+		return st.state
+	}
+	return nil
+}
+
+// Run polls the client every interval and updates the handler until ctx is done
+func (h *StatusHandler) Run(ctx context.Context, rc *rcon.RCONClient, interval time.Duration, onError func(error)) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	poll := func() {
+		state, err := rc.FullState(rcon.WithPriority(rcon.PriorityPoll))
+		if err == nil {
+			err = h.Update(state)
+		}
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}
+
+	poll()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			poll()
+		}
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (h *StatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	st := h.latest.Load()
+	if st == nil {
+		http.Error(w, "no state yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	hdr := w.Header()
+	hdr.Set("Content-Type", "application/json")
+	hdr.Set("ETag", st.etag)
+	hdr.Set("Last-Modified", st.updatedAt.UTC().Format(http.TimeFormat))
+	if r.Header.Get("If-None-Match") == st.etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	hdr.Set("Content-Length", strconv.Itoa(len(st.body)))
+	if r.Method == http.MethodHead {
+		return
+	}
+	w.Write(st.body)
+}
@@ -0,0 +1,41 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+func TestStatusHandler(t *testing.T) {
+	h := &StatusHandler{}
+	get := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/status", nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := get(""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("before the first update: %d, want 503", rec.Code)
+	}
+
+	h.Update(&rcon.FullServerState{Status: &rcon.ServerStatus{Map: "mp_raid"}})
+	rec := get("")
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" || !strings.Contains(rec.Body.String(), "mp_raid") {
+		t.Fatalf("after an update: %d %q %s", rec.Code, etag, rec.Body)
+	}
+	if rec := get(etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("same ETag: %d with %d bytes, want 304 and no body", rec.Code, rec.Body.Len())
+	}
+
+	h.Update(&rcon.FullServerState{Status: &rcon.ServerStatus{Map: "mp_nuketown_2020"}})
+	if rec := get(etag); rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Errorf("after a change: %d with ETag %q, want 200 and a new ETag", rec.Code, rec.Header().Get("ETag"))
+	}
+}
@@ -0,0 +1,62 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/maintenance"
+)
+
+// SwitchesHandler pauses and resumes subsystems at runtime: GET lists the paused ones
+// and POST with a JSON body {"subsystem", "server", "paused"} flips one, an empty
+// server meaning every server. Either replies with the paused list. Put it behind the
+// same authentication as the rest of the admin tools
+type SwitchesHandler struct {
+	Switches *maintenance.Switches
+}
+
+// ServeHTTP implements http.Handler
+func (h *SwitchesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req struct {
+			Subsystem string `json:"subsystem"`
+			Server    string `json:"server"`
+			Paused    bool   `json:"paused"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+			http.Error(w, "invalid switch: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Subsystem == "" {
+			http.Error(w, "missing subsystem", http.StatusBadRequest)
+			return
+		}
+		flip := h.Switches.Resume
+		if req.Paused {
+			flip = h.Switches.Pause
+		}
+		if err := flip(req.Subsystem, req.Server); errors.Is(err, maintenance.ErrPausedEverywhere) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	paused, err := h.Switches.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if paused == nil {
+		paused = []maintenance.Switch{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"paused": paused})
+}
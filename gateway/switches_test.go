@@ -0,0 +1,41 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/maintenance"
+)
+
+func TestSwitchesHandler(t *testing.T) {
+	h := &SwitchesHandler{Switches: &maintenance.Switches{}}
+	do := func(method, body string) ([]maintenance.Switch, int) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, "/switches", strings.NewReader(body)))
+		var got struct {
+			Paused []maintenance.Switch `json:"paused"`
+		}
+		json.NewDecoder(rec.Body).Decode(&got)
+		return got.Paused, rec.Code
+	}
+
+	paused, code := do(http.MethodPost, `{"subsystem":"Announcer","paused":true}`)
+	if code != http.StatusOK || len(paused) != 1 || paused[0].Subsystem != maintenance.Announcer || paused[0].Server != "" {
+		t.Fatalf("pause everywhere: %d %+v", code, paused)
+	}
+	if _, code := do(http.MethodPost, `{"subsystem":"announcer","server":"tdm-1"}`); code != http.StatusConflict {
+		t.Errorf("resume one server of a subsystem paused everywhere: %d, want 409", code)
+	}
+	if _, code := do(http.MethodPost, `{"paused":true}`); code != http.StatusBadRequest {
+		t.Errorf("missing subsystem: %d, want 400", code)
+	}
+	if paused, code := do(http.MethodPost, `{"subsystem":"announcer"}`); code != http.StatusOK || len(paused) != 0 {
+		t.Errorf("resume everywhere: %d %+v, want nothing paused", code, paused)
+	}
+	if paused, code := do(http.MethodGet, ""); code != http.StatusOK || paused == nil {
+		t.Errorf("GET: %d %v, want an empty list", code, paused)
+	}
+}
@@ -0,0 +1,123 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/timeline"
+)
+
+// TimelineHandler serves merged timelines as JSON. The range is given as from/to
+// (RFC 3339, HH:MM or a duration relative to now such as -30m) or as at=21:35 with an
+// optional window (default 5m) around it; server narrows the reply to one server
+type TimelineHandler struct {
+	Sources timeline.Sources
+	// Location interprets HH:MM times (default time.Local)
+	Location *time.Location
+	// MaxRange bounds to - from (default 24h)
+	MaxRange time.Duration
+}
+
+// ServeHTTP implements http.Handler
+func (h *TimelineHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	from, to, err := h.parseRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	all, err := h.Sources.Timeline(r.Context(), from, to)
+	if err != nil && len(all) == 0 {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	body := map[string]any{"from": from, "to": to}
+	if server := r.URL.Query().Get("server"); server != "" {
+		entries := all[server]
+		if entries == nil {
+			entries = []timeline.Entry{}
+		}
+		body["server"], body["entries"] = server, entries
+	} else {
+		body["servers"] = all
+	}
+	if err != nil {
+		body["error"] = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+// parseRange reads the requested time range from the query string
+func (h *TimelineHandler) parseRange(r *http.Request) (from, to time.Time, err error) {
+	q := r.URL.Query()
+	now := time.Now()
+	if at := q.Get("at"); at != "" {
+		t, err := parseTime(at, now, h.Location)
+		if err != nil {
+			return from, to, err
+		}
+		window := 5 * time.Minute
+		if s := q.Get("window"); s != "" {
+			if window, err = time.ParseDuration(s); err != nil || window <= 0 {
+				return from, to, fmt.Errorf("invalid window %q", s)
+			}
+		}
+		from, to = t.Add(-window), t.Add(window)
+	} else {
+		to = now
+		if s := q.Get("to"); s != "" {
+			if to, err = parseTime(s, now, h.Location); err != nil {
+				return from, to, err
+			}
+		}
+		from = to.Add(-time.Hour)
+		if s := q.Get("from"); s != "" {
+			if from, err = parseTime(s, now, h.Location); err != nil {
+				return from, to, err
+			}
+		}
+	}
+	max := h.MaxRange
+	if max <= 0 {
+		max = 24 * time.Hour
+	}
+	switch {
+	case !from.Before(to):
+		return from, to, fmt.Errorf("from must be before to")
+	case to.Sub(from) > max:
+		return from, to, fmt.Errorf("range is longer than %s", max)
+	}
+	return from, to, nil
+}
+
+// parseTime accepts RFC 3339, HH:MM (the last such time not in the future) or a duration relative to now
+func parseTime(s string, now time.Time, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		if d, err := time.ParseDuration(s); err == nil {
+			return now.Add(d), nil
+		}
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		clock, err := time.ParseInLocation(layout, s, loc)
+		if err != nil {
+			continue
+		}
+		n := now.In(loc)
+		t := time.Date(n.Year(), n.Month(), n.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, loc)
+		if t.After(now) {
+			t = t.AddDate(0, 0, -1)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
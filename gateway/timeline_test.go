@@ -0,0 +1,97 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/timeline"
+)
+
+// sourceFunc adapts a function to timeline.Source
+type sourceFunc func(ctx context.Context, from, to time.Time) ([]timeline.Entry, error)
+
+func (f sourceFunc) Entries(ctx context.Context, from, to time.Time) ([]timeline.Entry, error) {
+	return f(ctx, from, to)
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2026-02-28T21:35:00Z", time.Date(2026, 2, 28, 21, 35, 0, 0, time.UTC)},
+		{"-30m", now.Add(-30 * time.Minute)},
+		{"11:15", time.Date(2026, 3, 1, 11, 15, 0, 0, time.UTC)},
+		// later today is in the future, so it means yesterday
+		{"21:35:10", time.Date(2026, 2, 28, 21, 35, 10, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseTime(tt.in, now, time.UTC)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseTime(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := parseTime("yesterday", now, time.UTC); err == nil {
+		t.Error("parseTime accepted yesterday")
+	}
+}
+
+func TestTimelineHandler(t *testing.T) {
+	at := time.Date(2026, 3, 1, 11, 0, 0, 0, time.UTC)
+	var asked [2]time.Time
+	h := &TimelineHandler{Sources: timeline.Sources{
+		sourceFunc(func(ctx context.Context, from, to time.Time) ([]timeline.Entry, error) {
+			asked = [2]time.Time{from, to}
+			return []timeline.Entry{
+				{Server: "tdm-1", At: at, Summary: "map mp_raid"},
+				{Server: "sd-1", At: at, Summary: "map mp_hijacked"},
+			}, nil
+		}),
+		sourceFunc(func(ctx context.Context, from, to time.Time) ([]timeline.Entry, error) {
+			return nil, errors.New("chat log unavailable")
+		}),
+	}}
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		return rec
+	}
+
+	rec := get("/timeline?at=2026-03-01T11:00:00Z&window=2m&server=tdm-1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if !asked[0].Equal(at.Add(-2*time.Minute)) || !asked[1].Equal(at.Add(2*time.Minute)) {
+		t.Errorf("asked for %v, want 2m around %v", asked, at)
+	}
+	var body struct {
+		Server  string           `json:"server"`
+		Entries []timeline.Entry `json:"entries"`
+		Error   string           `json:"error"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Server != "tdm-1" || len(body.Entries) != 1 || body.Entries[0].Summary != "map mp_raid" {
+		t.Errorf("body %+v, want the one tdm-1 entry", body)
+	}
+	if body.Error != "chat log unavailable" {
+		t.Errorf("error %q, want the failing source's", body.Error)
+	}
+
+	for _, url := range []string{
+		"/timeline?from=2026-03-01T12:00:00Z&to=2026-03-01T11:00:00Z",
+		"/timeline?from=2026-02-01T00:00:00Z&to=2026-03-01T00:00:00Z",
+		"/timeline?at=11:00&window=-5m",
+	} {
+		if rec := get(url); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: %d, want 400", url, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Package i18n translates the operator-facing text of plutorcon, its alerts and its
+// reports. Messages are keyed by their English format string, so untranslated text
+// falls back to English and callers read like plain fmt calls
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// catalogs holds the bundled translations, one JSON object per language mapping
+// English format strings to translated ones
+//
+//go:embed catalogs/*.json
+var catalogs embed.FS
+
+// English is the source language; it needs no catalog
+const English = "en"
+
+var (
+	mu       sync.RWMutex
+	messages = map[string]map[string]string{}
+	current  = English
+)
+
+func init() {
+	files, err := catalogs.ReadDir("catalogs")
+	if err != nil {
+		panic(err)
+	}
+	for _, f := range files {
+		data, err := catalogs.ReadFile(path.Join("catalogs", f.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var msgs map[string]string
+		if err := json.Unmarshal(data, &msgs); err != nil {
+			panic(fmt.Sprintf("i18n: catalog %s: %v", f.Name(), err))
+		}
+		Register(strings.TrimSuffix(f.Name(), ".json"), msgs)
+	}
+}
+
+// Register adds translations for lang (e.g. "es" or "pt-br"), replacing bundled ones
+// with the same key, so a daemon can translate its own messages or add a language
+func Register(lang string, msgs map[string]string) {
+	lang = normalize(lang)
+	mu.Lock()
+	defer mu.Unlock()
+	cat := messages[lang]
+	if cat == nil {
+		cat = map[string]string{}
+		messages[lang] = cat
+	}
+	for k, v := range msgs {
+		cat[k] = v
+	}
+}
+
+// Languages returns the languages with a catalog, plus English
+func Languages() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	out := []string{English}
+	for lang := range messages {
+		out = append(out, lang)
+	}
+	sort.Strings(out[1:])
+	return out
+}
+
+// Detect picks a language from $PLUTORCON_LANG, then the POSIX locale variables
+// ($LC_ALL, $LC_MESSAGES, $LANG), falling back to English
+func Detect() string {
+	for _, env := range []string{"PLUTORCON_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(env); v != "" && v != "C" && v != "POSIX" {
+			return Match(v)
+		}
+	}
+	return English
+}
+
+// Match returns the best supported language for a tag like "pt_BR.UTF-8": the tag
+// itself, then its base language, then English
+func Match(tag string) string {
+	lang := normalize(tag)
+	mu.RLock()
+	defer mu.RUnlock()
+	if _, ok := messages[lang]; ok {
+		return lang
+	}
+	base, _, _ := strings.Cut(lang, "-")
+	if _, ok := messages[base]; ok {
+		return base
+	}
+	return English
+}
+
+// SetLanguage sets the language of the package-level functions; "" detects it from
+// the environment. Unsupported languages fall back as in Match
+func SetLanguage(lang string) {
+	if lang == "" {
+		lang = Detect()
+	} else {
+		lang = Match(lang)
+	}
+	mu.Lock()
+	current = lang
+	mu.Unlock()
+}
+
+// Language returns the language set with SetLanguage (English by default)
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// T translates msg into the current language
+func T(msg string) string {
+	return Printer{Lang: Language()}.T(msg)
+}
+
+// Sprintf formats a translated format string
+func Sprintf(format string, args ...any) string {
+	return Printer{Lang: Language()}.Sprintf(format, args...)
+}
+
+// Errorf is fmt.Errorf with a translated format string; %w still wraps
+func Errorf(format string, args ...any) error {
+	return Printer{Lang: Language()}.Errorf(format, args...)
+}
+
+// Printer translates into one language, for daemons serving operators in several
+// (e.g. one per Discord channel). The zero value prints English
+type Printer struct {
+	Lang string
+}
+
+// NewPrinter returns a printer for the best match of lang
+func NewPrinter(lang string) Printer {
+	return Printer{Lang: Match(lang)}
+}
+
+// T translates msg, or returns it unchanged without a translation
+func (p Printer) T(msg string) string {
+	if p.Lang == "" || p.Lang == English {
+		return msg
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	if s, ok := messages[p.Lang][msg]; ok && s != "" {
+		return s
+	}
+	return msg
+}
+
+// Sprintf formats a translated format string
+func (p Printer) Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(p.T(format), args...)
+}
+
+// Errorf is fmt.Errorf with a translated format string
+func (p Printer) Errorf(format string, args ...any) error {
+	if len(args) == 0 {
+		return errors.New(p.T(format))
+	}
+	return fmt.Errorf(p.T(format), args...)
+}
+
+// normalize lowercases a tag and drops its encoding, turning "pt_BR.UTF-8" into "pt-br"
+func normalize(tag string) string {
+	tag, _, _ = strings.Cut(strings.TrimSpace(tag), ".")
+	tag, _, _ = strings.Cut(tag, "@")
+	return strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+}
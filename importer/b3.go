@@ -0,0 +1,297 @@
+// Package importer moves players, aliases and penalties from other admin tools into
+// PlutoRCON's stores, for communities migrating from or running alongside them
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/policy"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+	"github.com/Yallamaztar/PlutoRCON/v2/tracker"
+)
+
+// Stats counts what an import wrote
+type Stats struct {
+	Players   int `json:"players"`
+	Names     int `json:"names"`
+	IPs       int `json:"ips"`
+	Penalties int `json:"penalties"`
+	Bans      int `json:"bans"`
+	// Lifted counts bans an import or export removed because they are gone from the source
+	Lifted  int `json:"lifted,omitempty"`
+	Skipped int `json:"skipped"`
+}
+
+// B3 imports a Big Brother Bot database, the one Echelon also reads. Open DB with the
+// driver of the B3 install (MySQL or SQLite); the queries use no placeholders
+type B3 struct {
+	DB *sql.DB
+	// Players receives the clients with their aliases and IP aliases, and one note per
+	// penalty. Big imports are much faster into a database store than a FileStore
+	Players tracker.Store
+	// Bans, when set, receives the bans and tempbans still in force
+	Bans policy.BanStore
+	// Server is recorded as where the players were seen (default "b3")
+	Server string
+	// Privacy rewrites the imported IPs; give it the settings of the live clients so
+	// imported and polled addresses match
+	Privacy *rcon.IPPrivacy
+}
+
+// b3Client is what the import needs of a clients row
+type b3Client struct {
+	guid, name, ip string
+}
+
+// Import copies everything in one pass. Running it again doesn't duplicate notes
+func (b *B3) Import(ctx context.Context) (*Stats, error) {
+	if b.DB == nil || b.Players == nil {
+		return nil, fmt.Errorf("b3 import needs DB and Players")
+	}
+	if err := b.Privacy.Validate(); err != nil {
+		return nil, err
+	}
+	server := b.Server
+	if server == "" {
+		server = "b3"
+	}
+	st := &Stats{}
+	seen := &sightings{store: b.Players, server: server}
+	clients, err := b.clients(ctx, seen, st)
+	if err != nil {
+		return st, fmt.Errorf("b3 clients: %w", err)
+	}
+	if err := b.aliases(ctx, seen, clients, "aliases", "alias", st); err != nil {
+		return st, fmt.Errorf("b3 aliases: %w", err)
+	}
+	if err := b.aliases(ctx, seen, clients, "ipaliases", "ip", st); err != nil {
+		return st, fmt.Errorf("b3 ipaliases: %w", err)
+	}
+	if err := seen.flush(); err != nil {
+		return st, fmt.Errorf("b3 sightings: %w", err)
+	}
+	if err := b.penalties(ctx, server, clients, st); err != nil {
+		return st, fmt.Errorf("b3 penalties: %w", err)
+	}
+	return st, nil
+}
+
+func (b *B3) clients(ctx context.Context, seen *sightings, st *Stats) (map[int64]b3Client, error) {
+	rows, err := b.DB.QueryContext(ctx, `SELECT id, guid, name, ip, time_add, time_edit FROM clients`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[int64]b3Client{}
+	for rows.Next() {
+		var id int64
+		var guid, name, ip sql.NullString
+		var added, edited sql.NullInt64
+		if err := rows.Scan(&id, &guid, &name, &ip, &added, &edited); err != nil {
+			return nil, err
+		}
+		c := b3Client{guid: normalizeGUID(guid.String), name: name.String, ip: b.Privacy.Apply(b3IP(ip.String))}
+		if c.guid == "" || isBot(c.guid) {
+			st.Skipped++
+			continue
+		}
+		out[id] = c
+		for _, at := range unixTimes(added, edited) {
+			if err := seen.add(at, tracker.Sighting{GUID: c.guid, Name: c.name, IP: c.ip}); err != nil {
+				return nil, err
+			}
+		}
+		st.Players++
+	}
+	return out, rows.Err()
+}
+
+// aliases imports the aliases (names) or ipaliases table
+func (b *B3) aliases(ctx context.Context, seen *sightings, clients map[int64]b3Client, table, column string, st *Stats) error {
+	rows, err := b.DB.QueryContext(ctx, fmt.Sprintf(`SELECT client_id, %s, time_add, time_edit FROM %s`, column, table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var value sql.NullString
+		var added, edited sql.NullInt64
+		if err := rows.Scan(&id, &value, &added, &edited); err != nil {
+			return err
+		}
+		c, ok := clients[id]
+		if !ok || value.String == "" {
+			st.Skipped++
+			continue
+		}
+		s := tracker.Sighting{GUID: c.guid}
+		if column == "ip" {
+			s.IP = b.Privacy.Apply(b3IP(value.String))
+			st.IPs++
+		} else {
+			s.Name = value.String
+			st.Names++
+		}
+		for _, at := range unixTimes(added, edited) {
+			if err := seen.add(at, s); err != nil {
+				return err
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func (b *B3) penalties(ctx context.Context, server string, clients map[int64]b3Client, st *Stats) error {
+	rows, err := b.DB.QueryContext(ctx, `SELECT type, client_id, admin_id, duration, inactive, reason, time_add, time_expire FROM penalties ORDER BY time_add`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	notes := noteIndex{store: b.Players}
+	now := time.Now()
+	for rows.Next() {
+		var kind, reason sql.NullString
+		var clientID, adminID, duration, inactive, added, expire sql.NullInt64
+		if err := rows.Scan(&kind, &clientID, &adminID, &duration, &inactive, &reason, &added, &expire); err != nil {
+			return err
+		}
+		c, ok := clients[clientID.Int64]
+		if !ok {
+			st.Skipped++
+			continue
+		}
+		admin := "b3"
+		if a, ok := clients[adminID.Int64]; ok && adminID.Int64 != 0 && a.name != "" {
+			admin = a.name
+		}
+		at := time.Unix(added.Int64, 0)
+		text := "B3 " + strings.ToLower(kind.String)
+		// duration is in minutes; -1 marks a permanent ban
+		if duration.Int64 > 0 {
+			text += " " + (time.Duration(duration.Int64) * time.Minute).String()
+		}
+		if r := strings.TrimSpace(reason.String); r != "" {
+			text += ": " + r
+		}
+		if inactive.Int64 != 0 {
+			text += " (lifted)"
+		}
+		saved, err := notes.save(tracker.Note{GUID: c.guid, Author: admin, Text: text, Server: server, At: at})
+		if err != nil {
+			return err
+		}
+		if saved {
+			st.Penalties++
+		}
+
+		if b.Bans == nil || inactive.Int64 != 0 || (kind.String != "Ban" && kind.String != "TempBan") {
+			continue
+		}
+		var expires time.Time
+		if expire.Int64 > 0 {
+			expires = time.Unix(expire.Int64, 0)
+		}
+		ban := policy.Ban{GUID: c.guid, IP: c.ip, Name: c.name, Reason: strings.TrimSpace(reason.String), Operator: admin, Server: server, At: at, Expires: expires}
+		if !ban.Active(now) {
+			continue
+		}
+		if err := b.Bans.SaveBan(ban); err != nil {
+			return err
+		}
+		st.Bans++
+	}
+	return rows.Err()
+}
+
+// sightingBatch is how many sightings an import hands the player store at once
+const sightingBatch = 1000
+
+// sightings buffers the sightings of an import and records them in batches, so a
+// store writes once per batch rather than once per client and timestamp
+type sightings struct {
+	store  tracker.Store
+	server string
+	polls  []tracker.Poll
+}
+
+func (s *sightings) add(at time.Time, seen tracker.Sighting) error {
+	s.polls = append(s.polls, tracker.Poll{Server: s.server, At: at, Seen: []tracker.Sighting{seen}})
+	if len(s.polls) < sightingBatch {
+		return nil
+	}
+	return s.flush()
+}
+
+func (s *sightings) flush() error {
+	polls := s.polls
+	s.polls = nil
+	return tracker.RecordPolls(s.store, polls)
+}
+
+// noteIndex saves notes unless an identical one exists, so imports can be repeated
+type noteIndex struct {
+	store tracker.Store
+	seen  map[string]map[string]bool
+}
+
+func (n *noteIndex) save(note tracker.Note) (bool, error) {
+	if n.seen == nil {
+		n.seen = map[string]map[string]bool{}
+	}
+	have, ok := n.seen[note.GUID]
+	if !ok {
+		existing, err := n.store.Notes(note.GUID)
+		if err != nil {
+			return false, err
+		}
+		have = map[string]bool{}
+		for _, e := range existing {
+			have[noteKey(e)] = true
+		}
+		n.seen[note.GUID] = have
+	}
+	key := noteKey(note)
+	if have[key] {
+		return false, nil
+	}
+	have[key] = true
+	return true, n.store.SaveNote(note)
+}
+
+func noteKey(n tracker.Note) string {
+	return n.At.UTC().Format(time.RFC3339) + "\x00" + n.Text
+}
+
+// unixTimes returns the set timestamps among secs, B3 keeps them as unix seconds
+func unixTimes(secs ...sql.NullInt64) []time.Time {
+	var out []time.Time
+	for _, s := range secs {
+		if s.Valid && s.Int64 > 0 {
+			out = append(out, time.Unix(s.Int64, 0))
+		}
+	}
+	return out
+}
+
+// b3IP drops the port some B3 parsers keep with the address
+func b3IP(ip string) string {
+	ip = strings.TrimSpace(ip)
+	if host, _, ok := strings.Cut(ip, ":"); ok && strings.Count(ip, ":") == 1 {
+		return host
+	}
+	return ip
+}
+
+func normalizeGUID(guid string) string {
+	return strings.ToLower(strings.TrimSpace(guid))
+}
+
+// isBot reports whether a GUID belongs to a bot rather than a person
+func isBot(guid string) bool {
+	return strings.HasPrefix(guid, "bot") || strings.Trim(guid, "0") == ""
+}
@@ -0,0 +1,342 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/policy"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+	"github.com/Yallamaztar/PlutoRCON/v2/tracker"
+)
+
+// IW4MAdmin penalty types (EFPenalty.PenaltyType)
+const (
+	iwWarning = 1
+	iwFlag    = 2
+	iwKick    = 3
+	iwTempBan = 4
+	iwBan     = 5
+)
+
+// exportMarker tags the penalties Export writes, in AutomatedOffense
+const exportMarker = "plutorcon"
+
+// IW4MAdmin imports penalties from an IW4MAdmin database and exports PlutoRCON's bans
+// back into it, so both tools enforce the same bans while a community runs them side
+// by side. Open DB with the driver matching the IW4MAdmin setup
+type IW4MAdmin struct {
+	DB *sql.DB
+	// Dialect is "sqlite" (the IW4MAdmin default), "mysql" or "postgres"
+	Dialect string
+	// Players, when set, receives one note per warning, flag, kick and ban
+	Players tracker.Store
+	// Bans receives the bans and tempbans in force, and is what Export writes out
+	Bans policy.BanStore
+	// Server is recorded on imported notes and bans (default "iw4madmin")
+	Server string
+	// Privacy rewrites the IPs of imported bans, like B3.Privacy
+	Privacy *rcon.IPPrivacy
+	// GUID turns a NetworkId into a GUID; the default is lower-case hex without
+	// leading zeros, the way IW4MAdmin shows it. NetworkID is its inverse for Export
+	GUID      func(networkID int64) string
+	NetworkID func(guid string) (int64, bool)
+	// PunisherID is the client exported penalties are issued by (default 1, IW4MAdmin's console)
+	PunisherID int64
+}
+
+func (m *IW4MAdmin) server() string {
+	if m.Server == "" {
+		return "iw4madmin"
+	}
+	return m.Server
+}
+
+func (m *IW4MAdmin) guid(id int64) string {
+	if m.GUID != nil {
+		return normalizeGUID(m.GUID(id))
+	}
+	return strconv.FormatUint(uint64(id), 16)
+}
+
+func (m *IW4MAdmin) networkID(guid string) (int64, bool) {
+	if m.NetworkID != nil {
+		return m.NetworkID(guid)
+	}
+	n, err := strconv.ParseUint(guid, 16, 64)
+	return int64(n), err == nil
+}
+
+// sql adapts a query written with double-quoted identifiers and ? placeholders
+func (m *IW4MAdmin) sql(query string) string {
+	switch m.Dialect {
+	case "mysql":
+		return strings.ReplaceAll(query, `"`, "`")
+	case "postgres":
+		var b strings.Builder
+		n := 0
+		for _, r := range query {
+			if r == '?' {
+				n++
+				b.WriteString("$" + strconv.Itoa(n))
+				continue
+			}
+			b.WriteRune(r)
+		}
+		return b.String()
+	}
+	return query
+}
+
+// dbTime formats t the way Entity Framework stores DateTime columns of the dialect
+func (m *IW4MAdmin) dbTime(t time.Time) any {
+	t = t.UTC()
+	switch m.Dialect {
+	case "postgres":
+		return t
+	case "mysql":
+		return t.Format("2006-01-02 15:04:05.000000")
+	}
+	return t.Format("2006-01-02 15:04:05.0000000")
+}
+
+// Import copies the penalties. Penalties that Export wrote are skipped, and running
+// it again doesn't duplicate notes. Bans a previous Import copied are removed from Bans
+// once IW4MAdmin lifted them or no longer lists them, so the next Export doesn't bring
+// them back
+func (m *IW4MAdmin) Import(ctx context.Context) (*Stats, error) {
+	if m.DB == nil || (m.Players == nil && m.Bans == nil) {
+		return nil, errors.New("iw4madmin import needs DB and Players or Bans")
+	}
+	if err := m.Privacy.Validate(); err != nil {
+		return nil, err
+	}
+	rows, err := m.DB.QueryContext(ctx, m.sql(`SELECT p."Type", p."Offense", p."When", p."Expires", p."Active",
+		COALESCE(p."AutomatedOffense", ''), c."NetworkId", COALESCE(a."Name", ''), a."IPAddress", COALESCE(pa."Name", '')
+		FROM "EFPenalties" p
+		JOIN "EFClients" c ON c."ClientId" = p."OffenderId"
+		LEFT JOIN "EFAlias" a ON a."AliasId" = c."CurrentAliasId"
+		LEFT JOIN "EFClients" pc ON pc."ClientId" = p."PunisherId"
+		LEFT JOIN "EFAlias" pa ON pa."AliasId" = pc."CurrentAliasId"
+		ORDER BY p."When"`))
+	if err != nil {
+		return nil, fmt.Errorf("iw4madmin penalties: %w", err)
+	}
+	defer rows.Close()
+	st := &Stats{}
+	notes := noteIndex{store: m.Players}
+	now := time.Now()
+	inForce := map[string]bool{}
+	for rows.Next() {
+		var kind, networkID int64
+		var offense, when, expires sql.NullString
+		var active bool
+		var automated, name, punisher string
+		var ip sql.NullInt64
+		if err := rows.Scan(&kind, &offense, &when, &expires, &active, &automated, &networkID, &name, &ip, &punisher); err != nil {
+			return st, err
+		}
+		label := map[int64]string{iwWarning: "warning", iwFlag: "flag", iwKick: "kick", iwTempBan: "tempban", iwBan: "ban"}[kind]
+		if label == "" || automated == exportMarker {
+			st.Skipped++
+			continue
+		}
+		guid := m.guid(networkID)
+		at, _ := parseEFTime(when.String)
+		exp, hasExp := parseEFTime(expires.String)
+		if punisher == "" {
+			punisher = "iw4madmin"
+		}
+		reason := strings.TrimSpace(offense.String)
+
+		if m.Players != nil {
+			text := "IW4MAdmin " + label
+			if kind == iwTempBan && hasExp {
+				text += " until " + exp.UTC().Format("2006-01-02 15:04 MST")
+			}
+			if reason != "" {
+				text += ": " + reason
+			}
+			if !active {
+				text += " (lifted)"
+			}
+			saved, err := notes.save(tracker.Note{GUID: guid, Author: punisher, Text: text, Server: m.server(), At: at})
+			if err != nil {
+				return st, err
+			}
+			if saved {
+				st.Penalties++
+			}
+		}
+
+		if m.Bans == nil || !active || (kind != iwBan && kind != iwTempBan) {
+			continue
+		}
+		ban := policy.Ban{GUID: guid, IP: m.Privacy.Apply(efIP(ip)), Name: name, Reason: reason, Operator: punisher, Server: m.server(), At: at}
+		if hasExp && kind == iwTempBan {
+			ban.Expires = exp
+		}
+		if !ban.Active(now) {
+			continue
+		}
+		if err := m.Bans.SaveBan(ban); err != nil {
+			return st, err
+		}
+		inForce[guid] = true
+		st.Bans++
+	}
+	if err := rows.Err(); err != nil {
+		return st, err
+	}
+	if m.Bans == nil {
+		return st, nil
+	}
+	return st, m.removeLifted(inForce, st)
+}
+
+// removeLifted removes the bans imported from this IW4MAdmin that it no longer enforces
+func (m *IW4MAdmin) removeLifted(inForce map[string]bool, st *Stats) error {
+	bans, err := m.Bans.Bans()
+	if err != nil {
+		return err
+	}
+	for _, b := range bans {
+		if b.Server != m.server() || inForce[b.GUID] {
+			continue
+		}
+		if err := m.Bans.RemoveBan(b.GUID); err != nil {
+			return err
+		}
+		st.Lifted++
+	}
+	return nil
+}
+
+// Export writes the active bans of Bans into IW4MAdmin as penalties of PunisherID, for
+// players IW4MAdmin knows. Penalties a previous Export wrote are lifted once their ban
+// is gone from Bans
+func (m *IW4MAdmin) Export(ctx context.Context) (*Stats, error) {
+	if m.DB == nil || m.Bans == nil {
+		return nil, errors.New("iw4madmin export needs DB and Bans")
+	}
+	bans, err := m.Bans.Bans()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	wanted := map[int64]policy.Ban{}
+	st := &Stats{}
+	for _, b := range bans {
+		if !b.Active(now) {
+			continue
+		}
+		id, ok := m.networkID(b.GUID)
+		if !ok {
+			st.Skipped++
+			continue
+		}
+		wanted[id] = b
+	}
+
+	// active bans IW4MAdmin has now, by offender NetworkId
+	rows, err := m.DB.QueryContext(ctx, m.sql(`SELECT p."PenaltyId", c."NetworkId", p."Expires", COALESCE(p."AutomatedOffense", '')
+		FROM "EFPenalties" p JOIN "EFClients" c ON c."ClientId" = p."OffenderId"
+		WHERE p."Active" = ? AND p."Type" IN (4, 5)`), true)
+	if err != nil {
+		return nil, fmt.Errorf("iw4madmin penalties: %w", err)
+	}
+	have := map[int64]bool{}
+	var lift []int64
+	for rows.Next() {
+		var penaltyID, networkID int64
+		var expires sql.NullString
+		var automated string
+		if err := rows.Scan(&penaltyID, &networkID, &expires, &automated); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if exp, ok := parseEFTime(expires.String); ok && !now.Before(exp) {
+			continue
+		}
+		if _, ok := wanted[networkID]; !ok && automated == exportMarker {
+			lift = append(lift, penaltyID)
+			continue
+		}
+		have[networkID] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range lift {
+		if _, err := m.DB.ExecContext(ctx, m.sql(`UPDATE "EFPenalties" SET "Active" = ? WHERE "PenaltyId" = ?`), false, id); err != nil {
+			return st, err
+		}
+		st.Lifted++
+	}
+
+	punisher := m.PunisherID
+	if punisher == 0 {
+		punisher = 1
+	}
+	for networkID, b := range wanted {
+		if have[networkID] {
+			continue
+		}
+		var clientID, linkID int64
+		err := m.DB.QueryRowContext(ctx, m.sql(`SELECT "ClientId", "AliasLinkId" FROM "EFClients" WHERE "NetworkId" = ?`), networkID).Scan(&clientID, &linkID)
+		if errors.Is(err, sql.ErrNoRows) {
+			st.Skipped++
+			continue
+		}
+		if err != nil {
+			return st, err
+		}
+		kind, expires := iwBan, any(nil)
+		if !b.Expires.IsZero() {
+			kind, expires = iwTempBan, m.dbTime(b.Expires)
+		}
+		reason := b.Reason
+		if reason == "" {
+			reason = "banned in PlutoRCON"
+		}
+		if _, err := m.DB.ExecContext(ctx, m.sql(`INSERT INTO "EFPenalties"
+			("OffenderId", "PunisherId", "LinkId", "Type", "Expires", "Offense", "AutomatedOffense", "When", "Active", "IsEvadedOffense")
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+			clientID, punisher, linkID, kind, expires, reason, exportMarker, m.dbTime(b.At), true, false); err != nil {
+			return st, err
+		}
+		st.Bans++
+	}
+	return st, nil
+}
+
+// parseEFTime reads a DateTime column as the drivers return it: text for SQLite and
+// MySQL, RFC 3339 once database/sql converted a time.Time. Times without a zone are UTC
+func parseEFTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.9999999", "2006-01-02T15:04:05.9999999", "2006-01-02 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// efIP decodes IW4MAdmin's IPv4 int, the address bytes read as a little-endian int32
+func efIP(ip sql.NullInt64) string {
+	if !ip.Valid || ip.Int64 == 0 {
+		return ""
+	}
+	v := uint32(ip.Int64)
+	return netip.AddrFrom4([4]byte{byte(v), byte(v >> 8), byte(v >> 16), byte(v >> 24)}).String()
+}
@@ -0,0 +1,83 @@
+// Package webhook holds the JSON POST helper shared by the webhook sinks and channels
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultTimeout bounds a POST when the caller passes no client
+const DefaultTimeout = 10 * time.Second
+
+// StatusError is returned for a non-2xx reply
+type StatusError struct {
+	Host   string
+	Code   int
+	Status string
+	// Body is the start of the reply, for the error message
+	Body string
+	// RetryAfter is how long a 429 reply asks to wait, from the Retry-After header or
+	// Discord's JSON retry_after; zero for other statuses
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("%s returned %s", e.Host, e.Status)
+	}
+	return fmt.Sprintf("%s returned %s: %s", e.Host, e.Status, e.Body)
+}
+
+// PostJSON sends body as JSON with the extra headers and returns a *StatusError for
+// any non-2xx status. A nil client uses one with DefaultTimeout
+func PostJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body []byte) error {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	serr := &StatusError{Host: req.URL.Host, Code: resp.StatusCode, Status: resp.Status}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		serr.RetryAfter = retryAfter(resp.Header.Get("Retry-After"), msg)
+	}
+	if msg = bytes.TrimSpace(msg); len(msg) > 512 {
+		msg = msg[:512]
+	}
+	serr.Body = string(msg)
+	return serr
+}
+
+// retryAfter reads the wait of a 429 reply, one second when it names none
+func retryAfter(header string, body []byte) time.Duration {
+	if secs, err := strconv.ParseFloat(header, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	var rl struct {
+		RetryAfter float64 `json:"retry_after"`
+	}
+	if json.Unmarshal(body, &rl) == nil && rl.RetryAfter > 0 {
+		return time.Duration(rl.RetryAfter * float64(time.Second))
+	}
+	return time.Second
+}
@@ -0,0 +1,99 @@
+package wire
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// ErrChallenge is returned when replies arrived but none echoed the request's challenge
+var ErrChallenge = errors.New("reply did not echo the request challenge")
+
+// ErrStale is returned when a reply arrived later than the freshness window allows
+var ErrStale = errors.New("reply is older than the freshness window")
+
+// Challenge is the nonce of one getinfo/getstatus request. Servers echo it as
+// \challenge\<nonce>, so replies to earlier requests and replayed datagrams can be told apart
+type Challenge struct {
+	Nonce string
+	// Rejected counts datagrams dropped for a missing or different challenge
+	Rejected int
+	matched  bool
+}
+
+// NewChallenge returns a challenge with a random nonce
+func NewChallenge() *Challenge {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return &Challenge{Nonce: hex.EncodeToString(b)}
+}
+
+// Request appends the nonce to a connectionless request
+func (c *Challenge) Request(request string) string {
+	return strings.TrimSpace(request) + " " + c.Nonce
+}
+
+// Decode wraps decode (DecodeOOB when nil) so frames that don't echo the nonce are rejected.
+// Once a frame matched, later fragments without a challenge key are accepted as its continuation
+func (c *Challenge) Decode(decode DecodeFunc) DecodeFunc {
+	if decode == nil {
+		decode = DecodeOOB
+	}
+	return func(dgram []byte) ([]Frame, error) {
+		frames, err := decode(dgram)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range frames {
+			got, ok := challengeOf(f.Body)
+			if got == c.Nonce || (!ok && c.matched) {
+				continue
+			}
+			c.Rejected++
+			return nil, ErrChallenge
+		}
+		c.matched = true
+		return frames, nil
+	}
+}
+
+// Err turns a read timeout into ErrChallenge when only mismatched replies arrived; it is safe on a nil Challenge
+func (c *Challenge) Err(err error) error {
+	if c == nil || c.Rejected == 0 {
+		return err
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return fmt.Errorf("%w (%d replies rejected)", ErrChallenge, c.Rejected)
+	}
+	return err
+}
+
+// Fresh returns ErrStale when a reply's first datagram came more than maxAge after sent (0 = no limit)
+func Fresh(sent, first time.Time, maxAge time.Duration) error {
+	if maxAge <= 0 || first.IsZero() {
+		return nil
+	}
+	if age := first.Sub(sent); age > maxAge {
+		return fmt.Errorf("%w: arrived %s after the request", ErrStale, age.Round(time.Millisecond))
+	}
+	return nil
+}
+
+// challengeOf reads the challenge key of the info string on the first line of a frame body
+func challengeOf(body []byte) (string, bool) {
+	line, _, _ := strings.Cut(string(body), "\n")
+	parts := strings.Split(strings.TrimSpace(line), "\\")
+	if len(parts) > 0 && parts[0] == "" {
+		parts = parts[1:]
+	}
+	for i := 0; i+1 < len(parts); i += 2 {
+		if parts[i] == "challenge" {
+			return parts[i+1], true
+		}
+	}
+	return "", false
+}
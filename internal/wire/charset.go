@@ -0,0 +1,72 @@
+package wire
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Codepage is the byte encoding a game uses for names, chat and dvar values
+type Codepage int
+
+const (
+	// CP1252 is Windows-1252, what the Plutonium titles emit
+	CP1252 Codepage = iota
+	// Latin1 is ISO-8859-1
+	Latin1
+	// UTF8 keeps text as is, replacing invalid bytes with U+FFFD
+	UTF8
+)
+
+func (c Codepage) String() string {
+	switch c {
+	case CP1252:
+		return "cp1252"
+	case Latin1:
+		return "latin1"
+	case UTF8:
+		return "utf8"
+	}
+	return "codepage(" + strconv.Itoa(int(c)) + ")"
+}
+
+// cp1252High maps 0x80-0x9F, where Windows-1252 differs from Latin-1; unassigned bytes map to themselves
+var cp1252High = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// ToUTF8 converts text received from a game to valid UTF-8. Lines that are already valid
+// UTF-8 pass through unchanged, since some mods and clients send UTF-8 names
+func ToUTF8(s string, c Codepage) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s) + len(s)/4)
+	for line := range strings.SplitAfterSeq(s, "\n") {
+		switch {
+		case utf8.ValidString(line):
+			b.WriteString(line)
+		case c == UTF8:
+			b.WriteString(strings.ToValidUTF8(line, "\uFFFD"))
+		default:
+			decodeLine(&b, line, c)
+		}
+	}
+	return b.String()
+}
+
+func decodeLine(b *strings.Builder, line string, c Codepage) {
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case ch < 0x80:
+			b.WriteByte(ch)
+		case ch < 0xA0 && c == CP1252:
+			b.WriteRune(cp1252High[ch-0x80])
+		default:
+			b.WriteRune(rune(ch))
+		}
+	}
+}
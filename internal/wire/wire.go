@@ -0,0 +1,337 @@
+// Package wire holds the Quake3-style out-of-band packet helpers shared by rcon and query
+package wire
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const readBufferSize = 65535
+
+// DefaultMaxResponse bounds a reassembled reply (512 KiB) when the caller sets no limit
+const DefaultMaxResponse = 512 << 10
+
+// ErrTooLarge is returned when a reply grows past its size limit
+var ErrTooLarge = errors.New("response exceeds the size limit")
+
+// FullDatagram is the body size at which servers split long prints; a reply whose
+// last datagram is this large was probably cut off by the read window
+const FullDatagram = 1000
+
+// OOBHeader prefixes every connectionless packet
+var OOBHeader = []byte{0xFF, 0xFF, 0xFF, 0xFF}
+
+var colorCodeRx = regexp.MustCompile(`\^[0-9A-Za-z]`)
+
+var statusPlayerLineRx = regexp.MustCompile(`^-?\d+\s+-?\d+\s+"`)
+
+// DeadlineReader is the part of a connection Read needs
+type DeadlineReader interface {
+	Read(b []byte) (int, error)
+	SetReadDeadline(t time.Time) error
+}
+
+// Packet builds an out-of-band packet for a payload
+func Packet(payload string) []byte {
+	packet := append(append([]byte{}, OOBHeader...), []byte(payload)...)
+	return append(packet, '\n')
+}
+
+// Response is a reply reassembled from one or more datagrams
+type Response struct {
+	// Data is the concatenated bodies with each datagram's OOB header and print prefix removed
+	Data      []byte
+	Datagrams int
+	// Dropped counts OOB datagrams of another reply type, e.g. a late answer to an earlier query
+	Dropped int
+	// Truncated is set when the reply looks cut off: a datagram filled the read buffer,
+	// the last one was full size, or a print didn't end with a newline. UDP carries no
+	// sequence numbers, so datagrams are kept in arrival order
+	Truncated bool
+	// First is when the first datagram of the reply arrived, for round-trip timing
+	First time.Time
+	// MaxGap is the longest wait between two datagrams of the reply, zero for one datagram
+	MaxGap time.Duration
+}
+
+// ReadResponse reads datagrams like Read but strips headers per datagram, so lines split
+// across datagrams are joined correctly. With expect set (e.g. "print" or "infoResponse"),
+// OOB datagrams of other reply types are dropped instead of interleaved into the reply
+func ReadResponse(conn DeadlineReader, readTimeout, readExtension time.Duration, expect string) (*Response, error) {
+	return ReadFrames(conn, readTimeout, readExtension, expect, nil, 0)
+}
+
+// ReadFrames is ReadResponse for any wire dialect: decode splits each datagram into
+// frames (DecodeOOB when nil) and datagrams it rejects are counted as dropped.
+// maxSize bounds the reply (DefaultMaxResponse when 0, unlimited when negative); past it
+// the rest of the burst is read and discarded for up to readTimeout, then ErrTooLarge is
+// returned along with the part kept
+func ReadFrames(conn DeadlineReader, readTimeout, readExtension time.Duration, expect string, decode DecodeFunc, maxSize int) (*Response, error) {
+	if readExtension < 0 {
+		readExtension = 0
+	}
+	if decode == nil {
+		decode = DecodeOOB
+	}
+	if maxSize == 0 {
+		maxSize = DefaultMaxResponse
+	}
+
+	a := &Assembler{Expect: expect, Max: maxSize}
+	deadline := time.Now().Add(readTimeout)
+	var stopBy, lastKept time.Time
+	tmp := make([]byte, readBufferSize)
+	for {
+		if a.over && stopBy.IsZero() {
+			stopBy = time.Now().Add(readTimeout)
+		}
+		if !stopBy.IsZero() && deadline.After(stopBy) {
+			deadline = stopBy
+		}
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+		n, err := conn.Read(tmp)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				if a.Datagrams() == 0 && !a.over {
+					return nil, err
+				}
+				break
+			}
+			return nil, err
+		}
+		if n == 0 {
+			continue
+		}
+		frames, err := decode(tmp[:n])
+		if err != nil {
+			a.res.Dropped++
+			continue
+		}
+		kept := false
+		for _, f := range frames {
+			if a.AddFrame(f) {
+				kept = true
+			}
+		}
+		if kept {
+			now := time.Now()
+			if a.res.First.IsZero() {
+				a.res.First = now
+			} else if gap := now.Sub(lastKept); gap > a.res.MaxGap {
+				a.res.MaxGap = gap
+			}
+			lastKept = now
+		}
+		if kept && n == len(tmp) {
+			a.res.Truncated = true
+		}
+		if readExtension > 0 {
+			deadline = time.Now().Add(readExtension)
+		}
+	}
+	if a.over {
+		return a.Response(), ErrTooLarge
+	}
+	return a.Response(), nil
+}
+
+// Frame is one reply unit decoded from a datagram
+type Frame struct {
+	// Kind is the reply type, e.g. "print" or "infoResponse"; empty if the dialect has none
+	Kind string
+	// Body is the payload with the dialect's framing removed
+	Body []byte
+}
+
+// DecodeFunc splits a received datagram into frames
+type DecodeFunc func(dgram []byte) ([]Frame, error)
+
+// DecodeOOB decodes a Quake3 out-of-band datagram into a single frame. Datagrams
+// without the OOB header are passed through as one frame without a kind
+func DecodeOOB(dgram []byte) ([]Frame, error) {
+	body, ok := bytes.CutPrefix(dgram, OOBHeader)
+	if !ok {
+		return []Frame{{Body: dgram}}, nil
+	}
+	kind, rest, found := bytes.Cut(body, []byte("\n"))
+	if !found {
+		return []Frame{{Kind: strings.TrimSpace(string(kind))}}, nil
+	}
+	return []Frame{{Kind: strings.TrimSpace(string(kind)), Body: rest}}, nil
+}
+
+// Assembler joins reply datagrams, stripping each one's header
+type Assembler struct {
+	Expect string
+	// Max bounds the kept bytes; frames past it are discarded (0 = unlimited)
+	Max int
+
+	res      Response
+	buf      bytes.Buffer
+	lastFull bool
+	lastOpen bool
+	over     bool
+}
+
+// Add appends one OOB datagram, returning false if it was dropped as another reply type
+func (a *Assembler) Add(dgram []byte) bool {
+	frames, _ := DecodeOOB(dgram)
+	kept := false
+	for _, f := range frames {
+		if a.AddFrame(f) {
+			kept = true
+		}
+	}
+	return kept
+}
+
+// AddFrame appends one decoded frame, returning false if it was dropped as another reply type
+func (a *Assembler) AddFrame(f Frame) bool {
+	if f.Kind != "" && a.Expect != "" && !strings.EqualFold(f.Kind, a.Expect) {
+		a.res.Dropped++
+		return false
+	}
+	if a.over || (a.Max > 0 && a.buf.Len()+len(f.Kind)+1+len(f.Body) > a.Max) {
+		a.over = true
+		return false
+	}
+	isPrint := strings.EqualFold(f.Kind, "print")
+	if f.Kind != "" && !isPrint && a.res.Datagrams == 0 {
+		// Info and status parsers expect the header line once; later fragments repeat it
+		a.buf.WriteString(f.Kind + "\n")
+	}
+	a.buf.Write(f.Body)
+	a.res.Datagrams++
+	a.lastFull = len(f.Body) >= FullDatagram
+	a.lastOpen = isPrint && len(f.Body) > 0 && f.Body[len(f.Body)-1] != '\n'
+	return true
+}
+
+// Datagrams returns how many datagrams were kept so far
+func (a *Assembler) Datagrams() int { return a.res.Datagrams }
+
+// Overflowed reports whether frames were discarded for exceeding Max
+func (a *Assembler) Overflowed() bool { return a.over }
+
+// Response returns the reassembled reply
+func (a *Assembler) Response() *Response {
+	res := a.res
+	res.Truncated = res.Truncated || a.lastFull || a.lastOpen || a.over
+	res.Data = append([]byte(nil), a.buf.Bytes()...)
+	return &res
+}
+
+// ReplyType returns the OOB reply type a connectionless query is answered with
+func ReplyType(request string) string {
+	word, _, _ := strings.Cut(strings.TrimSpace(request), " ")
+	switch strings.ToLower(word) {
+	case "getinfo":
+		return "infoResponse"
+	case "getstatus":
+		return "statusResponse"
+	}
+	return ""
+}
+
+// Normalize strips OOB headers and print prefixes from a response
+func Normalize(s string) string {
+	if s == "" {
+		return s
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	for {
+		changed := false
+		if strings.HasPrefix(s, "\xFF\xFF\xFF\xFF") {
+			s = s[4:]
+			changed = true
+		}
+		if strings.HasPrefix(s, "print\n") {
+			s = s[6:]
+			changed = true
+		}
+		if !changed {
+			break
+		}
+	}
+
+	s = strings.ReplaceAll(s, "\n\xFF\xFF\xFF\xFF", "\n")
+	s = strings.ReplaceAll(s, "\nprint\n", "\n")
+	return strings.TrimSpace(s)
+}
+
+// SplitLines splits a string into trimmed non-empty lines
+func SplitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	parts := strings.Split(s, "\n")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// StripColors removes ^<code> color codes (^0-^9 and letter variants)
+func StripColors(s string) string {
+	if s == "" {
+		return s
+	}
+	return colorCodeRx.ReplaceAllString(s, "")
+}
+
+// InfoString finds the \key\value line of an infoResponse/statusResponse and parses it.
+// Values have their color codes removed
+func InfoString(lines []string, header string) map[string]string {
+	var dataLine string
+	for _, l := range lines {
+		t := strings.TrimSpace(l)
+		if strings.EqualFold(t, header) || statusPlayerLineRx.MatchString(t) {
+			// statusResponse player lines carry a quoted name that may contain backslashes
+			continue
+		}
+		if strings.Contains(t, "\\") {
+			dataLine += t
+		}
+	}
+	if dataLine == "" && len(lines) > 0 {
+		dataLine = strings.TrimSpace(lines[len(lines)-1])
+	}
+
+	parts := strings.Split(dataLine, "\\")
+	if len(parts) > 0 && parts[0] == "" {
+		parts = parts[1:]
+	}
+	kv := map[string]string{}
+	for i := 0; i < len(parts)-1; i += 2 {
+		k := strings.TrimSpace(parts[i])
+		v := strings.TrimSpace(parts[i+1])
+		if k == "" {
+			continue
+		}
+		kv[k] = StripColors(v)
+	}
+	return kv
+}
+
+// Atoi converts a string to int, ignoring errors
+func Atoi(s string) int {
+	i, _ := strconv.Atoi(strings.TrimSpace(s))
+	return i
+}
+
+// Bool reads "1"/"true" as true
+func Bool(s string) bool {
+	v := strings.TrimSpace(s)
+	return v == "1" || strings.EqualFold(v, "true")
+}
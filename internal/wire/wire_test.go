@@ -0,0 +1,169 @@
+package wire
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeOOB(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want Frame
+	}{
+		{"print", "\xFF\xFF\xFF\xFFprint\nmap: mp_raid\n", Frame{Kind: "print", Body: []byte("map: mp_raid\n")}},
+		{"info", "\xFF\xFF\xFF\xFFinfoResponse\n\\a\\b", Frame{Kind: "infoResponse", Body: []byte("\\a\\b")}},
+		{"header only", "\xFF\xFF\xFF\xFFdisconnect", Frame{Kind: "disconnect"}},
+		{"no header", "plain", Frame{Body: []byte("plain")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeOOB([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 || got[0].Kind != tt.want.Kind || string(got[0].Body) != string(tt.want.Body) {
+				t.Errorf("DecodeOOB(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssembler(t *testing.T) {
+	full := strings.Repeat("x", FullDatagram-1) + "\n"
+	tests := []struct {
+		name      string
+		expect    string
+		max       int
+		dgrams    []string
+		data      string
+		dropped   int
+		truncated bool
+	}{
+		{
+			name:   "one print",
+			expect: "print",
+			dgrams: []string{"\xFF\xFF\xFF\xFFprint\nline\n"},
+			data:   "line\n",
+		},
+		{
+			name:   "line split across datagrams",
+			expect: "print",
+			dgrams: []string{"\xFF\xFF\xFF\xFFprint\nfirst ha", "\xFF\xFF\xFF\xFFprint\nlf\nsecond\n"},
+			data:   "first half\nsecond\n",
+		},
+		{
+			name:    "other reply type dropped",
+			expect:  "print",
+			dgrams:  []string{"\xFF\xFF\xFF\xFFinfoResponse\n\\a\\b", "\xFF\xFF\xFF\xFFprint\nok\n"},
+			data:    "ok\n",
+			dropped: 1,
+		},
+		{
+			name:      "print without newline",
+			expect:    "print",
+			dgrams:    []string{"\xFF\xFF\xFF\xFFprint\ncut"},
+			data:      "cut",
+			truncated: true,
+		},
+		{
+			name:      "full last datagram",
+			expect:    "print",
+			dgrams:    []string{"\xFF\xFF\xFF\xFFprint\n" + full},
+			data:      full,
+			truncated: true,
+		},
+		{
+			name:   "status header kept once",
+			expect: "statusResponse",
+			dgrams: []string{"\xFF\xFF\xFF\xFFstatusResponse\n\\a\\b\n", "\xFF\xFF\xFF\xFFstatusResponse\n0 5 \"bob\"\n"},
+			data:   "statusResponse\n\\a\\b\n0 5 \"bob\"\n",
+		},
+		{
+			name:      "over the size limit",
+			expect:    "print",
+			max:       20,
+			dgrams:    []string{"\xFF\xFF\xFF\xFFprint\nshort\n", "\xFF\xFF\xFF\xFFprint\nmuch too long for it\n"},
+			data:      "short\n",
+			truncated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Assembler{Expect: tt.expect, Max: tt.max}
+			for _, d := range tt.dgrams {
+				a.Add([]byte(d))
+			}
+			res := a.Response()
+			if string(res.Data) != tt.data {
+				t.Errorf("data = %q, want %q", res.Data, tt.data)
+			}
+			if res.Dropped != tt.dropped {
+				t.Errorf("dropped = %d, want %d", res.Dropped, tt.dropped)
+			}
+			if res.Truncated != tt.truncated {
+				t.Errorf("truncated = %t, want %t", res.Truncated, tt.truncated)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"", ""},
+		{"\xFF\xFF\xFF\xFFprint\nmap: mp_raid\r\n", "map: mp_raid"},
+		{"\xFF\xFF\xFF\xFFprint\na\n\xFF\xFF\xFF\xFFprint\nb\n", "a\nb"},
+		{"plain text\n", "plain text"},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestInfoString(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  map[string]string
+	}{
+		{
+			name:  "info response",
+			lines: []string{"infoResponse", `\hostname\^1My ^7Server\mapname\mp_raid`},
+			want:  map[string]string{"hostname": "My Server", "mapname": "mp_raid"},
+		},
+		{
+			name:  "status response with a backslash in a player name",
+			lines: []string{"statusResponse", `\sv_hostname\test\g_gametype\tdm`, `0 50 "back\slash"`},
+			want:  map[string]string{"sv_hostname": "test", "g_gametype": "tdm"},
+		},
+		{
+			name:  "odd key count",
+			lines: []string{`\a\1\b`},
+			want:  map[string]string{"a": "1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InfoString(tt.lines, strings.ToLower(tt.lines[0])); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InfoString = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripColors(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"^1Red^7Fox", "RedFox"},
+		{"^^12", "^2"},
+		{"no codes", "no codes"},
+		{"^", "^"},
+	}
+	for _, tt := range tests {
+		if got := StripColors(tt.in); got != tt.want {
+			t.Errorf("StripColors(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Package loadtest drives concurrent clients through the library's command path and
+// reports throughput and latency, against rcontest or, deliberately, a real server
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// Config describes one load test run
+type Config struct {
+	// Dial opens a client; it is called once per worker, or once in total with SharedClient
+	Dial func() (*rcon.RCONClient, error)
+	// Clients is the number of concurrent workers (default 8)
+	Clients int
+	// Duration bounds the run (default 10s); Requests, when set, also caps each worker
+	Duration time.Duration
+	Requests int
+	// Command and Args are sent on every request (default "status")
+	Command string
+	Args    string
+	// SharedClient makes all workers use one client, exercising its locking and reader
+	SharedClient bool
+	// Queue sends through Enqueue instead of SendCommand
+	Queue bool
+}
+
+// Report summarizes a run
+type Report struct {
+	Clients    int
+	Requests   int
+	Errors     int
+	Elapsed    time.Duration
+	Throughput float64
+	Min        time.Duration
+	P50        time.Duration
+	P90        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+	// FirstError is the first failure seen, for a hint at what went wrong
+	FirstError error
+}
+
+// Run executes the load test until Duration passes, every worker hit Requests, or ctx is done
+func Run(ctx context.Context, cfg Config) (*Report, error) {
+	if cfg.Dial == nil {
+		return nil, errors.New("loadtest: Dial is required")
+	}
+	if cfg.Clients <= 0 {
+		cfg.Clients = 8
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = 10 * time.Second
+	}
+	if cfg.Command == "" {
+		cfg.Command = "status"
+	}
+
+	clients := make([]*rcon.RCONClient, cfg.Clients)
+	for i := range clients {
+		if cfg.SharedClient && i > 0 {
+			clients[i] = clients[0]
+			continue
+		}
+		rc, err := cfg.Dial()
+		if err != nil {
+			closeAll(clients[:i], cfg.SharedClient)
+			return nil, fmt.Errorf("loadtest: dial client %d: %w", i, err)
+		}
+		clients[i] = rc
+	}
+	defer closeAll(clients, cfg.SharedClient)
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		errs      int
+		firstErr  error
+		wg        sync.WaitGroup
+	)
+	start := time.Now()
+	for _, rc := range clients {
+		wg.Add(1)
+		go func(rc *rcon.RCONClient) {
+			defer wg.Done()
+			for n := 0; cfg.Requests <= 0 || n < cfg.Requests; n++ {
+				if ctx.Err() != nil {
+					return
+				}
+				t := time.Now()
+				err := send(ctx, rc, cfg)
+				took := time.Since(t)
+				if ctx.Err() != nil && err != nil {
+					return
+				}
+				mu.Lock()
+				latencies = append(latencies, took)
+				if err != nil {
+					errs++
+					if firstErr == nil {
+						firstErr = err
+					}
+				}
+				mu.Unlock()
+			}
+		}(rc)
+	}
+	wg.Wait()
+
+	return summarize(cfg.Clients, latencies, errs, firstErr, time.Since(start)), nil
+}
+
+// send issues one request the way the config asks for
+func send(ctx context.Context, rc *rcon.RCONClient, cfg Config) error {
+	var args *string
+	if cfg.Args != "" {
+		args = &cfg.Args
+	}
+	if !cfg.Queue {
+		_, err := rc.SendCommand(cfg.Command, args)
+		return err
+	}
+	select {
+	case res := <-rc.Enqueue(cfg.Command, args):
+		return res.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// summarize builds the report from the recorded latencies
+func summarize(clients int, latencies []time.Duration, errs int, firstErr error, elapsed time.Duration) *Report {
+	r := &Report{Clients: clients, Requests: len(latencies), Errors: errs, Elapsed: elapsed, FirstError: firstErr}
+	if len(latencies) == 0 {
+		return r
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	pct := func(p float64) time.Duration {
+		return latencies[int(p*float64(len(latencies)-1))]
+	}
+	r.Min, r.Max = latencies[0], latencies[len(latencies)-1]
+	r.P50, r.P90, r.P99 = pct(0.50), pct(0.90), pct(0.99)
+	if elapsed > 0 {
+		r.Throughput = float64(len(latencies)) / elapsed.Seconds()
+	}
+	return r
+}
+
+// closeAll closes the dialed clients once each
+func closeAll(clients []*rcon.RCONClient, shared bool) {
+	for i, rc := range clients {
+		if rc == nil || (shared && i > 0) {
+			continue
+		}
+		rc.Close()
+	}
+}
+
+// Write prints the report as a short table
+func (r *Report) Write(w io.Writer) {
+	fmt.Fprintf(w, "clients     %d\n", r.Clients)
+	fmt.Fprintf(w, "requests    %d (%d errors)\n", r.Requests, r.Errors)
+	fmt.Fprintf(w, "elapsed     %s\n", r.Elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "throughput  %.1f req/s\n", r.Throughput)
+	fmt.Fprintf(w, "latency     min %s  p50 %s  p90 %s  p99 %s  max %s\n",
+		r.Min.Round(time.Microsecond), r.P50.Round(time.Microsecond), r.P90.Round(time.Microsecond),
+		r.P99.Round(time.Microsecond), r.Max.Round(time.Microsecond))
+	if r.FirstError != nil {
+		fmt.Fprintf(w, "first error %v\n", r.FirstError)
+	}
+}
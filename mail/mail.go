@@ -0,0 +1,288 @@
+// Package mail sends alerts and reports by SMTP, for operators who want critical
+// notifications outside chat platforms
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/alert"
+	"github.com/Yallamaztar/PlutoRCON/v2/report"
+)
+
+// DefaultSubject and DefaultBody render a batch of alerts. Templates see .Alerts,
+// .Firing and .Resolved ([]alert.Alert) and .Servers ([]string)
+const (
+	DefaultSubject = `{{if .Firing}}[FIRING {{len .Firing}}]{{else}}[RESOLVED]{{end}} {{join .Servers ", "}}`
+	DefaultBody    = `{{range .Alerts}}{{if .Resolved}}RESOLVED{{else}}FIRING{{end}}  {{.Server}}  {{.Rule}}
+  {{.Message}}
+  at {{.FiredAt.Format "2006-01-02 15:04:05 MST"}}{{if .Resolved}}, resolved {{.ResolvedAt.Format "15:04:05"}}{{end}}
+
+{{end}}`
+)
+
+// Sender mails alerts (as an alert.Channel) and reports (as a report.Sink)
+type Sender struct {
+	// Addr is the SMTP server, e.g. smtp.example.com:587
+	Addr string
+	// Username and Password enable PLAIN authentication
+	Username, Password string
+	// ImplicitTLS connects with TLS right away (port 465) instead of STARTTLS
+	ImplicitTLS bool
+	// From may carry a display name: "PlutoRCON <rcon@example.com>"
+	From string
+	To   []string
+	// Subject and Body are text/template sources for alert mails (default DefaultSubject and DefaultBody)
+	Subject, Body string
+	// Batch collects alerts for this long and sends them in one mail (0 = one mail per alert)
+	Batch time.Duration
+	// Timeout bounds one delivery (default 30s)
+	Timeout time.Duration
+	// OnError receives failures of batched sends, which happen after Notify returned
+	OnError func(error)
+
+	once      sync.Once
+	templates *template.Template
+	tmplErr   error
+
+	mu      sync.Mutex
+	pending []alert.Alert
+	timer   *time.Timer
+}
+
+// Notify implements alert.Channel. With Batch set, the alert is queued and the error
+// of its delivery goes to OnError
+func (s *Sender) Notify(ctx context.Context, a alert.Alert) error {
+	if s.Batch <= 0 {
+		return s.sendAlerts(ctx, []alert.Alert{a})
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = append(s.pending, a)
+	if s.timer == nil {
+		s.timer = time.AfterFunc(s.Batch, func() { s.Flush() })
+	}
+	return nil
+}
+
+// Flush sends the queued alerts now
+func (s *Sender) Flush() {
+	s.mu.Lock()
+	batch := s.pending
+	s.pending = nil
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+	if err := s.sendAlerts(ctx, batch); err != nil && s.OnError != nil {
+		s.OnError(err)
+	}
+}
+
+// Deliver implements report.Sink, sending the Markdown as text with the HTML alternative
+func (s *Sender) Deliver(ctx context.Context, d *report.Document) error {
+	subject := d.Title + " " + d.From.Format("2006-01-02")
+	return s.send(ctx, subject, d.Markdown, d.HTML)
+}
+
+func (s *Sender) timeout() time.Duration {
+	if s.Timeout <= 0 {
+		return 30 * time.Second
+	}
+	return s.Timeout
+}
+
+// parse compiles the templates once
+func (s *Sender) parse() (*template.Template, error) {
+	s.once.Do(func() {
+		subject, body := s.Subject, s.Body
+		if subject == "" {
+			subject = DefaultSubject
+		}
+		if body == "" {
+			body = DefaultBody
+		}
+		t := template.New("subject").Funcs(template.FuncMap{"join": strings.Join})
+		if _, err := t.Parse(subject); err != nil {
+			s.tmplErr = fmt.Errorf("mail subject template: %w", err)
+			return
+		}
+		if _, err := t.New("body").Parse(body); err != nil {
+			s.tmplErr = fmt.Errorf("mail body template: %w", err)
+			return
+		}
+		s.templates = t
+	})
+	return s.templates, s.tmplErr
+}
+
+func (s *Sender) sendAlerts(ctx context.Context, alerts []alert.Alert) error {
+	t, err := s.parse()
+	if err != nil {
+		return err
+	}
+	data := struct {
+		Alerts, Firing, Resolved []alert.Alert
+		Servers                  []string
+	}{Alerts: alerts}
+	seen := map[string]bool{}
+	for _, a := range alerts {
+		if a.Resolved {
+			data.Resolved = append(data.Resolved, a)
+		} else {
+			data.Firing = append(data.Firing, a)
+		}
+		if !seen[a.Server] {
+			seen[a.Server] = true
+			data.Servers = append(data.Servers, a.Server)
+		}
+	}
+	sort.Strings(data.Servers)
+	var subject, body bytes.Buffer
+	if err := t.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return err
+	}
+	if err := t.ExecuteTemplate(&body, "body", data); err != nil {
+		return err
+	}
+	return s.send(ctx, strings.Join(strings.Fields(subject.String()), " "), body.String(), "")
+}
+
+// send writes one message; html, when set, is added as a multipart alternative
+func (s *Sender) send(ctx context.Context, subject, text, html string) error {
+	if s.Addr == "" || s.From == "" || len(s.To) == 0 {
+		return errors.New("mail sender needs Addr, From and To")
+	}
+	msg, err := s.message(subject, text, html)
+	if err != nil {
+		return err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout())
+		defer cancel()
+	}
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return err
+	}
+
+	var conn net.Conn
+	if s.ImplicitTLS {
+		conn, err = (&tls.Dialer{Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", s.Addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", s.Addr)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok && !s.ImplicitTLS {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, host)); err != nil {
+			return err
+		}
+	}
+	from, err := netmail.ParseAddress(s.From)
+	if err != nil {
+		return fmt.Errorf("mail from: %w", err)
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, to := range s.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message builds the RFC 5322 message with quoted-printable UTF-8 bodies
+func (s *Sender) message(subject, text, html string) ([]byte, error) {
+	var b bytes.Buffer
+	var id [12]byte
+	rand.Read(id[:])
+	domain := s.From[strings.LastIndex(s.From, "@")+1:]
+	fmt.Fprintf(&b, "From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMessage-ID: <%s@%s>\r\nMIME-Version: 1.0\r\n",
+		s.From, strings.Join(s.To, ", "), mime.QEncoding.Encode("utf-8", subject),
+		time.Now().Format(time.RFC1123Z), hex.EncodeToString(id[:]), strings.Trim(domain, "<> "))
+
+	if html == "" {
+		b.WriteString("Content-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQP(&b, text); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+	mw := multipart.NewWriter(&b)
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", mw.Boundary())
+	for _, part := range []struct{ typ, body string }{{"text/plain", text}, {"text/html", html}} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.typ + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQP(pw, part.body); err != nil {
+			return nil, err
+		}
+	}
+	return b.Bytes(), mw.Close()
+}
+
+func writeQP(w io.Writer, s string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(s, "\n", "\r\n"))); err != nil {
+		return err
+	}
+	return qp.Close()
+}
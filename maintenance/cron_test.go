@@ -0,0 +1,88 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	bad := []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "* * * * 8"}
+	for _, expr := range bad {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("parseCron(%q) accepted", expr)
+		}
+	}
+
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 10, day, hour, minute, 0, 0, time.UTC) }
+	tests := []struct {
+		expr string
+		t    time.Time
+		want bool
+	}{
+		{"* * * * *", at(15, 3, 7), true},
+		{"30 4 * * *", at(15, 4, 30), true},
+		{"30 4 * * *", at(15, 4, 31), false},
+		{"*/15 * * * *", at(15, 9, 45), true},
+		{"*/15 * * * *", at(15, 9, 50), false},
+		{"10/20 * * * *", at(15, 9, 50), true},
+		{"0 9-17 * * *", at(15, 17, 0), true},
+		{"0 9-17 * * *", at(15, 18, 0), false},
+		{"0 4 * * 0", at(18, 4, 0), true}, // Sunday
+		{"0 4 * * 7", at(18, 4, 0), true}, // Sunday written as 7
+		{"0 4 * * 1,3", at(19, 4, 0), true},
+		{"0 4 * * 1,3", at(20, 4, 0), false},
+		{"0 4 * 11 *", at(15, 4, 0), false},
+		// with both day fields set, either one matches
+		{"0 4 1 * 4", at(15, 4, 0), true},
+		{"0 4 1 * 5", at(15, 4, 0), false},
+	}
+	for _, tt := range tests {
+		c, err := parseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("parseCron(%q): %v", tt.expr, err)
+		}
+		if got := c.matches(tt.t); got != tt.want {
+			t.Errorf("%q matches %v = %t, want %t", tt.expr, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestWindowBounds(t *testing.T) {
+	s := &Schedule{}
+	if err := s.Add(Window{Name: "weekly", Cron: "30 23 * * 0", Duration: 2 * time.Hour, Labels: []string{"eu"}}); err != nil {
+		t.Fatal(err)
+	}
+	s.LabelsFrom(func(server string) []string {
+		if server == "tdm-1" {
+			return []string{"EU"}
+		}
+		return nil
+	})
+	// Sunday 18 October 2026 23:30 until Monday 01:30
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 10, day, hour, minute, 0, 0, time.UTC) }
+	tests := []struct {
+		t    time.Time
+		want bool
+	}{
+		{at(18, 23, 29), false},
+		{at(18, 23, 30), true},
+		{at(19, 1, 29), true},
+		{at(19, 1, 30), false},
+		{at(26, 0, 0), true},
+		{at(21, 0, 0), false},
+	}
+	for _, tt := range tests {
+		if got := s.Suppressed("tdm-1", tt.t); got != tt.want {
+			t.Errorf("Suppressed at %v = %t, want %t", tt.t, got, tt.want)
+		}
+	}
+	if s.Suppressed("us-1", at(18, 23, 45)) {
+		t.Error("window applied to a server without the label")
+	}
+
+	w := s.windows["weekly"]
+	start, end, ok := w.bounds(at(19, 0, 15))
+	if !ok || !start.Equal(at(18, 23, 30)) || !end.Equal(at(19, 1, 30)) {
+		t.Errorf("bounds = %v, %v, %t", start, end, ok)
+	}
+}
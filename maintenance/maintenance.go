@@ -0,0 +1,160 @@
+// Package maintenance defines maintenance windows during which automation is suppressed
+package maintenance
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Window struct {
+	Name string
+	// Cron starts a recurring window lasting Duration, e.g. "0 4 * * 1" for Mondays 04:00
+	Cron     string
+	Duration time.Duration
+	// Start and End define a one-off window when Cron is empty
+	Start time.Time
+	End   time.Time
+	// Location is used to evaluate Cron (default UTC)
+	Location *time.Location
+
+	// Servers and Labels scope the window; both empty means every server
+	Servers []string
+	Labels  []string
+}
+
+// Schedule holds the configured maintenance windows
+type Schedule struct {
+	mu       sync.RWMutex
+	windows  map[string]*window
+	labels   map[string][]string
+	labelsOf func(server string) []string
+}
+
+type window struct {
+	Window
+	cron *cronSpec
+}
+
+// maxCronLookback bounds how far back a recurring window is searched
+const maxCronLookback = 7 * 24 * time.Hour
+
+// Add registers or replaces a window by name
+func (s *Schedule) Add(w Window) error {
+	if w.Name == "" {
+		return fmt.Errorf("maintenance window needs a name")
+	}
+	win := &window{Window: w}
+	if w.Cron != "" {
+		spec, err := parseCron(w.Cron)
+		if err != nil {
+			return err
+		}
+		if w.Duration <= 0 || w.Duration > maxCronLookback {
+			return fmt.Errorf("window %s: duration must be between 0 and %s", w.Name, maxCronLookback)
+		}
+		win.cron = spec
+	} else if w.Start.IsZero() || !w.End.After(w.Start) {
+		return fmt.Errorf("window %s: needs a cron expression or a start before its end", w.Name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.windows == nil {
+		s.windows = map[string]*window{}
+	}
+	s.windows[w.Name] = win
+	return nil
+}
+
+// Remove deletes a window by name
+func (s *Schedule) Remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.windows, name)
+}
+
+// SetLabels assigns labels to a server for label-scoped windows
+func (s *Schedule) SetLabels(server string, labels ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.labels == nil {
+		s.labels = map[string][]string{}
+	}
+	s.labels[server] = labels
+}
+
+// LabelsFrom adds the labels fn reports for a server to those given to SetLabels, e.g.
+// a manager.Manager's Labels so windows follow the labels set there
+func (s *Schedule) LabelsFrom(fn func(server string) []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.labelsOf = fn
+}
+
+// Active returns the first window covering server at t
+func (s *Schedule) Active(server string, t time.Time) (Window, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	labels := s.labels[server]
+	if s.labelsOf != nil {
+		labels = append(append([]string(nil), labels...), s.labelsOf(server)...)
+	}
+	for _, w := range s.windows {
+		if w.appliesTo(server, labels) && w.activeAt(t) {
+			return w.Window, true
+		}
+	}
+	return Window{}, false
+}
+
+// Suppressed reports whether automation for server is suppressed at t
+func (s *Schedule) Suppressed(server string, t time.Time) bool {
+	_, ok := s.Active(server, t)
+	return ok
+}
+
+// appliesTo reports whether the window is scoped to server or one of its labels
+func (w *window) appliesTo(server string, labels []string) bool {
+	if len(w.Servers) == 0 && len(w.Labels) == 0 {
+		return true
+	}
+	for _, s := range w.Servers {
+		if strings.EqualFold(s, server) {
+			return true
+		}
+	}
+	for _, want := range w.Labels {
+		for _, l := range labels {
+			if strings.EqualFold(want, l) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// activeAt reports whether t falls inside the window
+func (w *window) activeAt(t time.Time) bool {
+	_, _, ok := w.bounds(t)
+	return ok
+}
+
+// bounds returns the start and end of the occurrence of the window covering t
+func (w *window) bounds(t time.Time) (start, end time.Time, ok bool) {
+	if w.cron == nil {
+		return w.Start, w.End, !t.Before(w.Start) && t.Before(w.End)
+	}
+	loc := w.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	start = w.cron.prev(t, t.Add(-w.Duration))
+	if start.IsZero() {
+		return time.Time{}, time.Time{}, false
+	}
+	end = start.Add(w.Duration)
+	return start, end, t.Before(end)
+}
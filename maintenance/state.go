@@ -0,0 +1,59 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// snapshot is the JSON form of a Schedule; locations travel by their IANA name
+type snapshot struct {
+	Windows []snapshotWindow    `json:"windows"`
+	Labels  map[string][]string `json:"labels,omitempty"`
+}
+
+type snapshotWindow struct {
+	Window
+	Location string `json:"Location,omitempty"`
+}
+
+// Snapshot encodes the windows and labels, e.g. to hand them to a standby instance
+func (s *Schedule) Snapshot() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	snap := snapshot{Labels: s.labels}
+	for _, w := range s.windows {
+		sw := snapshotWindow{Window: w.Window}
+		if w.Location != nil {
+			sw.Location = w.Location.String()
+		}
+		snap.Windows = append(snap.Windows, sw)
+	}
+	return json.Marshal(snap)
+}
+
+// Restore replaces the windows and labels with those of a Snapshot
+func (s *Schedule) Restore(data []byte) error {
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+	var fresh Schedule
+	for _, sw := range snap.Windows {
+		w := sw.Window
+		if sw.Location != "" {
+			loc, err := time.LoadLocation(sw.Location)
+			if err != nil {
+				return fmt.Errorf("window %s: %w", w.Name, err)
+			}
+			w.Location = loc
+		}
+		if err := fresh.Add(w); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.windows, s.labels = fresh.windows, snap.Labels
+	return nil
+}
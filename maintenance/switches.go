@@ -0,0 +1,193 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Subsystems the library can pause; any other name works too
+const (
+	Announcer = "announcer"
+	Policies  = "policies"
+	Rules     = "rules"
+)
+
+// ErrPausedEverywhere is returned by Resume for one server of a subsystem paused on
+// every server
+var ErrPausedEverywhere = errors.New("paused on every server")
+
+// Switch is one paused subsystem; an empty Server pauses it on every server
+type Switch struct {
+	Subsystem string    `json:"subsystem"`
+	Server    string    `json:"server,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// Switches pauses and resumes subsystems per server at runtime. With Path set the
+// state is kept in that JSON file, so it survives restarts, and a change made to the
+// file by another process (such as plutorcon switch) is picked up on the next check
+type Switches struct {
+	Path string
+
+	mu     sync.Mutex
+	loaded bool
+	mod    time.Time
+	paused []Switch
+}
+
+// Pause stops a subsystem on server, or everywhere with server empty
+func (s *Switches) Pause(subsystem, server string) error {
+	subsystem, server = strings.ToLower(strings.TrimSpace(subsystem)), strings.TrimSpace(server)
+	if subsystem == "" {
+		return errors.New("switch needs a subsystem")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return err
+	}
+	for _, sw := range s.paused {
+		if sw.Subsystem == subsystem && sw.Server == server {
+			return nil
+		}
+	}
+	s.paused = append(s.paused, Switch{Subsystem: subsystem, Server: server, At: time.Now()})
+	return s.saveLocked()
+}
+
+// Resume undoes Pause. Resuming one server of a subsystem paused everywhere is an
+// error; resume it everywhere instead
+func (s *Switches) Resume(subsystem, server string) error {
+	subsystem, server = strings.ToLower(strings.TrimSpace(subsystem)), strings.TrimSpace(server)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return err
+	}
+	keep := s.paused[:0]
+	for _, sw := range s.paused {
+		if sw.Subsystem == subsystem && sw.Server == "" && server != "" {
+			return fmt.Errorf("%s: %w", subsystem, ErrPausedEverywhere)
+		}
+		if sw.Subsystem != subsystem || sw.Server != server {
+			keep = append(keep, sw)
+		}
+	}
+	s.paused = keep
+	return s.saveLocked()
+}
+
+// Paused reports whether a subsystem is paused on server. A state file that can't be
+// read leaves the last known state in place
+func (s *Switches) Paused(subsystem, server string) bool {
+	subsystem = strings.ToLower(subsystem)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadLocked()
+	for _, sw := range s.paused {
+		if sw.Subsystem == subsystem && (sw.Server == "" || sw.Server == server) {
+			return true
+		}
+	}
+	return false
+}
+
+// List returns the paused switches by subsystem and server
+func (s *Switches) List() ([]Switch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	out := append([]Switch(nil), s.paused...)
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Subsystem != out[j].Subsystem {
+			return out[i].Subsystem < out[j].Subsystem
+		}
+		return out[i].Server < out[j].Server
+	})
+	return out, nil
+}
+
+// Gate returns the switch of one subsystem, for the Suppress fields of
+// policy.Enforcer, alert.Engine, alert.RuleConfig and rcon.Announcer
+func (s *Switches) Gate(subsystem string) Gate {
+	return Gate{switches: s, subsystem: subsystem}
+}
+
+// Gate suppresses a subsystem while its switch is paused
+type Gate struct {
+	switches  *Switches
+	subsystem string
+}
+
+// Suppressed reports whether the subsystem is paused on server
+func (g Gate) Suppressed(server string, _ time.Time) bool {
+	return g.switches != nil && g.switches.Paused(g.subsystem, server)
+}
+
+// loadLocked reads the file when it changed since the last read; a missing file
+// means nothing is paused
+func (s *Switches) loadLocked() error {
+	if s.Path == "" {
+		return nil
+	}
+	fi, err := os.Stat(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		if s.loaded && !s.mod.IsZero() {
+			s.paused, s.mod = nil, time.Time{}
+		}
+		s.loaded = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if s.loaded && fi.ModTime().Equal(s.mod) {
+		return nil
+	}
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return err
+	}
+	var paused []Switch
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &paused); err != nil {
+			return fmt.Errorf("switches %s: %w", s.Path, err)
+		}
+	}
+	s.paused, s.mod, s.loaded = paused, fi.ModTime(), true
+	return nil
+}
+
+// saveLocked replaces the file through a rename so a crash never leaves half of it
+func (s *Switches) saveLocked() error {
+	if s.Path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.paused, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return err
+	}
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.Path); err != nil {
+		return err
+	}
+	if fi, err := os.Stat(s.Path); err == nil {
+		s.mod = fi.ModTime()
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+// Package manager fans RCON operations out over several named servers
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// Manager holds named clients for managing several servers at once
+type Manager struct {
+	// Suppress reports servers in maintenance, e.g. a maintenance.Schedule reading the
+	// manager's labels through LabelsFrom. HealthCheck leaves them alone meanwhile
+	Suppress interface {
+		Suppressed(server string, t time.Time) bool
+	}
+	// OnDown is the auto-restart hook: HealthCheck calls it when a server stops
+	// answering and can't be redialed, e.g. to restart the server process. It isn't
+	// called again for that server until it has answered once more
+	OnDown func(name string, err error)
+
+	mu      sync.RWMutex
+	clients map[string]*rcon.RCONClient
+	labels  map[string][]string
+}
+
+// Error collects per-server failures of a broadcast operation
+type Error struct {
+	Errors map[string]error
+}
+
+func (e *Error) Error() string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ": " + e.Errors[name].Error()
+	}
+	return fmt.Sprintf("%d server(s) failed: %s", len(names), strings.Join(parts, "; "))
+}
+
+// Status is the status of one managed server
+type Status struct {
+	Server string             `json:"server"`
+	Status *rcon.ServerStatus `json:"status"`
+	Err    error              `json:"-"`
+}
+
+// MarshalJSON reports Err as its message
+func (s Status) MarshalJSON() ([]byte, error) {
+	type plain Status
+	return json.Marshal(struct {
+		plain
+		Err *string `json:"error,omitempty"`
+	}{plain(s), errString(s.Err)})
+}
+
+// New returns an empty manager
+func New() *Manager {
+	return &Manager{clients: map[string]*rcon.RCONClient{}}
+}
+
+// Add registers a client under a name, replacing any client with the same name
+func (m *Manager) Add(name string, rc *rcon.RCONClient) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.clients == nil {
+		m.clients = map[string]*rcon.RCONClient{}
+	}
+	m.clients[name] = rc
+}
+
+// Remove drops a client from the manager, returning it so the caller can close it
+func (m *Manager) Remove(name string) *rcon.RCONClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rc := m.clients[name]
+	delete(m.clients, name)
+	delete(m.labels, name)
+	return rc
+}
+
+// SetLabels assigns labels to a server, e.g. for label-scoped maintenance windows
+func (m *Manager) SetLabels(name string, labels ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.labels == nil {
+		m.labels = map[string][]string{}
+	}
+	m.labels[name] = labels
+}
+
+// Labels returns the labels of a server
+func (m *Manager) Labels(name string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]string(nil), m.labels[name]...)
+}
+
+// Suppressed reports whether Suppress has a server in maintenance
+func (m *Manager) Suppressed(name string) bool {
+	return m.Suppress != nil && m.Suppress.Suppressed(name, time.Now())
+}
+
+// Get returns a named client
+func (m *Manager) Get(name string) (*rcon.RCONClient, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rc, ok := m.clients[name]
+	return rc, ok
+}
+
+// Names returns the member names in sorted order
+func (m *Manager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.clients))
+	for name := range m.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Each runs fn against every client in parallel, returning a *Error for the ones that failed
+func (m *Manager) Each(fn func(name string, rc *rcon.RCONClient) error) error {
+	m.mu.RLock()
+	clients := make(map[string]*rcon.RCONClient, len(m.clients))
+	for name, rc := range m.clients {
+		clients[name] = rc
+	}
+	m.mu.RUnlock()
+
+	var (
+		mu   sync.Mutex
+		errs = map[string]error{}
+		wg   sync.WaitGroup
+	)
+	for name, rc := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(name, rc); err != nil {
+				mu.Lock()
+				errs[name] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return &Error{Errors: errs}
+	}
+	return nil
+}
+
+// Say broadcasts a message to every server
+func (m *Manager) Say(message string) error {
+	return m.Each(func(_ string, rc *rcon.RCONClient) error { return rc.Say(message) })
+}
+
+// SetDvar sets a dvar on every server
+func (m *Manager) SetDvar(dvar, value string) error {
+	return m.Each(func(_ string, rc *rcon.RCONClient) error { return rc.SetDvar(dvar, value) })
+}
+
+// Status polls every server in parallel, returning results sorted by server name
+func (m *Manager) Status() []Status {
+	var (
+		mu  sync.Mutex
+		out []Status
+	)
+	m.Each(func(name string, rc *rcon.RCONClient) error {
+		st, err := rc.Status()
+		mu.Lock()
+		out = append(out, Status{Server: name, Status: st, Err: err})
+		mu.Unlock()
+		return err
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].Server < out[j].Server })
+	return out
+}
+
+// FindGUID reports which server a player is currently on
+func (m *Manager) FindGUID(guid string) (string, *rcon.Player, bool) {
+	guid = strings.ToLower(strings.TrimSpace(guid))
+	for _, res := range m.Status() {
+		if res.Err != nil || res.Status == nil {
+			continue
+		}
+		for i := range res.Status.Players {
+			if strings.ToLower(res.Status.Players[i].GUID) == guid {
+				return res.Server, &res.Status.Players[i], true
+			}
+		}
+	}
+	return "", nil, false
+}
+
+// HealthCheck probes every server each interval until ctx is done, redialing one that
+// stops answering if its auto reconnect is enabled, and returns ctx.Err(). Servers in
+// maintenance are skipped, so a server taken down on purpose is neither redialed nor
+// handed to OnDown. Servers added later are not checked
+func (m *Manager) HealthCheck(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	m.Each(func(name string, rc *rcon.RCONClient) error {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		down := false
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-t.C:
+				if m.Suppressed(name) {
+					continue
+				}
+				if err := rc.Probe(); err == nil {
+					down = false
+				} else if !down {
+					down = true
+					if m.OnDown != nil {
+						m.OnDown(name, err)
+					}
+				}
+			}
+		}
+	})
+	return ctx.Err()
+}
+
+// Close closes every client
+func (m *Manager) Close() error {
+	return m.Each(func(_ string, rc *rcon.RCONClient) error { return rc.Close() })
+}
+
+func errString(err error) *string {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	return &msg
+}
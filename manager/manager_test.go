@@ -0,0 +1,52 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon/rcontest"
+)
+
+type suppressSet map[string]bool
+
+func (s suppressSet) Suppressed(server string, _ time.Time) bool { return s[server] }
+
+func TestHealthCheckMaintenance(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+	m := New()
+	m.Suppress = suppressSet{"maint": true}
+	m.OnDown = func(name string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, name)
+	}
+	for _, name := range []string{"up", "down", "maint"} {
+		srv := rcontest.NewServer("secret")
+		defer srv.Close()
+		rc, err := srv.Client(rcon.WithCommandDefaults(rcon.WithTimeout(50*time.Millisecond), rcon.WithReadExtension(10*time.Millisecond)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.Add(name, rc)
+		if name != "up" {
+			srv.Close()
+		}
+	}
+	defer m.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+	m.HealthCheck(ctx, 20*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) != 1 || calls[0] != "down" {
+		t.Fatalf("OnDown called for %q, want once for down", calls)
+	}
+}
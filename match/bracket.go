@@ -0,0 +1,96 @@
+package match
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/webhook"
+)
+
+type Report struct {
+	// MatchID is the bracket match this result belongs to (e.g. a Challonge match id)
+	MatchID string  `json:"match_id"`
+	Server  string  `json:"server,omitempty"`
+	Result  *Result `json:"result"`
+}
+
+// BracketReporter pushes match results to a tournament platform
+type BracketReporter interface {
+	ReportMatch(ctx context.Context, r Report) error
+}
+
+// Bracket reports finished matches to every reporter, tagged with the scheduled bracket match id
+type Bracket struct {
+	Server    string
+	Reporters []BracketReporter
+	Timeout   time.Duration
+	OnError   func(Report, error)
+
+	mu   sync.Mutex
+	next string
+}
+
+// SetMatch sets the bracket match id for the match currently being played
+func (b *Bracket) SetMatch(id string) {
+	b.mu.Lock()
+	b.next = id
+	b.mu.Unlock()
+}
+
+// Attach reports every match ended by the tracker, keeping its existing OnMatchEnded callback
+func (b *Bracket) Attach(t *Tracker) {
+	prev := t.OnMatchEnded
+	t.OnMatchEnded = func(r *Result) {
+		if prev != nil {
+			prev(r)
+		}
+		b.mu.Lock()
+		id := b.next
+		b.next = ""
+		b.mu.Unlock()
+		if id == "" {
+			return
+		}
+		timeout := b.Timeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		rep := Report{MatchID: id, Server: b.Server, Result: r}
+		if err := b.Report(ctx, rep); err != nil && b.OnError != nil {
+			b.OnError(rep, err)
+		}
+	}
+}
+
+// Report sends a report to every reporter, returning the joined errors
+func (b *Bracket) Report(ctx context.Context, r Report) error {
+	var errs []error
+	for _, rep := range b.Reporters {
+		if err := rep.ReportMatch(ctx, r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WebhookReporter POSTs each report as JSON to URL
+type WebhookReporter struct {
+	URL     string
+	Headers map[string]string
+	Client  *http.Client
+}
+
+// ReportMatch implements BracketReporter
+func (w *WebhookReporter) ReportMatch(ctx context.Context, r Report) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return webhook.PostJSON(ctx, w.Client, w.URL, w.Headers, data)
+}
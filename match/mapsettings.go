@@ -0,0 +1,104 @@
+package match
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// MapSettings applies per-map dvar overrides when a map starts and reverts them on the next map
+type MapSettings struct {
+	Client *rcon.RCONClient
+	// Overrides maps a map name (e.g. "mp_nuketown_2020") to the dvars to set on it
+	Overrides map[string]map[string]string
+	OnError   func(error)
+
+	mu    sync.Mutex
+	saved map[string]string
+}
+
+// Attach applies overrides on every match the tracker starts, keeping its existing callback
+func (s *MapSettings) Attach(t *Tracker) {
+	prev := t.OnMatchStarted
+	t.OnMatchStarted = func(m Match) {
+		if err := s.Apply(m.Map); err != nil && s.OnError != nil {
+			s.OnError(err)
+		}
+		if prev != nil {
+			prev(m)
+		}
+	}
+}
+
+// Apply reverts the previous map's overrides and sets the overrides for mapName
+func (s *MapSettings) Apply(mapName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	if err := s.revertLocked(); err != nil {
+		errs = append(errs, err)
+	}
+
+	overrides := s.lookup(mapName)
+	if len(overrides) == 0 {
+		return errors.Join(errs...)
+	}
+
+	s.saved = make(map[string]string, len(overrides))
+	for dvar, value := range overrides {
+		prev, err := s.Client.GetDvar(dvar)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("read %s: %w", dvar, err))
+			continue
+		}
+		if err := s.Client.SetDvar(dvar, value); err != nil {
+			errs = append(errs, fmt.Errorf("set %s: %w", dvar, err))
+			continue
+		}
+		s.saved[dvar] = prev
+	}
+	return errors.Join(errs...)
+}
+
+// Revert restores the dvars changed by the last Apply
+func (s *MapSettings) Revert() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.revertLocked()
+}
+
+// revertLocked restores saved dvars; callers hold s.mu
+func (s *MapSettings) revertLocked() error {
+	var errs []error
+	for dvar, value := range s.saved {
+		var err error
+		if value == "" {
+			arg := dvar + ` ""`
+			_, err = s.Client.SendCommand("set", &arg)
+		} else {
+			err = s.Client.SetDvar(dvar, value)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("revert %s: %w", dvar, err))
+		}
+	}
+	s.saved = nil
+	return errors.Join(errs...)
+}
+
+// lookup returns the overrides for a map, ignoring case
+func (s *MapSettings) lookup(mapName string) map[string]string {
+	if o, ok := s.Overrides[mapName]; ok {
+		return o
+	}
+	for name, o := range s.Overrides {
+		if strings.EqualFold(name, mapName) {
+			return o
+		}
+	}
+	return nil
+}
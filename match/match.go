@@ -0,0 +1,119 @@
+package match
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+type Match struct {
+	Map       string
+	Gametype  string
+	StartedAt time.Time
+}
+
+// Tracker follows status polls and detects when matches start and end
+type Tracker struct {
+	OnMatchStarted func(Match)
+	OnMatchEnded   func(*Result)
+
+	mu      sync.Mutex
+	current *Match
+	last    *rcon.ServerStatus
+}
+
+// Observe feeds a status snapshot to the tracker; a map change ends the running match
+func (t *Tracker) Observe(st *rcon.ServerStatus, gametype string) *Result {
+	if st == nil || st.Map == "" {
+		return nil
+	}
+	at := st.RetrievedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	t.mu.Lock()
+	var ended *Result
+	var started *Match
+	if t.current != nil && !strings.EqualFold(t.current.Map, st.Map) {
+		ended = buildResult(*t.current, t.last, at)
+		t.current = nil
+	}
+	if t.current == nil {
+		t.current = &Match{Map: st.Map, Gametype: gametype, StartedAt: at}
+		m := *t.current
+		started = &m
+	} else if t.current.Gametype == "" {
+		t.current.Gametype = gametype
+	}
+	t.last = st
+	t.mu.Unlock()
+
+	if ended != nil && t.OnMatchEnded != nil {
+		t.OnMatchEnded(ended)
+	}
+	if started != nil && t.OnMatchStarted != nil {
+		t.OnMatchStarted(*started)
+	}
+	return ended
+}
+
+// End finishes the running match now (e.g. on a log ExitLevel line) and returns its result
+func (t *Tracker) End() *Result {
+	t.mu.Lock()
+	if t.current == nil {
+		t.mu.Unlock()
+		return nil
+	}
+	res := buildResult(*t.current, t.last, time.Now())
+	t.current = nil
+	t.mu.Unlock()
+
+	if t.OnMatchEnded != nil {
+		t.OnMatchEnded(res)
+	}
+	return res
+}
+
+// Current returns the running match, if any
+func (t *Tracker) Current() (Match, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.current == nil {
+		return Match{}, false
+	}
+	return *t.current, true
+}
+
+// buildResult assembles a result from the last snapshot of a match
+func buildResult(m Match, last *rcon.ServerStatus, endedAt time.Time) *Result {
+	res := &Result{
+		Map:       m.Map,
+		Gametype:  m.Gametype,
+		StartedAt: m.StartedAt,
+		EndedAt:   endedAt,
+		Duration:  endedAt.Sub(m.StartedAt),
+	}
+	if last == nil {
+		return res
+	}
+	for _, p := range last.Players {
+		res.Players = append(res.Players, PlayerResult{
+			ClientNum: p.ClientNum,
+			GUID:      p.GUID,
+			Name:      p.Name,
+			Score:     p.Score,
+		})
+	}
+	sort.SliceStable(res.Players, func(i, j int) bool {
+		return res.Players[i].Score > res.Players[j].Score
+	})
+	if len(res.Players) > 0 && (len(res.Players) == 1 || res.Players[0].Score > res.Players[1].Score) {
+		w := res.Players[0]
+		res.Winner = &w
+	}
+	return res
+}
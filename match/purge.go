@@ -0,0 +1,104 @@
+package match
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Purger is a sink that can delete stored results. FileSink implements it
+type Purger interface {
+	// Purge removes a player from every stored result
+	Purge(ctx context.Context, guid string) error
+	// PurgeOlderThan deletes the results of matches that ended before d ago and
+	// returns how many
+	PurgeOlderThan(ctx context.Context, d time.Duration) (int, error)
+}
+
+// RedactPlayer removes a player from a result; when they won, Winner becomes nil. It
+// reports whether the player was in the result
+func RedactPlayer(r *Result, guid string) bool {
+	guid = strings.TrimSpace(guid)
+	if r == nil || guid == "" {
+		return false
+	}
+	found := false
+	players := r.Players[:0]
+	for _, p := range r.Players {
+		if strings.EqualFold(p.GUID, guid) {
+			found = true
+			continue
+		}
+		players = append(players, p)
+	}
+	r.Players = players
+	if r.Winner != nil && strings.EqualFold(r.Winner.GUID, guid) {
+		r.Winner, found = nil, true
+	}
+	return found
+}
+
+// Purge implements Purger, rewriting the files the player appears in
+func (f *FileSink) Purge(ctx context.Context, guid string) error {
+	guid = strings.TrimSpace(guid)
+	if guid == "" {
+		return errors.New("purge needs a guid")
+	}
+	return f.each(ctx, func(path string, data []byte, r *Result) error {
+		if !bytes.Contains(bytes.ToLower(data), []byte(strings.ToLower(guid))) || !RedactPlayer(r, guid) {
+			return nil
+		}
+		out, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, out, 0o644)
+	})
+}
+
+// PurgeOlderThan implements Purger
+func (f *FileSink) PurgeOlderThan(ctx context.Context, d time.Duration) (int, error) {
+	cutoff := time.Now().Add(-d)
+	n := 0
+	err := f.each(ctx, func(path string, _ []byte, r *Result) error {
+		if !r.EndedAt.Before(cutoff) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	return n, err
+}
+
+// each calls fn with every result file in Dir; files that aren't results are skipped
+func (f *FileSink) each(ctx context.Context, fn func(path string, data []byte, r *Result) error) error {
+	files, err := filepath.Glob(filepath.Join(f.Dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var r Result
+		if json.Unmarshal(data, &r) != nil || r.EndedAt.IsZero() {
+			continue
+		}
+		if err := fn(path, data, &r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
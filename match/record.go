@@ -0,0 +1,47 @@
+package match
+
+import (
+	"fmt"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// AutoRecorder starts a demo when a match starts and stops it when the match ends
+type AutoRecorder struct {
+	Client *rcon.RCONClient
+	// Name builds the demo name for a match; defaults to <map>_<gametype>_<unix time>
+	Name    func(Match) string
+	OnError func(error)
+}
+
+// Attach hooks the recorder into a tracker's lifecycle callbacks, keeping any existing ones
+func (a *AutoRecorder) Attach(t *Tracker) {
+	prevStart, prevEnd := t.OnMatchStarted, t.OnMatchEnded
+	t.OnMatchStarted = func(m Match) {
+		if prevStart != nil {
+			prevStart(m)
+		}
+		a.report(a.Client.StartRecording(a.name(m)))
+	}
+	t.OnMatchEnded = func(r *Result) {
+		a.report(a.Client.StopRecording())
+		if prevEnd != nil {
+			prevEnd(r)
+		}
+	}
+}
+
+// name returns the demo name for a match
+func (a *AutoRecorder) name(m Match) string {
+	if a.Name != nil {
+		return a.Name(m)
+	}
+	return fmt.Sprintf("%s_%s_%d", safeName(m.Map), safeName(m.Gametype), m.StartedAt.Unix())
+}
+
+// report forwards a recording error to OnError
+func (a *AutoRecorder) report(err error) {
+	if err != nil && a.OnError != nil {
+		a.OnError(err)
+	}
+}
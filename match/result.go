@@ -0,0 +1,47 @@
+package match
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type PlayerResult struct {
+	ClientNum int    `json:"client_num"`
+	GUID      string `json:"guid"`
+	Name      string `json:"name"`
+	Score     int    `json:"score"`
+}
+
+type Result struct {
+	Map       string         `json:"map"`
+	Gametype  string         `json:"gametype"`
+	StartedAt time.Time      `json:"started_at"`
+	EndedAt   time.Time      `json:"ended_at"`
+	Duration  time.Duration  `json:"-"`
+	Players   []PlayerResult `json:"players"`
+	// Winner is the top scorer, nil on a tie or an empty server
+	Winner *PlayerResult `json:"winner,omitempty"`
+}
+
+// MarshalJSON writes the duration in whole seconds alongside the other fields
+func (r *Result) MarshalJSON() ([]byte, error) {
+	type plain Result
+	return json.Marshal(struct {
+		*plain
+		DurationSec int64 `json:"duration_sec"`
+	}{(*plain)(r), int64(r.Duration / time.Second)})
+}
+
+// UnmarshalJSON reads what MarshalJSON writes, restoring the duration
+func (r *Result) UnmarshalJSON(data []byte) error {
+	type plain Result
+	v := struct {
+		*plain
+		DurationSec int64 `json:"duration_sec"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	r.Duration = time.Duration(v.DurationSec) * time.Second
+	return nil
+}
@@ -0,0 +1,109 @@
+package match
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/webhook"
+)
+
+// Sink receives finished match results
+type Sink interface {
+	Write(ctx context.Context, r *Result) error
+}
+
+// Exporter writes every result to all of its sinks
+type Exporter struct {
+	Sinks []Sink
+}
+
+// Export writes a result to every sink, returning the joined errors of the sinks that failed
+func (e *Exporter) Export(ctx context.Context, r *Result) error {
+	var errs []error
+	for _, s := range e.Sinks {
+		if err := s.Write(ctx, r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FileSink writes each result as an indented JSON file into Dir
+type FileSink struct {
+	Dir string
+}
+
+// Write implements Sink
+func (f *FileSink) Write(_ context.Context, r *Result) error {
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s_%s_%s.json", r.EndedAt.UTC().Format("20060102T150405Z"), safeName(r.Map), safeName(r.Gametype))
+	return os.WriteFile(filepath.Join(f.Dir, name), data, 0o644)
+}
+
+// WebhookSink POSTs each result as JSON to URL
+type WebhookSink struct {
+	URL     string
+	Headers map[string]string
+	Client  *http.Client
+}
+
+// Write implements Sink
+func (w *WebhookSink) Write(ctx context.Context, r *Result) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return webhook.PostJSON(ctx, w.Client, w.URL, w.Headers, data)
+}
+
+// SQLSink inserts each result into a table through database/sql
+type SQLSink struct {
+	DB *sql.DB
+	// Query receives map, gametype, started_at, ended_at and the JSON document.
+	// Defaults to an INSERT into match_results using ? placeholders
+	Query string
+}
+
+// Write implements Sink
+func (s *SQLSink) Write(ctx context.Context, r *Result) error {
+	if s.DB == nil {
+		return errors.New("sql sink has no database")
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	q := s.Query
+	if q == "" {
+		q = "INSERT INTO match_results (map, gametype, started_at, ended_at, document) VALUES (?, ?, ?, ?, ?)"
+	}
+	_, err = s.DB.ExecContext(ctx, q, r.Map, r.Gametype, r.StartedAt.UTC(), r.EndedAt.UTC(), string(data))
+	return err
+}
+
+// safeName makes a string usable inside a file name
+func safeName(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
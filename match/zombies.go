@@ -0,0 +1,98 @@
+package match
+
+import (
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/events"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// ZombiesScript is a GSC script for T6 zombies servers. Stock servers expose neither
+// the round number nor downs and revives, so it mirrors level.round_number into the
+// zm_round dvar RoundTracker polls and logs the RoundStart, Downed and Revived lines
+// of the events package. Install it in the server's scripts\zm folder
+//
+//go:embed zm_plutorcon.gsc
+var ZombiesScript string
+
+// zombiesGametypes are the T6 zombies g_gametype values
+var zombiesGametypes = map[string]bool{
+	"zclassic":  true,
+	"zstandard": true,
+	"zgrief":    true,
+	"zcleansed": true,
+}
+
+// IsZombies reports whether a gametype is a T6 zombies mode
+func IsZombies(gametype string) bool {
+	return zombiesGametypes[strings.ToLower(strings.TrimSpace(gametype))]
+}
+
+// ZombiesProfile is the T6 profile for zombies servers, for rcon.WithProfile. Status
+// and the commands are T6's; zombies has no playlists or hardcore mode, so those
+// capabilities are off and the calls fail with rcon.ErrUnsupported
+func ZombiesProfile() rcon.GameProfile {
+	return rcon.GameProfile{Game: rcon.GameT6, Capabilities: rcon.Capabilities{Recording: true}}
+}
+
+// RoundTracker follows the zombies round number and reports round changes, from
+// Poll or from the RoundStart lines given to Observe
+type RoundTracker struct {
+	Client *rcon.RCONClient
+	// RoundDvar holds the current round. Stock servers don't expose it; ZombiesScript
+	// mirrors level.round_number into it (default "zm_round")
+	RoundDvar     string
+	OnRoundChange func(prev, round int)
+
+	mu    sync.Mutex
+	round int
+}
+
+// Poll reads the round dvar and fires OnRoundChange when it moved
+func (r *RoundTracker) Poll() (int, error) {
+	name := r.RoundDvar
+	if name == "" {
+		name = "zm_round"
+	}
+	val, err := r.Client.GetDvar(name)
+	if err != nil {
+		return 0, err
+	}
+	round, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil {
+		return 0, fmt.Errorf("invalid round value %q in %s", val, name)
+	}
+
+	r.set(round)
+	return round, nil
+}
+
+// Observe takes the round from a RoundStart log event; other events are ignored
+func (r *RoundTracker) Observe(ev events.Event) {
+	if rs, ok := ev.(*events.RoundStart); ok {
+		r.set(rs.Round)
+	}
+}
+
+// set records round and fires OnRoundChange when it moved
+func (r *RoundTracker) set(round int) {
+	r.mu.Lock()
+	prev := r.round
+	r.round = round
+	r.mu.Unlock()
+
+	if prev != round && r.OnRoundChange != nil {
+		r.OnRoundChange(prev, round)
+	}
+}
+
+// Round returns the last polled round
+func (r *RoundTracker) Round() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.round
+}
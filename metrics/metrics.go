@@ -0,0 +1,200 @@
+// Package metrics turns server state into one-shot metric snapshots for TSDBs
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// Snapshot is one poll of a server
+type Snapshot struct {
+	Server     string    `json:"server"`
+	Time       time.Time `json:"time"`
+	Up         bool      `json:"up"`
+	Hostname   string    `json:"hostname,omitempty"`
+	Map        string    `json:"map,omitempty"`
+	GameType   string    `json:"gametype,omitempty"`
+	Players    int       `json:"players"`
+	Bots       int       `json:"bots"`
+	Loading    int       `json:"loading"`
+	MaxClients int       `json:"max_clients"`
+	AvgPing    float64   `json:"avg_ping_ms"`
+	MaxPing    int       `json:"max_ping_ms"`
+	// PollTime is how long the getinfo, getstatus and status round trips took
+	PollTime float64 `json:"poll_ms"`
+	// RejectedDatagrams counts replies dropped for coming from an address other than the server's
+	RejectedDatagrams uint64 `json:"rejected_datagrams"`
+}
+
+// Collect polls a server once. A server that doesn't answer yields a snapshot with Up false
+// rather than an error, so exports keep a continuous series
+func Collect(rc *rcon.RCONClient, server string) *Snapshot {
+	start := time.Now()
+	st, err := rc.FullState(rcon.WithPriority(rcon.PriorityPoll))
+	snap := &Snapshot{Server: server, Time: start, RejectedDatagrams: rc.RejectedDatagrams()}
+	if err != nil {
+		return snap
+	}
+	snap.Up = true
+	snap.PollTime = float64(time.Since(start).Microseconds()) / 1000
+	if st.Info != nil {
+		snap.Hostname = st.Info.Hostname
+		snap.MaxClients = st.Info.MaxClients
+	}
+	if st.StatusInfo != nil {
+		snap.Map = st.StatusInfo.MapName
+		snap.GameType = st.StatusInfo.GameType
+		if snap.MaxClients == 0 {
+			snap.MaxClients = max(st.StatusInfo.ComMaxClients, st.StatusInfo.SvMaxClients)
+		}
+	}
+	if st.Status != nil {
+		pingSum, pinged := 0, 0
+		for _, p := range st.Status.Players {
+			snap.Players++
+			if p.GUID == "0" || strings.EqualFold(p.IP, "bot") {
+				snap.Bots++
+			}
+			if p.Loading || p.Zombie {
+				snap.Loading++
+				continue
+			}
+			ping := p.Ping
+			pingSum += ping
+			pinged++
+			if ping > snap.MaxPing {
+				snap.MaxPing = ping
+			}
+		}
+		if pinged > 0 {
+			snap.AvgPing = float64(pingSum) / float64(pinged)
+		}
+	}
+	return snap
+}
+
+// Write writes snapshots in one of the formats "influx", "prom" or "json"
+func Write(w io.Writer, format string, snaps []*Snapshot) error {
+	switch strings.ToLower(format) {
+	case "influx":
+		return WriteInflux(w, snaps)
+	case "prom", "prometheus":
+		return WritePrometheus(w, snaps)
+	case "json":
+		return WriteJSON(w, snaps)
+	}
+	return fmt.Errorf("unknown metrics format %q (want influx, prom or json)", format)
+}
+
+// WriteJSON writes the snapshots as a JSON array
+func WriteJSON(w io.Writer, snaps []*Snapshot) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(snaps)
+}
+
+// WriteInflux writes one line protocol point per snapshot
+func WriteInflux(w io.Writer, snaps []*Snapshot) error {
+	for _, s := range snaps {
+		tags := "plutorcon,server=" + influxEscape(s.Server)
+		if s.Map != "" {
+			tags += ",map=" + influxEscape(s.Map)
+		}
+		if s.GameType != "" {
+			tags += ",gametype=" + influxEscape(s.GameType)
+		}
+		fields := []string{fmt.Sprintf("up=%t", s.Up)}
+		for _, f := range numericFields(s) {
+			fields = append(fields, f.name+"="+f.value(true))
+		}
+		if _, err := fmt.Fprintf(w, "%s %s %d\n", tags, strings.Join(fields, ","), s.Time.UnixNano()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WritePrometheus writes the snapshots in the Prometheus text exposition format
+func WritePrometheus(w io.Writer, snaps []*Snapshot) error {
+	type sample struct {
+		labels string
+		value  string
+	}
+	series := map[string][]sample{}
+	var names []string
+	add := func(name, labels, value string) {
+		if _, ok := series[name]; !ok {
+			names = append(names, name)
+		}
+		series[name] = append(series[name], sample{labels, value})
+	}
+	for _, s := range snaps {
+		labels := fmt.Sprintf(`server="%s",map="%s",gametype="%s"`, promEscape(s.Server), promEscape(s.Map), promEscape(s.GameType))
+		up := "0"
+		if s.Up {
+			up = "1"
+		}
+		add("plutorcon_up", labels, up)
+		for _, f := range numericFields(s) {
+			add("plutorcon_"+f.name, labels, f.value(false))
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := fmt.Fprintf(w, "# TYPE %s gauge\n", name); err != nil {
+			return err
+		}
+		for _, smp := range series[name] {
+			if _, err := fmt.Fprintf(w, "%s{%s} %s\n", name, smp.labels, smp.value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type field struct {
+	name  string
+	i     int
+	f     float64
+	float bool
+}
+
+// value formats the field; influx integers carry an i suffix
+func (f field) value(influx bool) string {
+	if f.float {
+		return strconv.FormatFloat(f.f, 'f', -1, 64)
+	}
+	if influx {
+		return strconv.Itoa(f.i) + "i"
+	}
+	return strconv.Itoa(f.i)
+}
+
+func numericFields(s *Snapshot) []field {
+	return []field{
+		{name: "players", i: s.Players},
+		{name: "bots", i: s.Bots},
+		{name: "loading", i: s.Loading},
+		{name: "max_clients", i: s.MaxClients},
+		{name: "avg_ping_ms", f: s.AvgPing, float: true},
+		{name: "max_ping_ms", i: s.MaxPing},
+		{name: "poll_ms", f: s.PollTime, float: true},
+		{name: "rejected_datagrams", i: int(s.RejectedDatagrams)},
+	}
+}
+
+var influxReplacer = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+func influxEscape(s string) string { return influxReplacer.Replace(s) }
+
+var promReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func promEscape(s string) string { return promReplacer.Replace(s) }
@@ -0,0 +1,210 @@
+// Package notify posts formatted server events to Discord-compatible webhooks
+package notify
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// Kind names a notifiable event
+type Kind string
+
+const (
+	ServerDown Kind = "server_down"
+	ServerUp   Kind = "server_up"
+	// PlayersAbove and PlayersBelow fire when the player count crosses Notifier.Threshold
+	PlayersAbove Kind = "players_above"
+	PlayersBelow Kind = "players_below"
+	// WatchedJoin fires when a player from Notifier.Watch joins
+	WatchedJoin Kind = "watched_join"
+	MapChange   Kind = "map_change"
+)
+
+// DefaultTemplates are the messages used for kinds missing from Notifier.Templates
+var DefaultTemplates = map[Kind]string{
+	ServerDown:   "**{server}** is down: {error}",
+	ServerUp:     "**{server}** is back up",
+	PlayersAbove: "**{server}** has {players} players (threshold {threshold})",
+	PlayersBelow: "**{server}** dropped to {players} players (threshold {threshold})",
+	WatchedJoin:  "**{player}** ({guid}) joined **{server}**",
+	MapChange:    "**{server}** changed map: {old_map_name} -> {map_name}",
+}
+
+// Event is one occurrence handed to the templates
+type Event struct {
+	Kind    Kind
+	Server  string
+	At      time.Time
+	Player  *rcon.Player
+	Players int
+	OldMap  string
+	Map     string
+	Err     error
+}
+
+// Notifier turns Monitor callbacks into webhook messages. Templates may use {server},
+// {kind}, {time}, {player}, {guid}, {client}, {players}, {threshold}, {map}, {old_map},
+// {map_name}, {old_map_name} and {error}
+type Notifier struct {
+	Webhook *Webhook
+	// Server is the name shown in messages
+	Server string
+	// Game picks the display names of {map_name} and {old_map_name} (default T6)
+	Game rcon.Game
+	// Kinds limits which events are sent (nil = all)
+	Kinds []Kind
+	// Threshold fires PlayersAbove/PlayersBelow when the count crosses it (0 = off)
+	Threshold int
+	// Watch lists GUIDs whose joins are announced
+	Watch     []string
+	Templates map[Kind]string
+	// Timeout bounds one delivery (default 10s)
+	Timeout time.Duration
+	OnError func(Event, error)
+
+	mu    sync.Mutex
+	count int
+	known bool
+}
+
+// Attach registers the notifier on a Monitor, keeping callbacks that are already set.
+// Call it before Monitor.Run
+func (n *Notifier) Attach(m *rcon.Monitor) {
+	down, up, join, mapChange, status := m.OnServerDown, m.OnServerUp, m.OnPlayerJoin, m.OnMapChange, m.OnStatus
+	m.OnServerDown = func(err error) {
+		if down != nil {
+			down(err)
+		}
+		n.Notify(Event{Kind: ServerDown, Err: err})
+	}
+	m.OnServerUp = func() {
+		if up != nil {
+			up()
+		}
+		n.Notify(Event{Kind: ServerUp})
+	}
+	m.OnPlayerJoin = func(p rcon.Player) {
+		if join != nil {
+			join(p)
+		}
+		if n.watched(p.GUID) {
+			n.Notify(Event{Kind: WatchedJoin, Player: &p})
+		}
+	}
+	m.OnMapChange = func(old, cur string) {
+		if mapChange != nil {
+			mapChange(old, cur)
+		}
+		n.Notify(Event{Kind: MapChange, OldMap: old, Map: cur})
+	}
+	m.OnStatus = func(st *rcon.ServerStatus) {
+		if status != nil {
+			status(st)
+		}
+		n.Observe(len(m.Players()), st.Map)
+	}
+}
+
+// Observe feeds a player count to the threshold check; the first count only primes it
+func (n *Notifier) Observe(players int, mapName string) {
+	if n.Threshold <= 0 {
+		return
+	}
+	n.mu.Lock()
+	prev, known := n.count, n.known
+	n.count, n.known = players, true
+	n.mu.Unlock()
+	if !known {
+		return
+	}
+	switch {
+	case prev < n.Threshold && players >= n.Threshold:
+		n.Notify(Event{Kind: PlayersAbove, Players: players, Map: mapName})
+	case prev >= n.Threshold && players < n.Threshold:
+		n.Notify(Event{Kind: PlayersBelow, Players: players, Map: mapName})
+	}
+}
+
+// Notify renders and posts an event if its kind is enabled; failures go to OnError
+func (n *Notifier) Notify(ev Event) {
+	if !n.enabled(ev.Kind) || n.Webhook == nil {
+		return
+	}
+	if ev.Server == "" {
+		ev.Server = n.Server
+	}
+	if ev.At.IsZero() {
+		ev.At = time.Now()
+	}
+	timeout := n.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := n.Webhook.Send(ctx, n.Render(ev)); err != nil && n.OnError != nil {
+		n.OnError(ev, err)
+	}
+}
+
+// Render fills the template of an event's kind
+func (n *Notifier) Render(ev Event) string {
+	tmpl, ok := n.Templates[ev.Kind]
+	if !ok {
+		tmpl = DefaultTemplates[ev.Kind]
+	}
+	if tmpl == "" {
+		tmpl = "{server}: {kind}"
+	}
+	var player, guid, client, errText string
+	if ev.Player != nil {
+		player = wire.StripColors(ev.Player.Name)
+		guid = ev.Player.GUID
+		client = strconv.Itoa(ev.Player.ClientNum)
+	}
+	if ev.Err != nil {
+		errText = ev.Err.Error()
+	}
+	return strings.NewReplacer(
+		"{server}", ev.Server,
+		"{kind}", string(ev.Kind),
+		"{time}", ev.At.Format(time.RFC3339),
+		"{player}", player,
+		"{guid}", guid,
+		"{client}", client,
+		"{players}", strconv.Itoa(ev.Players),
+		"{threshold}", strconv.Itoa(n.Threshold),
+		"{map}", ev.Map,
+		"{old_map}", ev.OldMap,
+		"{map_name}", rcon.MapDisplayName(n.Game, ev.Map),
+		"{old_map_name}", rcon.MapDisplayName(n.Game, ev.OldMap),
+		"{error}", errText,
+	).Replace(tmpl)
+}
+
+func (n *Notifier) enabled(k Kind) bool {
+	if n.Kinds == nil {
+		return true
+	}
+	for _, want := range n.Kinds {
+		if want == k {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Notifier) watched(guid string) bool {
+	for _, g := range n.Watch {
+		if strings.EqualFold(g, guid) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/webhook"
+)
+
+// maxContent is Discord's limit on the content of one message
+const maxContent = 2000
+
+// Webhook posts messages as Discord webhook JSON ({"content": ...})
+type Webhook struct {
+	URL       string
+	Username  string
+	AvatarURL string
+	Client    *http.Client
+}
+
+type payload struct {
+	Content         string          `json:"content"`
+	Username        string          `json:"username,omitempty"`
+	AvatarURL       string          `json:"avatar_url,omitempty"`
+	AllowedMentions allowedMentions `json:"allowed_mentions"`
+}
+
+// allowedMentions keeps player names such as "@everyone" from pinging anyone
+type allowedMentions struct {
+	Parse []string `json:"parse"`
+}
+
+// Send posts one message, waiting out a single rate limit response
+func (w *Webhook) Send(ctx context.Context, content string) error {
+	if r := []rune(content); len(r) > maxContent {
+		content = string(r[:maxContent-1]) + "…"
+	}
+	body, err := json.Marshal(payload{Content: content, Username: w.Username, AvatarURL: w.AvatarURL, AllowedMentions: allowedMentions{Parse: []string{}}})
+	if err != nil {
+		return err
+	}
+	for attempt := 0; ; attempt++ {
+		wait, err := w.post(ctx, body)
+		if err == nil || wait == 0 || attempt > 0 {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// post sends the body once; a 429 reply returns how long to wait before retrying
+func (w *Webhook) post(ctx context.Context, body []byte) (time.Duration, error) {
+	err := webhook.PostJSON(ctx, w.Client, w.URL, nil, body)
+	var serr *webhook.StatusError
+	if errors.As(err, &serr) {
+		return serr.RetryAfter, err
+	}
+	return 0, err
+}
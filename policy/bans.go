@@ -0,0 +1,82 @@
+package policy
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// Ban is a ban kept outside the game server, so it holds on every server sharing the store
+type Ban struct {
+	GUID     string    `json:"guid"`
+	IP       string    `json:"ip,omitempty"`
+	Name     string    `json:"name,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	Operator string    `json:"operator,omitempty"`
+	Server   string    `json:"server,omitempty"`
+	At       time.Time `json:"at"`
+	// Expires is zero for permanent bans
+	Expires time.Time `json:"expires,omitzero"`
+}
+
+// Active reports whether the ban is in force at t
+func (b Ban) Active(t time.Time) bool {
+	return b.Expires.IsZero() || t.Before(b.Expires)
+}
+
+// BanStore persists bans. GUIDs reach it lower-cased
+type BanStore interface {
+	// SaveBan adds a ban or replaces the one with the same GUID
+	SaveBan(b Ban) error
+	RemoveBan(guid string) error
+	// Bans returns every stored ban, expired ones included, newest first
+	Bans() ([]Ban, error)
+	// FindBan returns a ban active at t matching guid or ip, or nil
+	FindBan(guid, ip string, at time.Time) (*Ban, error)
+}
+
+// BanPolicy kicks players who have an active ban in Store
+type BanPolicy struct {
+	Store BanStore
+	// Message is the kick reason; {reason} is replaced with the ban's reason
+	Message string
+	// MatchIP also refuses other GUIDs joining from a banned player's IP. Addresses
+	// truncated by rcon.IPTruncate are never matched, as they stand for a whole /24
+	MatchIP bool
+}
+
+func (b *BanPolicy) Name() string { return "bans" }
+
+// Check implements JoinPolicy
+func (b *BanPolicy) Check(p rcon.Player) (Decision, error) {
+	none := Decision{Policy: b.Name(), Action: ActionNone}
+	if b.Store == nil {
+		return none, nil
+	}
+	ip := ""
+	if b.MatchIP && !rcon.IsTruncatedIP(p.IP) {
+		ip, _ = playerIP(p.IP)
+		if rcon.IsHashedIP(p.IP) {
+			// bans recorded under rcon.WithIPPrivacy hold the same hash
+			ip = p.IP
+		}
+	}
+	guid := normalizeGUID(p.GUID)
+	if guid == "" && ip == "" {
+		return none, nil
+	}
+	ban, err := b.Store.FindBan(guid, ip, time.Now())
+	if err != nil || ban == nil {
+		return none, err
+	}
+	msg := b.Message
+	if msg == "" {
+		msg = "you are banned: {reason}"
+	}
+	reason := ban.Reason
+	if reason == "" {
+		reason = "no reason given"
+	}
+	return Decision{Policy: b.Name(), Action: ActionKick, Reason: strings.ReplaceAll(msg, "{reason}", reason)}, nil
+}
@@ -0,0 +1,85 @@
+package policy
+
+import (
+	"strings"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// CountryPolicy allows or denies players by GeoIP country code
+type CountryPolicy struct {
+	Enricher Enricher
+	Action   Action
+	Message  string
+
+	// Allow, when set, only lets these countries in. Deny blocks these countries.
+	// Codes are ISO 3166-1 alpha-2 (e.g. "DE", "US")
+	Allow []string
+	Deny  []string
+
+	// ExemptGUIDs are never acted on
+	ExemptGUIDs []string
+
+	// BlockUnknown treats players without a resolvable country as denied when Allow is set
+	BlockUnknown bool
+}
+
+func (c *CountryPolicy) Name() string { return "country" }
+
+// Check implements JoinPolicy
+func (c *CountryPolicy) Check(p rcon.Player) (Decision, error) {
+	none := Decision{Policy: c.Name(), Action: ActionNone}
+	if c.Enricher == nil || c.Action == ActionNone {
+		return none, nil
+	}
+	if containsGUID(c.ExemptGUIDs, p.GUID) {
+		return none, nil
+	}
+	ip, ok := playerIP(p.IP)
+	if !ok {
+		return none, nil
+	}
+
+	info, err := c.Enricher.Lookup(ip)
+	if err != nil {
+		return none, err
+	}
+	country := ""
+	if info != nil {
+		country = strings.ToUpper(strings.TrimSpace(info.Country))
+	}
+
+	if country == "" {
+		if len(c.Allow) > 0 && c.BlockUnknown {
+			return c.deny("unknown"), nil
+		}
+		return none, nil
+	}
+	if containsCountry(c.Deny, country) {
+		return c.deny(country), nil
+	}
+	if len(c.Allow) > 0 && !containsCountry(c.Allow, country) {
+		return c.deny(country), nil
+	}
+	return none, nil
+}
+
+// deny builds the decision for a blocked country
+func (c *CountryPolicy) deny(country string) Decision {
+	msg := c.Message
+	if msg == "" {
+		msg = "connections from your region are not allowed"
+	}
+	msg = strings.ReplaceAll(msg, "{country}", country)
+	return Decision{Policy: c.Name(), Action: c.Action, Reason: msg}
+}
+
+// containsCountry reports whether code is in list, ignoring case
+func containsCountry(list []string, code string) bool {
+	for _, c := range list {
+		if strings.EqualFold(strings.TrimSpace(c), code) {
+			return true
+		}
+	}
+	return false
+}
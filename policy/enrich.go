@@ -0,0 +1,177 @@
+package policy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"strings"
+)
+
+type IPInfo struct {
+	IP         string
+	Country    string
+	ASN        int
+	Org        string
+	VPN        bool
+	Proxy      bool
+	Datacenter bool
+}
+
+// Enricher looks up extra information about a player IP
+type Enricher interface {
+	Lookup(ip string) (*IPInfo, error)
+}
+
+// RangeEnricher marks IPs inside known VPN or datacenter ranges
+type RangeEnricher struct {
+	VPN        []netip.Prefix
+	Datacenter []netip.Prefix
+}
+
+// Lookup implements Enricher
+func (r *RangeEnricher) Lookup(ip string) (*IPInfo, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP %q", ip)
+	}
+	info := &IPInfo{IP: ip}
+	info.VPN = containsAddr(r.VPN, addr)
+	info.Datacenter = containsAddr(r.Datacenter, addr)
+	return info, nil
+}
+
+// CountryEnricher resolves countries from a CIDR to country code table
+type CountryEnricher struct {
+	Ranges []CountryRange
+}
+
+type CountryRange struct {
+	Prefix  netip.Prefix
+	Country string
+}
+
+// Lookup implements Enricher, preferring the most specific matching range
+func (c *CountryEnricher) Lookup(ip string) (*IPInfo, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP %q", ip)
+	}
+	addr = addr.Unmap()
+	info := &IPInfo{IP: ip}
+	best := -1
+	for _, r := range c.Ranges {
+		if r.Prefix.Bits() > best && r.Prefix.Contains(addr) {
+			best = r.Prefix.Bits()
+			info.Country = r.Country
+		}
+	}
+	return info, nil
+}
+
+// ParseCountryRanges reads "cidr,country" lines (e.g. "203.0.113.0/24,AU"), skipping blanks and # comments
+func ParseCountryRanges(r io.Reader) ([]CountryRange, error) {
+	var out []CountryRange
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cidr, country, ok := strings.Cut(line, ",")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected cidr,country", n)
+		}
+		p, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid range %q", n, cidr)
+		}
+		out = append(out, CountryRange{Prefix: p.Masked(), Country: strings.ToUpper(strings.TrimSpace(country))})
+	}
+	return out, sc.Err()
+}
+
+// Chain merges the results of several enrichers, later ones filling fields earlier ones left empty
+type Chain []Enricher
+
+// Lookup implements Enricher
+func (c Chain) Lookup(ip string) (*IPInfo, error) {
+	info := &IPInfo{IP: ip}
+	for _, e := range c {
+		r, err := e.Lookup(ip)
+		if err != nil {
+			return nil, err
+		}
+		if r == nil {
+			continue
+		}
+		if info.Country == "" {
+			info.Country = r.Country
+		}
+		if info.ASN == 0 {
+			info.ASN = r.ASN
+		}
+		if info.Org == "" {
+			info.Org = r.Org
+		}
+		info.VPN = info.VPN || r.VPN
+		info.Proxy = info.Proxy || r.Proxy
+		info.Datacenter = info.Datacenter || r.Datacenter
+	}
+	return info, nil
+}
+
+// ParseRanges reads one CIDR (or bare IP) per line, skipping blanks and # comments
+func ParseRanges(r io.Reader) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.Contains(line, "/") {
+			addr, err := netip.ParseAddr(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid address %q", n, line)
+			}
+			out = append(out, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid range %q", n, line)
+		}
+		out = append(out, p.Masked())
+	}
+	return out, sc.Err()
+}
+
+// containsAddr reports whether any prefix contains addr
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// playerIP returns the player's address if it is a real IP (bots and loopback clients are skipped)
+func playerIP(ip string) (string, bool) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil || addr.IsLoopback() {
+		return "", false
+	}
+	return addr.String(), true
+}
+
+// normalizeGUID lowercases and trims a GUID for comparisons
+func normalizeGUID(g string) string {
+	return strings.ToLower(strings.TrimSpace(g))
+}
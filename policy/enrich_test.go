@@ -0,0 +1,61 @@
+package policy
+
+import (
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRanges(t *testing.T) {
+	got, err := ParseRanges(strings.NewReader("# datacenters\n203.0.113.7/24\n\n198.51.100.9 # one host\n2001:db8::/32\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []netip.Prefix{
+		netip.MustParsePrefix("203.0.113.0/24"),
+		netip.MustParsePrefix("198.51.100.9/32"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseRanges = %v, want %v", got, want)
+	}
+
+	for _, in := range []string{"203.0.113.0/33", "not an ip", "1.2.3.4/24/8"} {
+		if _, err := ParseRanges(strings.NewReader(in)); err == nil {
+			t.Errorf("ParseRanges(%q) accepted", in)
+		}
+	}
+}
+
+func TestParseCountryRanges(t *testing.T) {
+	got, err := ParseCountryRanges(strings.NewReader("# cidr,country\n203.0.113.7/24, au\n203.0.113.128/25,nz\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []CountryRange{
+		{Prefix: netip.MustParsePrefix("203.0.113.0/24"), Country: "AU"},
+		{Prefix: netip.MustParsePrefix("203.0.113.128/25"), Country: "NZ"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseCountryRanges = %v, want %v", got, want)
+	}
+
+	// the most specific range wins
+	db := &CountryEnricher{Ranges: got}
+	for ip, country := range map[string]string{"203.0.113.5": "AU", "203.0.113.200": "NZ", "198.51.100.1": ""} {
+		info, err := db.Lookup(ip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Country != country {
+			t.Errorf("Lookup(%s) country %q, want %q", ip, info.Country, country)
+		}
+	}
+
+	for _, in := range []string{"203.0.113.0/24", "bad,AU"} {
+		if _, err := ParseCountryRanges(strings.NewReader(in)); err == nil {
+			t.Errorf("ParseCountryRanges(%q) accepted", in)
+		}
+	}
+}
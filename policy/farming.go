@@ -0,0 +1,137 @@
+package policy
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+type FarmThreshold struct {
+	// MinIdle is the LastMsg value from which a player counts as inactive
+	MinIdle time.Duration
+	// MinScoreGain is the score increase between two polls that counts as suspicious
+	MinScoreGain int
+	// Polls is the number of consecutive suspicious polls before a review event
+	Polls int
+}
+
+var defaultFarmThreshold = FarmThreshold{MinIdle: 10 * time.Second, MinScoreGain: 1, Polls: 3}
+
+type ReviewEvent struct {
+	Player    rcon.Player
+	Gametype  string
+	ScoreGain int
+	Idle      time.Duration
+	Polls     int
+	At        time.Time
+}
+
+// FarmDetector flags players whose score keeps rising while they look inactive
+type FarmDetector struct {
+	// Default applies to gametypes missing from Thresholds (keyed by lowercase gametype, e.g. "tdm")
+	Default    FarmThreshold
+	Thresholds map[string]FarmThreshold
+
+	// OnReview is called for every review event raised by Observe
+	OnReview func(ReviewEvent)
+
+	mu      sync.Mutex
+	players map[string]*farmState
+}
+
+type farmState struct {
+	score    int
+	streak   int
+	gain     int
+	reported bool
+	seen     bool
+}
+
+// Observe compares a status snapshot with the previous one and returns any new review events
+func (f *FarmDetector) Observe(st *rcon.ServerStatus, gametype string) []ReviewEvent {
+	if st == nil {
+		return nil
+	}
+	th := f.threshold(gametype)
+	at := st.RetrievedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	f.mu.Lock()
+	if f.players == nil {
+		f.players = map[string]*farmState{}
+	}
+	for _, s := range f.players {
+		s.seen = false
+	}
+
+	var events []ReviewEvent
+	for _, p := range st.Players {
+		guid := normalizeGUID(p.GUID)
+		if guid == "" {
+			continue
+		}
+		s, ok := f.players[guid]
+		if !ok {
+			f.players[guid] = &farmState{score: p.Score, seen: true}
+			continue
+		}
+		s.seen = true
+
+		gain := p.Score - s.score
+		s.score = p.Score
+		idle := time.Duration(p.LastMsg) * time.Millisecond
+		if idle < th.MinIdle || gain < th.MinScoreGain {
+			s.streak, s.gain, s.reported = 0, 0, false
+			continue
+		}
+
+		s.streak++
+		s.gain += gain
+		if s.streak >= th.Polls && !s.reported {
+			s.reported = true
+			events = append(events, ReviewEvent{
+				Player:    p,
+				Gametype:  gametype,
+				ScoreGain: s.gain,
+				Idle:      idle,
+				Polls:     s.streak,
+				At:        at,
+			})
+		}
+	}
+	for guid, s := range f.players {
+		if !s.seen {
+			delete(f.players, guid)
+		}
+	}
+	f.mu.Unlock()
+
+	if f.OnReview != nil {
+		for _, ev := range events {
+			f.OnReview(ev)
+		}
+	}
+	return events
+}
+
+// threshold returns the configured threshold for a gametype, filling unset fields from defaults
+func (f *FarmDetector) threshold(gametype string) FarmThreshold {
+	th, ok := f.Thresholds[strings.ToLower(gametype)]
+	if !ok {
+		th = f.Default
+	}
+	if th.MinIdle <= 0 {
+		th.MinIdle = defaultFarmThreshold.MinIdle
+	}
+	if th.MinScoreGain <= 0 {
+		th.MinScoreGain = defaultFarmThreshold.MinScoreGain
+	}
+	if th.Polls <= 0 {
+		th.Polls = defaultFarmThreshold.Polls
+	}
+	return th
+}
@@ -0,0 +1,194 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// Action is what a policy wants done with a player
+type Action int
+
+const (
+	ActionNone Action = iota
+	ActionWarn
+	ActionFlag
+	ActionKick
+)
+
+func (a Action) String() string {
+	switch a {
+	case ActionNone:
+		return "none"
+	case ActionWarn:
+		return "warn"
+	case ActionFlag:
+		return "flag"
+	case ActionKick:
+		return "kick"
+	}
+	return "action(" + strconv.Itoa(int(a)) + ")"
+}
+
+type Decision struct {
+	Policy string
+	Action Action
+	Reason string
+}
+
+// JoinPolicy decides what to do with a player that just joined
+type JoinPolicy interface {
+	Name() string
+	Check(p rcon.Player) (Decision, error)
+}
+
+// Enforcer runs join policies and carries out their decisions
+type Enforcer struct {
+	Client   *rcon.RCONClient
+	Policies []JoinPolicy
+
+	// OnFlag is called for ActionFlag decisions (and for all decisions when DryRun is set)
+	OnFlag func(p rcon.Player, d Decision)
+	DryRun bool
+	// VerifySlot re-checks on a fresh status that the player still holds their slot
+	// before warning or kicking (rcon.WithTargetGUID). A player who left meanwhile is
+	// skipped, not an error
+	VerifySlot bool
+
+	// Server names this enforcer for Suppress, e.g. a maintenance.Schedule
+	Server   string
+	Suppress interface {
+		Suppressed(server string, t time.Time) bool
+	}
+
+	mu      sync.Mutex
+	actions map[string]map[Action]uint64
+}
+
+// Join checks a player against every join policy and applies the strictest decision.
+// A policy that fails doesn't stop the others; its error is joined into the result
+func (e *Enforcer) Join(p rcon.Player) (Decision, error) {
+	return e.run(p, e.Policies)
+}
+
+// Sweep runs policies against every player of a status snapshot, e.g. a LagPolicy on each poll
+func (e *Enforcer) Sweep(st *rcon.ServerStatus, policies ...JoinPolicy) ([]Decision, error) {
+	if st == nil {
+		return nil, nil
+	}
+	var out []Decision
+	var errs []error
+	for _, p := range st.Players {
+		d, err := e.run(p, policies)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if d.Action != ActionNone {
+			out = append(out, d)
+		}
+	}
+	return out, errors.Join(errs...)
+}
+
+// run checks a player against policies and applies the strictest decision
+func (e *Enforcer) run(p rcon.Player, policies []JoinPolicy) (Decision, error) {
+	final := Decision{Action: ActionNone}
+	if e.Suppress != nil && e.Suppress.Suppressed(e.Server, time.Now()) {
+		return final, nil
+	}
+	var errs []error
+	for _, pol := range policies {
+		d, err := pol.Check(p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("policy %s: %w", pol.Name(), err))
+			continue
+		}
+		if d.Policy == "" {
+			d.Policy = pol.Name()
+		}
+		if d.Action > final.Action {
+			final = d
+		}
+	}
+	if final.Action == ActionNone {
+		return final, errors.Join(errs...)
+	}
+
+	e.count(final)
+	if err := e.apply(p, final); err != nil {
+		errs = append(errs, err)
+	}
+	return final, errors.Join(errs...)
+}
+
+// apply carries out a single decision against the client
+func (e *Enforcer) apply(p rcon.Player, d Decision) error {
+	if e.DryRun || d.Action == ActionFlag {
+		if e.OnFlag != nil {
+			e.OnFlag(p, d)
+		}
+		return nil
+	}
+	if e.Client == nil {
+		return fmt.Errorf("enforcer has no RCON client")
+	}
+
+	var opts []rcon.CommandOption
+	if e.VerifySlot && p.GUID != "" {
+		opts = append(opts, rcon.WithTargetGUID(p.GUID))
+	}
+	var err error
+	switch d.Action {
+	case ActionWarn:
+		err = e.Client.Tell(p.ClientNum, d.Reason, opts...)
+	case ActionKick:
+		err = e.Client.Kick(strconv.Itoa(p.ClientNum), d.Reason, opts...)
+	}
+	if errors.Is(err, rcon.ErrSlotReused) {
+		return nil
+	}
+	return err
+}
+
+// count records an action taken by a policy
+func (e *Enforcer) count(d Decision) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.actions == nil {
+		e.actions = map[string]map[Action]uint64{}
+	}
+	if e.actions[d.Policy] == nil {
+		e.actions[d.Policy] = map[Action]uint64{}
+	}
+	e.actions[d.Policy][d.Action]++
+}
+
+// Actions returns a copy of the per-policy action counters
+func (e *Enforcer) Actions() map[string]map[Action]uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make(map[string]map[Action]uint64, len(e.actions))
+	for pol, m := range e.actions {
+		c := make(map[Action]uint64, len(m))
+		for a, n := range m {
+			c[a] = n
+		}
+		out[pol] = c
+	}
+	return out
+}
+
+// containsGUID reports whether guid is in the list, ignoring case
+func containsGUID(guids []string, guid string) bool {
+	guid = normalizeGUID(guid)
+	for _, g := range guids {
+		if normalizeGUID(g) == guid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// fixedPolicy returns the same decision or error for every player
+type fixedPolicy struct {
+	name   string
+	action Action
+	err    error
+}
+
+func (f fixedPolicy) Name() string { return f.name }
+
+func (f fixedPolicy) Check(p rcon.Player) (Decision, error) {
+	return Decision{Action: f.action, Reason: f.name}, f.err
+}
+
+func TestEnforcerRunsEveryPolicy(t *testing.T) {
+	errLookup := errors.New("lookup failed")
+	var flagged []Decision
+	e := &Enforcer{
+		DryRun: true,
+		Policies: []JoinPolicy{
+			fixedPolicy{name: "warn", action: ActionWarn},
+			fixedPolicy{name: "vpn", err: errLookup},
+			fixedPolicy{name: "ban", action: ActionKick},
+			fixedPolicy{name: "country", err: errLookup},
+		},
+		OnFlag: func(p rcon.Player, d Decision) { flagged = append(flagged, d) },
+	}
+	d, err := e.Join(rcon.Player{ClientNum: 3, Name: "player"})
+	if d.Action != ActionKick || d.Policy != "ban" {
+		t.Errorf("decision %+v, want the kick of policy ban", d)
+	}
+	if len(flagged) != 1 || flagged[0].Action != ActionKick {
+		t.Errorf("applied %+v, want only the kick", flagged)
+	}
+	if !errors.Is(err, errLookup) {
+		t.Fatalf("err %v, want the lookup failures", err)
+	}
+	if want := "policy vpn: lookup failed\npolicy country: lookup failed"; err.Error() != want {
+		t.Errorf("err %q, want %q", err, want)
+	}
+}
@@ -0,0 +1,217 @@
+package policy
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+const defaultQualityWindow = 30
+
+// Quality summarizes a player's connection over the polls a QualityTracker keeps
+type Quality struct {
+	GUID    string  `json:"guid"`
+	Samples int     `json:"samples"`
+	Avg     float64 `json:"avg"`
+	Median  float64 `json:"median"`
+	Jitter  float64 `json:"jitter"`
+	Max     int     `json:"max"`
+	// LoadIncidents counts the runs of polls that showed the player as LOAD
+	LoadIncidents int       `json:"load_incidents"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+// QualityTracker keeps a rolling ping history per player across status polls
+type QualityTracker struct {
+	// Window is the number of polls kept per player (default 30); pings and LOAD
+	// incidents older than that no longer count
+	Window int
+
+	mu      sync.Mutex
+	players map[string]*pingHistory
+}
+
+type pingHistory struct {
+	polls    []pingPoll
+	lastSeen time.Time
+}
+
+// pingPoll is one poll of a player: their ping, or that they were loading
+type pingPoll struct {
+	ping    int
+	loading bool
+}
+
+// Observe records the pings of every player in a status snapshot
+func (q *QualityTracker) Observe(st *rcon.ServerStatus) {
+	if st == nil {
+		return
+	}
+	window := q.Window
+	if window <= 0 {
+		window = defaultQualityWindow
+	}
+	seen := st.RetrievedAt
+	if seen.IsZero() {
+		seen = time.Now()
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.players == nil {
+		q.players = map[string]*pingHistory{}
+	}
+	for _, p := range st.Players {
+		guid := normalizeGUID(p.GUID)
+		if guid == "" {
+			continue
+		}
+		h := q.players[guid]
+		if h == nil {
+			h = &pingHistory{}
+			q.players[guid] = h
+		}
+		h.lastSeen = seen
+		if p.Zombie && !p.Loading {
+			continue
+		}
+		h.polls = append(h.polls, pingPoll{ping: p.Ping, loading: p.Loading})
+		if len(h.polls) > window {
+			h.polls = append(h.polls[:0], h.polls[len(h.polls)-window:]...)
+		}
+	}
+}
+
+// PlayerQuality returns the connection quality for a GUID
+func (q *QualityTracker) PlayerQuality(guid string) (Quality, bool) {
+	guid = normalizeGUID(guid)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	h, ok := q.players[guid]
+	if !ok {
+		return Quality{}, false
+	}
+	return h.quality(guid), true
+}
+
+// Players returns the connection quality of every tracked player, worst median first
+func (q *QualityTracker) Players() []Quality {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make([]Quality, 0, len(q.players))
+	for guid, h := range q.players {
+		out = append(out, h.quality(guid))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Median != out[j].Median {
+			return out[i].Median > out[j].Median
+		}
+		return out[i].GUID < out[j].GUID
+	})
+	return out
+}
+
+// Forget drops the history of players not seen since the given time
+func (q *QualityTracker) Forget(before time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for guid, h := range q.players {
+		if h.lastSeen.Before(before) {
+			delete(q.players, guid)
+		}
+	}
+}
+
+// quality computes the summary statistics of a ping history
+func (h *pingHistory) quality(guid string) Quality {
+	out := Quality{GUID: guid, LastSeen: h.lastSeen}
+	var pings []int
+	for i, p := range h.polls {
+		if !p.loading {
+			pings = append(pings, p.ping)
+		} else if i == 0 || !h.polls[i-1].loading {
+			out.LoadIncidents++
+		}
+	}
+	out.Samples = len(pings)
+	if len(pings) == 0 {
+		return out
+	}
+
+	sum := 0
+	for i, p := range pings {
+		sum += p
+		if p > out.Max {
+			out.Max = p
+		}
+		if i > 0 {
+			out.Jitter += math.Abs(float64(p - pings[i-1]))
+		}
+	}
+	out.Avg = float64(sum) / float64(len(pings))
+	if len(pings) > 1 {
+		out.Jitter /= float64(len(pings) - 1)
+	}
+
+	sorted := append([]int(nil), pings...)
+	sort.Ints(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		out.Median = float64(sorted[mid-1]+sorted[mid]) / 2
+	} else {
+		out.Median = float64(sorted[mid])
+	}
+	return out
+}
+
+// LagPolicy acts on players whose tracked connection quality is too poor
+type LagPolicy struct {
+	Tracker *QualityTracker
+	Action  Action
+	Message string
+
+	// MinSamples is the number of pings needed before judging a player (default 5)
+	MinSamples int
+	// Zero disables a threshold
+	MaxMedian        float64
+	MaxJitter        float64
+	MaxLoadIncidents int
+
+	ExemptGUIDs []string
+}
+
+func (l *LagPolicy) Name() string { return "lag" }
+
+// Check implements JoinPolicy; it is meant to be run on every poll via Enforcer.Sweep
+func (l *LagPolicy) Check(p rcon.Player) (Decision, error) {
+	none := Decision{Policy: l.Name(), Action: ActionNone}
+	if l.Tracker == nil || l.Action == ActionNone || containsGUID(l.ExemptGUIDs, p.GUID) {
+		return none, nil
+	}
+	q, ok := l.Tracker.PlayerQuality(p.GUID)
+	if !ok {
+		return none, nil
+	}
+	minSamples := l.MinSamples
+	if minSamples <= 0 {
+		minSamples = 5
+	}
+
+	bad := l.MaxLoadIncidents > 0 && q.LoadIncidents >= l.MaxLoadIncidents
+	if q.Samples >= minSamples {
+		bad = bad || (l.MaxMedian > 0 && q.Median > l.MaxMedian)
+		bad = bad || (l.MaxJitter > 0 && q.Jitter > l.MaxJitter)
+	}
+	if !bad {
+		return none, nil
+	}
+
+	msg := l.Message
+	if msg == "" {
+		msg = "connection too unstable"
+	}
+	return Decision{Policy: l.Name(), Action: l.Action, Reason: msg}, nil
+}
@@ -0,0 +1,34 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+func TestQualityWindow(t *testing.T) {
+	q := &QualityTracker{Window: 4}
+	poll := func(p rcon.Player) {
+		p.GUID = "abc"
+		q.Observe(&rcon.ServerStatus{Players: []rcon.Player{p}})
+	}
+	// one incident spanning two polls
+	poll(rcon.Player{Loading: true})
+	poll(rcon.Player{Loading: true})
+	poll(rcon.Player{Ping: 50})
+	got, _ := q.PlayerQuality("abc")
+	if got.LoadIncidents != 1 || got.Samples != 1 {
+		t.Fatalf("quality %+v, want 1 incident and 1 sample", got)
+	}
+
+	for _, ping := range []int{60, 70, 80, 90} {
+		poll(rcon.Player{Ping: ping})
+	}
+	got, _ = q.PlayerQuality("abc")
+	if got.LoadIncidents != 0 || got.Samples != 4 || got.Median != 75 || got.Jitter != 10 {
+		t.Errorf("quality %+v, want the incident out of the window and 4 samples", got)
+	}
+	if players := q.Players(); len(players) != 1 || players[0].Max != 90 {
+		t.Errorf("Players = %+v", players)
+	}
+}
@@ -0,0 +1,57 @@
+package policy
+
+import (
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// VPNPolicy acts on players connecting from VPN, proxy or datacenter addresses
+type VPNPolicy struct {
+	Enricher Enricher
+	Action   Action
+	Message  string
+
+	// AllowGUIDs are trusted players that are never acted on
+	AllowGUIDs []string
+
+	// IgnoreDatacenter only acts on VPN/proxy hits
+	IgnoreDatacenter bool
+}
+
+func (v *VPNPolicy) Name() string { return "vpn" }
+
+// Check implements JoinPolicy
+func (v *VPNPolicy) Check(p rcon.Player) (Decision, error) {
+	none := Decision{Policy: v.Name(), Action: ActionNone}
+	if v.Enricher == nil || v.Action == ActionNone {
+		return none, nil
+	}
+	if containsGUID(v.AllowGUIDs, p.GUID) {
+		return none, nil
+	}
+	ip, ok := playerIP(p.IP)
+	if !ok {
+		return none, nil
+	}
+
+	info, err := v.Enricher.Lookup(ip)
+	if err != nil {
+		return none, err
+	}
+	if info == nil {
+		return none, nil
+	}
+
+	hit := info.VPN || info.Proxy
+	if !v.IgnoreDatacenter && info.Datacenter {
+		hit = true
+	}
+	if !hit {
+		return none, nil
+	}
+
+	msg := v.Message
+	if msg == "" {
+		msg = "VPN/proxy connections are not allowed"
+	}
+	return Decision{Policy: v.Name(), Action: v.Action, Reason: msg}, nil
+}
@@ -0,0 +1,218 @@
+// Package primetime describes when servers are busy in their players' local time, keyed
+// by region label, for automation that should behave differently at peak hours
+package primetime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Window is a daily span of local time. To before From wraps past midnight, so
+// 20:00-02:00 on Fridays runs into Saturday morning
+type Window struct {
+	// Days limits the window to days it starts on (empty = every day)
+	Days []time.Weekday
+	// From and To are offsets from local midnight; To may be 24h
+	From, To time.Duration
+}
+
+// ParseWindow parses "18:00-23:00", "mon-fri 18:00-23:30" or "sat,sun 12:00-24:00"
+func ParseWindow(s string) (Window, error) {
+	var w Window
+	f := strings.Fields(s)
+	if len(f) == 0 || len(f) > 2 {
+		return w, fmt.Errorf("prime time window %q: expected [days] HH:MM-HH:MM", s)
+	}
+	if len(f) == 2 {
+		days, err := parseDays(f[0])
+		if err != nil {
+			return w, fmt.Errorf("prime time window %q: %w", s, err)
+		}
+		w.Days = days
+	}
+	from, to, ok := strings.Cut(f[len(f)-1], "-")
+	if !ok {
+		return w, fmt.Errorf("prime time window %q: expected HH:MM-HH:MM", s)
+	}
+	var err error
+	if w.From, err = parseClock(from); err != nil {
+		return w, fmt.Errorf("prime time window %q: %w", s, err)
+	}
+	if w.To, err = parseClock(to); err != nil {
+		return w, fmt.Errorf("prime time window %q: %w", s, err)
+	}
+	if w.From == w.To {
+		return w, fmt.Errorf("prime time window %q is empty", s)
+	}
+	return w, nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseDays parses "mon-fri", "sat,sun" or "daily"
+func parseDays(s string) ([]time.Weekday, error) {
+	if strings.EqualFold(s, "daily") {
+		return nil, nil
+	}
+	var out []time.Weekday
+	for _, part := range strings.Split(strings.ToLower(s), ",") {
+		a, b, isRange := strings.Cut(part, "-")
+		from, ok := weekdays[a]
+		if !ok {
+			return nil, fmt.Errorf("unknown day %q", a)
+		}
+		to := from
+		if isRange {
+			if to, ok = weekdays[b]; !ok {
+				return nil, fmt.Errorf("unknown day %q", b)
+			}
+		}
+		for d := from; ; d = (d + 1) % 7 {
+			out = append(out, d)
+			if d == to {
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// parseClock parses HH:MM, allowing 24:00
+func parseClock(s string) (time.Duration, error) {
+	h, m, ok := strings.Cut(s, ":")
+	hh, err1 := strconv.Atoi(h)
+	mm, err2 := strconv.Atoi(m)
+	if !ok || err1 != nil || err2 != nil || hh < 0 || mm < 0 || mm > 59 || hh > 24 || hh == 24 && mm != 0 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return time.Duration(hh)*time.Hour + time.Duration(mm)*time.Minute, nil
+}
+
+// String formats the window the way ParseWindow reads it
+func (w Window) String() string {
+	clock := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+	}
+	span := clock(w.From) + "-" + clock(w.To)
+	if len(w.Days) == 0 {
+		return span
+	}
+	days := make([]string, len(w.Days))
+	for i, d := range w.Days {
+		days[i] = strings.ToLower(d.String()[:3])
+	}
+	return strings.Join(days, ",") + " " + span
+}
+
+// contains reports whether the local time t falls inside the window
+func (w Window) contains(t time.Time) bool {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+	if w.From < w.To {
+		return w.startsOn(t.Weekday()) && offset >= w.From && offset < w.To
+	}
+	// wrapping window: the evening part starts today, the morning part started yesterday
+	return w.startsOn(t.Weekday()) && offset >= w.From ||
+		w.startsOn((t.Weekday()+6)%7) && offset < w.To
+}
+
+func (w Window) startsOn(d time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, day := range w.Days {
+		if day == d {
+			return true
+		}
+	}
+	return false
+}
+
+// Region is the local time and prime-time windows of servers carrying its label
+type Region struct {
+	Name string
+	// Location is the players' time zone (default UTC)
+	Location *time.Location
+	Windows  []Window
+}
+
+// Calendar maps servers to regions. The zero value is ready to use; servers without a
+// region are never in prime time
+type Calendar struct {
+	mu      sync.RWMutex
+	regions map[string]*Region
+	servers map[string]string
+}
+
+// AddRegion registers or replaces a region by name
+func (c *Calendar) AddRegion(r Region) error {
+	if r.Name == "" {
+		return fmt.Errorf("region needs a name")
+	}
+	if r.Location == nil {
+		r.Location = time.UTC
+	}
+	r.Windows = append([]Window(nil), r.Windows...)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.regions == nil {
+		c.regions = map[string]*Region{}
+	}
+	c.regions[strings.ToLower(r.Name)] = &r
+	return nil
+}
+
+// SetRegion assigns a server to a region label
+func (c *Calendar) SetRegion(server, region string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.servers == nil {
+		c.servers = map[string]string{}
+	}
+	c.servers[server] = strings.ToLower(region)
+}
+
+// Region returns the region of a server
+func (c *Calendar) Region(server string) (Region, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	r, ok := c.regions[c.servers[server]]
+	if !ok {
+		return Region{}, false
+	}
+	return *r, true
+}
+
+// Local returns t in the time zone of the server's region
+func (c *Calendar) Local(server string, t time.Time) time.Time {
+	if r, ok := c.Region(server); ok {
+		return t.In(r.Location)
+	}
+	return t.UTC()
+}
+
+// PrimeTime reports whether t falls in a prime-time window of the server's region
+func (c *Calendar) PrimeTime(server string, t time.Time) bool {
+	r, ok := c.Region(server)
+	if !ok {
+		return false
+	}
+	local := t.In(r.Location)
+	for _, w := range r.Windows {
+		if w.contains(local) {
+			return true
+		}
+	}
+	return false
+}
+
+// OffPeak is the opposite of PrimeTime, shaped for alert.SuppressFunc
+func (c *Calendar) OffPeak(server string, t time.Time) bool {
+	return !c.PrimeTime(server, t)
+}
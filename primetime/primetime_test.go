@@ -0,0 +1,75 @@
+package primetime
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Window
+		wantErr bool
+	}{
+		{in: "18:00-23:00", want: Window{From: 18 * time.Hour, To: 23 * time.Hour}},
+		{in: "mon-fri 18:00-23:30", want: Window{
+			Days: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+			From: 18 * time.Hour, To: 23*time.Hour + 30*time.Minute}},
+		{in: "sat,sun 12:00-24:00", want: Window{Days: []time.Weekday{time.Saturday, time.Sunday}, From: 12 * time.Hour, To: 24 * time.Hour}},
+		{in: "fri-mon 20:00-02:00", want: Window{
+			Days: []time.Weekday{time.Friday, time.Saturday, time.Sunday, time.Monday},
+			From: 20 * time.Hour, To: 2 * time.Hour}},
+		{in: "daily 08:00-09:00", want: Window{From: 8 * time.Hour, To: 9 * time.Hour}},
+		{in: "", wantErr: true},
+		{in: "18:00", wantErr: true},
+		{in: "18:00-18:00", wantErr: true},
+		{in: "24:30-01:00", wantErr: true},
+		{in: "18:60-19:00", wantErr: true},
+		{in: "funday 18:00-19:00", wantErr: true},
+		{in: "mon 18:00-19:00 extra", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseWindow(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWindow err = %v, want error %t", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseWindow = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowString(t *testing.T) {
+	for _, s := range []string{"18:00-23:00", "mon,tue 18:00-23:30", "sat,sun 12:00-24:00"} {
+		w, err := ParseWindow(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := w.String(); got != s {
+			t.Errorf("String() = %q, want %q", got, s)
+		}
+	}
+}
+
+func TestWindowContains(t *testing.T) {
+	w, _ := ParseWindow("fri 20:00-02:00")
+	at := func(day, hour int) time.Time { return time.Date(2026, 10, day, hour, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		t    time.Time
+		want bool
+	}{
+		{at(16, 21), true},  // Friday evening
+		{at(17, 1), true},   // Saturday morning, still Friday's window
+		{at(17, 2), false},  // window ended
+		{at(17, 21), false}, // Saturday evening
+		{at(15, 21), false}, // Thursday evening
+	}
+	for _, tt := range tests {
+		if got := w.contains(tt.t); got != tt.want {
+			t.Errorf("contains(%v) = %t, want %t", tt.t, got, tt.want)
+		}
+	}
+}
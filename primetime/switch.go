@@ -0,0 +1,127 @@
+package primetime
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// Period is the part of a server's day a Switcher is in
+type Period string
+
+const (
+	PrimeTime Period = "prime"
+	OffPeak   Period = "offpeak"
+	// Seeding is prime time with too few players online
+	Seeding Period = "seeding"
+)
+
+// Settings are applied when a period starts; nil fields are left alone
+type Settings struct {
+	Dvars    map[string]string
+	Rotation []rcon.RotationEntry
+}
+
+// Switcher applies different settings to a server by time of day and population, e.g.
+// a small-map seeding rotation while it fills at prime time and lighter rules at night
+type Switcher struct {
+	Client   *rcon.RCONClient
+	Server   string
+	Calendar *Calendar
+	Prime    Settings
+	OffPeak  Settings
+	// Seeding replaces Prime while fewer than SeedBelow players are online (0 = never);
+	// it ends once SeedUntil are (default SeedBelow), so a server at the threshold doesn't flap
+	Seeding   Settings
+	SeedBelow int
+	SeedUntil int
+	OnChange  func(old, cur Period)
+	OnError   func(error)
+
+	mu  sync.Mutex
+	cur Period
+}
+
+// Period returns the period a server is in at t with players online, without applying it
+func (s *Switcher) Period(t time.Time, players int) Period {
+	s.mu.Lock()
+	cur := s.cur
+	s.mu.Unlock()
+	if !s.Calendar.PrimeTime(s.Server, t) {
+		return OffPeak
+	}
+	until := s.SeedUntil
+	if until < s.SeedBelow {
+		until = s.SeedBelow
+	}
+	switch {
+	case s.SeedBelow > 0 && players < s.SeedBelow:
+		return Seeding
+	case cur == Seeding && players < until:
+		return Seeding
+	}
+	return PrimeTime
+}
+
+// Observe applies the settings of the period at t when it differs from the last one
+func (s *Switcher) Observe(t time.Time, players int) error {
+	p := s.Period(t, players)
+	s.mu.Lock()
+	old := s.cur
+	if p == old {
+		s.mu.Unlock()
+		return nil
+	}
+	s.cur = p
+	s.mu.Unlock()
+
+	if err := s.apply(s.settings(p)); err != nil {
+		// try again on the next observation
+		s.mu.Lock()
+		s.cur = old
+		s.mu.Unlock()
+		return fmt.Errorf("%s: switch to %s: %w", s.Server, p, err)
+	}
+	if s.OnChange != nil {
+		s.OnChange(old, p)
+	}
+	return nil
+}
+
+func (s *Switcher) settings(p Period) Settings {
+	switch p {
+	case Seeding:
+		return s.Seeding
+	case PrimeTime:
+		return s.Prime
+	}
+	return s.OffPeak
+}
+
+func (s *Switcher) apply(set Settings) error {
+	for name, value := range set.Dvars {
+		if err := s.Client.SetDvar(name, value); err != nil {
+			return fmt.Errorf("set %s: %w", name, err)
+		}
+	}
+	if len(set.Rotation) > 0 {
+		return s.Client.SetMapRotation(set.Rotation)
+	}
+	return nil
+}
+
+// Attach observes every status poll of a Monitor, keeping the OnStatus callback that is
+// already set. Call it before Monitor.Run
+func (s *Switcher) Attach(m *rcon.Monitor) {
+	status := m.OnStatus
+	m.OnStatus = func(st *rcon.ServerStatus) {
+		if status != nil {
+			status(st)
+		}
+		if err := s.Observe(st.RetrievedAt, len(st.Players)); err != nil && s.OnError != nil {
+			s.OnError(err)
+		}
+	}
+}
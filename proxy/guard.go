@@ -0,0 +1,175 @@
+package proxy
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// SecurityEvent reports a source being blocked or released by a BruteForceGuard
+type SecurityEvent struct {
+	Source   string
+	Failures int
+	Blocked  bool
+	Until    time.Time
+	At       time.Time
+}
+
+// BruteForceGuard counts bad-password attempts per source IP and drops sources
+// that exceed MaxFailures within Window for BlockFor
+type BruteForceGuard struct {
+	MaxFailures int
+	Window      time.Duration
+	BlockFor    time.Duration
+	// OnEvent is called when a source is blocked or its block expires
+	OnEvent func(ev SecurityEvent)
+
+	mu      sync.Mutex
+	sources map[string]*attempts
+	swept   time.Time
+}
+
+type attempts struct {
+	failures []time.Time
+	until    time.Time
+}
+
+// NewBruteForceGuard returns a guard allowing 5 bad attempts per minute, blocking for 15 minutes
+func NewBruteForceGuard() *BruteForceGuard {
+	return &BruteForceGuard{MaxFailures: 5, Window: time.Minute, BlockFor: 15 * time.Minute}
+}
+
+// Blocked reports whether a source is currently dropped
+func (g *BruteForceGuard) Blocked(source string, now time.Time) bool {
+	g.mu.Lock()
+	a := g.sources[source]
+	if a == nil || a.until.IsZero() {
+		g.mu.Unlock()
+		return false
+	}
+	if now.Before(a.until) {
+		g.mu.Unlock()
+		return true
+	}
+	delete(g.sources, source)
+	g.mu.Unlock()
+
+	g.emit(SecurityEvent{Source: source, At: now})
+	return false
+}
+
+// Fail records a bad-password attempt and blocks the source once over the limit
+func (g *BruteForceGuard) Fail(source string, now time.Time) {
+	g.mu.Lock()
+	if g.sources == nil {
+		g.sources = map[string]*attempts{}
+	}
+	released := g.sweep(now)
+	defer func() {
+		for _, ev := range released {
+			g.emit(ev)
+		}
+	}()
+	a := g.sources[source]
+	if a == nil {
+		a = &attempts{}
+		g.sources[source] = a
+	}
+	cutoff := now.Add(-g.window())
+	kept := a.failures[:0]
+	for _, t := range a.failures {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	a.failures = append(kept, now)
+
+	limit := g.MaxFailures
+	if limit <= 0 {
+		limit = 5
+	}
+	if len(a.failures) < limit || !a.until.IsZero() {
+		g.mu.Unlock()
+		return
+	}
+	block := g.BlockFor
+	if block <= 0 {
+		block = 15 * time.Minute
+	}
+	a.until = now.Add(block)
+	ev := SecurityEvent{Source: source, Failures: len(a.failures), Blocked: true, Until: a.until, At: now}
+	g.mu.Unlock()
+
+	g.emit(ev)
+}
+
+// Succeed clears the failure count of a source after a good password
+func (g *BruteForceGuard) Succeed(source string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.sources, source)
+}
+
+// BlockedSources returns the sources currently dropped and when their block ends
+func (g *BruteForceGuard) BlockedSources(now time.Time) map[string]time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	out := map[string]time.Time{}
+	for src, a := range g.sources {
+		if !a.until.IsZero() && now.Before(a.until) {
+			out[src] = a.until
+		}
+	}
+	return out
+}
+
+// sweep forgets, at most once per window, sources whose failures have all left the
+// window and whose block has ended, so sources that never come back don't pile up.
+// It returns the release events of the blocks it dropped; g.mu must be held
+func (g *BruteForceGuard) sweep(now time.Time) []SecurityEvent {
+	if now.Sub(g.swept) < g.window() {
+		return nil
+	}
+	g.swept = now
+	cutoff := now.Add(-g.window())
+	var released []SecurityEvent
+	for src, a := range g.sources {
+		switch n := len(a.failures); {
+		case a.until.IsZero():
+			if n > 0 && a.failures[n-1].After(cutoff) {
+				continue
+			}
+		case now.Before(a.until):
+			continue
+		default:
+			released = append(released, SecurityEvent{Source: src, At: now})
+		}
+		delete(g.sources, src)
+	}
+	return released
+}
+
+func (g *BruteForceGuard) window() time.Duration {
+	if g.Window <= 0 {
+		return time.Minute
+	}
+	return g.Window
+}
+
+func (g *BruteForceGuard) emit(ev SecurityEvent) {
+	if g.OnEvent != nil {
+		g.OnEvent(ev)
+	}
+}
+
+// hostOf returns the IP part of a UDP source address
+func hostOf(addr net.Addr) string {
+	if u, ok := addr.(*net.UDPAddr); ok {
+		return u.IP.String()
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
@@ -0,0 +1,38 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGuardForgetsStaleSources(t *testing.T) {
+	var events []SecurityEvent
+	g := &BruteForceGuard{MaxFailures: 2, Window: time.Minute, BlockFor: 5 * time.Minute,
+		OnEvent: func(ev SecurityEvent) { events = append(events, ev) }}
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	g.Fail("10.0.0.1", start)
+	g.Fail("10.0.0.2", start)
+	g.Fail("10.0.0.2", start)
+	if !g.Blocked("10.0.0.2", start.Add(time.Second)) {
+		t.Fatal("10.0.0.2 not blocked after 2 failures")
+	}
+
+	// a window later 10.0.0.1 is forgotten while 10.0.0.2 stays blocked
+	g.Fail("10.0.0.3", start.Add(2*time.Minute))
+	if _, ok := g.sources["10.0.0.1"]; ok {
+		t.Error("10.0.0.1 kept after its failures left the window")
+	}
+	if !g.Blocked("10.0.0.2", start.Add(2*time.Minute)) {
+		t.Error("10.0.0.2 released before its block ended")
+	}
+
+	// once the block ends 10.0.0.2 goes too, with a release event
+	g.Fail("10.0.0.3", start.Add(10*time.Minute))
+	if len(g.sources) != 1 {
+		t.Errorf("sources %v, want only 10.0.0.3", g.sources)
+	}
+	if len(events) != 2 || events[1].Source != "10.0.0.2" || events[1].Blocked {
+		t.Errorf("events %+v, want a block and a release of 10.0.0.2", events)
+	}
+}
@@ -0,0 +1,237 @@
+// Package proxy re-exposes a game server through local listeners
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+	"github.com/Yallamaztar/PlutoRCON/v2/query"
+)
+
+// QueryProxy answers Quake3 getinfo/getstatus and GameSpy v1 queries on a local
+// address with data fetched from the real server, for trackers that can't reach it
+type QueryProxy struct {
+	Upstream *query.Client
+	// Listen is the local UDP address, e.g. ":27960"
+	Listen string
+	// CacheTTL is how long an upstream reply is reused (default 5s)
+	CacheTTL time.Duration
+	OnError  func(error)
+
+	conn net.PacketConn
+
+	mu    sync.Mutex
+	cache map[string]cachedReply
+}
+
+type cachedReply struct {
+	lines []string
+	at    time.Time
+}
+
+// ListenAndServe serves queries until ctx is done
+func (p *QueryProxy) ListenAndServe(ctx context.Context) error {
+	conn, err := net.ListenPacket("udp", p.Listen)
+	if err != nil {
+		return err
+	}
+	p.conn = conn
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, 2048)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		reply, err := p.handle(buf[:n])
+		if err != nil {
+			if p.OnError != nil {
+				p.OnError(err)
+			}
+			continue
+		}
+		if reply != nil {
+			conn.WriteTo(reply, addr)
+		}
+	}
+}
+
+// Addr returns the bound listen address once serving
+func (p *QueryProxy) Addr() net.Addr {
+	if p.conn == nil {
+		return nil
+	}
+	return p.conn.LocalAddr()
+}
+
+// handle builds the reply for one datagram
+func (p *QueryProxy) handle(pkt []byte) ([]byte, error) {
+	if bytes.HasPrefix(pkt, wire.OOBHeader) {
+		req := strings.TrimSpace(string(pkt[len(wire.OOBHeader):]))
+		word, challenge, _ := strings.Cut(req, " ")
+		switch strings.ToLower(word) {
+		case "getinfo":
+			return p.quakeReply("getinfo", "infoResponse", challenge)
+		case "getstatus":
+			return p.quakeReply("getstatus", "statusResponse", challenge)
+		}
+		return nil, nil
+	}
+	if bytes.HasPrefix(pkt, []byte{'\\'}) {
+		return p.gamespyReply(strings.ToLower(strings.Trim(string(pkt), "\\\x00\n")))
+	}
+	return nil, nil
+}
+
+// quakeReply re-serves an upstream reply, echoing the caller's challenge
+func (p *QueryProxy) quakeReply(request, header, challenge string) ([]byte, error) {
+	lines, err := p.fetch(request)
+	if err != nil {
+		return nil, err
+	}
+	kv := wire.InfoString(lines, strings.ToLower(header))
+	if challenge != "" {
+		kv["challenge"] = challenge
+	} else {
+		delete(kv, "challenge")
+	}
+
+	var b strings.Builder
+	b.Write(wire.OOBHeader)
+	b.WriteString(header + "\n")
+	b.WriteString(infoString(kv))
+	for _, l := range playerLines(lines) {
+		b.WriteString("\n" + l)
+	}
+	b.WriteString("\n")
+	return []byte(b.String()), nil
+}
+
+// gamespyReply answers the GameSpy v1 \basic\, \info\, \players\ and \status\ queries
+func (p *QueryProxy) gamespyReply(req string) ([]byte, error) {
+	lines, err := p.fetch("getstatus")
+	if err != nil {
+		return nil, err
+	}
+	kv := wire.InfoString(lines, "statusresponse")
+	players := playerLines(lines)
+
+	var b strings.Builder
+	basic := func() {
+		fmt.Fprintf(&b, "\\gamename\\%s\\gamever\\%s", gsValue(kv["gamename"]), gsValue(kv["shortversion"]))
+	}
+	info := func() {
+		fmt.Fprintf(&b, "\\hostname\\%s\\mapname\\%s\\gametype\\%s\\numplayers\\%d\\maxplayers\\%s",
+			gsValue(kv["sv_hostname"]), gsValue(kv["mapname"]), gsValue(kv["g_gametype"]), len(players), gsValue(kv["sv_maxclients"]))
+	}
+	playerList := func() {
+		for i, l := range players {
+			score, ping, name := splitPlayerLine(l)
+			fmt.Fprintf(&b, "\\player_%d\\%s\\score_%d\\%s\\ping_%d\\%s", i, gsValue(name), i, score, i, ping)
+		}
+	}
+
+	switch req {
+	case "basic":
+		basic()
+	case "info":
+		info()
+	case "players":
+		playerList()
+	case "status":
+		basic()
+		info()
+		playerList()
+	default:
+		return nil, nil
+	}
+	b.WriteString("\\final\\\\queryid\\1.1")
+	return []byte(b.String()), nil
+}
+
+// fetch returns the cached upstream reply for a request, refreshing it when stale
+func (p *QueryProxy) fetch(request string) ([]string, error) {
+	ttl := p.CacheTTL
+	if ttl <= 0 {
+		ttl = 5 * time.Second
+	}
+	p.mu.Lock()
+	c, ok := p.cache[request]
+	p.mu.Unlock()
+	if ok && time.Since(c.at) < ttl {
+		return c.lines, nil
+	}
+
+	lines, err := p.Upstream.Query(request)
+	if err != nil {
+		if ok {
+			return c.lines, nil
+		}
+		return nil, err
+	}
+	p.mu.Lock()
+	if p.cache == nil {
+		p.cache = map[string]cachedReply{}
+	}
+	p.cache[request] = cachedReply{lines: lines, at: time.Now()}
+	p.mu.Unlock()
+	return lines, nil
+}
+
+// playerLines returns the player rows that follow the key/value line of a reply
+func playerLines(lines []string) []string {
+	var out []string
+	seenKV := false
+	for _, l := range lines {
+		if strings.Contains(l, "\\") {
+			seenKV = true
+			continue
+		}
+		if seenKV {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// splitPlayerLine splits a `score ping "name"` row
+func splitPlayerLine(l string) (score, ping, name string) {
+	fields := strings.SplitN(l, " ", 3)
+	if len(fields) < 3 {
+		return "0", "0", l
+	}
+	return fields[0], fields[1], strings.Trim(fields[2], `"`)
+}
+
+// gsValue strips color codes and backslashes that would break the GameSpy format
+func gsValue(s string) string {
+	return strings.ReplaceAll(wire.StripColors(s), "\\", "")
+}
+
+// infoString renders a \key\value string with sorted keys
+func infoString(kv map[string]string) string {
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString("\\" + k + "\\" + kv[k])
+	}
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package proxy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+	"github.com/Yallamaztar/PlutoRCON/v2/query"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon/rcontest"
+)
+
+func TestQueryProxyReplies(t *testing.T) {
+	srv := rcontest.NewServer("real")
+	defer srv.Close()
+	srv.SetPlayers("mp_raid", []rcon.Player{{ClientNum: 0, Name: "^1Red Baron", Score: 12, Ping: 48}})
+	upstream, err := query.New(srv.Host(), srv.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer upstream.Close()
+	p := &QueryProxy{Upstream: upstream}
+
+	reply, err := p.handle(append(append([]byte{}, wire.OOBHeader...), "getstatus nonce42"...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(reply[len(wire.OOBHeader):])
+	for _, want := range []string{"statusResponse\n", `\challenge\nonce42`, `\sv_hostname\rcontest`, "\n12 48 \"^1Red Baron\"\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("getstatus reply %q lacks %q", got, want)
+		}
+	}
+
+	// served from the cache, while upstream changed meanwhile
+	srv.SetStatusVar("sv_hostname", "renamed")
+	reply, err = p.handle([]byte(`\status\`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `\gamename\\gamever\\hostname\rcontest\mapname\mp_raid\gametype\tdm\numplayers\1\maxplayers\18` +
+		`\player_0\Red Baron\score_0\12\ping_0\48\final\\queryid\1.1`
+	if string(reply) != want {
+		t.Errorf("GameSpy status\n got %q\nwant %q", reply, want)
+	}
+
+	if reply, err := p.handle([]byte("hello")); reply != nil || err != nil {
+		t.Errorf("unknown datagram answered with %q, %v", reply, err)
+	}
+}
@@ -0,0 +1,272 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+	"github.com/Yallamaztar/PlutoRCON/v2/session"
+)
+
+const maxReplyPayload = 1300
+
+// RCONProxy listens for Quake3 rcon packets, checks them against its own passwords
+// and command allowlist, and forwards allowed commands to the real server
+type RCONProxy struct {
+	Upstream *rcon.RCONClient
+	// Listen is the local UDP address, e.g. ":28960"
+	Listen string
+	// Passwords are the virtual passwords accepted by the proxy
+	Passwords []string
+	// Allow lists permitted commands ("status", "say", "map_*"); "*" allows everything,
+	// empty allows nothing
+	Allow []string
+	// Deny lists commands that are always rejected, checked before Allow
+	Deny []string
+	// Accounts are per-moderator virtual passwords with their own role and audit identity
+	Accounts []Account
+	// Roles are named command lists referenced by Account.Role
+	Roles map[string]Role
+	// OnCommand is called for every request with the outcome
+	OnCommand func(req Request)
+	// Sessions, when set, records every request in a transcript per account identity,
+	// with source "proxy"; requests with a bad password go to "anonymous"
+	Sessions *session.Store
+	// Server names the upstream in transcripts
+	Server string
+	// OnError receives transcript failures, which never hold up a request
+	OnError func(err error)
+
+	// Guard drops sources that keep sending bad passwords; nil disables it
+	Guard *BruteForceGuard
+	// Workers bounds the requests handled at once (default 16); datagrams arriving
+	// while all are busy are dropped, as the server itself would under load
+	Workers int
+
+	conn     net.PacketConn
+	sessMu   sync.Mutex
+	sessions map[string]*session.Session
+}
+
+// Role is a named command allowlist shared by several accounts
+type Role struct {
+	Allow []string
+	Deny  []string
+}
+
+// Account is a virtual password mapped to an identity for the audit trail
+type Account struct {
+	Identity string
+	Password string
+	// Role names an entry of RCONProxy.Roles; when empty Allow and Deny apply. Either way
+	// nothing is allowed unless listed, so full access takes an explicit "*"
+	Role  string
+	Allow []string
+	Deny  []string
+}
+
+type Request struct {
+	From     net.Addr
+	Identity string
+	Role     string
+	Command  string
+	Args     string
+	Allowed  bool
+	Reply    []string
+	Err      error
+}
+
+var (
+	errBadPassword = errors.New("bad rconpassword")
+	errDenied      = errors.New("command not allowed")
+)
+
+// ListenAndServe serves rcon packets until ctx is done
+func (p *RCONProxy) ListenAndServe(ctx context.Context) error {
+	conn, err := net.ListenPacket("udp", p.Listen)
+	if err != nil {
+		return err
+	}
+	p.conn = conn
+	defer p.closeSessions()
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	workers := p.Workers
+	if workers <= 0 {
+		workers = 16
+	}
+	sem := make(chan struct{}, workers)
+	buf := make([]byte, 2048)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		select {
+		case sem <- struct{}{}:
+		default:
+			continue
+		}
+		pkt := append([]byte(nil), buf[:n]...)
+		go func() {
+			defer func() { <-sem }()
+			p.serve(addr, pkt)
+		}()
+	}
+}
+
+// Addr returns the bound listen address once serving
+func (p *RCONProxy) Addr() net.Addr {
+	if p.conn == nil {
+		return nil
+	}
+	return p.conn.LocalAddr()
+}
+
+// serve handles one datagram
+func (p *RCONProxy) serve(addr net.Addr, pkt []byte) {
+	if !bytes.HasPrefix(pkt, wire.OOBHeader) {
+		return
+	}
+	payload := strings.TrimSpace(string(pkt[len(wire.OOBHeader):]))
+	word, rest, _ := strings.Cut(payload, " ")
+	if !strings.EqualFold(word, "rcon") {
+		return
+	}
+	pass, cmdline, _ := strings.Cut(rest, " ")
+	cmd, args, _ := strings.Cut(strings.TrimSpace(cmdline), " ")
+	req := Request{From: addr, Command: cmd, Args: args}
+
+	host := hostOf(addr)
+	if p.Guard != nil && p.Guard.Blocked(host, time.Now()) {
+		return
+	}
+
+	var reply []string
+	start := time.Now()
+	var took time.Duration
+	acct, ok := p.account(pass)
+	if ok {
+		req.Identity, req.Role = acct.Identity, acct.Role
+	}
+	switch {
+	case !ok:
+		req.Err = errBadPassword
+		reply = []string{"Bad rconpassword."}
+		if p.Guard != nil {
+			p.Guard.Fail(host, time.Now())
+		}
+	// the server runs every command of a ";" or line separated list, while only the
+	// first one is checked, so such lists are refused outright
+	case cmd == "" || strings.ContainsAny(cmdline, ";\r\n") || !p.allowed(acct, cmd):
+		req.Err = errDenied
+		reply = []string{"Command not allowed."}
+	default:
+		if p.Guard != nil {
+			p.Guard.Succeed(host)
+		}
+		req.Allowed = true
+		var argp *string
+		if args != "" {
+			argp = &args
+		}
+		reply, req.Err = p.Upstream.SendCommand(cmd, argp, rcon.WithPriority(rcon.PriorityAdmin))
+		took = time.Since(start)
+		if req.Err != nil {
+			reply = []string{"Upstream error: " + req.Err.Error()}
+		}
+	}
+
+	req.Reply = reply
+	if p.OnCommand != nil {
+		p.OnCommand(req)
+	}
+	p.record(req, start, took)
+	sendPrint(p.conn, addr, reply)
+}
+
+// account resolves a virtual password; plain Passwords map to an account using the proxy-wide lists
+func (p *RCONProxy) account(pass string) (Account, bool) {
+	if pass == "" {
+		return Account{}, false
+	}
+	for _, a := range p.Accounts {
+		if samePassword(a.Password, pass) {
+			return a, true
+		}
+	}
+	for _, pw := range p.Passwords {
+		if samePassword(pw, pass) {
+			return Account{Allow: p.Allow, Deny: p.Deny}, true
+		}
+	}
+	return Account{}, false
+}
+
+// samePassword compares passwords in constant time
+func samePassword(want, got string) bool {
+	return subtle.ConstantTimeCompare([]byte(want), []byte(got)) == 1
+}
+
+// allowed checks a command against the proxy deny list and the account's lists
+func (p *RCONProxy) allowed(a Account, cmd string) bool {
+	if matchCommand(p.Deny, cmd) {
+		return false
+	}
+	allow, deny := a.Allow, a.Deny
+	if a.Role != "" {
+		r, ok := p.Roles[a.Role]
+		if !ok {
+			return false
+		}
+		allow, deny = r.Allow, r.Deny
+	}
+	if matchCommand(deny, cmd) {
+		return false
+	}
+	return matchCommand(allow, cmd)
+}
+
+// sendPrint writes lines as print datagrams, split to stay under the datagram limit
+func sendPrint(conn net.PacketConn, addr net.Addr, lines []string) {
+	var chunk strings.Builder
+	flush := func() {
+		conn.WriteTo(append(append([]byte{}, wire.OOBHeader...), "print\n"+chunk.String()...), addr)
+		chunk.Reset()
+	}
+	for _, l := range lines {
+		if chunk.Len() > 0 && chunk.Len()+len(l)+1 > maxReplyPayload {
+			flush()
+		}
+		chunk.WriteString(l + "\n")
+	}
+	flush()
+}
+
+// matchCommand reports whether cmd matches a pattern list; a trailing * matches a prefix
+func matchCommand(patterns []string, cmd string) bool {
+	cmd = strings.ToLower(cmd)
+	for _, pat := range patterns {
+		pat = strings.ToLower(strings.TrimSpace(pat))
+		if pat == "*" || pat == cmd {
+			return true
+		}
+		if strings.HasSuffix(pat, "*") && strings.HasPrefix(cmd, strings.TrimSuffix(pat, "*")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon/rcontest"
+)
+
+func TestProxyDropsWhenBusy(t *testing.T) {
+	port := strconv.Itoa(freePort(t))
+	release := make(chan struct{})
+	var handled atomic.Int32
+	p := &RCONProxy{
+		Listen:    "127.0.0.1:" + port,
+		Passwords: []string{"secret"},
+		Workers:   2,
+		OnCommand: func(req Request) {
+			handled.Add(1)
+			<-release
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- p.ListenAndServe(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+
+	conn, err := net.Dial("udp", "127.0.0.1:"+port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for range 10 {
+		conn.Write(append(append([]byte{}, wire.OOBHeader...), "rcon wrong status"...))
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if n := handled.Load(); n != 2 {
+		t.Errorf("handled %d requests, want 2 with the rest dropped", n)
+	}
+}
+
+func TestProxyAllowlist(t *testing.T) {
+	srv := rcontest.NewServer("real")
+	defer srv.Close()
+	upstream, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer upstream.Close()
+
+	port := strconv.Itoa(freePort(t))
+	p := &RCONProxy{
+		Upstream: upstream,
+		Listen:   "127.0.0.1:" + port,
+		Deny:     []string{"rcon_password"},
+		Accounts: []Account{
+			{Identity: "alice", Password: "alicepw", Role: "mod"},
+			{Identity: "root", Password: "rootpw", Allow: []string{"*"}},
+		},
+		Roles: map[string]Role{"mod": {Allow: []string{"say", "map_*"}, Deny: []string{"map_restart"}}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- p.ListenAndServe(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+
+	send := func(pass, cmd, args string) []string {
+		rc, err := rcon.New("127.0.0.1", port, pass)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		reply, _ := rc.SendCommand(cmd, &args, rcon.WithRetries(0), rcon.WithReadExtension(10*time.Millisecond))
+		return reply
+	}
+	denied := []string{"Command not allowed."}
+	for _, tt := range []struct {
+		pass, cmd, args string
+		forwarded       bool
+	}{
+		{"alicepw", "say", "hello", true},
+		{"alicepw", "map_rotate", "", true},
+		{"alicepw", "map_restart", "", false},
+		{"alicepw", "kick", "bob", false},
+		{"alicepw", "say", "hi; rcon_password x", false},
+		{"rootpw", "kick", "bob", true},
+		{"rootpw", "rcon_password", "x", false},
+	} {
+		srv.Reset()
+		reply := send(tt.pass, tt.cmd, tt.args)
+		got := len(srv.Commands()) > 0
+		if got != tt.forwarded {
+			t.Errorf("%s %s %q: forwarded %v, want %v (reply %q)", tt.pass, tt.cmd, tt.args, got, tt.forwarded, reply)
+		}
+		if !tt.forwarded && !slices.Equal(reply, denied) {
+			t.Errorf("%s %s %q: reply %q, want %q", tt.pass, tt.cmd, tt.args, reply, denied)
+		}
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,55 @@
+package proxy
+
+import (
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/session"
+)
+
+// record adds a request to the transcript of its account, opening one on first use
+func (p *RCONProxy) record(req Request, at time.Time, took time.Duration) {
+	if p.Sessions == nil {
+		return
+	}
+	op := req.Identity
+	if op == "" {
+		op = "anonymous"
+	}
+	p.sessMu.Lock()
+	if p.sessions == nil {
+		p.sessions = map[string]*session.Session{}
+	}
+	s := p.sessions[op]
+	if s == nil {
+		var err error
+		if s, err = p.Sessions.Open(op, "proxy", p.Server); err != nil {
+			p.sessMu.Unlock()
+			p.recordError(err)
+			return
+		}
+		p.sessions[op] = s
+	}
+	p.sessMu.Unlock()
+
+	e := session.Entry{At: at, Command: req.Command, Args: req.Args, Response: req.Reply, Took: took}
+	if req.Err != nil {
+		e.Error = req.Err.Error()
+	}
+	p.recordError(s.Record(e))
+}
+
+func (p *RCONProxy) recordError(err error) {
+	if err != nil && p.OnError != nil {
+		p.OnError(err)
+	}
+}
+
+// closeSessions ends every open transcript
+func (p *RCONProxy) closeSessions() {
+	p.sessMu.Lock()
+	defer p.sessMu.Unlock()
+	for op, s := range p.sessions {
+		p.recordError(s.Close())
+		delete(p.sessions, op)
+	}
+}
@@ -0,0 +1,93 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon/rcontest"
+	"github.com/Yallamaztar/PlutoRCON/v2/session"
+)
+
+// freePort returns a UDP port nothing listens on right now
+func freePort(t *testing.T) int {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestProxySessions(t *testing.T) {
+	srv := rcontest.NewServer("real")
+	defer srv.Close()
+	upstream, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer upstream.Close()
+
+	store := &session.Store{Dir: t.TempDir()}
+	port := strconv.Itoa(freePort(t))
+	p := &RCONProxy{
+		Upstream: upstream,
+		Listen:   "127.0.0.1:" + port,
+		Accounts: []Account{{Identity: "alice", Password: "alicepw", Allow: []string{"say"}}},
+		Sessions: store,
+		Server:   "tdm-1",
+		OnError:  func(err error) { t.Error(err) },
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- p.ListenAndServe(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+
+	send := func(pass, cmd, args string) {
+		rc, err := rcon.New("127.0.0.1", port, pass)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		rc.SendCommand(cmd, &args, rcon.WithRetries(0))
+	}
+	send("alicepw", "say", "hello")
+	send("alicepw", "map", "mp_raid")
+	send("wrong", "say", "hi")
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	metas, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byOperator := map[string]session.Meta{}
+	for _, m := range metas {
+		byOperator[m.Operator] = m
+		if m.Source != "proxy" || m.Server != "tdm-1" {
+			t.Errorf("session %+v, want source proxy on tdm-1", m)
+		}
+	}
+	if len(metas) != 2 || byOperator["alice"].ID == "" || byOperator["anonymous"].ID == "" {
+		t.Fatalf("sessions %+v, want one for alice and one for the bad password", metas)
+	}
+
+	tr, err := store.Load(byOperator["alice"].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tr.Entries) != 2 {
+		t.Fatalf("alice's transcript has %d entries, want 2", len(tr.Entries))
+	}
+	if e := tr.Entries[0]; e.Command != "say" || e.Args != "hello" || e.Error != "" {
+		t.Errorf("first entry %+v", e)
+	}
+	if e := tr.Entries[1]; e.Command != "map" || e.Error == "" {
+		t.Errorf("a denied command is recorded as %+v", e)
+	}
+}
@@ -0,0 +1,31 @@
+package query
+
+import "strconv"
+
+type FriendlyFire int
+
+const (
+	FriendlyFireOff FriendlyFire = iota
+	FriendlyFireOn
+	FriendlyFireReflect
+	FriendlyFireShared
+)
+
+func (f FriendlyFire) String() string {
+	switch f {
+	case FriendlyFireOff:
+		return "off"
+	case FriendlyFireOn:
+		return "on"
+	case FriendlyFireReflect:
+		return "reflect"
+	case FriendlyFireShared:
+		return "shared"
+	}
+	return "friendlyfire(" + strconv.Itoa(int(f)) + ")"
+}
+
+// FriendlyFire returns scr_team_fftype as a typed value
+func (s *ServerStatusInfo) FriendlyFire() FriendlyFire {
+	return FriendlyFire(s.ScrTeamFFType)
+}
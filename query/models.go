@@ -0,0 +1,122 @@
+package query
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+)
+
+// ErrResponseTooLarge is returned when a reply grows past Client.MaxResponse
+var ErrResponseTooLarge = wire.ErrTooLarge
+
+// ErrChallenge is returned when Client.Challenge is set and only replies with another nonce arrived
+var ErrChallenge = wire.ErrChallenge
+
+// ErrStale is returned when a reply arrived later than Client.MaxAge
+var ErrStale = wire.ErrStale
+
+// Codepage is the byte encoding of a game's text
+type Codepage = wire.Codepage
+
+const (
+	CP1252 = wire.CP1252
+	Latin1 = wire.Latin1
+	UTF8   = wire.UTF8
+)
+
+type ServerInfo struct {
+	NetFieldChk int64  `json:"net_field_chk"`
+	Protocol    int    `json:"protocol"`
+	SessionMode int    `json:"session_mode"`
+	Hostname    string `json:"hostname"`
+	MapName     string `json:"map_name"`
+	IsInGame    bool   `json:"is_in_game"`
+	MaxClients  int    `json:"max_clients"`
+	GameType    string `json:"game_type"`
+	HW          int    `json:"hw"`
+	Mod         bool   `json:"mod"`
+	// GameName and FsGame identify the running mod, when the server reports them
+	GameName    string    `json:"game_name,omitempty"`
+	FsGame      string    `json:"fs_game,omitempty"`
+	Voice       bool      `json:"voice"`
+	SecKey      string    `json:"sec_key"`
+	SecID       string    `json:"sec_id"`
+	HostAddr    string    `json:"host_addr"`
+	RetrievedAt time.Time `json:"retrieved_at"`
+}
+
+type ServerStatusInfo struct {
+	ComMaxClients            int            `json:"com_max_clients"`
+	GameType                 string         `json:"game_type"`
+	RandomSeed               int            `json:"random_seed"`
+	GameName                 string         `json:"game_name"`
+	MapName                  string         `json:"map_name"`
+	PlaylistEnabled          bool           `json:"playlist_enabled"`
+	PlaylistEntry            int            `json:"playlist_entry"`
+	Protocol                 int            `json:"protocol"`
+	ScrTeamFFType            int            `json:"scr_team_ff_type"`
+	ShortVersion             bool           `json:"short_version"`
+	SvAllowAimAssist         bool           `json:"sv_allow_aim_assist"`
+	SvAllowAnonymous         bool           `json:"sv_allow_anonymous"`
+	SvClientFpsLimit         int            `json:"sv_client_fps_limit"`
+	SvDisableClientConsole   bool           `json:"sv_disable_client_console"`
+	SvHostname               string         `json:"sv_hostname"`
+	SvMaxClients             int            `json:"sv_max_clients"`
+	SvMaxPing                int            `json:"sv_max_ping"`
+	SvMinPing                int            `json:"sv_min_ping"`
+	SvPatchDSR50             bool           `json:"sv_patch_dsr50"`
+	SvPrivateClients         int            `json:"sv_private_clients"`
+	SvPrivateClientsForUsers int            `json:"sv_private_clients_for_users"`
+	SvPure                   bool           `json:"sv_pure"`
+	SvVoice                  bool           `json:"sv_voice"`
+	PasswordEnabled          bool           `json:"password_enabled"`
+	ModEnabled               bool           `json:"mod_enabled"`
+	Players                  []StatusPlayer `json:"players"`
+	RetrievedAt              time.Time      `json:"retrieved_at"`
+}
+
+// StatusPlayer is a player line of a getstatus reply; it carries no client number or GUID
+type StatusPlayer struct {
+	Score int    `json:"score"`
+	Ping  int    `json:"ping"`
+	Name  string `json:"name"`
+}
+
+type ProbeResult struct {
+	Latency time.Duration `json:"-"`
+	Info    *ServerInfo   `json:"info"`
+}
+
+// MarshalJSON reports the latency in milliseconds
+func (p ProbeResult) MarshalJSON() ([]byte, error) {
+	type plain ProbeResult
+	return json.Marshal(struct {
+		plain
+		Latency float64 `json:"latency_ms"`
+	}{plain(p), millis(p.Latency)})
+}
+
+type LatencyStats struct {
+	Samples int           `json:"samples"`
+	Lost    int           `json:"lost"`
+	Min     time.Duration `json:"-"`
+	Avg     time.Duration `json:"-"`
+	Max     time.Duration `json:"-"`
+}
+
+// MarshalJSON reports the round trips in milliseconds
+func (l LatencyStats) MarshalJSON() ([]byte, error) {
+	type plain LatencyStats
+	return json.Marshal(struct {
+		plain
+		Min float64 `json:"min_ms"`
+		Avg float64 `json:"avg_ms"`
+		Max float64 `json:"max_ms"`
+	}{plain(l), millis(l.Min), millis(l.Avg), millis(l.Max)})
+}
+
+// millis converts a duration to fractional milliseconds for JSON
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
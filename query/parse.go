@@ -0,0 +1,105 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+)
+
+// InfoKeys names the getinfo keys that differ between titles
+type InfoKeys struct {
+	MaxClients string
+	GameType   string
+}
+
+var defaultInfoKeys = InfoKeys{MaxClients: "com_maxclients", GameType: "gametype"}
+
+var statusPlayerRx = regexp.MustCompile(`^(-?\d+)\s+(-?\d+)\s+"(.*)"$`)
+
+// ParseInfoResponse parses the lines of a T6 getinfo reply
+func ParseInfoResponse(lines []string) (*ServerInfo, error) {
+	return ParseInfoResponseKeys(lines, defaultInfoKeys)
+}
+
+// ParseInfoResponseKeys parses the lines of a getinfo reply using title-specific keys
+func ParseInfoResponseKeys(lines []string, keys InfoKeys) (*ServerInfo, error) {
+	if keys.MaxClients == "" {
+		keys.MaxClients = defaultInfoKeys.MaxClients
+	}
+	if keys.GameType == "" {
+		keys.GameType = defaultInfoKeys.GameType
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("empty infoResponse")
+	}
+	kv := wire.InfoString(lines, "inforesponse")
+
+	info := &ServerInfo{RetrievedAt: time.Now()}
+	info.NetFieldChk, _ = strconv.ParseInt(kv["netfieldchk"], 10, 64)
+	info.Protocol = wire.Atoi(kv["protocol"])
+	info.SessionMode = wire.Atoi(kv["sessionmode"])
+	info.Hostname = kv["hostname"]
+	info.MapName = kv["mapname"]
+	info.IsInGame = wire.Bool(kv["isInGame"])
+	info.MaxClients = wire.Atoi(kv[keys.MaxClients])
+	info.GameType = kv[keys.GameType]
+	info.HW = wire.Atoi(kv["hw"])
+	info.Mod = wire.Bool(kv["mod"])
+	info.GameName = kv["gamename"]
+	info.FsGame = kv["fs_game"]
+	info.Voice = wire.Bool(kv["voice"])
+	info.SecKey = kv["seckey"]
+	info.SecID = kv["secid"]
+	info.HostAddr = kv["hostaddr"]
+	return info, nil
+}
+
+// ParseStatusResponse parses the lines of a getstatus reply
+func ParseStatusResponse(lines []string) (*ServerStatusInfo, error) {
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("empty statusResponse")
+	}
+	kv := wire.InfoString(lines, "statusresponse")
+
+	info := &ServerStatusInfo{RetrievedAt: time.Now()}
+	info.ComMaxClients = wire.Atoi(kv["com_maxclients"])
+	info.GameType = kv["g_gametype"]
+	info.RandomSeed = wire.Atoi(kv["g_randomSeed"])
+	info.GameName = kv["gamename"]
+	info.MapName = kv["mapname"]
+	info.PlaylistEnabled = wire.Bool(kv["playlist_enabled"])
+	info.PlaylistEntry = wire.Atoi(kv["playlist_entry"])
+	info.Protocol = wire.Atoi(kv["protocol"])
+	info.ScrTeamFFType = wire.Atoi(kv["scr_team_fftype"])
+	info.ShortVersion = wire.Bool(kv["shortversion"])
+	info.SvAllowAimAssist = wire.Bool(kv["sv_allowAimAssist"])
+	info.SvAllowAnonymous = wire.Bool(kv["sv_allowAnonymous"])
+	info.SvClientFpsLimit = wire.Atoi(kv["sv_clientFpsLimit"])
+	info.SvDisableClientConsole = wire.Bool(kv["sv_disableClientConsole"])
+	info.SvHostname = kv["sv_hostname"]
+	info.SvMaxClients = wire.Atoi(kv["sv_maxclients"])
+	info.SvMaxPing = wire.Atoi(kv["sv_maxPing"])
+	info.SvMinPing = wire.Atoi(kv["sv_minPing"])
+	info.SvPatchDSR50 = wire.Bool(kv["sv_patch_dsr50"])
+	info.SvPrivateClients = wire.Atoi(kv["sv_privateClients"])
+
+	if v, ok := kv["sv_privateClientsForClients"]; ok {
+		info.SvPrivateClientsForUsers = wire.Atoi(v)
+	} else {
+		info.SvPrivateClientsForUsers = wire.Atoi(kv["sv_privateClientsForUsers"])
+	}
+	info.SvPure = wire.Bool(kv["sv_pure"])
+	info.SvVoice = wire.Bool(kv["sv_voice"])
+	info.PasswordEnabled = wire.Bool(kv["pswrd"])
+	info.ModEnabled = wire.Bool(kv["mod"])
+
+	for _, line := range lines {
+		if m := statusPlayerRx.FindStringSubmatch(line); m != nil {
+			info.Players = append(info.Players, StatusPlayer{Score: wire.Atoi(m[1]), Ping: wire.Atoi(m[2]), Name: m[3]})
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,53 @@
+package query
+
+import (
+	"sort"
+	"sync"
+)
+
+type PlaylistInfo struct {
+	Enabled bool   `json:"enabled"`
+	Entry   int    `json:"entry"`
+	Name    string `json:"name"`
+}
+
+var (
+	playlistMu sync.RWMutex
+	// playlistNames are the registered playlist entries. None are built in: an entry
+	// indexes the playlists.info the server loads, and the numbering differs between
+	// game builds and custom playlist files, so it comes from the server's own file
+	playlistNames = map[int]string{}
+)
+
+// PlaylistName returns the display name of a playlist entry
+func PlaylistName(entry int) (string, bool) {
+	playlistMu.RLock()
+	defer playlistMu.RUnlock()
+	name, ok := playlistNames[entry]
+	return name, ok
+}
+
+// RegisterPlaylist adds or renames a playlist entry, as listed in the server's playlists.info
+func RegisterPlaylist(entry int, name string) {
+	playlistMu.Lock()
+	defer playlistMu.Unlock()
+	playlistNames[entry] = name
+}
+
+// Playlists returns the known playlist entries in ascending order
+func Playlists() []PlaylistInfo {
+	playlistMu.RLock()
+	defer playlistMu.RUnlock()
+	out := make([]PlaylistInfo, 0, len(playlistNames))
+	for entry, name := range playlistNames {
+		out = append(out, PlaylistInfo{Entry: entry, Name: name})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Entry < out[j].Entry })
+	return out
+}
+
+// PlaylistName returns the display name of the active playlist entry
+func (s *ServerStatusInfo) PlaylistName() string {
+	name, _ := PlaylistName(s.PlaylistEntry)
+	return name
+}
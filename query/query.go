@@ -0,0 +1,165 @@
+// Package query talks to Plutonium servers over the connectionless getinfo/getstatus
+// protocol. It needs no RCON password, for server browsers and status pages
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+)
+
+const (
+	defaultTimeout       = time.Second
+	defaultReadExtension = 350 * time.Millisecond
+)
+
+type Client struct {
+	IP      string
+	Port    int
+	Timeout time.Duration
+	// Keys selects title-specific getinfo keys; the zero value reads T6 replies
+	Keys InfoKeys
+	// MaxResponse bounds the bytes kept for one reply (0 = 512 KiB, negative = no limit)
+	MaxResponse int
+	// Challenge sends a fresh nonce with every getinfo/getstatus and drops replies that
+	// don't echo it, so duplicated or replayed datagrams can't stand in for the answer
+	Challenge bool
+	// MaxAge rejects replies arriving later than this after their request with ErrStale (0 = off)
+	MaxAge time.Duration
+	// Codepage is the encoding replies are converted to UTF-8 from (default CP1252)
+	Codepage Codepage
+	Conn     *net.UDPConn
+	mu       sync.Mutex
+}
+
+func New(ip, port string) (*Client, error) {
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		return nil, errors.New("invalid port number")
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", ip, portNum))
+	if err != nil {
+		return nil, errors.New("failed to resolve UDP address")
+	}
+
+	conn, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		return nil, errors.New("failed to establish UDP connection")
+	}
+
+	return &Client{IP: ip, Port: portNum, Timeout: defaultTimeout, Conn: conn}, nil
+}
+
+// Close the query UDP connection
+func (c *Client) Close() error {
+	return c.Conn.Close()
+}
+
+// Get Server Info
+func (c *Client) GetInfo() (*ServerInfo, error) {
+	lines, err := c.Query("getinfo")
+	if err != nil {
+		return nil, err
+	}
+	return ParseInfoResponseKeys(lines, c.Keys)
+}
+
+// Get Server Status
+func (c *Client) GetStatus() (*ServerStatusInfo, error) {
+	lines, err := c.Query("getstatus")
+	if err != nil {
+		return nil, err
+	}
+	return ParseStatusResponse(lines)
+}
+
+// Probe sends getinfo and measures the round trip
+func (c *Client) Probe() (*ProbeResult, error) {
+	lines, latency, err := c.roundTrip("getinfo")
+	if err != nil {
+		return nil, err
+	}
+	info, err := ParseInfoResponseKeys(lines, c.Keys)
+	if err != nil {
+		return nil, err
+	}
+	return &ProbeResult{Latency: latency, Info: info}, nil
+}
+
+// Latency sends n getinfo requests and summarizes their round trips; lost requests count against Lost
+func (c *Client) Latency(n int) (*LatencyStats, error) {
+	if n <= 0 {
+		n = 1
+	}
+	stats := &LatencyStats{Samples: n}
+	var total time.Duration
+	var lerr error
+	for i := 0; i < n; i++ {
+		_, rtt, err := c.roundTrip("getinfo")
+		if err != nil {
+			stats.Lost++
+			lerr = err
+			continue
+		}
+		total += rtt
+		if stats.Min == 0 || rtt < stats.Min {
+			stats.Min = rtt
+		}
+		if rtt > stats.Max {
+			stats.Max = rtt
+		}
+	}
+	if stats.Lost == n {
+		return nil, lerr
+	}
+	stats.Avg = total / time.Duration(n-stats.Lost)
+	return stats, nil
+}
+
+// Query sends a connectionless request and returns the reply lines
+func (c *Client) Query(request string) ([]string, error) {
+	lines, _, err := c.roundTrip(request)
+	return lines, err
+}
+
+// roundTrip is Query that also reports the time until the first reply datagram
+func (c *Client) roundTrip(request string) ([]string, time.Duration, error) {
+	if c.Conn == nil {
+		return nil, 0, fmt.Errorf("query connection is not established")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expect := wire.ReplyType(request)
+	var ch *wire.Challenge
+	var decode wire.DecodeFunc
+	if c.Challenge && expect != "" {
+		ch = wire.NewChallenge()
+		request = ch.Request(request)
+		decode = ch.Decode(nil)
+	}
+
+	start := time.Now()
+	if _, err := c.Conn.Write(wire.Packet(request)); err != nil {
+		return nil, 0, err
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	res, err := wire.ReadFrames(c.Conn, timeout, defaultReadExtension, expect, decode, c.MaxResponse)
+	if err != nil {
+		return nil, 0, ch.Err(err)
+	}
+	if err := wire.Fresh(start, res.First, c.MaxAge); err != nil {
+		return nil, 0, err
+	}
+	return wire.SplitLines(wire.ToUTF8(wire.Normalize(string(res.Data)), c.Codepage)), res.First.Sub(start), nil
+}
@@ -0,0 +1,234 @@
+package rcon
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// adaptiveMinSamples is how many replies a command needs before its own timings are used
+	adaptiveMinSamples = 3
+	// adaptiveMaxCommands bounds the per-command table; other commands only feed the server-wide timings
+	adaptiveMaxCommands = 64
+	// adaptiveMinExtension keeps a learned read extension from dropping below scheduler jitter
+	adaptiveMinExtension = 50 * time.Millisecond
+)
+
+// WithAdaptiveTimeouts learns how quickly the server answers each command and tunes the
+// read timeout and extension from it, kept between floor and ceiling (default 250ms and
+// 5s). A LAN server then fails fast and a distant one stops timing out. Until a command
+// has a few replies the server-wide timings are used, and before that the client's
+// Timeout. WithTimeout and WithReadExtension on a command still win
+func WithAdaptiveTimeouts(floor, ceiling time.Duration) Option {
+	return func(rc *RCONClient) {
+		if floor <= 0 {
+			floor = 250 * time.Millisecond
+		}
+		if ceiling < floor {
+			ceiling = max(5*time.Second, floor)
+		}
+		rc.learner = &timeoutLearner{floor: floor, ceiling: ceiling, commands: map[string]*latencyStats{}}
+	}
+}
+
+// LearnedTimeout is what WithAdaptiveTimeouts learned about one command, or about the
+// whole server when Command is empty
+type LearnedTimeout struct {
+	Command string `json:"command"`
+	Samples int    `json:"samples"`
+	// RTT is the smoothed time to the first datagram of a reply
+	RTT time.Duration `json:"rtt"`
+	// Timeout and Extension are what the next command will wait for
+	Timeout   time.Duration `json:"timeout"`
+	Extension time.Duration `json:"extension,omitempty"`
+	// Misses counts timeouts since the last reply; each doubles Timeout up to the ceiling
+	Misses int `json:"misses,omitempty"`
+}
+
+// LearnedTimeouts returns the timings learned so far, server-wide first, or nil
+// without WithAdaptiveTimeouts
+func (rc *RCONClient) LearnedTimeouts() []LearnedTimeout {
+	if rc == nil || rc.learner == nil {
+		return nil
+	}
+	return rc.learner.snapshot()
+}
+
+// ResetLearnedTimeouts forgets the learned timings, e.g. after the server moved host
+func (rc *RCONClient) ResetLearnedTimeouts() {
+	if rc == nil || rc.learner == nil {
+		return
+	}
+	rc.learner.reset()
+}
+
+// longReply sets the read extension for a reply known to span datagrams, leaving it
+// to the learned value when there is one
+func longReply(d time.Duration) CommandOption {
+	return func(s *commandSettings) {
+		if !s.extensionSet {
+			s.readExtension = d
+		}
+	}
+}
+
+// timeoutLearner keeps smoothed reply timings per command. The timeout follows TCP's
+// retransmission timer: smoothed RTT plus four times its variation
+type timeoutLearner struct {
+	floor, ceiling time.Duration
+
+	mu       sync.Mutex
+	server   latencyStats
+	commands map[string]*latencyStats
+}
+
+type latencyStats struct {
+	samples int
+	srtt    time.Duration
+	rttvar  time.Duration
+	// gap is a slowly decaying peak of the wait between datagrams of multi-datagram replies
+	gap    time.Duration
+	misses int
+}
+
+// learnKey is the command word timings are kept under
+func learnKey(cmd string) string {
+	word, _, _ := strings.Cut(strings.TrimSpace(cmd), " ")
+	return strings.ToLower(word)
+}
+
+// tune applies the learned timings for cmd to s, unless the caller set them
+func (l *timeoutLearner) tune(cmd string, s *commandSettings) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	st := l.statsLocked(cmd)
+	if !s.timeoutSet {
+		if t, ok := l.timeoutLocked(st); ok {
+			s.readTimeout = t
+		}
+	}
+	if !s.extensionSet {
+		if e, ok := l.extensionLocked(st); ok {
+			s.readExtension = e
+		}
+	}
+}
+
+// observe records a reply to cmd that took rtt to start, waited up to gap between
+// datagrams, and looked cut off when truncated
+func (l *timeoutLearner) observe(cmd string, rtt, gap time.Duration, truncated bool) {
+	if l == nil || rtt <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.server.add(rtt, gap, truncated)
+	key := learnKey(cmd)
+	st := l.commands[key]
+	if st == nil {
+		if len(l.commands) >= adaptiveMaxCommands {
+			return
+		}
+		st = &latencyStats{}
+		l.commands[key] = st
+	}
+	st.add(rtt, gap, truncated)
+}
+
+// missed records a command that got no reply in time
+func (l *timeoutLearner) missed(cmd string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.server.misses++
+	if st := l.commands[learnKey(cmd)]; st != nil {
+		st.misses++
+	}
+}
+
+func (l *timeoutLearner) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.server = latencyStats{}
+	l.commands = map[string]*latencyStats{}
+}
+
+func (l *timeoutLearner) snapshot() []LearnedTimeout {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := []LearnedTimeout{l.learnedLocked("", &l.server)}
+	keys := make([]string, 0, len(l.commands))
+	for k := range l.commands {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		out = append(out, l.learnedLocked(k, l.commands[k]))
+	}
+	return out
+}
+
+func (l *timeoutLearner) learnedLocked(cmd string, st *latencyStats) LearnedTimeout {
+	t, _ := l.timeoutLocked(st)
+	e, _ := l.extensionLocked(st)
+	return LearnedTimeout{Command: cmd, Samples: st.samples, RTT: st.srtt, Timeout: t, Extension: e, Misses: st.misses}
+}
+
+// statsLocked returns the stats to tune cmd with: its own once it has enough samples,
+// otherwise the server-wide ones
+func (l *timeoutLearner) statsLocked(cmd string) *latencyStats {
+	if st := l.commands[learnKey(cmd)]; st != nil && st.samples >= adaptiveMinSamples {
+		return st
+	}
+	return &l.server
+}
+
+// timeoutLocked is the read timeout for st, doubled per miss, and whether enough is known
+func (l *timeoutLearner) timeoutLocked(st *latencyStats) (time.Duration, bool) {
+	if st.samples < adaptiveMinSamples {
+		return 0, false
+	}
+	t := max(st.srtt+4*st.rttvar, l.floor)
+	for i := 0; i < st.misses && t < l.ceiling; i++ {
+		t *= 2
+	}
+	return min(t, l.ceiling), true
+}
+
+// extensionLocked is the read extension for st, once a multi-datagram reply was seen
+func (l *timeoutLearner) extensionLocked(st *latencyStats) (time.Duration, bool) {
+	if st.gap <= 0 {
+		return 0, false
+	}
+	return min(max(2*st.gap+adaptiveMinExtension, adaptiveMinExtension), l.ceiling), true
+}
+
+func (st *latencyStats) add(rtt, gap time.Duration, truncated bool) {
+	if st.samples == 0 {
+		st.srtt, st.rttvar = rtt, rtt/2
+	} else {
+		diff := st.srtt - rtt
+		if diff < 0 {
+			diff = -diff
+		}
+		st.rttvar += (diff - st.rttvar) / 4
+		st.srtt += (rtt - st.srtt) / 8
+	}
+	st.samples++
+	st.misses = 0
+
+	if gap > 0 {
+		st.gap = max(st.gap-st.gap/8, gap)
+	}
+	if truncated && st.gap > 0 {
+		// the extension was too short to catch the rest of the reply
+		st.gap = min(2*st.gap, time.Minute)
+	}
+}
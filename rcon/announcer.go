@@ -0,0 +1,211 @@
+package rcon
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Announcement is one rotating broadcast. Interval, when set, replaces the announcer's
+// default wait after this message
+type Announcement struct {
+	Message  string
+	Interval time.Duration
+}
+
+// Announcer says a list of messages in turn on an interval. Messages may use {map},
+// {gametype}, {players}, {maxplayers}, {hostname} and {nextmap}, resolved when sent;
+// {map_name}, {gametype_name} and {nextmap_name} are their display names
+type Announcer struct {
+	Client   *RCONClient
+	Messages []Announcement
+	// Interval is the default wait between messages (default 2 minutes)
+	Interval time.Duration
+	// SkipEmpty holds messages back while nobody is online
+	SkipEmpty bool
+	// OnError sees failures to resolve or send a message; the rotation carries on
+	OnError func(err error)
+
+	// Server names this announcer for Suppress, e.g. a maintenance.Switches gate;
+	// while suppressed, Run holds the rotation where it is
+	Server   string
+	Suppress interface {
+		Suppressed(server string, t time.Time) bool
+	}
+
+	mu     sync.Mutex
+	next   int
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewAnnouncer returns an announcer cycling through messages every interval
+func NewAnnouncer(rc *RCONClient, interval time.Duration, messages ...string) *Announcer {
+	a := &Announcer{Client: rc, Interval: interval}
+	for _, m := range messages {
+		a.Messages = append(a.Messages, Announcement{Message: m})
+	}
+	return a
+}
+
+// Start runs the announcer in the background until Stop
+func (a *Announcer) Start() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel, a.done = cancel, make(chan struct{})
+	go func(done chan struct{}) {
+		defer close(done)
+		a.Run(ctx)
+	}(a.done)
+}
+
+// Stop ends a Start'ed announcer and waits for it to finish
+func (a *Announcer) Stop() {
+	a.mu.Lock()
+	cancel, done := a.cancel, a.done
+	a.cancel, a.done = nil, nil
+	a.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// Run announces until ctx is done, waiting before the first message
+func (a *Announcer) Run(ctx context.Context) {
+	t := time.NewTimer(a.wait(-1))
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if a.Suppress != nil && a.Suppress.Suppressed(a.Server, time.Now()) {
+			t.Reset(a.wait(-1))
+			continue
+		}
+		i, err := a.Announce()
+		if err != nil && a.OnError != nil {
+			a.OnError(err)
+		}
+		t.Reset(a.wait(i))
+	}
+}
+
+// Announce sends the next message now and returns its index, or -1 if there are none
+func (a *Announcer) Announce() (int, error) {
+	a.mu.Lock()
+	if len(a.Messages) == 0 {
+		a.mu.Unlock()
+		return -1, nil
+	}
+	i := a.next % len(a.Messages)
+	a.next = i + 1
+	msg := a.Messages[i].Message
+	a.mu.Unlock()
+
+	if a.SkipEmpty {
+		st, err := a.Client.Status()
+		if err != nil {
+			return i, err
+		}
+		if len(st.Players) == 0 {
+			return i, nil
+		}
+	}
+	text, err := a.Render(msg)
+	if err != nil {
+		return i, fmt.Errorf("announcement %d: %w", i+1, err)
+	}
+	return i, a.Client.Say(text)
+}
+
+// Render resolves the placeholders of a message, querying only what it uses
+func (a *Announcer) Render(msg string) (string, error) {
+	if !strings.Contains(msg, "{") {
+		return msg, nil
+	}
+	var st *ServerStatus
+	status := func() (*ServerStatus, error) {
+		if st != nil {
+			return st, nil
+		}
+		var err error
+		st, err = a.Client.Status()
+		return st, err
+	}
+	dvar := func(name string) func() (string, error) {
+		return func() (string, error) { return a.Client.GetDvar(name) }
+	}
+
+	resolvers := map[string]func() (string, error){
+		"{map}": func() (string, error) {
+			s, err := status()
+			if err != nil {
+				return "", err
+			}
+			return s.Map, nil
+		},
+		"{players}": func() (string, error) {
+			s, err := status()
+			if err != nil {
+				return "", err
+			}
+			return strconv.Itoa(len(s.Players)), nil
+		},
+		"{maxplayers}": dvar("sv_maxclients"),
+		"{hostname}":   dvar("sv_hostname"),
+		"{gametype}":   dvar("g_gametype"),
+		"{nextmap}": func() (string, error) {
+			e, err := a.Client.NextMap()
+			if err != nil {
+				return "", err
+			}
+			return e.Map, nil
+		},
+	}
+	for key, internal := range map[string]string{"{map_name}": "{map}", "{gametype_name}": "{gametype}", "{nextmap_name}": "{nextmap}"} {
+		resolve, display := resolvers[internal], MapDisplayName
+		if internal == "{gametype}" {
+			display = GametypeDisplayName
+		}
+		resolvers[key] = func() (string, error) {
+			v, err := resolve()
+			return display(a.Client.Game(), v), err
+		}
+	}
+	// one pass over msg, so a value such as a hostname holding "{map}" stays as it is
+	var pairs []string
+	for key, resolve := range resolvers {
+		if !strings.Contains(msg, key) {
+			continue
+		}
+		val, err := resolve()
+		if err != nil {
+			return "", fmt.Errorf("resolve %s: %w", key, err)
+		}
+		pairs = append(pairs, key, val)
+	}
+	return strings.NewReplacer(pairs...).Replace(msg), nil
+}
+
+// wait returns the pause after message i (-1 before the first one)
+func (a *Announcer) wait(i int) time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if i >= 0 && i < len(a.Messages) && a.Messages[i].Interval > 0 {
+		return a.Messages[i].Interval
+	}
+	if a.Interval > 0 {
+		return a.Interval
+	}
+	return 2 * time.Minute
+}
@@ -0,0 +1,20 @@
+package rcon
+
+import "testing"
+
+func TestAnnouncerRenderOnePass(t *testing.T) {
+	srv := newFakeServer()
+	srv.setDvar("sv_hostname", "Best {gametype} server")
+	srv.setDvar("g_gametype", "tdm")
+	rc := srv.client()
+	defer rc.Close()
+
+	a := &Announcer{Client: rc}
+	got, err := a.Render("Welcome to {hostname}, playing {gametype}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Welcome to Best {gametype} server, playing tdm"; got != want {
+		t.Errorf("Render = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,213 @@
+package rcon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type BanEntry struct {
+	GUID string `json:"guid"`
+	Name string `json:"name"`
+	IP   string `json:"ip"`
+	// Expires is zero for permanent bans
+	Expires time.Time `json:"-"`
+	Reason  string    `json:"reason"`
+}
+
+// MarshalJSON reports a permanent ban's expiry as null
+func (b BanEntry) MarshalJSON() ([]byte, error) {
+	type plain BanEntry
+	var expires *time.Time
+	if !b.Expires.IsZero() {
+		expires = &b.Expires
+	}
+	return json.Marshal(struct {
+		plain
+		Expires *time.Time `json:"expires"`
+	}{plain(b), expires})
+}
+
+// banCommands are the console commands used for bans; client variants take a slot number
+type banCommands struct {
+	banClient     string
+	banUser       string
+	tempBanClient string
+	tempBanUser   string
+	unban         string
+	banList       string
+}
+
+var t6BanCommands = banCommands{
+	banClient:     "banclient",
+	banUser:       "banUser",
+	tempBanClient: "tempBanClient",
+	tempBanUser:   "tempBanUser",
+	unban:         "unban",
+	banList:       "banlist",
+}
+
+// Ban a player (client number or name) permanently. With WithOfflineQueue a ban by
+// name is queued while the server is unreachable
+func (rc *RCONClient) Ban(player, reason string, opts ...CommandOption) error {
+	cmds := rc.banCommands()
+	send := func() error { return rc.banCommand(player, reason, cmds.banClient, cmds.banUser, "", opts) }
+	if isClientNum(player) {
+		return send()
+	}
+	return rc.offlineOr(OfflineAction{Kind: ActionBan, Target: strings.TrimSpace(player), Reason: reason}, send)
+}
+
+// Ban a player (client number or name) for a limited time. With WithOfflineQueue a ban
+// by name is queued while the server is unreachable, and applied for the time left
+func (rc *RCONClient) TempBan(player string, duration time.Duration, reason string, opts ...CommandOption) error {
+	if duration <= 0 {
+		return fmt.Errorf("tempban duration must be positive")
+	}
+	send := func() error { return rc.tempBan(player, duration, reason, opts) }
+	if isClientNum(player) {
+		return send()
+	}
+	return rc.offlineOr(OfflineAction{Kind: ActionTempBan, Target: strings.TrimSpace(player), Reason: reason, Duration: duration}, send)
+}
+
+// Remove a ban by GUID (or name on titles that ban by name). With WithOfflineQueue it
+// is queued while the server is unreachable
+func (rc *RCONClient) Unban(guid string) error {
+	guid = strings.TrimSpace(guid)
+	if guid == "" {
+		return fmt.Errorf("guid cannot be empty")
+	}
+	return rc.offlineOr(OfflineAction{Kind: ActionUnban, Target: guid}, func() error { return rc.unban(guid) })
+}
+
+// tempBan sends a temp ban rounded up to whole minutes
+func (rc *RCONClient) tempBan(player string, duration time.Duration, reason string, opts []CommandOption) error {
+	minutes := int((duration + time.Minute - 1) / time.Minute)
+	cmds := rc.banCommands()
+	return rc.banCommand(player, reason, cmds.tempBanClient, cmds.tempBanUser, strconv.Itoa(minutes)+"m", opts)
+}
+
+// unban sends the unban command
+func (rc *RCONClient) unban(guid string) error {
+	_, err := rc.SendCommand(rc.banCommands().unban, &guid)
+	return err
+}
+
+// isClientNum reports whether player names a slot rather than a player
+func isClientNum(player string) bool {
+	_, err := strconv.Atoi(strings.TrimSpace(player))
+	return err == nil
+}
+
+// Get the server's ban list
+func (rc *RCONClient) BanList() ([]BanEntry, error) {
+	res, err := rc.SendCommand(rc.banCommands().banList, nil, RequireResponse(), longReply(time.Second))
+	if err != nil {
+		return nil, err
+	}
+	return parseBanList(res), nil
+}
+
+// banCommands returns the ban command set for the client
+func (rc *RCONClient) banCommands() banCommands {
+	return rc.profile().bans
+}
+
+// banCommand picks the slot or name variant of a ban command and sends it
+func (rc *RCONClient) banCommand(player, reason, clientCmd, userCmd, duration string, opts []CommandOption) error {
+	player = strings.TrimSpace(player)
+	if player == "" {
+		return fmt.Errorf("player cannot be empty")
+	}
+	if err := rc.verifyTarget(player, opts); err != nil {
+		return err
+	}
+
+	cmd := userCmd
+	target := player
+	if _, err := strconv.Atoi(player); err == nil {
+		cmd = clientCmd
+	} else if strings.ContainsAny(player, " \t") {
+		target = fmt.Sprintf("%q", player)
+	}
+
+	arg := target
+	if duration != "" {
+		arg += " " + duration
+	}
+	if reason != "" {
+		arg += fmt.Sprintf(" %q", reason)
+	}
+	_, err := rc.SendCommand(cmd, &arg, opts...)
+	return err
+}
+
+var (
+	banGUIDRx = regexp.MustCompile(`^[0-9a-fA-F]{8,32}$`)
+	banNameRx = regexp.MustCompile(`"([^"]*)"`)
+)
+
+// parseBanList parses banlist lines of the form: <guid> [ip] [expires] "<name>" [reason...]
+func parseBanList(lines []string) []BanEntry {
+	var out []BanEntry
+	for _, line := range lines {
+		clean := strings.TrimSpace(stripColorCodes(line))
+		var entry BanEntry
+		if m := banNameRx.FindStringSubmatchIndex(clean); m != nil {
+			entry.Name = clean[m[2]:m[3]]
+			entry.Reason = strings.TrimSpace(clean[m[1]:])
+			clean = clean[:m[0]]
+		}
+
+		// the GUID is the first column; a hex-looking token further on, such as a unix
+		// expiry, is not one
+		fields := strings.Fields(clean)
+		if len(fields) == 0 || !banGUIDRx.MatchString(fields[0]) {
+			continue
+		}
+		entry.GUID = strings.ToLower(fields[0])
+		for _, tok := range fields[1:] {
+			switch {
+			case entry.IP == "" && isIP(tok):
+				entry.IP = tok
+			case entry.Expires.IsZero():
+				if t, ok := parseBanExpiry(tok); ok {
+					entry.Expires = t
+				}
+			}
+		}
+		entry.Reason = strings.Trim(entry.Reason, `" `)
+		out = append(out, entry)
+	}
+	return out
+}
+
+// isIP reports whether a token is an IP address, with or without a port, or its hash
+func isIP(tok string) bool {
+	if IsHashedIP(tok) {
+		return true
+	}
+	if _, err := netip.ParseAddr(tok); err == nil {
+		return true
+	}
+	_, err := netip.ParseAddrPort(tok)
+	return err == nil
+}
+
+// parseBanExpiry reads an expiry as RFC3339, a date, or a unix timestamp
+func parseBanExpiry(tok string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.Parse(layout, tok); err == nil {
+			return t, true
+		}
+	}
+	if n, err := strconv.ParseInt(tok, 10, 64); err == nil && n > 1_000_000_000 {
+		return time.Unix(n, 0), true
+	}
+	return time.Time{}, false
+}
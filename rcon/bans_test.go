@@ -0,0 +1,79 @@
+package rcon
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseBanList(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []BanEntry
+	}{
+		{
+			name:  "guid and name",
+			lines: []string{`0110000100000001 "^1Bad^7Guy"`},
+			want:  []BanEntry{{GUID: "0110000100000001", Name: "BadGuy"}},
+		},
+		{
+			name:  "ip, expiry and reason",
+			lines: []string{`ABCDEF0123456789 203.0.113.5 2030-01-02 "Cheater" wallhack "again"`},
+			want: []BanEntry{{GUID: "abcdef0123456789", IP: "203.0.113.5", Name: "Cheater", Reason: `wallhack "again`,
+				Expires: time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name:  "hashed ip and unix expiry",
+			lines: []string{`0110000100000001 ip-0123456789abcdef 1893456000 "Name"`},
+			want:  []BanEntry{{GUID: "0110000100000001", IP: "ip-0123456789abcdef", Name: "Name", Expires: time.Unix(1893456000, 0)}},
+		},
+		{
+			name:  "no guid column",
+			lines: []string{`203.0.113.5 1893456000 "Name"`, `"Other" 0110000100000001`},
+		},
+		{
+			name:  "header and blank lines skipped",
+			lines: []string{"Banned players:", "", `0110000100000001 "A"`},
+			want:  []BanEntry{{GUID: "0110000100000001", Name: "A"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseBanList(tt.lines)
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseBanList = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Expires.Equal(tt.want[i].Expires) {
+					t.Errorf("entry %d expires %v, want %v", i, got[i].Expires, tt.want[i].Expires)
+				}
+				got[i].Expires, tt.want[i].Expires = time.Time{}, time.Time{}
+				if !reflect.DeepEqual(got[i], tt.want[i]) {
+					t.Errorf("entry %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseBanExpiry(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+		ok   bool
+	}{
+		{"2030-01-02T03:04:05Z", time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC), true},
+		{"2030-01-02T03:04", time.Date(2030, 1, 2, 3, 4, 0, 0, time.UTC), true},
+		{"2030-01-02", time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC), true},
+		{"1893456000", time.Unix(1893456000, 0), true},
+		{"12345", time.Time{}, false},
+		{"never", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseBanExpiry(tt.in)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("parseBanExpiry(%q) = %v, %t, want %v, %t", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
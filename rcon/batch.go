@@ -0,0 +1,60 @@
+package rcon
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// BatchResult is the outcome of one command of a batch
+type BatchResult struct {
+	Index int `json:"index"`
+	// Line is the source line for script commands
+	Line     int      `json:"line,omitempty"`
+	Command  string   `json:"command"`
+	Response []string `json:"response"`
+	Err      error    `json:"-"`
+	// Truncated is set when the reply looked cut off
+	Truncated bool          `json:"truncated"`
+	Took      time.Duration `json:"-"`
+}
+
+// MarshalJSON reports Err as its message and Took in milliseconds
+func (r BatchResult) MarshalJSON() ([]byte, error) {
+	type plain BatchResult
+	return json.Marshal(struct {
+		plain
+		Err  *string `json:"error,omitempty"`
+		Took float64 `json:"took_ms"`
+	}{plain(r), errString(r.Err), float64(r.Took) / float64(time.Millisecond)})
+}
+
+// Send console command lines one after another. each, when set, sees every result as it
+// arrives and stops the batch by returning an error, which SendBatch then returns
+func (rc *RCONClient) SendBatch(lines []string, each func(BatchResult) error) ([]BatchResult, error) {
+	results := make([]BatchResult, 0, len(lines))
+	for i, line := range lines {
+		res := rc.sendLine(i, line)
+		results = append(results, res)
+		if each != nil {
+			if err := each(res); err != nil {
+				return results, err
+			}
+		}
+	}
+	return results, nil
+}
+
+// sendLine splits a console line into command and arguments and sends it
+func (rc *RCONClient) sendLine(i int, line string) BatchResult {
+	line = strings.TrimSpace(line)
+	cmd, args, _ := strings.Cut(line, " ")
+	var argp *string
+	if args = strings.TrimSpace(args); args != "" {
+		argp = &args
+	}
+	start := time.Now()
+	var truncated bool
+	resp, err := rc.SendCommand(cmd, argp, WithTruncatedFlag(&truncated))
+	return BatchResult{Index: i, Command: line, Response: resp, Err: err, Truncated: truncated, Took: time.Since(start)}
+}
@@ -0,0 +1,296 @@
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	beLogin   = 0x00
+	beCommand = 0x01
+	beMessage = 0x02
+
+	beKeepAlive = 30 * time.Second
+	// bePending is how long a command waits for its reply before its sequence number
+	// stops being accepted; far past any command timeout
+	bePending = time.Minute
+)
+
+// ErrBattlEyeAuth is returned when a BattlEye RCon server rejects the password
+var ErrBattlEyeAuth = errors.New("battleye rcon authentication failed")
+
+// BattlEyeCodec speaks the BattlEye RCon UDP scheme: CRC-checked packets carrying a
+// one-byte sequence number, replies split into numbered parts, and server messages the
+// client has to acknowledge. Replies to sequence numbers it is not waiting on are
+// dropped, so a late answer never leaks into the next command's reply
+type BattlEyeCodec struct {
+	mu      sync.Mutex
+	seq     byte
+	pending map[byte]time.Time
+	parts   map[byte][][]byte
+}
+
+// NewBattlEyeCodec returns a codec starting at sequence number 0
+func NewBattlEyeCodec() *BattlEyeCodec {
+	return &BattlEyeCodec{pending: map[byte]time.Time{}, parts: map[byte][][]byte{}}
+}
+
+// EncodeCommand implements Codec. The password is sent once at login, not per command
+func (c *BattlEyeCodec) EncodeCommand(_, cmdline string) []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for seq, at := range c.pending {
+		if now.Sub(at) > bePending {
+			delete(c.pending, seq)
+			delete(c.parts, seq)
+		}
+	}
+	seq := c.nextLocked()
+	c.pending[seq] = now
+	return bePacket(beCommand, append([]byte{seq}, cmdline...))
+}
+
+// EncodeQuery implements Codec; BattlEye has no connectionless queries
+func (c *BattlEyeCodec) EncodeQuery(string) []byte { return nil }
+
+// Decode implements Codec. Command replies come back as "print" frames once all their
+// parts arrived, server messages as "message" frames and login results as "login"
+func (c *BattlEyeCodec) Decode(dgram []byte) ([]Frame, error) {
+	typ, body, err := beParse(dgram)
+	if err != nil {
+		return nil, err
+	}
+	switch typ {
+	case beLogin:
+		return []Frame{{Kind: "login", Body: body}}, nil
+	case beMessage:
+		if len(body) == 0 {
+			return nil, fmt.Errorf("battleye message without sequence number")
+		}
+		return []Frame{{Kind: "message", Body: body[1:]}}, nil
+	case beCommand:
+		if len(body) == 0 {
+			return nil, fmt.Errorf("battleye reply without sequence number")
+		}
+		return c.reply(body[0], body[1:]), nil
+	}
+	return nil, fmt.Errorf("unknown battleye packet type %#x", typ)
+}
+
+// reply collects the parts of a command reply and returns it once complete
+func (c *BattlEyeCodec) reply(seq byte, body []byte) []Frame {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.pending[seq]; !ok {
+		return []Frame{{Kind: "stale"}}
+	}
+
+	if len(body) >= 3 && body[0] == 0x00 {
+		total, index := int(body[1]), int(body[2])
+		parts := c.parts[seq]
+		if parts == nil {
+			parts = make([][]byte, total)
+			c.parts[seq] = parts
+		}
+		if index >= len(parts) {
+			return nil
+		}
+		parts[index] = append([]byte(nil), body[3:]...)
+		for _, p := range parts {
+			if p == nil {
+				return nil
+			}
+		}
+		body = bytes.Join(parts, nil)
+		delete(c.parts, seq)
+	}
+	delete(c.pending, seq)
+
+	// Replies don't end in a newline; add one so they aren't mistaken for cut-off prints
+	if len(body) > 0 && body[len(body)-1] != '\n' {
+		body = append(body, '\n')
+	}
+	return []Frame{{Kind: "print", Body: body}}
+}
+
+// login builds the login packet
+func (c *BattlEyeCodec) login(password string) []byte {
+	return bePacket(beLogin, []byte(password))
+}
+
+// keepAlive builds an empty command whose reply is dropped as stale
+func (c *BattlEyeCodec) keepAlive() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return bePacket(beCommand, []byte{c.nextLocked()})
+}
+
+// ack returns the acknowledgement a server message datagram needs, or nil
+func (c *BattlEyeCodec) ack(dgram []byte) []byte {
+	typ, body, err := beParse(dgram)
+	if err != nil || typ != beMessage || len(body) == 0 {
+		return nil
+	}
+	return bePacket(beMessage, body[:1])
+}
+
+// nextLocked returns the next sequence number, dropping what is left of its last use
+// (a command that timed out 256 sends ago); callers hold c.mu
+func (c *BattlEyeCodec) nextLocked() byte {
+	seq := c.seq
+	c.seq++
+	delete(c.pending, seq)
+	delete(c.parts, seq)
+	return seq
+}
+
+// bePacket frames a payload: "BE", CRC32 of the rest, 0xFF, type, payload
+func bePacket(typ byte, payload []byte) []byte {
+	rest := append([]byte{0xFF, typ}, payload...)
+	pkt := make([]byte, 6, 6+len(rest))
+	pkt[0], pkt[1] = 'B', 'E'
+	binary.LittleEndian.PutUint32(pkt[2:6], crc32.ChecksumIEEE(rest))
+	return append(pkt, rest...)
+}
+
+// beParse checks a packet's header and checksum and returns its type and payload
+func beParse(pkt []byte) (byte, []byte, error) {
+	if len(pkt) < 8 || pkt[0] != 'B' || pkt[1] != 'E' || pkt[6] != 0xFF {
+		return 0, nil, fmt.Errorf("not a battleye packet")
+	}
+	if crc32.ChecksumIEEE(pkt[6:]) != binary.LittleEndian.Uint32(pkt[2:6]) {
+		return 0, nil, fmt.Errorf("battleye packet checksum mismatch")
+	}
+	return pkt[7], pkt[8:], nil
+}
+
+// BattlEyeTransport is a logged-in BattlEye RCon UDP connection. It acknowledges server
+// messages as they are read and keeps the session alive while idle
+type BattlEyeTransport struct {
+	conn  *net.UDPConn
+	codec *BattlEyeCodec
+
+	mu        sync.Mutex
+	lastWrite time.Time
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// WithBattlEye talks BattlEye RCon instead of Quake3 OOB, logging in with password on every dial
+func WithBattlEye(password string, timeout time.Duration) Option {
+	return func(rc *RCONClient) {
+		codec := NewBattlEyeCodec()
+		rc.codec = codec
+		rc.dialer = func(ip string, port int) (Transport, error) {
+			return DialBattlEye(net.JoinHostPort(ip, strconv.Itoa(port)), password, timeout, codec)
+		}
+	}
+}
+
+// DialBattlEye connects and logs in to a BattlEye RCon server. A nil codec gets a new one
+func DialBattlEye(addr, password string, timeout time.Duration, codec *BattlEyeCodec) (*BattlEyeTransport, error) {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	if codec == nil {
+		codec = NewBattlEyeCodec()
+	}
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, errors.New("failed to resolve UDP address")
+	}
+	conn, err := net.DialUDP("udp", nil, raddr)
+	if err != nil {
+		return nil, errors.New("failed to establish UDP connection")
+	}
+	t := &BattlEyeTransport{conn: conn, codec: codec, done: make(chan struct{})}
+	if err := t.login(password, timeout); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go t.keepAlive()
+	return t, nil
+}
+
+// login sends the password and waits for the login result
+func (t *BattlEyeTransport) login(password string, timeout time.Duration) error {
+	if _, err := t.Write(t.codec.login(password)); err != nil {
+		return err
+	}
+	t.conn.SetReadDeadline(time.Now().Add(timeout))
+	defer t.conn.SetReadDeadline(time.Time{})
+	buf := make([]byte, 64)
+	for {
+		n, err := t.conn.Read(buf)
+		if err != nil {
+			return err
+		}
+		frames, err := t.codec.Decode(buf[:n])
+		if err != nil || len(frames) == 0 || frames[0].Kind != "login" {
+			continue
+		}
+		if len(frames[0].Body) == 0 || frames[0].Body[0] != 0x01 {
+			return ErrBattlEyeAuth
+		}
+		return nil
+	}
+}
+
+// keepAlive sends an empty command whenever nothing was written for a while, since
+// servers drop sessions that stay quiet for 45 seconds
+func (t *BattlEyeTransport) keepAlive() {
+	tick := time.NewTicker(beKeepAlive / 3)
+	defer tick.Stop()
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-tick.C:
+			t.mu.Lock()
+			idle := time.Since(t.lastWrite) >= beKeepAlive
+			t.mu.Unlock()
+			if idle {
+				t.Write(t.codec.keepAlive())
+			}
+		}
+	}
+}
+
+// Codec returns the codec whose sequence numbers this connection uses
+func (t *BattlEyeTransport) Codec() *BattlEyeCodec { return t.codec }
+
+// Write implements Transport
+func (t *BattlEyeTransport) Write(b []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastWrite = time.Now()
+	return t.conn.Write(b)
+}
+
+// Read implements Transport, acknowledging server messages before returning them
+func (t *BattlEyeTransport) Read(b []byte) (int, error) {
+	n, err := t.conn.Read(b)
+	if err == nil {
+		if ack := t.codec.ack(b[:n]); ack != nil {
+			t.Write(ack)
+		}
+	}
+	return n, err
+}
+
+// Close implements Transport
+func (t *BattlEyeTransport) Close() error {
+	t.closeOnce.Do(func() { close(t.done) })
+	return t.conn.Close()
+}
+
+// SetReadDeadline implements Transport
+func (t *BattlEyeTransport) SetReadDeadline(d time.Time) error { return t.conn.SetReadDeadline(d) }
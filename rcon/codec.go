@@ -0,0 +1,52 @@
+package rcon
+
+import "github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+
+// Frame is one reply unit a Codec decoded from a datagram
+type Frame = wire.Frame
+
+// Codec converts commands to datagrams and datagrams back to reply frames, so a new
+// wire dialect only needs a Codec (and possibly a Transport) instead of changes to SendCommand
+type Codec interface {
+	// EncodeCommand builds the datagram for an authenticated command line
+	EncodeCommand(password, cmdline string) []byte
+	// EncodeQuery builds the datagram for a connectionless request such as getinfo,
+	// or returns nil if the dialect has none
+	EncodeQuery(request string) []byte
+	// Decode splits a received datagram into frames. Frames whose Kind does not match the
+	// expected reply ("print", "infoResponse", ...) are dropped; an empty Kind always matches
+	Decode(dgram []byte) ([]Frame, error)
+}
+
+// QuakeCodec is the default Quake3 out-of-band dialect spoken by Plutonium servers
+type QuakeCodec struct{}
+
+// EncodeCommand implements Codec
+func (QuakeCodec) EncodeCommand(password, cmdline string) []byte {
+	return wire.Packet("rcon " + password + " " + cmdline)
+}
+
+// EncodeQuery implements Codec
+func (QuakeCodec) EncodeQuery(request string) []byte {
+	return wire.Packet(request)
+}
+
+// Decode implements Codec
+func (QuakeCodec) Decode(dgram []byte) ([]Frame, error) {
+	return wire.DecodeOOB(dgram)
+}
+
+// WithCodec replaces the Quake3 OOB wire format
+func WithCodec(c Codec) Option {
+	return func(rc *RCONClient) {
+		rc.codec = c
+	}
+}
+
+// codecOrDefault returns the client's codec, QuakeCodec if none was set
+func (rc *RCONClient) codecOrDefault() Codec {
+	if rc.codec == nil {
+		return QuakeCodec{}
+	}
+	return rc.codec
+}
@@ -0,0 +1,155 @@
+package rcon
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestQuakeCodec(t *testing.T) {
+	var c QuakeCodec
+	if got := string(c.EncodeCommand("secret", "status")); got != "\xFF\xFF\xFF\xFFrcon secret status\n" {
+		t.Errorf("EncodeCommand = %q", got)
+	}
+	if got := string(c.EncodeQuery("getinfo xyz")); got != "\xFF\xFF\xFF\xFFgetinfo xyz\n" {
+		t.Errorf("EncodeQuery = %q", got)
+	}
+	frames, err := c.Decode([]byte("\xFF\xFF\xFF\xFFprint\nmap: mp_raid\n"))
+	if err != nil || len(frames) != 1 || frames[0].Kind != "print" || string(frames[0].Body) != "map: mp_raid\n" {
+		t.Errorf("Decode = %+v, %v", frames, err)
+	}
+}
+
+func TestBattlEyePacket(t *testing.T) {
+	pkt := bePacket(beCommand, []byte("\x05players"))
+	typ, body, err := beParse(pkt)
+	if err != nil || typ != beCommand || string(body) != "\x05players" {
+		t.Fatalf("beParse = %#x, %q, %v", typ, body, err)
+	}
+
+	bad := append([]byte(nil), pkt...)
+	bad[len(bad)-1] ^= 0xFF
+	for name, p := range map[string][]byte{
+		"checksum":  bad,
+		"short":     pkt[:7],
+		"no marker": append([]byte("BX"), pkt[2:]...),
+	} {
+		if _, _, err := beParse(p); err == nil {
+			t.Errorf("%s: beParse accepted a broken packet", name)
+		}
+	}
+}
+
+// serverReply builds a command reply the way a BattlEye server sends it
+func serverReply(seq byte, body string) []byte {
+	return bePacket(beCommand, append([]byte{seq}, body...))
+}
+
+// serverPart builds one part of a reply split into total parts
+func serverPart(seq, total, index byte, body string) []byte {
+	return bePacket(beCommand, append([]byte{seq, 0x00, total, index}, body...))
+}
+
+func TestBattlEyeCodecReplies(t *testing.T) {
+	tests := []struct {
+		name   string
+		dgrams func(seq byte) [][]byte
+		want   []string
+	}{
+		{
+			name:   "single reply",
+			dgrams: func(seq byte) [][]byte { return [][]byte{serverReply(seq, "Players on server")} },
+			want:   []string{"print:Players on server\n"},
+		},
+		{
+			name: "parts out of order",
+			dgrams: func(seq byte) [][]byte {
+				return [][]byte{serverPart(seq, 3, 2, "c\n"), serverPart(seq, 3, 0, "a"), serverPart(seq, 3, 1, "b")}
+			},
+			want: []string{"print:abc\n"},
+		},
+		{
+			name:   "reply to a sequence nobody waits on",
+			dgrams: func(seq byte) [][]byte { return [][]byte{serverReply(seq+1, "late"), serverReply(seq, "ok")} },
+			want:   []string{"stale:", "print:ok\n"},
+		},
+		{
+			name: "server message",
+			dgrams: func(seq byte) [][]byte {
+				return [][]byte{bePacket(beMessage, []byte("\x07hello")), serverReply(seq, "ok")}
+			},
+			want: []string{"message:hello", "print:ok\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewBattlEyeCodec()
+			cmd := c.EncodeCommand("ignored", "players")
+			_, body, err := beParse(cmd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(body[1:], []byte("players")) {
+				t.Errorf("command payload %q", body)
+			}
+			var got []string
+			for _, d := range tt.dgrams(body[0]) {
+				frames, err := c.Decode(d)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, f := range frames {
+					got = append(got, f.Kind+":"+string(f.Body))
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("frames %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("frame %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBattlEyeAck(t *testing.T) {
+	c := NewBattlEyeCodec()
+	ack := c.ack(bePacket(beMessage, []byte("\x09hello")))
+	typ, body, err := beParse(ack)
+	if err != nil || typ != beMessage || !bytes.Equal(body, []byte{0x09}) {
+		t.Errorf("ack = %#x %q %v", typ, body, err)
+	}
+	if c.ack(serverReply(1, "x")) != nil {
+		t.Error("a command reply was acknowledged")
+	}
+}
+
+func TestBattlEyeCodecForgetsUnanswered(t *testing.T) {
+	c := NewBattlEyeCodec()
+	_, body, _ := beParse(c.EncodeCommand("", "players"))
+	old := body[0]
+	c.Decode(serverPart(old, 2, 0, "a"))
+
+	// the sequence number comes round again for a keep-alive
+	for range 256 {
+		c.keepAlive()
+	}
+	if frames, _ := c.Decode(serverReply(old, "late")); len(frames) != 1 || frames[0].Kind != "stale" {
+		t.Errorf("reply to a reused sequence number = %+v, want stale", frames)
+	}
+
+	// a command nobody answered is dropped once it is too old
+	_, body, _ = beParse(c.EncodeCommand("", "players"))
+	c.mu.Lock()
+	c.pending[body[0]] = time.Now().Add(-2 * bePending)
+	c.mu.Unlock()
+	c.EncodeCommand("", "bans")
+	c.mu.Lock()
+	n := len(c.pending)
+	c.mu.Unlock()
+	if n != 1 {
+		t.Errorf("%d commands pending, want 1", n)
+	}
+}
@@ -7,12 +7,24 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+	"github.com/Yallamaztar/PlutoRCON/v2/query"
 )
 
 // Send RCON command with optional arguments and settings
-func (rc *RCONClient) SendCommand(cmd string, args *string, opts ...commandOption) ([]string, error) {
-	if rc.Conn == nil {
-		return nil, fmt.Errorf("RCON connection is not established")
+func (rc *RCONClient) SendCommand(cmd string, args *string, opts ...CommandOption) ([]string, error) {
+	if rc == nil {
+		return nil, ErrNilClient
+	}
+	return rc.chain()(Command{Name: cmd, Args: args, opts: opts})
+}
+
+// send writes a command and reads its reply; it is the innermost CommandFunc
+func (rc *RCONClient) send(c Command) ([]string, error) {
+	cmd, args, opts := c.Name, c.Args, c.opts
+	if rc.observer {
+		return nil, ErrReadOnly
 	}
 
 	s := commandSettings{
@@ -20,37 +32,49 @@ func (rc *RCONClient) SendCommand(cmd string, args *string, opts ...commandOptio
 		readTimeout:    rc.timeoutOrDefault(),
 		readExtension:  defaultReadExtension,
 		requireSuccess: false,
+		backoff:        LinearBackoff(150 * time.Millisecond),
 	}
 
+	for _, opt := range rc.commandOpts {
+		opt(&s)
+	}
 	for _, opt := range opts {
 		opt(&s)
 	}
+	rc.learner.tune(cmd, &s)
 
-	var payload string
-	if args != nil && strings.TrimSpace(*args) != "" {
-		payload = fmt.Sprintf("rcon %s %s %s", rc.Password, strings.TrimSpace(cmd), strings.TrimSpace(*args))
-	} else {
-		payload = fmt.Sprintf("rcon %s %s", rc.Password, strings.TrimSpace(cmd))
-	}
-	packet := append([]byte{0xFF, 0xFF, 0xFF, 0xFF}, []byte(payload)...)
-	packet = append(packet, '\n')
+	packet := rc.commandPacket(cmd, args)
 
+	defer rc.gate.acquire(s.priority)()
+	rc.limiter.wait()
 	rc.mu.Lock()
 	defer rc.mu.Unlock()
+	if err := rc.readyLocked(); err != nil {
+		return nil, err
+	}
 
 	var lerr error
 	for i := 0; i <= s.retries; i++ {
-		if _, err := rc.Conn.Write(packet); err != nil {
+		sent := time.Now()
+		if _, err := rc.conn.Write(packet); err != nil {
 			lerr = err
 			if i < s.retries {
-				time.Sleep(time.Duration(i+1) * 150 * time.Millisecond)
+				time.Sleep(max(s.backoff(i), rc.limiter.reserve()))
 			}
 			continue
 		}
 
-		res, err := rc.readResponse(s.readTimeout, s.readExtension)
+		res, frames, err := rc.readFrames(s.readTimeout, s.readExtension, "print", rc.codecOrDefault().Decode)
+		if s.truncated != nil {
+			*s.truncated = frames != nil && frames.Truncated
+		}
+		// a reply after a resend may answer an earlier attempt, so only the first attempt is timed
+		if i == 0 && frames != nil && !frames.First.IsZero() {
+			rc.learner.observe(cmd, frames.First.Sub(sent), frames.MaxGap, frames.Truncated)
+		}
 		if len(res) > 0 {
-			return res, nil
+			rc.noteAliveLocked()
+			return rc.scrubIPs(cmd, res), nil
 		}
 
 		if err == nil {
@@ -62,13 +86,16 @@ func (rc *RCONClient) SendCommand(cmd string, args *string, opts ...commandOptio
 				if !s.requireSuccess {
 					return nil, nil
 				}
+				rc.noteTimeoutLocked()
+				rc.learner.missed(cmd)
 				lerr = err
 			} else {
 				return nil, err
 			}
 		}
 		if i < s.retries {
-			time.Sleep(time.Duration(i+1) * 150 * time.Millisecond)
+			// the resend's token is taken during the backoff already spent under the lock
+			time.Sleep(max(s.backoff(i), rc.limiter.reserve()))
 		}
 	}
 
@@ -82,13 +109,55 @@ func (rc *RCONClient) SendCommand(cmd string, args *string, opts ...commandOptio
 	return nil, lerr
 }
 
-// Server Status
-func (rc *RCONClient) Status() (*ServerStatus, error) {
-	res, err := rc.SendCommand("status", nil, requireResponse(), withReadExtension(1*time.Second))
+// commandPacket builds the rcon datagram for a command
+func (rc *RCONClient) commandPacket(cmd string, args *string) []byte {
+	line := strings.TrimSpace(cmd)
+	if args != nil && strings.TrimSpace(*args) != "" {
+		line += " " + strings.TrimSpace(*args)
+	}
+	return rc.codecOrDefault().EncodeCommand(rc.Password, line)
+}
+
+// Server Status, players ordered by ClientNum
+func (rc *RCONClient) Status(opts ...CommandOption) (*ServerStatus, error) {
+	var truncated bool
+	opts = append([]CommandOption{RequireResponse(), longReply(time.Second), WithTruncatedFlag(&truncated)}, opts...)
+	res, err := rc.SendCommand("status", nil, opts...)
 	if err != nil {
 		return nil, err
 	}
+	var st *ServerStatus
+	if p := rc.modParser(); p != nil && p.Status != nil {
+		st = p.Status(res, rc.game)
+	}
+	if st == nil {
+		st = parseStatus(res, rc.profile().StatusPattern)
+	}
+	if st.Raw == nil {
+		st.Raw = res
+	}
+	// mod parsers may read rows the status pattern didn't scrub
+	for i := range st.Players {
+		st.Players[i].IP = rc.ipPrivacy.Apply(st.Players[i].IP)
+	}
+	if st.RetrievedAt.IsZero() {
+		st.RetrievedAt = time.Now()
+	}
+	sortPlayers(st.Players, ByClientNum)
+	rc.noteMaps(st.Map)
+	rc.noteModMap(st.Map)
+	st.Truncated = truncated
+	return st, nil
+}
+
+// ParseStatus parses the lines of a status reply using a title's row format, players
+// ordered by ClientNum
+func ParseStatus(res []string, game Game) *ServerStatus {
+	return parseStatus(res, builtinProfile(game).StatusPattern)
+}
 
+// parseStatus parses a status reply whose player rows match pattern
+func parseStatus(res []string, pattern *regexp.Regexp) *ServerStatus {
 	status := &ServerStatus{Raw: res, RetrievedAt: time.Now()}
 
 	for _, line := range res {
@@ -115,18 +184,6 @@ func (rc *RCONClient) Status() (*ServerStatus, error) {
 	}
 
 	lines := res[start:]
-	pattern := regexp.MustCompile(
-		`(?P<num>\d+)\s+` +
-			`(?P<score>-?\d+)\s+` +
-			`(?P<bot>\w+)?\s*` +
-			`(?P<ping>\d+|LOAD)\s+` +
-			`(?P<guid>[0-9a-fA-F]+)\s+` +
-			`(?P<name>.+?)\s+` +
-			`(?P<lastmsg>\d+)\s+` +
-			`(?P<ipport>\S+)\s+` +
-			`(?P<qport>\d+)\s+` +
-			`(?P<rate>\d+)`,
-	)
 
 	var players []Player
 	for _, line := range lines {
@@ -155,18 +212,9 @@ func (rc *RCONClient) Status() (*ServerStatus, error) {
 		ip, portStr, _ := strings.Cut(ipport, ":")
 		port, _ := strconv.Atoi(portStr)
 
-		pingStr := group("ping")
-		var ping any = pingStr
-		if pingStr != "LOAD" {
-			if val, err := strconv.Atoi(pingStr); err == nil {
-				ping = val
-			}
-		}
-
 		player := Player{
 			ClientNum: atoi(group("num")),
 			Name:      group("name"),
-			Ping:      ping,
 			Score:     atoi(group("score")),
 			IP:        ip,
 			Port:      port,
@@ -175,42 +223,65 @@ func (rc *RCONClient) Status() (*ServerStatus, error) {
 			LastMsg:   atoi(group("lastmsg")),
 			Rate:      atoi(group("rate")),
 		}
+		switch ping := group("ping"); ping {
+		case "LOAD", "CNCT":
+			player.Loading = true
+		case "ZMBI":
+			player.Zombie = true
+		default:
+			player.Ping = atoi(ping)
+		}
 
 		players = append(players, player)
 	}
 
+	sortPlayers(players, ByClientNum)
 	status.Players = players
-	return status, nil
+	return status
 }
 
-// Say a message to all players
+// Say a message to all players, split into several packets when it is long
 func (rc *RCONClient) Say(message string) error {
 	if message == "" {
 		return fmt.Errorf("message cannot be empty")
 	}
-	_, err := rc.SendCommand("say", &message)
-	return err
+	for _, chunk := range rc.messageChunks(message) {
+		if _, err := rc.SendCommand(rc.profile().Say, &chunk); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// Tell a player a message
-func (rc *RCONClient) Tell(clientNum int, message string) error {
+// Tell a player a message, split into several packets when it is long. Options apply
+// to every packet; see WithTargetGUID
+func (rc *RCONClient) Tell(clientNum int, message string, opts ...CommandOption) error {
 	if message == "" {
 		return fmt.Errorf("message cannot be empty")
 	}
-
-	arg := fmt.Sprintf("%d %s", clientNum, message)
-	_, err := rc.SendCommand("tell", &arg)
-	return err
+	if err := rc.verifyTarget(strconv.Itoa(clientNum), opts); err != nil {
+		return err
+	}
+	for _, chunk := range rc.messageChunks(message) {
+		arg := fmt.Sprintf("%d %s", clientNum, chunk)
+		if _, err := rc.SendCommand(rc.profile().Tell, &arg, opts...); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// Kick a player with reason
-func (rc *RCONClient) Kick(player, reason string) error {
+// Kick a player with reason; see WithTargetGUID
+func (rc *RCONClient) Kick(player, reason string, opts ...CommandOption) error {
 	if player == "" || reason == "" {
 		return fmt.Errorf("player and reason cannot be empty")
 	}
+	if err := rc.verifyTarget(player, opts); err != nil {
+		return err
+	}
 
-	cmd := fmt.Sprintf("%s '%s'", player, reason)
-	_, err := rc.SendCommand("clientkick_for_reason", &cmd)
+	cmd, args, _ := strings.Cut(fmt.Sprintf(rc.profile().Kick, player, reason), " ")
+	_, err := rc.SendCommand(cmd, &args, opts...)
 	return err
 }
 
@@ -219,14 +290,33 @@ func (rc *RCONClient) SetDvar(dvar, value string) error {
 	if dvar == "" || value == "" {
 		return fmt.Errorf("dvar and value cannot be empty")
 	}
+	return rc.offlineOr(OfflineAction{Kind: ActionSetDvar, Target: dvar, Value: value}, func() error {
+		_, err := rc.setDvar(dvar, value)
+		return err
+	})
+}
+
+// setDvar sends the set command and returns the server's reply
+func (rc *RCONClient) setDvar(dvar, value string) ([]string, error) {
+	if dvar == "" || value == "" {
+		return nil, fmt.Errorf("dvar and value cannot be empty")
+	}
 
+	raw := value
 	if strings.ContainsAny(value, " \t\"") {
 		value = fmt.Sprintf("\"%s\"", strings.ReplaceAll(value, "\"", "\\\""))
 	}
 
 	cmd := fmt.Sprintf("%s %s", dvar, value)
-	_, err := rc.SendCommand("set", &cmd)
-	return err
+	res, err := rc.SendCommand("set", &cmd)
+	// forgotten after the send, so a read racing with it can't cache the old value; on
+	// an error the set may still have reached the server
+	rc.forgetDvar(dvar)
+	if err == nil {
+		// later offline changes are checked against this value, not the one read before it
+		rc.offline.saw(dvar, raw)
+	}
+	return res, err
 }
 
 // Get dvar value
@@ -234,15 +324,16 @@ func (rc *RCONClient) GetDvar(dvar string) (string, error) {
 	if dvar == "" {
 		return "", fmt.Errorf("dvar cannot be empty")
 	}
+	if val, ok := rc.cachedDvar(dvar); ok {
+		return val, nil
+	}
 
-	name := regexp.QuoteMeta(strings.TrimSpace(dvar))
-	rx1 := regexp.MustCompile(fmt.Sprintf(`(?i)^%s\s+is:\s+"?(?P<val>.*?)"?(?:\s|$)`, name))
-	rx2 := regexp.MustCompile(fmt.Sprintf(`(?i)^%s\s*[:=]\s*"?(?P<val>.*?)"?$`, name))
+	patterns := dvarPatterns(dvar)
 
 	const maxAttempts = 3
 	var lastClean string
 	for attempt := 0; attempt < maxAttempts; attempt++ {
-		res, err := rc.SendCommand(dvar, nil, requireResponse())
+		res, err := rc.SendCommand(dvar, nil, RequireResponse())
 		if err != nil {
 			return "", err
 		}
@@ -252,19 +343,9 @@ func (rc *RCONClient) GetDvar(dvar string) (string, error) {
 			if clean == "" {
 				continue
 			}
-			if m := rx1.FindStringSubmatch(clean); m != nil {
-				for i, n := range rx1.SubexpNames() {
-					if n == "val" {
-						return stripColorCodes(m[i]), nil
-					}
-				}
-			}
-			if m := rx2.FindStringSubmatch(clean); m != nil {
-				for i, n := range rx2.SubexpNames() {
-					if n == "val" {
-						return stripColorCodes(m[i]), nil
-					}
-				}
+			if val, ok := matchDvar(clean, patterns); ok {
+				rc.cacheDvar(dvar, val)
+				return val, nil
 			}
 			if !strings.Contains(strings.ToLower(clean), "sv_iw4madmin_in") {
 				if lastClean == "" {
@@ -292,177 +373,98 @@ func (rc *RCONClient) GetDvar(dvar string) (string, error) {
 }
 
 // Get Server Info
-func (rc *RCONClient) GetInfo() (*ServerInfo, error) {
-	if rc.Conn == nil {
-		return nil, fmt.Errorf("RCON connection is not established")
-	}
-
-	packet := append([]byte{0xFF, 0xFF, 0xFF, 0xFF}, []byte("getinfo")...)
-	packet = append(packet, '\n')
-
-	rc.mu.Lock()
-	defer rc.mu.Unlock()
-
-	if _, err := rc.Conn.Write(packet); err != nil {
+func (rc *RCONClient) GetInfo(opts ...CommandOption) (*ServerInfo, error) {
+	lines, err := rc.query("getinfo", opts)
+	if err != nil {
 		return nil, err
 	}
-	lines, err := rc.readResponse(rc.timeoutOrDefault(), defaultReadExtension)
+	return rc.parseInfo(lines)
+}
+
+// Get Server Status
+func (rc *RCONClient) GetStatus(opts ...CommandOption) (*ServerStatusInfo, error) {
+	lines, err := rc.query("getstatus", opts)
 	if err != nil {
 		return nil, err
 	}
-	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty infoResponse")
-	}
+	return query.ParseStatusResponse(lines)
+}
 
-	var dataLine string
-	for _, l := range lines {
-		t := strings.TrimSpace(l)
-		if strings.EqualFold(t, "inforesponse") {
-			continue
-		}
-		if strings.HasPrefix(t, "\\") || strings.Contains(t, "\\") {
-			if dataLine == "" {
-				dataLine = t
-			} else {
-				dataLine += t
-			}
-		}
+// query sends a connectionless request over the client connection; of opts only
+// the priority applies
+func (rc *RCONClient) query(request string, opts []CommandOption) ([]string, error) {
+	if rc == nil {
+		return nil, ErrNilClient
 	}
-	if dataLine == "" {
-		dataLine = strings.TrimSpace(lines[len(lines)-1])
+	defer rc.gate.acquire(rc.priorityOf(opts))()
+	rc.limiter.wait()
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if err := rc.readyLocked(); err != nil {
+		return nil, err
 	}
 
-	parts := strings.Split(dataLine, "\\")
-	if len(parts) > 0 && parts[0] == "" {
-		parts = parts[1:]
+	codec := rc.codecOrDefault()
+	expect := wire.ReplyType(request)
+	decode := codec.Decode
+	var ch *wire.Challenge
+	if rc.queryChallenge {
+		ch = wire.NewChallenge()
+		decode = ch.Decode(decode)
 	}
-	kv := map[string]string{}
-	for i := 0; i < len(parts)-1; i += 2 {
-		key := strings.TrimSpace(parts[i])
-		val := strings.TrimSpace(parts[i+1])
-		if key == "" {
-			continue
-		}
-		kv[key] = stripColorCodes(val)
+	line := request
+	if ch != nil {
+		line = ch.Request(request)
 	}
-
-	info := &ServerInfo{RetrievedAt: time.Now()}
-
-	atoint64Safe := func(k string) int64 {
-		v, ok := kv[k]
-		if !ok {
-			return 0
-		}
-		n, _ := strconv.ParseInt(v, 10, 64)
-		return n
-	}
-
-	info.NetFieldChk = atoint64Safe("netfieldchk")
-	info.Protocol = atoi("protocol")
-	info.SessionMode = atoi("sessionmode")
-	info.Hostname = kv["hostname"]
-	info.MapName = kv["mapname"]
-	info.IsInGame = boolSafe("isInGame")
-	info.MaxClients = atoi("com_maxclients")
-	info.GameType = kv["gametype"]
-	info.HW = atoi("hw")
-	info.Mod = boolSafe("mod")
-	info.Voice = boolSafe("voice")
-	info.SecKey = kv["seckey"]
-	info.SecID = kv["secid"]
-	info.HostAddr = kv["hostaddr"]
-
-	return info, nil
-}
-
-// Get Server Status
-func (rc *RCONClient) GetStatus() (*ServerStatusInfo, error) {
-	if rc.Conn == nil {
-		return nil, fmt.Errorf("RCON connection is not established")
+	packet := codec.EncodeQuery(line)
+	if packet == nil {
+		return nil, fmt.Errorf("%s: %w", request, ErrUnsupported)
 	}
-
-	packet := append([]byte{0xFF, 0xFF, 0xFF, 0xFF}, []byte("getstatus")...)
-	packet = append(packet, '\n')
-
-	rc.mu.Lock()
-	defer rc.mu.Unlock()
-
-	if _, err := rc.Conn.Write(packet); err != nil {
+	s := commandSettings{readTimeout: rc.timeoutOrDefault(), readExtension: defaultReadExtension}
+	rc.learner.tune(request, &s)
+	sent := time.Now()
+	if _, err := rc.conn.Write(packet); err != nil {
 		return nil, err
 	}
-
-	lines, err := rc.readResponse(rc.timeoutOrDefault(), defaultReadExtension)
+	lines, res, err := rc.readFrames(s.readTimeout, s.readExtension, expect, decode)
 	if err != nil {
-		return nil, err
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			rc.noteTimeoutLocked()
+			rc.learner.missed(request)
+		}
+		return nil, ch.Err(err)
+	}
+	rc.noteAliveLocked()
+	if !res.First.IsZero() {
+		rc.learner.observe(request, res.First.Sub(sent), res.MaxGap, res.Truncated)
 	}
-	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty statusResponse")
+	if err := wire.Fresh(sent, res.First, rc.queryMaxAge); err != nil {
+		return nil, err
 	}
+	return lines, nil
+}
 
-	var dataLine string
-	for _, l := range lines {
-		t := strings.TrimSpace(l)
-		if strings.EqualFold(t, "statusresponse") {
-			continue
-		}
-		if strings.HasPrefix(t, "\\") || strings.Contains(t, "\\") {
-			if dataLine == "" {
-				dataLine = t
-			} else {
-				dataLine += t
-			}
-		}
+// Start a server-side demo recording
+func (rc *RCONClient) StartRecording(name string) error {
+	if err := rc.requireCapability(rc.profile().Capabilities.Recording, "demo recording"); err != nil {
+		return err
 	}
-	if dataLine == "" {
-		dataLine = strings.TrimSpace(lines[len(lines)-1])
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("recording name cannot be empty")
 	}
+	if strings.ContainsAny(name, " \t\"';") {
+		return fmt.Errorf("invalid recording name %q", name)
+	}
+	_, err := rc.SendCommand("record", &name)
+	return err
+}
 
-	parts := strings.Split(dataLine, "\\")
-	if len(parts) > 0 && parts[0] == "" {
-		parts = parts[1:]
+// Stop the current server-side demo recording
+func (rc *RCONClient) StopRecording() error {
+	if err := rc.requireCapability(rc.profile().Capabilities.Recording, "demo recording"); err != nil {
+		return err
 	}
-	kv := map[string]string{}
-	for i := 0; i < len(parts)-1; i += 2 {
-		k := strings.TrimSpace(parts[i])
-		v := strings.TrimSpace(parts[i+1])
-		if k == "" {
-			continue
-		}
-		kv[k] = stripColorCodes(v)
-	}
-
-	info := &ServerStatusInfo{RetrievedAt: time.Now()}
-
-	info.ComMaxClients = atoi("com_maxclients")
-	info.GameType = kv["g_gametype"]
-	info.RandomSeed = atoi("g_randomSeed")
-	info.GameName = kv["gamename"]
-	info.MapName = kv["mapname"]
-	info.PlaylistEnabled = boolSafe("playlist_enabled")
-	info.PlaylistEntry = atoi("playlist_entry")
-	info.Protocol = atoi("protocol")
-	info.ScrTeamFFType = atoi("scr_team_fftype")
-	info.ShortVersion = boolSafe("shortversion")
-	info.SvAllowAimAssist = boolSafe("sv_allowAimAssist")
-	info.SvAllowAnonymous = boolSafe("sv_allowAnonymous")
-	info.SvClientFpsLimit = atoi("sv_clientFpsLimit")
-	info.SvDisableClientConsole = boolSafe("sv_disableClientConsole")
-	info.SvHostname = kv["sv_hostname"]
-	info.SvMaxClients = atoi("sv_maxclients")
-	info.SvMaxPing = atoi("sv_maxPing")
-	info.SvMinPing = atoi("sv_minPing")
-	info.SvPatchDSR50 = boolSafe("sv_patch_dsr50")
-	info.SvPrivateClients = atoi("sv_privateClients")
-
-	if v, ok := kv["sv_privateClientsForClients"]; ok {
-		info.SvPrivateClientsForUsers, _ = strconv.Atoi(v)
-	} else {
-		info.SvPrivateClientsForUsers = atoi("sv_privateClientsForUsers")
-	}
-	info.SvPure = boolSafe("sv_pure")
-	info.SvVoice = boolSafe("sv_voice")
-	info.PasswordEnabled = boolSafe("pswrd")
-	info.ModEnabled = boolSafe("mod")
-
-	return info, nil
+	_, err := rc.SendCommand("stoprecord", nil)
+	return err
 }
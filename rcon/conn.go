@@ -0,0 +1,249 @@
+package rcon
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+type ConnState int
+
+const (
+	// StateUnknown is the state of a client that never connected
+	StateUnknown ConnState = iota
+	StateConnected
+	StateDegraded
+	StateReconnecting
+	StateDisconnected
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case StateUnknown:
+		return "unknown"
+	case StateConnected:
+		return "connected"
+	case StateDegraded:
+		return "degraded"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateDisconnected:
+		return "disconnected"
+	}
+	return "state(" + strconv.Itoa(int(s)) + ")"
+}
+
+// Option configures a client in New
+type Option func(*RCONClient)
+
+// WithAutoReconnect re-dials the socket after this many consecutive timeouts of commands that expect a reply
+func WithAutoReconnect(timeouts int) Option {
+	return func(rc *RCONClient) {
+		rc.reconnectAfter = timeouts
+	}
+}
+
+// WithReconnectBackoff sets the delay range and attempt count used by Reconnect (default 250ms-5s, 5 attempts)
+func WithReconnectBackoff(lo, hi time.Duration, attempts int) Option {
+	return func(rc *RCONClient) {
+		rc.backoffMin, rc.backoffMax, rc.reconnectAttempts = lo, hi, attempts
+	}
+}
+
+// WithStateHook is called whenever the connection state changes. It runs while the client
+// is locked, so it must not call back into the client
+func WithStateHook(fn func(old, new ConnState)) Option {
+	return func(rc *RCONClient) {
+		rc.onState = fn
+	}
+}
+
+// State returns the last known connection state
+func (rc *RCONClient) State() ConnState {
+	if rc == nil {
+		return StateDisconnected
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.state
+}
+
+// Connect (re)opens the UDP socket to the configured server
+func (rc *RCONClient) Connect() error {
+	if rc == nil {
+		return ErrNilClient
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.connectLocked()
+}
+
+// Reconnect closes the socket and dials again, backing off between failed attempts.
+// It returns ErrClosed once Close was called, leaving the client closed
+func (rc *RCONClient) Reconnect() error {
+	if rc == nil {
+		return ErrNilClient
+	}
+	return rc.redial()
+}
+
+// IsAlive pings the server with getinfo, at poll priority
+func (rc *RCONClient) IsAlive() bool {
+	_, err := rc.GetInfo(WithPriority(PriorityPoll))
+	return err == nil
+}
+
+// Probe pings the server once and, if it doesn't answer and auto reconnect is enabled,
+// redials and pings it again. It returns nil once the server answers
+func (rc *RCONClient) Probe() error {
+	if rc == nil {
+		return ErrNilClient
+	}
+	_, err := rc.GetInfo(WithPriority(PriorityPoll))
+	if err != nil && rc.reconnectAfter > 0 {
+		if err = rc.Reconnect(); err == nil {
+			_, err = rc.GetInfo(WithPriority(PriorityPoll))
+		}
+	}
+	return err
+}
+
+// HealthCheck pings the server every interval until ctx is done, reconnecting when it
+// stops answering if auto reconnect is enabled. It returns ctx.Err() once ctx is done, or
+// the error of a reconnect that failed after all attempts, leaving the client disconnected
+func (rc *RCONClient) HealthCheck(ctx context.Context, interval time.Duration) error {
+	if rc == nil {
+		return ErrNilClient
+	}
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			if rc.IsAlive() {
+				continue
+			}
+			if rc.reconnectAfter > 0 {
+				if err := rc.Reconnect(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// connectLocked opens the transport; callers hold rc.mu
+func (rc *RCONClient) connectLocked() error {
+	dialer := rc.dialer
+	if dialer == nil {
+		dialer = DialUDP
+	}
+	conn, err := dialer(rc.IP, rc.Port)
+	if err != nil {
+		return err
+	}
+	if rc.conn != nil {
+		if c, ok := rc.conn.(rejectCounter); ok {
+			rc.rejectedBase += c.Rejected()
+		}
+		rc.conn.Close()
+	}
+	rc.conn = conn
+	rc.closed = false
+	rc.timeouts = 0
+	rc.setStateLocked(StateConnected)
+	return nil
+}
+
+// redial reconnects with exponential backoff. rc.mu is only held for each dial, so
+// commands and State aren't blocked while it waits, and it doesn't wait after the last attempt
+func (rc *RCONClient) redial() error {
+	lo, hi, attempts := rc.backoffMin, rc.backoffMax, rc.reconnectAttempts
+	if lo <= 0 {
+		lo = 250 * time.Millisecond
+	}
+	if hi < lo {
+		hi = 5 * time.Second
+	}
+	if attempts <= 0 {
+		attempts = 5
+	}
+
+	rc.mu.Lock()
+	if rc.closed {
+		rc.mu.Unlock()
+		return ErrClosed
+	}
+	rc.setStateLocked(StateReconnecting)
+	rc.mu.Unlock()
+	delay := lo
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			time.Sleep(delay)
+			delay = min(delay*2, hi)
+		}
+		rc.mu.Lock()
+		// Close during the backoff ends the redial instead of reopening the client
+		if rc.closed {
+			rc.mu.Unlock()
+			return ErrClosed
+		}
+		if err = rc.connectLocked(); err == nil {
+			rc.mu.Unlock()
+			return nil
+		}
+		if i == attempts-1 {
+			rc.setStateLocked(StateDisconnected)
+		}
+		rc.mu.Unlock()
+	}
+	return fmt.Errorf("reconnect failed after %d attempts: %w", attempts, err)
+}
+
+// noteAliveLocked records a successful reply; callers hold rc.mu
+func (rc *RCONClient) noteAliveLocked() {
+	rc.timeouts = 0
+	rc.setStateLocked(StateConnected)
+}
+
+// noteTimeoutLocked records a timed out reply and re-dials once the threshold is hit: once
+// right away, then with backoff in the background if that fails; callers hold rc.mu
+func (rc *RCONClient) noteTimeoutLocked() {
+	rc.timeouts++
+	rc.setStateLocked(StateDegraded)
+	if rc.reconnectAfter <= 0 || rc.timeouts < rc.reconnectAfter || rc.redialing {
+		return
+	}
+	if rc.connectLocked() == nil {
+		return
+	}
+	rc.redialing = true
+	go func() {
+		rc.redial()
+		rc.mu.Lock()
+		rc.redialing = false
+		rc.mu.Unlock()
+	}()
+}
+
+// setStateLocked changes the state and fires the hook; callers hold rc.mu
+func (rc *RCONClient) setStateLocked(s ConnState) {
+	if rc.state == s {
+		return
+	}
+	old := rc.state
+	rc.state = s
+	if rc.onState != nil {
+		rc.onState(old, s)
+	}
+	if s == StateConnected && rc.offline.pending() {
+		go rc.ApplyPending()
+	}
+}
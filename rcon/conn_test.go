@@ -0,0 +1,109 @@
+package rcon
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failingDialer dials s once and fails every dial after that
+func failingDialer(s *fakeServer, dials *atomic.Int32) Dialer {
+	errDial := errors.New("network unreachable")
+	return func(string, int) (Transport, error) {
+		if dials.Add(1) > 1 {
+			return nil, errDial
+		}
+		return &fakeConn{srv: s, replies: make(chan []byte, 64)}, nil
+	}
+}
+
+// Reconnect backs off between attempts without holding the client, and not after the last one
+func TestReconnectBackoff(t *testing.T) {
+	srv := newFakeServer()
+	var dials atomic.Int32
+	rc := srv.client(WithDialer(failingDialer(srv, &dials)), WithReconnectBackoff(40*time.Millisecond, time.Second, 3))
+	defer rc.Close()
+
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() { done <- rc.Reconnect() }()
+
+	time.Sleep(10 * time.Millisecond)
+	stateAt := time.Now()
+	if s := rc.State(); s != StateReconnecting {
+		t.Errorf("state during backoff = %v, want reconnecting", s)
+	}
+	if d := time.Since(stateAt); d > 20*time.Millisecond {
+		t.Errorf("State blocked %v during the backoff", d)
+	}
+
+	err := <-done
+	elapsed := time.Since(start)
+	if err == nil {
+		t.Fatal("Reconnect succeeded with a failing dialer")
+	}
+	if n := dials.Load(); n != 4 {
+		t.Errorf("%d dials, want the first and 3 attempts", n)
+	}
+	// 40ms + 80ms between the attempts; sleeping after the last would add another 160ms
+	if elapsed < 120*time.Millisecond || elapsed > 250*time.Millisecond {
+		t.Errorf("Reconnect took %v, want about 120ms", elapsed)
+	}
+	if s := rc.State(); s != StateDisconnected {
+		t.Errorf("state = %v, want disconnected", s)
+	}
+}
+
+func TestStateTransitions(t *testing.T) {
+	srv := newFakeServer()
+	var dials atomic.Int32
+	var seen []ConnState
+	rc := srv.client(WithDialer(failingDialer(srv, &dials)), WithStateHook(func(old, new ConnState) {
+		if len(seen) == 0 {
+			seen = append(seen, old)
+		}
+		seen = append(seen, new)
+	}))
+	if len(seen) != 2 || seen[0] != StateUnknown || seen[1] != StateConnected {
+		t.Errorf("states on connect %v, want [unknown connected]", seen)
+	}
+
+	rc.Close()
+	if err := rc.Reconnect(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Reconnect after Close = %v, want ErrClosed", err)
+	}
+	if n := dials.Load(); n != 1 {
+		t.Errorf("%d dials, want only the first", n)
+	}
+	if s := rc.State(); s != StateDisconnected {
+		t.Errorf("state after Close = %v, want disconnected", s)
+	}
+}
+
+func TestHealthCheckReconnectError(t *testing.T) {
+	srv := newFakeServer()
+	var dials atomic.Int32
+	rc := srv.client(WithDialer(failingDialer(srv, &dials)), WithAutoReconnect(5), WithReconnectBackoff(time.Millisecond, time.Millisecond, 2))
+	defer rc.Close()
+	srv.setDown(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := rc.HealthCheck(ctx, 10*time.Millisecond); err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("HealthCheck = %v, want the reconnect error", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	live := srv.client()
+	defer live.Close()
+	if err := live.HealthCheck(ctx, time.Millisecond); !errors.Is(err, context.Canceled) {
+		t.Errorf("HealthCheck after cancel = %v", err)
+	}
+	var nilClient *RCONClient
+	if err := nilClient.HealthCheck(ctx, time.Millisecond); !errors.Is(err, ErrNilClient) {
+		t.Errorf("HealthCheck on nil = %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package rcon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// CheckStatus is the outcome of one diagnostic check
+type CheckStatus string
+
+const (
+	CheckOK   CheckStatus = "ok"
+	CheckWarn CheckStatus = "warn"
+	CheckFail CheckStatus = "fail"
+	// CheckSkip marks a check that couldn't run, usually because an earlier one failed
+	CheckSkip CheckStatus = "skip"
+)
+
+// Check is one diagnostic result with a hint on how to fix a failure
+type Check struct {
+	Name   string        `json:"name"`
+	Status CheckStatus   `json:"status"`
+	Detail string        `json:"detail,omitempty"`
+	Hint   string        `json:"hint,omitempty"`
+	Took   time.Duration `json:"took"`
+}
+
+// Diagnosis is the report of Diagnose
+type Diagnosis struct {
+	Server string    `json:"server"`
+	At     time.Time `json:"at"`
+	Checks []Check   `json:"checks"`
+}
+
+// OK reports whether no check failed; warnings don't count
+func (d *Diagnosis) OK() bool {
+	for _, c := range d.Checks {
+		if c.Status == CheckFail {
+			return false
+		}
+	}
+	return true
+}
+
+// Probe is an extra check for Diagnose, e.g. log file access or storage health
+type Probe func(ctx context.Context) Check
+
+// Diagnose runs a battery of checks against the server: UDP reachability, rcon
+// authentication, status parsing and the configured game, followed by probes. Checks
+// that depend on a failed one are skipped rather than failing again
+func (rc *RCONClient) Diagnose(ctx context.Context, probes ...Probe) *Diagnosis {
+	if rc == nil {
+		return &Diagnosis{At: time.Now(), Checks: []Check{{Name: "client", Status: CheckFail, Detail: ErrNilClient.Error()}}}
+	}
+	d := &Diagnosis{Server: fmt.Sprintf("%s:%d", rc.IP, rc.Port), At: time.Now()}
+	run := func(name string, fn func() Check) Check {
+		if err := ctx.Err(); err != nil {
+			c := Check{Name: name, Status: CheckSkip, Detail: err.Error()}
+			d.Checks = append(d.Checks, c)
+			return c
+		}
+		start := time.Now()
+		c := fn()
+		c.Name, c.Took = name, time.Since(start)
+		d.Checks = append(d.Checks, c)
+		return c
+	}
+
+	var info *ServerInfo
+	reach := run("udp", func() Check {
+		var err error
+		if info, err = rc.GetInfo(); err != nil {
+			return Check{Status: CheckFail, Detail: err.Error(), Hint: reachHint(err, rc.Port)}
+		}
+		return Check{Status: CheckOK, Detail: fmt.Sprintf("%s on %s", stripColorCodes(info.Hostname), info.MapName)}
+	})
+
+	game := func() Check {
+		if info == nil || info.GameName == "" {
+			return Check{Status: CheckSkip, Detail: "the server doesn't report its game"}
+		}
+		g, err := ParseGame(info.GameName)
+		if err != nil || g == rc.game {
+			return Check{Status: CheckOK, Detail: fmt.Sprintf("configured %s, server reports %s", rc.game, info.GameName)}
+		}
+		return Check{Status: CheckWarn, Detail: fmt.Sprintf("configured %s, server reports %s", rc.game, info.GameName),
+			Hint: fmt.Sprintf("use WithGame(%s) (-game %s) so commands and status rows match", g, g)}
+	}
+
+	var lines []string
+	auth := func() Check {
+		switch {
+		case reach.Status != CheckOK:
+			return Check{Status: CheckSkip, Detail: "server unreachable"}
+		case rc.observer:
+			return Check{Status: CheckSkip, Detail: "read-only client without a password"}
+		}
+		var err error
+		lines, err = rc.SendCommand("status", nil, RequireResponse(), longReply(time.Second))
+		if err != nil {
+			return Check{Status: CheckFail, Detail: err.Error(),
+				Hint: "getinfo answers but rcon commands don't; the server may ignore rcon from this address or rate limit it"}
+		}
+		reply := strings.ToLower(strings.Join(lines, " "))
+		if strings.Contains(reply, "password") {
+			lines = nil
+		}
+		switch {
+		case strings.Contains(reply, "no rconpassword set"):
+			return Check{Status: CheckFail, Detail: "the server has no rcon password",
+				Hint: `set rcon_password "..." in the server config and restart it`}
+		case strings.Contains(reply, "bad rconpassword"), strings.Contains(reply, "invalid password"):
+			return Check{Status: CheckFail, Detail: "the server rejected the password",
+				Hint: "check the password for stray quotes or spaces; after several failures Plutonium may block the address for a while"}
+		}
+		return Check{Status: CheckOK}
+	}
+
+	status := func() Check {
+		if lines == nil {
+			return Check{Status: CheckSkip, Detail: "no authenticated status reply"}
+		}
+		if p := rc.modParser(); p != nil && p.Status != nil {
+			if st := p.Status(lines, rc.game); st != nil {
+				return Check{Status: CheckOK, Detail: fmt.Sprintf("%s, %d players (mod parser)", st.Map, len(st.Players))}
+			}
+		}
+		st := ParseStatus(lines, rc.game)
+		rows := 0
+		for _, l := range lines {
+			if statusRowStart.MatchString(l) {
+				rows++
+			}
+		}
+		switch {
+		case rows > len(st.Players):
+			return Check{Status: CheckFail, Detail: fmt.Sprintf("parsed %d of %d player rows", len(st.Players), rows),
+				Hint: "the status layout doesn't match the configured game; check -game, or register a mod parser"}
+		case st.Map == "":
+			return Check{Status: CheckWarn, Detail: "no map line in the status reply",
+				Hint: "the server may still be loading a map, or a mod changed the status output"}
+		}
+		return Check{Status: CheckOK, Detail: fmt.Sprintf("%s, %d players", st.Map, len(st.Players))}
+	}
+
+	run("game", game)
+	run("auth", auth)
+	run("status", status)
+	for _, p := range probes {
+		if ctx.Err() != nil {
+			break
+		}
+		start := time.Now()
+		c := p(ctx)
+		if c.Took == 0 {
+			c.Took = time.Since(start)
+		}
+		d.Checks = append(d.Checks, c)
+	}
+	return d
+}
+
+// statusRowStart matches lines that look like a status player row
+var statusRowStart = regexp.MustCompile(`^\s*\d+\s+-?\d+\s+`)
+
+// reachHint explains a failed getinfo
+func reachHint(err error, port int) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return fmt.Sprintf("nothing listens on UDP %d; check the port (net_port) and that the server is running", port)
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return fmt.Sprintf("no reply; check the address, that UDP %d is open in the firewall and that the server binds the public interface (net_ip)", port)
+	case errors.Is(err, ErrChallenge), errors.Is(err, ErrStale):
+		return "replies arrived but didn't match the request; another client may share the socket or a proxy rewrites replies"
+	}
+	return "check the address and port"
+}
@@ -0,0 +1,85 @@
+package rcon
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// dvarCache keeps recently read dvar values for a fixed TTL
+type dvarCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cachedDvar
+}
+
+type cachedDvar struct {
+	value string
+	at    time.Time
+}
+
+// WithDvarCache caches GetDvar/GetDvars results for ttl, so hot paths reading the same
+// dvars every second don't hit the server each time. Setting a dvar through the client
+// invalidates its entry; changes made elsewhere show up once the entry expires
+func WithDvarCache(ttl time.Duration) Option {
+	return func(rc *RCONClient) {
+		if ttl > 0 {
+			rc.dvarCache = &dvarCache{ttl: ttl, entries: map[string]cachedDvar{}}
+		}
+	}
+}
+
+// InvalidateDvars drops every cached dvar value, e.g. after a map change
+func (rc *RCONClient) InvalidateDvars() {
+	if c := rc.cache(); c != nil {
+		c.mu.Lock()
+		c.entries = map[string]cachedDvar{}
+		c.mu.Unlock()
+	}
+}
+
+// InvalidateDvar drops the cached value of one dvar, e.g. one a server-side mod writes
+func (rc *RCONClient) InvalidateDvar(name string) {
+	rc.forgetDvar(name)
+}
+
+// cache returns the dvar cache, nil when caching is off
+func (rc *RCONClient) cache() *dvarCache {
+	if rc == nil {
+		return nil
+	}
+	return rc.dvarCache
+}
+
+func (rc *RCONClient) cachedDvar(name string) (string, bool) {
+	c := rc.cache()
+	if c == nil {
+		return "", false
+	}
+	key := strings.ToLower(strings.TrimSpace(name))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || time.Since(e.at) > c.ttl {
+		delete(c.entries, key)
+		return "", false
+	}
+	return e.value, true
+}
+
+func (rc *RCONClient) cacheDvar(name, value string) {
+	rc.offline.saw(name, value)
+	if c := rc.cache(); c != nil {
+		c.mu.Lock()
+		c.entries[strings.ToLower(strings.TrimSpace(name))] = cachedDvar{value: value, at: time.Now()}
+		c.mu.Unlock()
+	}
+}
+
+func (rc *RCONClient) forgetDvar(name string) {
+	if c := rc.cache(); c != nil {
+		c.mu.Lock()
+		delete(c.entries, strings.ToLower(strings.TrimSpace(name)))
+		c.mu.Unlock()
+	}
+}
@@ -0,0 +1,96 @@
+package rcon
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DvarInfo is one dvar as listed by dvardump or dvarlist
+type DvarInfo struct {
+	Value   string `json:"value"`
+	Default string `json:"default,omitempty"`
+	// Flags are the single-letter flag columns dvarlist prints (e.g. "SA" for saved, archived)
+	Flags  string `json:"flags,omitempty"`
+	Domain string `json:"domain,omitempty"`
+}
+
+var (
+	dumpVerboseRx = regexp.MustCompile(`^"?([^"\s]+)"?\s+is:\s*"(.*?)"(?:\s+default:\s*"(.*?)")?`)
+	dumpDomainRx  = regexp.MustCompile(`(?i)^domain is\s+(.*)$`)
+	dumpListRx    = regexp.MustCompile(`^((?:[A-Z?]\s+)*)([A-Za-z_][\w.]*)\s+"(.*)"$`)
+)
+
+// Dump every dvar whose name starts with prefix (empty for all) using dvardump, falling
+// back to dvarlist. The reply spans many datagrams; if it looks cut off the dvars read
+// so far are returned together with an error
+func (rc *RCONClient) DumpDvars(prefix string) (map[string]DvarInfo, error) {
+	prefix = strings.TrimSpace(prefix)
+	var args *string
+	if prefix != "" {
+		args = &prefix
+	}
+
+	var out map[string]DvarInfo
+	var truncated bool
+	var lerr error
+	for _, cmd := range []string{"dvardump", "dvarlist"} {
+		res, err := rc.SendCommand(cmd, args, RequireResponse(), longReply(time.Second), WithTruncatedFlag(&truncated))
+		if err != nil {
+			lerr = err
+			continue
+		}
+		out = ParseDvarDump(res, prefix)
+		if len(out) > 0 {
+			break
+		}
+	}
+	if len(out) == 0 && lerr != nil {
+		return nil, lerr
+	}
+	for name, d := range out {
+		rc.cacheDvar(name, d.Value)
+	}
+	if truncated {
+		return out, fmt.Errorf("dvar dump was truncated after %d dvars", len(out))
+	}
+	return out, nil
+}
+
+// ParseDvarDump parses dvardump/dvarlist output, keeping names that start with prefix.
+// It reads both the listing format (flags, name, quoted value) and the verbose
+// `"name" is:"value" default:"value"` format with optional "Domain is" lines
+func ParseDvarDump(lines []string, prefix string) map[string]DvarInfo {
+	out := map[string]DvarInfo{}
+	keep := func(name string) bool {
+		return prefix == "" || strings.HasPrefix(strings.ToLower(name), strings.ToLower(prefix))
+	}
+
+	last := ""
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if m := dumpDomainRx.FindStringSubmatch(line); m != nil {
+			if d, ok := out[last]; ok {
+				d.Domain = strings.TrimSpace(m[1])
+				out[last] = d
+			}
+			continue
+		}
+		last = ""
+		if m := dumpVerboseRx.FindStringSubmatch(line); m != nil {
+			if keep(m[1]) {
+				out[m[1]] = DvarInfo{Value: stripColorCodes(m[2]), Default: stripColorCodes(m[3])}
+				last = m[1]
+			}
+			continue
+		}
+		if m := dumpListRx.FindStringSubmatch(line); m != nil {
+			if keep(m[2]) {
+				out[m[2]] = DvarInfo{Value: stripColorCodes(m[3]), Flags: strings.Join(strings.Fields(m[1]), "")}
+				last = m[2]
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,57 @@
+package rcon
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDvarDump(t *testing.T) {
+	tests := []struct {
+		name   string
+		lines  []string
+		prefix string
+		want   map[string]DvarInfo
+	}{
+		{
+			name: "verbose with domain",
+			lines: []string{
+				`"g_speed" is:"190^7" default:"190^7"`,
+				`Domain is any integer from 0 to 1000`,
+				`"sv_hostname" is:"^1My^7 Server^7" default:"CoDHost^7"`,
+			},
+			want: map[string]DvarInfo{
+				"g_speed":     {Value: "190", Default: "190", Domain: "any integer from 0 to 1000"},
+				"sv_hostname": {Value: "My Server", Default: "CoDHost"},
+			},
+		},
+		{
+			name:  "listing",
+			lines: []string{`S A     g_gametype "tdm"`, `        scr_dm_timelimit "10"`, `1234 total dvars`},
+			want: map[string]DvarInfo{
+				"g_gametype":       {Value: "tdm", Flags: "SA"},
+				"scr_dm_timelimit": {Value: "10"},
+			},
+		},
+		{
+			name:   "prefix",
+			lines:  []string{`"g_speed" is:"190"`, `"sv_hostname" is:"x"`, `"G_gravity" is:"800"`},
+			prefix: "g_",
+			want: map[string]DvarInfo{
+				"g_speed":   {Value: "190"},
+				"G_gravity": {Value: "800"},
+			},
+		},
+		{
+			name:  "domain without a dvar",
+			lines: []string{`Domain is any text`},
+			want:  map[string]DvarInfo{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseDvarDump(tt.lines, tt.prefix); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDvarDump = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
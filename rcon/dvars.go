@@ -0,0 +1,259 @@
+package rcon
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// dvarPatterns returns the regexes that match a dvar's value line
+func dvarPatterns(dvar string) []*regexp.Regexp {
+	name := regexp.QuoteMeta(strings.TrimSpace(dvar))
+	return []*regexp.Regexp{
+		regexp.MustCompile(fmt.Sprintf(`(?i)^"?%s"?\s+is:\s*"(?P<val>[^"]*)"`, name)),
+		regexp.MustCompile(fmt.Sprintf(`(?i)^"?%s"?\s+is:\s*(?P<val>\S*)`, name)),
+		regexp.MustCompile(fmt.Sprintf(`(?i)^"?%s"?\s*[:=]\s*"?(?P<val>.*?)"?$`, name)),
+	}
+}
+
+// matchDvar extracts the value from a cleaned line using the dvar's patterns
+func matchDvar(clean string, patterns []*regexp.Regexp) (string, bool) {
+	for _, rx := range patterns {
+		m := rx.FindStringSubmatch(clean)
+		if m == nil {
+			continue
+		}
+		for i, n := range rx.SubexpNames() {
+			if n == "val" {
+				return stripColorCodes(m[i]), true
+			}
+		}
+	}
+	return "", false
+}
+
+// ParseDvar extracts a dvar's value from reply lines the way GetDvar does, without the fallbacks
+func ParseDvar(name string, lines []string) (string, bool) {
+	patterns := dvarPatterns(name)
+	for _, line := range lines {
+		if val, ok := matchDvar(strings.TrimSpace(stripColorCodes(line)), patterns); ok {
+			return val, true
+		}
+	}
+	return "", false
+}
+
+// Get several dvars at once, pipelining the requests into one shared read window.
+// Pass a slice with GetDvars(names...)
+func (rc *RCONClient) GetDvars(names ...string) (map[string]string, error) {
+	if err := rc.ready(); err != nil {
+		return nil, err
+	}
+	if rc.observer {
+		return nil, ErrReadOnly
+	}
+
+	out := make(map[string]string, len(names))
+	patterns := make(map[string][]*regexp.Regexp, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("dvar cannot be empty")
+		}
+		if val, ok := rc.cachedDvar(name); ok {
+			out[name] = val
+			continue
+		}
+		patterns[name] = dvarPatterns(name)
+	}
+	if len(patterns) == 0 {
+		return out, nil
+	}
+
+	// The pipeline writes straight to the socket, so with middleware installed every
+	// dvar goes through GetDvar instead where the middleware can see it
+	var lines []string
+	if !rc.hasMiddleware() {
+		var err error
+		if lines, err = rc.pipeline(patterns); err != nil {
+			return nil, err
+		}
+	}
+	for _, line := range lines {
+		clean := strings.TrimSpace(stripColorCodes(line))
+		for name, p := range patterns {
+			if _, done := out[name]; done {
+				continue
+			}
+			if val, ok := matchDvar(clean, p); ok {
+				out[name] = val
+				rc.cacheDvar(name, val)
+			}
+		}
+	}
+
+	// Anything the shared window missed falls back to a single round trip
+	for name := range patterns {
+		if _, ok := out[name]; ok {
+			continue
+		}
+		val, err := rc.GetDvar(name)
+		if err != nil {
+			return nil, fmt.Errorf("dvar %s: %w", name, err)
+		}
+		out[name] = val
+	}
+	return out, nil
+}
+
+// Get a dvar as an integer
+func (rc *RCONClient) GetDvarInt(dvar string) (int, error) {
+	val, err := rc.GetDvar(dvar)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(normalizeDvarValue(val))
+	if err != nil {
+		return 0, &DvarParseError{Name: dvar, Value: val, Type: "int", Err: err}
+	}
+	return n, nil
+}
+
+// Get a dvar as a float
+func (rc *RCONClient) GetDvarFloat(dvar string) (float64, error) {
+	val, err := rc.GetDvar(dvar)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(normalizeDvarValue(val), 64)
+	if err != nil {
+		return 0, &DvarParseError{Name: dvar, Value: val, Type: "float", Err: err}
+	}
+	return f, nil
+}
+
+// Get a dvar as a bool, accepting 1/0, true/false, yes/no and on/off
+func (rc *RCONClient) GetDvarBool(dvar string) (bool, error) {
+	val, err := rc.GetDvar(dvar)
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(normalizeDvarValue(val)) {
+	case "1", "true", "yes", "on":
+		return true, nil
+	case "0", "false", "no", "off":
+		return false, nil
+	}
+	return false, &DvarParseError{Name: dvar, Value: val, Type: "bool", Err: strconv.ErrSyntax}
+}
+
+// pipeline writes one query per dvar and collects every reply line in a single read window
+func (rc *RCONClient) pipeline(patterns map[string][]*regexp.Regexp) ([]string, error) {
+	defer rc.gate.acquire(rc.priorityOf(nil))()
+	rc.limiter.wait()
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if err := rc.readyLocked(); err != nil {
+		return nil, err
+	}
+
+	first := true
+	for name := range patterns {
+		// the writes are one exchange, so only the first is paced before locking
+		if !first {
+			rc.limiter.wait()
+		}
+		first = false
+		if _, err := rc.conn.Write(rc.commandPacket(name, nil)); err != nil {
+			return nil, err
+		}
+	}
+	lines, _, err := rc.readResponse(rc.timeoutOrDefault(), defaultReadExtension, "print")
+	if err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return lines, nil
+}
+
+// Set a dvar and read it back to confirm the server accepted the value.
+// Latched dvars keep their old value until a restart and are not compared
+func (rc *RCONClient) SetDvarVerified(dvar, value string) error {
+	res, err := rc.SetDvarWithResult(dvar, value, false)
+	if err != nil {
+		return err
+	}
+	if res.Latched {
+		return nil
+	}
+	got, err := rc.GetDvar(dvar)
+	if err != nil {
+		return err
+	}
+	if !dvarValuesEqual(value, got) {
+		return &DvarMismatchError{Name: dvar, Want: value, Got: got}
+	}
+	return nil
+}
+
+// dvarValuesEqual compares dvar values ignoring quoting, color codes, case and float formatting
+func dvarValuesEqual(a, b string) bool {
+	a, b = normalizeDvarValue(a), normalizeDvarValue(b)
+	if strings.EqualFold(a, b) {
+		return true
+	}
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	return errA == nil && errB == nil && fa == fb
+}
+
+// normalizeDvarValue strips surrounding quotes, color codes and whitespace
+func normalizeDvarValue(s string) string {
+	s = strings.TrimSpace(stripColorCodes(s))
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	return strings.TrimSpace(strings.ReplaceAll(s, `\"`, `"`))
+}
+
+type DvarSetResult struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// Latched is set when the server only applies the value after a map restart
+	Latched bool `json:"latched"`
+	// Restarted is set when the latched value was applied by a map_restart
+	Restarted bool `json:"restarted"`
+}
+
+// Set a dvar and report whether the change is live or latched until the next restart.
+// With restartIfLatched a latched change is applied right away through map_restart
+func (rc *RCONClient) SetDvarWithResult(dvar, value string, restartIfLatched bool) (*DvarSetResult, error) {
+	lines, err := rc.setDvar(dvar, value)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &DvarSetResult{Name: dvar, Value: value, Latched: isLatchedResponse(lines)}
+	if res.Latched && restartIfLatched {
+		if err := rc.MapRestart(); err != nil {
+			return res, fmt.Errorf("map_restart for latched %s: %w", dvar, err)
+		}
+		res.Restarted = true
+	}
+	return res, nil
+}
+
+// isLatchedResponse detects the "will be changed upon restarting" reply of latched dvars
+func isLatchedResponse(lines []string) bool {
+	for _, line := range lines {
+		l := strings.ToLower(stripColorCodes(line))
+		if strings.Contains(l, "will be changed upon restarting") || strings.Contains(l, "latched") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package rcon
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDvar(t *testing.T) {
+	tests := []struct {
+		name  string
+		dvar  string
+		lines []string
+		want  string
+		ok    bool
+	}{
+		{"quoted", "g_speed", []string{`"g_speed" is:"190^7" default:"190^7"`}, "190", true},
+		{"unquoted value", "g_speed", []string{`g_speed is: 190`}, "190", true},
+		{"case-insensitive name", "G_Speed", []string{`"g_speed" is:"190"`}, "190", true},
+		{"equals form", "sv_hostname", []string{`sv_hostname = "My Server"`}, "My Server", true},
+		{"empty value", "g_password", []string{`"g_password" is:"^7" default:"^7"`}, "", true},
+		{"other dvar", "g_speed", []string{`"g_speedx" is:"1"`}, "", false},
+		{"unknown command", "g_speed", []string{"Unknown command \"g_speed\""}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseDvar(tt.dvar, tt.lines)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("ParseDvar = %q, %t, want %q, %t", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSetDvarClearsCache(t *testing.T) {
+	srv := newFakeServer()
+	srv.setDvar("g_gametype", "tdm")
+	rc := srv.client(WithDvarCache(time.Minute))
+	defer rc.Close()
+
+	if v, err := rc.GetDvar("g_gametype"); err != nil || v != "tdm" {
+		t.Fatalf("GetDvar = %q, %v", v, err)
+	}
+	// a read cached while the set is on the wire must not outlive it
+	rc.Use(func(next CommandFunc) CommandFunc {
+		return func(cmd Command) ([]string, error) {
+			res, err := next(cmd)
+			if cmd.Name == "set" {
+				rc.cacheDvar("g_gametype", "tdm")
+			}
+			return res, err
+		}
+	})
+	if err := rc.SetDvar("g_gametype", "dom"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := rc.GetDvar("g_gametype"); err != nil || v != "dom" {
+		t.Errorf("GetDvar after SetDvar = %q, %v", v, err)
+	}
+}
@@ -0,0 +1,106 @@
+package rcon
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+)
+
+// ErrNilClient is returned by methods called on a nil *RCONClient
+var ErrNilClient = errors.New("RCON client is nil")
+
+// ErrNotConnected is returned when the client has no transport, e.g. a zero RCONClient
+// that didn't come from New
+var ErrNotConnected = errors.New("RCON connection is not established")
+
+// ErrClosed is returned once the client was closed; Connect opens it again
+var ErrClosed = errors.New("RCON client is closed")
+
+// ErrReadOnly is returned when an observer client is asked to run an RCON command
+var ErrReadOnly = errors.New("client is read-only: RCON commands need a password")
+
+// ErrResponseTooLarge is returned when a reply grows past the client's size limit
+var ErrResponseTooLarge = wire.ErrTooLarge
+
+// ErrChallenge is returned when WithQueryChallenge is set and only replies with another nonce arrived
+var ErrChallenge = wire.ErrChallenge
+
+// ErrStale is returned when a query reply arrived later than the WithQueryChallenge window
+var ErrStale = wire.ErrStale
+
+// ErrUnsupported is returned when the configured game lacks a feature
+var ErrUnsupported = errors.New("not supported by this game")
+
+// ErrNoSalt is returned by New and the importers when IPHash is configured without a
+// Salt, since unsalted hashes of the IPv4 space are trivially reversed
+var ErrNoSalt = errors.New("IPHash needs a non-empty Salt")
+
+// DvarMismatchError is returned when a dvar reads back differently than it was set
+type DvarMismatchError struct {
+	Name string
+	Want string
+	Got  string
+}
+
+func (e *DvarMismatchError) Error() string {
+	return fmt.Sprintf("dvar %s is %q after setting %q", e.Name, e.Got, e.Want)
+}
+
+// DvarParseError is returned when a dvar value can't be parsed as the requested type
+type DvarParseError struct {
+	Name  string
+	Value string
+	Type  string
+	Err   error
+}
+
+func (e *DvarParseError) Error() string {
+	return fmt.Sprintf("dvar %s: cannot parse %q as %s", e.Name, e.Value, e.Type)
+}
+
+func (e *DvarParseError) Unwrap() error { return e.Err }
+
+// UnknownMapError is returned when a map change or rotation names a map the server
+// isn't known to have; Suggestions are the closest available maps
+type UnknownMapError struct {
+	Game        Game
+	Map         string
+	Suggestions []string
+}
+
+func (e *UnknownMapError) Error() string {
+	msg := fmt.Sprintf("unknown %s map %q", e.Game, e.Map)
+	if len(e.Suggestions) > 0 {
+		msg += fmt.Sprintf(" (did you mean %s?)", strings.Join(e.Suggestions, ", "))
+	}
+	return msg + "; use RegisterMap for custom maps"
+}
+
+// ErrSlotReused is returned when a client number no longer holds the player an action
+// was meant for: they left, or someone else took the slot
+var ErrSlotReused = errors.New("player left or their slot was reused")
+
+// PlayerNotFoundError is returned when no connected player matches a query
+type PlayerNotFoundError struct {
+	Query string
+}
+
+func (e *PlayerNotFoundError) Error() string {
+	return fmt.Sprintf("no player matching %q", e.Query)
+}
+
+// AmbiguousPlayerError is returned when a query matches more than one player
+type AmbiguousPlayerError struct {
+	Query      string
+	Candidates []Player
+}
+
+func (e *AmbiguousPlayerError) Error() string {
+	names := make([]string, len(e.Candidates))
+	for i, p := range e.Candidates {
+		names[i] = fmt.Sprintf("%d:%s", p.ClientNum, stripColorCodes(p.Name))
+	}
+	return fmt.Sprintf("%q is ambiguous, matches %s", e.Query, strings.Join(names, ", "))
+}
@@ -0,0 +1,147 @@
+package rcon
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ExecOptions controls ExecCommands and ExecScript
+type ExecOptions struct {
+	// StopOnError ends the run at the first failing command
+	StopOnError bool
+	// Pace waits between commands, on top of any client rate limit
+	Pace time.Duration
+	// Each, when set, sees every result as it arrives
+	Each func(BatchResult)
+}
+
+// ExecError is returned when commands of an exec run failed; the run's results are
+// still returned next to it
+type ExecError struct {
+	Failed []BatchResult
+	Total  int
+}
+
+func (e *ExecError) Error() string {
+	first := e.Failed[0]
+	return fmt.Sprintf("%d of %d commands failed, first %q: %v", len(e.Failed), e.Total, first.Command, first.Err)
+}
+
+// ScriptLine is one console command of a script with its 1-based source line
+type ScriptLine struct {
+	Line    int
+	Command string
+}
+
+// Send a sequence of commands, collecting a result per command
+func (rc *RCONClient) ExecCommands(cmds []Command, opts ExecOptions) ([]BatchResult, error) {
+	lines := make([]ScriptLine, len(cmds))
+	for i, c := range cmds {
+		lines[i] = ScriptLine{Command: c.Line()}
+	}
+	return rc.ExecLines(lines, opts)
+}
+
+// Send every command of a .cfg-style script. Blank lines and // or # comments are
+// skipped and a line may hold several commands separated by semicolons
+func (rc *RCONClient) ExecScript(r io.Reader, opts ExecOptions) ([]BatchResult, error) {
+	if rc == nil {
+		return nil, ErrNilClient
+	}
+	if r == nil {
+		return nil, errors.New("exec script: nil reader")
+	}
+	lines, err := ParseScript(r)
+	if err != nil {
+		return nil, err
+	}
+	return rc.ExecLines(lines, opts)
+}
+
+// errStopExec ends the SendBatch of an exec run with StopOnError
+var errStopExec = errors.New("exec stopped")
+
+// Send parsed script lines in order through SendBatch, with pacing and error
+// collection; ExecScript is ParseScript followed by ExecLines
+func (rc *RCONClient) ExecLines(lines []ScriptLine, opts ExecOptions) ([]BatchResult, error) {
+	cmds := make([]string, len(lines))
+	for i, l := range lines {
+		cmds[i] = l.Command
+	}
+	var failed []BatchResult
+	results, err := rc.SendBatch(cmds, func(res BatchResult) error {
+		res.Line = lines[res.Index].Line
+		if opts.Each != nil {
+			opts.Each(res)
+		}
+		if res.Err != nil {
+			failed = append(failed, res)
+			if opts.StopOnError {
+				return errStopExec
+			}
+		}
+		if opts.Pace > 0 && res.Index < len(lines)-1 {
+			time.Sleep(opts.Pace)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopExec) {
+		return results, err
+	}
+	for i := range results {
+		results[i].Line = lines[i].Line
+	}
+	if len(failed) > 0 {
+		return results, &ExecError{Failed: failed, Total: len(lines)}
+	}
+	return results, nil
+}
+
+// ParseScript splits a .cfg-style script into commands
+func ParseScript(r io.Reader) ([]ScriptLine, error) {
+	var out []ScriptLine
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		for _, cmd := range splitScriptLine(sc.Text()) {
+			out = append(out, ScriptLine{Line: n, Command: cmd})
+		}
+	}
+	return out, sc.Err()
+}
+
+// splitScriptLine cuts a line at semicolons and comments outside double quotes
+func splitScriptLine(line string) []string {
+	var out []string
+	var cur strings.Builder
+	flush := func() {
+		if c := strings.TrimSpace(cur.String()); c != "" {
+			out = append(out, c)
+		}
+		cur.Reset()
+	}
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == ';':
+			flush()
+			continue
+		case c == '#' && strings.TrimSpace(cur.String()) == "":
+			flush()
+			return out
+		case c == '/' && i+1 < len(line) && line[i+1] == '/':
+			flush()
+			return out
+		}
+		cur.WriteByte(c)
+	}
+	flush()
+	return out
+}
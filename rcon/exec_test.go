@@ -0,0 +1,31 @@
+package rcon
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseScript(t *testing.T) {
+	script := `// server.cfg
+set sv_hostname "Clan; Server" // name
+# comment
+   set g_speed 190; set g_gravity 800
+
+map_rotate
+say "http://example.com"`
+	got, err := ParseScript(strings.NewReader(script))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ScriptLine{
+		{Line: 2, Command: `set sv_hostname "Clan; Server"`},
+		{Line: 4, Command: "set g_speed 190"},
+		{Line: 4, Command: "set g_gravity 800"},
+		{Line: 6, Command: "map_rotate"},
+		{Line: 7, Command: `say "http://example.com"`},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseScript =\n%+v\nwant\n%+v", got, want)
+	}
+}
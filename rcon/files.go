@@ -0,0 +1,109 @@
+package rcon
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ListFiles lists the files of a game directory, optionally only those with extension
+// ext ("cfg" or ".cfg"). Titles without the Files capability return ErrUnsupported
+func (rc *RCONClient) ListFiles(dir, ext string) ([]string, error) {
+	if err := rc.requireCapability(rc.profile().Capabilities.Files, "file listing"); err != nil {
+		return nil, err
+	}
+	dir = strings.Trim(strings.ReplaceAll(dir, `\`, "/"), "/")
+	if strings.Contains(dir, "..") || !safeFileArg(dir) {
+		return nil, fmt.Errorf("invalid directory %q", dir)
+	}
+	if !safeFileArg(ext) {
+		return nil, fmt.Errorf("invalid extension %q", ext)
+	}
+	if dir == "" {
+		dir = "."
+	}
+	args := dir
+	if ext = strings.TrimPrefix(ext, "."); ext != "" {
+		args += " " + ext
+	}
+	res, err := rc.SendCommand("dir", &args, RequireResponse())
+	if err != nil {
+		return nil, err
+	}
+	return parseDir(res)
+}
+
+// safeFileArg rejects what would end the dir argument or start another command: spaces,
+// quotes, separators and control characters such as \n
+func safeFileArg(s string) bool {
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" \"';", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseDir reads the names of a dir reply. The engine's FS_Dir_f, which CoD4x keeps from
+// Quake 3, prints "Directory of <dir> <ext>", a line of dashes and then one name per
+// line, leaving subdirectories out
+func parseDir(lines []string) ([]string, error) {
+	start := -1
+	for i, line := range lines {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(stripColorCodes(line))), "directory of") {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("unexpected dir reply: %q", strings.Join(lines, "\n"))
+	}
+	if start < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[start]), "---") {
+		start++
+	}
+	var out []string
+	for _, line := range lines[start:] {
+		if line = strings.TrimSpace(stripColorCodes(line)); line != "" {
+			out = append(out, line)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// ListConfigs lists the .cfg files the server can exec
+func (rc *RCONClient) ListConfigs() ([]string, error) {
+	return rc.ListFiles("", "cfg")
+}
+
+// ListMapFiles lists the maps installed in the server's maps/mp directory
+func (rc *RCONClient) ListMapFiles() ([]string, error) {
+	files, err := rc.ListFiles("maps/mp", "d3dbsp")
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range files {
+		files[i] = strings.TrimSuffix(path.Base(f), path.Ext(f))
+	}
+	return files, nil
+}
+
+// ConfigExists tells whether the server has the config file name ("server.cfg")
+func (rc *RCONClient) ConfigExists(name string) (bool, error) {
+	name = strings.Trim(strings.ReplaceAll(name, `\`, "/"), "/")
+	dir, file := path.Split(name)
+	if path.Ext(file) == "" {
+		file += ".cfg"
+	}
+	files, err := rc.ListFiles(dir, "cfg")
+	if err != nil {
+		return false, err
+	}
+	for _, f := range files {
+		if strings.EqualFold(path.Base(f), file) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
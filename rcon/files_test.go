@@ -0,0 +1,80 @@
+package rcon
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		want    []string
+		wantErr bool
+	}{
+		{"configs", []string{"Directory of main cfg", "---------------", "server.cfg", "dedicated.cfg", ""}, []string{"dedicated.cfg", "server.cfg"}, false},
+		{"empty", []string{"Directory of maps/mp d3dbsp", "---------------"}, nil, false},
+		{"colored", []string{"^7Directory of main cfg", "---------------", "^2zm.cfg"}, []string{"zm.cfg"}, false},
+		{"unknown command", []string{"Unknown command \"dir\""}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDir(tt.lines)
+			if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDir = %q, %v; want %q (error %t)", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	srv := newFakeServer()
+	srv.handle = func(cmdline string) string {
+		switch cmdline {
+		case "dir . cfg":
+			return "Directory of . cfg\n---------------\nserver.cfg\nsnd.cfg\n"
+		case "dir maps/mp d3dbsp":
+			return "Directory of maps/mp d3dbsp\n---------------\nmaps/mp/mp_crash.d3dbsp\nmaps/mp/mp_strike.d3dbsp\n"
+		}
+		return ""
+	}
+	cod4x := GameProfile{Game: GameT4, Capabilities: Capabilities{Files: true}}
+	rc := srv.client(WithProfile(cod4x))
+	defer rc.Close()
+
+	if got, err := rc.ListConfigs(); err != nil || !reflect.DeepEqual(got, []string{"server.cfg", "snd.cfg"}) {
+		t.Errorf("ListConfigs = %q, %v", got, err)
+	}
+	if got, err := rc.ListMapFiles(); err != nil || !reflect.DeepEqual(got, []string{"mp_crash", "mp_strike"}) {
+		t.Errorf("ListMapFiles = %q, %v", got, err)
+	}
+	if ok, err := rc.ConfigExists("SND"); err != nil || !ok {
+		t.Errorf("ConfigExists(SND) = %t, %v", ok, err)
+	}
+	for _, tt := range []struct{ dir, ext string }{
+		{"../etc", ""},
+		{"main\nquit", "cfg"},
+		{"main", "cfg;quit"},
+		{"main", "c fg"},
+		{"main\rquit", ""},
+	} {
+		if _, err := rc.ListFiles(tt.dir, tt.ext); err == nil {
+			t.Errorf("ListFiles(%q, %q) was sent", tt.dir, tt.ext)
+		}
+	}
+	for _, cmd := range srv.commands() {
+		if cmd != "dir . cfg" && cmd != "dir maps/mp d3dbsp" {
+			t.Errorf("sent %q", cmd)
+		}
+	}
+
+	if rc.Game() != GameT4 || rc.profile().Kick != Profile(GameT4).Kick || rc.banCommands() != Profile(GameT4).bans {
+		t.Error("WithProfile didn't fill the commands in from the built-in profile")
+	}
+	plain := srv.client()
+	defer plain.Close()
+	if _, err := plain.ListConfigs(); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("ListConfigs on T6 = %v, want ErrUnsupported", err)
+	}
+}
@@ -0,0 +1,232 @@
+package rcon
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+	"github.com/Yallamaztar/PlutoRCON/v2/query"
+)
+
+type Game int
+
+const (
+	GameT6 Game = iota
+	GameIW5
+	GameT4
+	GameT5
+	GameIW6
+)
+
+func (g Game) String() string {
+	switch g {
+	case GameT6:
+		return "t6"
+	case GameIW5:
+		return "iw5"
+	case GameT4:
+		return "t4"
+	case GameT5:
+		return "t5"
+	case GameIW6:
+		return "iw6"
+	}
+	return fmt.Sprintf("game(%d)", int(g))
+}
+
+// ParseGame parses a title name such as "t6" or "iw5"
+func ParseGame(s string) (Game, error) {
+	for g := GameT6; g <= GameIW6; g++ {
+		if strings.EqualFold(strings.TrimSpace(s), g.String()) {
+			return g, nil
+		}
+	}
+	return GameT6, fmt.Errorf("unknown game %q", s)
+}
+
+type Capabilities struct {
+	Recording bool
+	Playlists bool
+	Hardcore  bool
+	// Files is set for dialects whose dir command lists server files over rcon, such as
+	// CoD4x; none of the Plutonium titles expose it, so set it through WithProfile
+	Files bool
+}
+
+// GameProfile holds the command names and parsing rules of one Plutonium title
+type GameProfile struct {
+	Game Game
+	// Kick is a format taking the player and the reason
+	Kick          string
+	Tell          string
+	Say           string
+	StatusPattern *regexp.Regexp
+	InfoKeys      query.InfoKeys
+	Capabilities  Capabilities
+	// Codepage is the encoding of names and chat in replies
+	Codepage Codepage
+	bans     banCommands
+}
+
+var (
+	// statusWithBot matches status rows that carry a bot column (T6, IW5, IW6)
+	statusWithBot = regexp.MustCompile(
+		`(?P<num>\d+)\s+` +
+			`(?P<score>-?\d+)\s+` +
+			`(?P<bot>\w+)?\s*` +
+			`(?P<ping>\d+|LOAD|CNCT|ZMBI)\s+` +
+			`(?P<guid>[0-9a-fA-F]+)\s+` +
+			`(?P<name>.+?)\s+` +
+			`(?P<lastmsg>\d+)\s+` +
+			`(?P<ipport>\S+)\s+` +
+			`(?P<qport>-?\d+)\s+` +
+			`(?P<rate>\d+)`,
+	)
+	// statusNoBot matches status rows without a bot column (T4, T5)
+	statusNoBot = regexp.MustCompile(
+		`(?P<num>\d+)\s+` +
+			`(?P<score>-?\d+)\s+` +
+			`(?P<ping>\d+|LOAD|CNCT|ZMBI)\s+` +
+			`(?P<guid>[0-9a-fA-F]+)\s+` +
+			`(?P<name>.+?)\s+` +
+			`(?P<lastmsg>\d+)\s+` +
+			`(?P<ipport>\S+)\s+` +
+			`(?P<qport>-?\d+)\s+` +
+			`(?P<rate>\d+)`,
+	)
+
+	iwBanCommands = banCommands{
+		banClient:     "banClient",
+		banUser:       "banUser",
+		tempBanClient: "tempBanClient",
+		tempBanUser:   "tempBanUser",
+		unban:         "unbanUser",
+		banList:       "banlist",
+	}
+
+	t6InfoKeys = query.InfoKeys{MaxClients: "com_maxclients", GameType: "gametype"}
+	iwInfoKeys = query.InfoKeys{MaxClients: "sv_maxclients", GameType: "gametype"}
+)
+
+var gameProfiles = map[Game]*GameProfile{
+	GameT6: {
+		Game: GameT6, Kick: "clientkick_for_reason %s '%s'", Tell: "tell", Say: "say",
+		StatusPattern: statusWithBot, InfoKeys: t6InfoKeys, bans: t6BanCommands,
+		Capabilities: Capabilities{Recording: true, Playlists: true, Hardcore: true},
+	},
+	GameIW5: {
+		Game: GameIW5, Kick: "clientkick %s \"%s\"", Tell: "tell", Say: "say",
+		StatusPattern: statusWithBot, InfoKeys: iwInfoKeys, bans: iwBanCommands,
+	},
+	GameT4: {
+		Game: GameT4, Kick: "clientkick %s \"%s\"", Tell: "tell", Say: "say",
+		StatusPattern: statusNoBot, InfoKeys: iwInfoKeys, bans: iwBanCommands,
+		Capabilities: Capabilities{Recording: true},
+	},
+	GameT5: {
+		Game: GameT5, Kick: "clientkick %s \"%s\"", Tell: "tell", Say: "say",
+		StatusPattern: statusNoBot, InfoKeys: iwInfoKeys, bans: iwBanCommands,
+	},
+	GameIW6: {
+		Game: GameIW6, Kick: "clientkick %s \"%s\"", Tell: "tell", Say: "say",
+		StatusPattern: statusWithBot, InfoKeys: iwInfoKeys, bans: iwBanCommands,
+	},
+}
+
+// Codepage is the byte encoding of a title's text; replies are converted to UTF-8
+type Codepage = wire.Codepage
+
+const (
+	CP1252 = wire.CP1252
+	Latin1 = wire.Latin1
+	UTF8   = wire.UTF8
+)
+
+// WithCodepage overrides the encoding replies are decoded from, e.g. UTF8 for a mod
+// that sends UTF-8 names (default: the game profile's, CP1252 for all Plutonium titles)
+func WithCodepage(c Codepage) Option {
+	return func(rc *RCONClient) {
+		rc.codepage = &c
+	}
+}
+
+// textCodepage returns the codepage replies are decoded from
+func (rc *RCONClient) textCodepage() Codepage {
+	if rc != nil && rc.codepage != nil {
+		return *rc.codepage
+	}
+	return rc.profile().Codepage
+}
+
+// Profile returns a copy of the built-in profile of a title, e.g. as the base of a
+// WithProfile dialect; changing it leaves the built-in one alone
+func Profile(g Game) *GameProfile {
+	p := *builtinProfile(g)
+	return &p
+}
+
+// builtinProfile returns the shared built-in profile of a title, which must not be changed
+func builtinProfile(g Game) *GameProfile {
+	if p, ok := gameProfiles[g]; ok {
+		return p
+	}
+	return gameProfiles[GameT6]
+}
+
+// WithGame selects the command and parsing profile of a Plutonium title (default T6)
+func WithGame(g Game) Option {
+	return func(rc *RCONClient) {
+		rc.game = g
+	}
+}
+
+// WithProfile makes the client speak a dialect the built-in titles don't cover, such as
+// a CoD4x server. Kick, Tell, Say, StatusPattern and InfoKeys left empty, and the
+// ban commands, come from the built-in profile of p.Game; Capabilities are p's own
+func WithProfile(p GameProfile) Option {
+	return func(rc *RCONClient) {
+		base := builtinProfile(p.Game)
+		if p.Kick == "" {
+			p.Kick = base.Kick
+		}
+		if p.Tell == "" {
+			p.Tell = base.Tell
+		}
+		if p.Say == "" {
+			p.Say = base.Say
+		}
+		if p.StatusPattern == nil {
+			p.StatusPattern = base.StatusPattern
+		}
+		if p.InfoKeys == (query.InfoKeys{}) {
+			p.InfoKeys = base.InfoKeys
+		}
+		p.bans = base.bans
+		rc.game, rc.custom = p.Game, &p
+	}
+}
+
+// Game returns the title the client was configured for
+func (rc *RCONClient) Game() Game {
+	if rc == nil {
+		return GameT6
+	}
+	return rc.game
+}
+
+// profile returns the client's game profile
+func (rc *RCONClient) profile() *GameProfile {
+	if rc != nil && rc.custom != nil {
+		return rc.custom
+	}
+	return builtinProfile(rc.Game())
+}
+
+// requireCapability returns ErrUnsupported when the title lacks a feature
+func (rc *RCONClient) requireCapability(ok bool, feature string) error {
+	if !ok {
+		return fmt.Errorf("%s on %s: %w", feature, rc.Game(), ErrUnsupported)
+	}
+	return nil
+}
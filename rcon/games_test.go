@@ -0,0 +1,27 @@
+package rcon
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProfileCopy(t *testing.T) {
+	p := Profile(GameIW5)
+	p.Kick = "kick %s"
+	p.Capabilities.Files = true
+	if got := Profile(GameIW5); got.Kick == "kick %s" || got.Capabilities.Files {
+		t.Errorf("changing a returned profile changed the built-in one: %+v", got)
+	}
+}
+
+func TestSetHardcoreNeedsTitleDvars(t *testing.T) {
+	srv := newFakeServer()
+	rc := srv.client(WithProfile(GameProfile{Game: GameIW5, Capabilities: Capabilities{Hardcore: true}}))
+	defer rc.Close()
+	if err := rc.SetHardcore(true); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("SetHardcore on IW5 = %v, want ErrUnsupported", err)
+	}
+	if cmds := srv.commands(); len(cmds) != 0 {
+		t.Errorf("sent %q", cmds)
+	}
+}
@@ -0,0 +1,233 @@
+package rcon
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// finalWarning is how long before the kick a second warning goes out, for graces
+// long enough that the first one may have scrolled away
+const finalWarning = 5 * time.Second
+
+// defaultKickMessage is what KickQueue tells players unless Message is set
+const defaultKickMessage = "You will be kicked in {time}: {reason}"
+
+// KickQueue kicks players after a grace period, with at most one pending kick per
+// client number. Each kick tells the player why and when, and goes ahead only if the
+// same player (client number and GUID) still holds the slot
+type KickQueue struct {
+	Client *RCONClient
+	// Message is told to the player ahead of the kick; {reason} and {time} are
+	// replaced (default "You will be kicked in {time}: {reason}")
+	Message string
+	// TimeText renders the wait for {time} (default "30 seconds", "2 minutes")
+	TimeText func(d time.Duration) string
+	// OnDone sees how each queued kick ended: nil once kicked, ErrSlotReused when the
+	// player left, context.Canceled when it was cancelled, or the send error
+	OnDone func(p Player, err error)
+
+	mu      sync.Mutex
+	pending map[int]*queuedKick
+	wg      sync.WaitGroup
+}
+
+type queuedKick struct {
+	p      Player
+	cancel context.CancelFunc
+}
+
+// Add queues a kick of p after grace, replacing one already pending for the slot
+func (q *KickQueue) Add(p Player, reason string, grace time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	k := &queuedKick{p: p, cancel: cancel}
+	q.mu.Lock()
+	if q.pending == nil {
+		q.pending = map[int]*queuedKick{}
+	}
+	if old := q.pending[p.ClientNum]; old != nil {
+		old.cancel()
+	}
+	q.pending[p.ClientNum] = k
+	q.wg.Add(1)
+	q.mu.Unlock()
+
+	go func() {
+		defer q.wg.Done()
+		defer cancel()
+		err := q.Kick(ctx, p, reason, grace)
+		q.mu.Lock()
+		if q.pending[p.ClientNum] == k {
+			delete(q.pending, p.ClientNum)
+		}
+		q.mu.Unlock()
+		if q.OnDone != nil {
+			q.OnDone(p, err)
+		}
+	}()
+}
+
+// Cancel drops the pending kick of a client number and reports whether there was one
+func (q *KickQueue) Cancel(clientNum int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	k := q.pending[clientNum]
+	if k == nil {
+		return false
+	}
+	k.cancel()
+	delete(q.pending, clientNum)
+	return true
+}
+
+// Pending returns the players with a kick pending, by client number
+func (q *KickQueue) Pending() []Player {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make([]Player, 0, len(q.pending))
+	for _, k := range q.pending {
+		out = append(out, k.p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ClientNum < out[j].ClientNum })
+	return out
+}
+
+// Stop cancels every pending kick and waits until they have ended
+func (q *KickQueue) Stop() {
+	q.mu.Lock()
+	for num, k := range q.pending {
+		k.cancel()
+		delete(q.pending, num)
+	}
+	q.mu.Unlock()
+	q.wg.Wait()
+}
+
+// Kick tells p why and when they will be kicked, waits grace, and kicks them only if
+// the same player is still connected. It blocks for grace, outside the queue; if they
+// left or someone else took the slot, nobody is kicked and the error is ErrSlotReused.
+// Once ctx is done the wait ends with ctx.Err() and nobody is kicked
+func (q *KickQueue) Kick(ctx context.Context, p Player, reason string, grace time.Duration) error {
+	rc := q.Client
+	if rc == nil {
+		return ErrNilClient
+	}
+	if reason == "" {
+		return fmt.Errorf("reason cannot be empty")
+	}
+	if err := rc.samePlayer(p); err != nil {
+		return err
+	}
+	if grace > 0 {
+		if err := rc.Tell(p.ClientNum, q.message(reason, grace)); err != nil {
+			return err
+		}
+		wait := grace
+		if grace > 3*finalWarning {
+			if err := sleepContext(ctx, grace-finalWarning); err != nil {
+				return err
+			}
+			if err := rc.samePlayer(p); err != nil {
+				return err
+			}
+			if err := rc.Tell(p.ClientNum, q.message(reason, finalWarning)); err != nil {
+				return err
+			}
+			wait = finalWarning
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+		if err := rc.samePlayer(p); err != nil {
+			return err
+		}
+	}
+	return rc.Kick(strconv.Itoa(p.ClientNum), reason)
+}
+
+// message renders Message for a kick in d
+func (q *KickQueue) message(reason string, d time.Duration) string {
+	msg := q.Message
+	if msg == "" {
+		msg = defaultKickMessage
+	}
+	timeText := q.TimeText
+	if timeText == nil {
+		timeText = graceText
+	}
+	return strings.NewReplacer("{reason}", reason, "{time}", timeText(d)).Replace(msg)
+}
+
+// KickWithGrace is KickQueue.Kick with the default English message
+func (rc *RCONClient) KickWithGrace(ctx context.Context, p Player, reason string, grace time.Duration) error {
+	return (&KickQueue{Client: rc}).Kick(ctx, p, reason, grace)
+}
+
+// sleepContext waits d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// verifyTarget applies the WithTargetGUID of opts to an action on player, which must
+// then be a client number
+func (rc *RCONClient) verifyTarget(player string, opts []CommandOption) error {
+	var s commandSettings
+	for _, opt := range opts {
+		opt(&s)
+	}
+	if s.targetGUID == "" {
+		return nil
+	}
+	num, err := strconv.Atoi(strings.TrimSpace(player))
+	if err != nil {
+		return fmt.Errorf("WithTargetGUID needs a client number, not %q", player)
+	}
+	return rc.samePlayer(Player{ClientNum: num, GUID: s.targetGUID})
+}
+
+// samePlayer checks on a fresh status that p's client number still holds p: the
+// same GUID, or the same name for players without one
+func (rc *RCONClient) samePlayer(p Player) error {
+	st, err := rc.Status()
+	if err != nil {
+		return err
+	}
+	for _, cur := range st.Players {
+		if cur.ClientNum != p.ClientNum {
+			continue
+		}
+		if p.GUID != "" && strings.EqualFold(cur.GUID, p.GUID) ||
+			p.GUID == "" && stripColorCodes(cur.Name) == stripColorCodes(p.Name) {
+			return nil
+		}
+		break
+	}
+	return fmt.Errorf("client %d: %w", p.ClientNum, ErrSlotReused)
+}
+
+// graceText renders a wait for players: "30 seconds", "2 minutes"
+func graceText(d time.Duration) string {
+	if d < time.Minute {
+		n := int((d + time.Second - 1) / time.Second)
+		if n == 1 {
+			return "1 second"
+		}
+		return strconv.Itoa(n) + " seconds"
+	}
+	n := int((d + time.Minute - 1) / time.Minute)
+	if n == 1 {
+		return "1 minute"
+	}
+	return strconv.Itoa(n) + " minutes"
+}
@@ -0,0 +1,104 @@
+package rcon
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// kickServer answers status with guid in slot 3, or an empty server once guid is ""
+func kickServer(guid *string, mu *sync.Mutex) *fakeServer {
+	srv := newFakeServer()
+	srv.handle = func(cmdline string) string {
+		if cmdline != "status" {
+			return ""
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		out := "map: mp_raid\nnum score bot ping guid name lastmsg address qport rate\n"
+		if *guid != "" {
+			out += "  3     0   0  50 " + *guid + " Sniper 0 1.2.3.4:28960 1234 25000\n"
+		}
+		return out
+	}
+	return srv
+}
+
+func sentCommand(srv *fakeServer, prefix string) []string {
+	var out []string
+	for _, c := range srv.commands() {
+		if strings.HasPrefix(c, prefix) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func TestKickQueueMessage(t *testing.T) {
+	var mu sync.Mutex
+	guid := "abcdef01"
+	srv := kickServer(&guid, &mu)
+	rc := srv.client(WithCommandDefaults(short))
+	defer rc.Close()
+
+	q := &KickQueue{Client: rc, Message: "Kick in {time}: {reason}", TimeText: func(time.Duration) string { return "soon" }}
+	p := Player{ClientNum: 3, GUID: guid}
+	if err := q.Kick(context.Background(), p, "camping", 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if tells := sentCommand(srv, "tell"); len(tells) != 1 || tells[0] != "tell 3 Kick in soon: camping" {
+		t.Errorf("tells %q", tells)
+	}
+	if kicks := sentCommand(srv, "clientkick"); len(kicks) != 1 {
+		t.Errorf("kicks %q", kicks)
+	}
+}
+
+func TestKickQueueLeftBeforeWarning(t *testing.T) {
+	var mu sync.Mutex
+	guid := ""
+	srv := kickServer(&guid, &mu)
+	rc := srv.client(WithCommandDefaults(short))
+	defer rc.Close()
+
+	err := rc.KickWithGrace(context.Background(), Player{ClientNum: 3, GUID: "abcdef01"}, "camping", time.Second)
+	if !errors.Is(err, ErrSlotReused) {
+		t.Fatalf("err = %v, want ErrSlotReused", err)
+	}
+	if tells := sentCommand(srv, "tell"); len(tells) != 0 {
+		t.Errorf("told an empty slot: %q", tells)
+	}
+}
+
+func TestKickQueueCancel(t *testing.T) {
+	var mu sync.Mutex
+	guid := "abcdef01"
+	srv := kickServer(&guid, &mu)
+	rc := srv.client(WithCommandDefaults(short))
+	defer rc.Close()
+
+	done := make(chan error, 1)
+	q := &KickQueue{Client: rc, OnDone: func(p Player, err error) { done <- err }}
+	q.Add(Player{ClientNum: 3, GUID: guid}, "camping", time.Minute)
+	if len(q.Pending()) != 1 {
+		t.Fatalf("pending %v", q.Pending())
+	}
+	if !q.Cancel(3) {
+		t.Fatal("Cancel found no pending kick")
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("cancelled kick kept waiting")
+	}
+	q.Stop()
+	if kicks := sentCommand(srv, "clientkick"); len(kicks) != 0 {
+		t.Errorf("kicked after cancel: %q", kicks)
+	}
+}
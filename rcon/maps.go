@@ -0,0 +1,219 @@
+package rcon
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	mapsMu sync.RWMutex
+	// knownMaps are the stock multiplayer and zombies maps of each title
+	knownMaps = map[Game]map[string]bool{
+		GameT6: mapSet(
+			"mp_la", "mp_dockside", "mp_carrier", "mp_drone", "mp_express", "mp_hijacked",
+			"mp_meltdown", "mp_overflow", "mp_nightclub", "mp_raid", "mp_slums", "mp_village",
+			"mp_turbine", "mp_socotra", "mp_nuketown_2020", "mp_downhill", "mp_mirage", "mp_hydro",
+			"mp_skate", "mp_concert", "mp_magma", "mp_vertigo", "mp_studio", "mp_uplink",
+			"mp_bridge", "mp_castaway", "mp_paintball", "mp_dig", "mp_frostbite", "mp_pod",
+			"mp_takeoff",
+			"zm_transit", "zm_nuked", "zm_highrise", "zm_prison", "zm_buried", "zm_tomb",
+		),
+		GameIW5: mapSet(
+			"mp_alpha", "mp_bootleg", "mp_bravo", "mp_carbon", "mp_dome", "mp_exchange",
+			"mp_hardhat", "mp_interchange", "mp_lambeth", "mp_mogadishu", "mp_paris", "mp_plaza2",
+			"mp_radar", "mp_seatown", "mp_underground", "mp_village", "mp_terminal_cls", "mp_rust",
+			"mp_highrise", "mp_italy", "mp_park", "mp_overwatch", "mp_morningwood", "mp_meteora",
+			"mp_cement", "mp_qadeem", "mp_aground_ss", "mp_courtyard_ss", "mp_hillside_ss",
+			"mp_restrepo_ss", "mp_burn_ss", "mp_crosswalk_ss", "mp_six_ss", "mp_shipbreaker",
+			"mp_roughneck", "mp_nola", "mp_boardwalk", "mp_moab",
+		),
+		GameT4: mapSet(
+			"mp_airfield", "mp_asylum", "mp_castle", "mp_shrine", "mp_courtyard", "mp_dome",
+			"mp_downfall", "mp_hangar", "mp_makin", "mp_makin_day", "mp_outskirts", "mp_roundhouse",
+			"mp_seelow", "mp_suburban", "mp_kneedeep", "mp_nachtfeuer", "mp_subway", "mp_kwai",
+			"mp_stalingrad", "mp_docks", "mp_drum", "mp_bgate", "mp_vodka",
+			"nazi_zombie_prototype", "nazi_zombie_asylum", "nazi_zombie_sumpf", "nazi_zombie_factory",
+		),
+		GameT5: mapSet(
+			"mp_array", "mp_cracked", "mp_crisis", "mp_firingrange", "mp_duga", "mp_hanoi",
+			"mp_cairo", "mp_havoc", "mp_cosmodrome", "mp_nuked", "mp_radiation", "mp_mountain",
+			"mp_villa", "mp_russianbase", "mp_berlinwall2", "mp_discovery", "mp_kowloon",
+			"mp_stadium", "mp_gridlock", "mp_hotel", "mp_outskirts", "mp_zoo", "mp_drivein",
+			"mp_area51", "mp_golfcourse", "mp_silo",
+			"zombie_theater", "zombie_pentagon", "zombietron", "zombie_cosmodrome", "zombie_coast",
+			"zombie_temple", "zombie_moon", "zombie_cod5_prototype", "zombie_cod5_asylum",
+			"zombie_cod5_sumpf", "zombie_cod5_factory",
+		),
+		GameIW6: mapSet(
+			"mp_prisonbreak", "mp_dart", "mp_lonestar", "mp_frag", "mp_snow", "mp_fahrenheit",
+			"mp_hashima", "mp_warhawk", "mp_sovereign", "mp_zebra", "mp_skeleton", "mp_chasm",
+			"mp_flooded", "mp_strikezone", "mp_descent_new", "mp_ca_red_river", "mp_ca_rumble",
+			"mp_swamp", "mp_boneyard_ns", "mp_ca_impact", "mp_ca_behemoth", "mp_battery3",
+			"mp_dig", "mp_favela_iw6", "mp_pirate", "mp_zulu", "mp_conflict", "mp_mine",
+			"mp_shipment_ns", "mp_zerosub",
+		),
+	}
+)
+
+func mapSet(names ...string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[n] = true
+	}
+	return m
+}
+
+// IsKnownMap reports whether a map ships with (or was registered for) a title
+func IsKnownMap(g Game, name string) bool {
+	mapsMu.RLock()
+	defer mapsMu.RUnlock()
+	return knownMaps[g][strings.ToLower(strings.TrimSpace(name))]
+}
+
+// RegisterMap adds a custom or usermap so rotation and map changes of every client accept it
+func RegisterMap(g Game, name string) {
+	mapsMu.Lock()
+	defer mapsMu.Unlock()
+	if knownMaps[g] == nil {
+		knownMaps[g] = map[string]bool{}
+	}
+	knownMaps[g][strings.ToLower(strings.TrimSpace(name))] = true
+}
+
+// KnownMaps returns the known maps of a title in sorted order
+func KnownMaps(g Game) []string {
+	mapsMu.RLock()
+	defer mapsMu.RUnlock()
+	out := make([]string, 0, len(knownMaps[g]))
+	for name := range knownMaps[g] {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// AvailableMaps returns the maps the server can load, sorted: the title's known maps, the
+// ones the configured mod lists, the maps installed on titles that can list files, and
+// every map this client has seen in sv_maprotation or status
+func (rc *RCONClient) AvailableMaps() ([]string, error) {
+	if _, err := rc.GetMapRotation(); err != nil {
+		return nil, err
+	}
+	set := rc.availableMaps()
+	if files, err := rc.ListMapFiles(); err == nil {
+		for _, f := range files {
+			set[strings.ToLower(f)] = true
+		}
+	} else if !errors.Is(err, ErrUnsupported) {
+		return nil, err
+	}
+	out := make([]string, 0, len(set))
+	for name := range set {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// availableMaps collects the maps known without asking the server
+func (rc *RCONClient) availableMaps() map[string]bool {
+	set := map[string]bool{}
+	if rc == nil {
+		return set
+	}
+	for _, name := range KnownMaps(rc.game) {
+		set[name] = true
+	}
+	if p := rc.modParser(); p != nil {
+		for _, name := range p.Maps {
+			set[strings.ToLower(strings.TrimSpace(name))] = true
+		}
+	}
+	rc.seenMu.Lock()
+	for name := range rc.seenMaps {
+		set[name] = true
+	}
+	rc.seenMu.Unlock()
+	return set
+}
+
+// noteMaps remembers maps the server reported, so later edits accept them
+func (rc *RCONClient) noteMaps(names ...string) {
+	if rc == nil {
+		return
+	}
+	rc.seenMu.Lock()
+	defer rc.seenMu.Unlock()
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || strings.ContainsAny(name, " \t\"") {
+			continue
+		}
+		if rc.seenMaps == nil {
+			rc.seenMaps = map[string]bool{}
+		}
+		rc.seenMaps[name] = true
+	}
+}
+
+// noteRotation remembers the maps of a sv_maprotation value
+func (rc *RCONClient) noteRotation(entries []RotationEntry) {
+	for _, e := range entries {
+		rc.noteMaps(e.Map)
+	}
+}
+
+// suggestMaps returns up to three maps close to name: those containing it or within a
+// few edits of it, closest first
+func suggestMaps(name string, maps map[string]bool) []string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	type candidate struct {
+		name string
+		dist int
+	}
+	var found []candidate
+	limit := max(2, len(name)/3)
+	for m := range maps {
+		d := editDistance(name, m)
+		if strings.Contains(m, name) || strings.Contains(name, m) {
+			d = min(d, 1)
+		}
+		if d <= limit {
+			found = append(found, candidate{m, d})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].dist != found[j].dist {
+			return found[i].dist < found[j].dist
+		}
+		return found[i].name < found[j].name
+	})
+	var out []string
+	for i := 0; i < len(found) && i < 3; i++ {
+		out = append(out, found[i].name)
+	}
+	return out
+}
+
+// editDistance is the Levenshtein distance of two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,144 @@
+package rcon
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+)
+
+// MaxMessageLength is how many visible characters Say and Tell put in one packet;
+// longer messages are split at spaces since the game cuts them off
+const MaxMessageLength = 100
+
+// WithMessagePrefix prepends a tag such as "[^5Admin^7] " to every Say and Tell packet
+func WithMessagePrefix(prefix string) Option {
+	return func(rc *RCONClient) {
+		rc.messagePrefix = prefix
+	}
+}
+
+// Colors builds color-coded text; see Color
+type Colors struct{}
+
+// Color builds color-coded text that resets to white afterwards: Color.Red("text") is "^1text^7"
+var Color Colors
+
+func (Colors) Black(s string) string   { return colorize('0', s) }
+func (Colors) Red(s string) string     { return colorize('1', s) }
+func (Colors) Green(s string) string   { return colorize('2', s) }
+func (Colors) Yellow(s string) string  { return colorize('3', s) }
+func (Colors) Blue(s string) string    { return colorize('4', s) }
+func (Colors) Cyan(s string) string    { return colorize('5', s) }
+func (Colors) Magenta(s string) string { return colorize('6', s) }
+func (Colors) White(s string) string   { return colorize('7', s) }
+func (Colors) Gray(s string) string    { return colorize('9', s) }
+
+// Plain strips the color codes of untrusted text, e.g. a player name, so it can't recolor the rest of a message
+func (Colors) Plain(s string) string { return StripColors(s) }
+
+func colorize(code byte, s string) string {
+	return "^" + string(code) + s + "^7"
+}
+
+// StripColors removes ^N color codes
+func StripColors(s string) string { return wire.StripColors(s) }
+
+// VisibleLength counts the characters of s that are shown in game, ignoring color codes
+func VisibleLength(s string) int { return utf8.RuneCountInString(StripColors(s)) }
+
+// messageChunks splits a message into packets of at most MaxMessageLength visible
+// characters including the client's prefix
+func (rc *RCONClient) messageChunks(message string) []string {
+	var prefix string
+	if rc != nil {
+		prefix = rc.messagePrefix
+	}
+	max := MaxMessageLength - VisibleLength(prefix)
+	if max < 20 {
+		max = 20
+	}
+	chunks := splitMessage(message, max)
+	for i := range chunks {
+		chunks[i] = prefix + chunks[i]
+	}
+	return chunks
+}
+
+// splitMessage breaks msg into chunks of at most max visible characters, at spaces where
+// possible. A color active at the end of one chunk is repeated at the start of the next
+func splitMessage(msg string, max int) []string {
+	if VisibleLength(msg) <= max {
+		return []string{msg}
+	}
+	var out []string
+	var cur strings.Builder
+	curLen := 0
+	carry := ""
+	flush := func() {
+		if curLen == 0 {
+			return
+		}
+		chunk := carry + cur.String()
+		out = append(out, chunk)
+		if c := lastColor(chunk); c != "^7" {
+			carry = c
+		} else {
+			carry = ""
+		}
+		cur.Reset()
+		curLen = 0
+	}
+	for _, word := range strings.Split(msg, " ") {
+		n := VisibleLength(word)
+		if curLen > 0 && curLen+1+n <= max {
+			cur.WriteString(" " + word)
+			curLen += 1 + n
+			continue
+		}
+		flush()
+		for n > max {
+			head, tail := cutVisible(word, max)
+			cur.WriteString(head)
+			curLen = max
+			flush()
+			word, n = tail, VisibleLength(tail)
+		}
+		cur.WriteString(word)
+		curLen = n
+	}
+	flush()
+	return out
+}
+
+// cutVisible splits s after n visible characters, keeping color codes intact
+func cutVisible(s string, n int) (string, string) {
+	seen := 0
+	for i := 0; i < len(s); {
+		if s[i] == '^' && i+1 < len(s) && isColorChar(s[i+1]) {
+			i += 2
+			continue
+		}
+		if seen == n {
+			return s[:i], s[i:]
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		seen++
+	}
+	return s, ""
+}
+
+// lastColor returns the last ^N code in s, or "" if there is none
+func lastColor(s string) string {
+	for i := len(s) - 2; i >= 0; i-- {
+		if s[i] == '^' && isColorChar(s[i+1]) {
+			return s[i : i+2]
+		}
+	}
+	return ""
+}
+
+func isColorChar(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
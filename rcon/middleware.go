@@ -0,0 +1,55 @@
+package rcon
+
+// Command is an outbound RCON command as seen by middleware
+type Command struct {
+	Name string
+	Args *string
+
+	opts []CommandOption
+}
+
+// Line returns the command as typed in a console
+func (c Command) Line() string {
+	if c.Args == nil || *c.Args == "" {
+		return c.Name
+	}
+	return c.Name + " " + *c.Args
+}
+
+// CommandFunc sends a command and returns the reply lines
+type CommandFunc func(cmd Command) ([]string, error)
+
+// Middleware wraps the sending of every command, including the ones issued by helpers
+// such as Say, Kick or GetDvar. It can log, measure, rewrite or short-circuit (dry-run) them
+type Middleware func(next CommandFunc) CommandFunc
+
+// Use appends middleware to the client; the first one added is the outermost.
+// Middleware runs without the client lock held, so it may call back into the client
+func (rc *RCONClient) Use(mw ...Middleware) {
+	if rc == nil {
+		return
+	}
+	rc.mwMu.Lock()
+	defer rc.mwMu.Unlock()
+	rc.middleware = append(rc.middleware, mw...)
+}
+
+// chain builds the CommandFunc of the current middleware stack
+func (rc *RCONClient) chain() CommandFunc {
+	rc.mwMu.Lock()
+	mws := rc.middleware
+	rc.mwMu.Unlock()
+
+	next := CommandFunc(rc.send)
+	for i := len(mws) - 1; i >= 0; i-- {
+		next = mws[i](next)
+	}
+	return next
+}
+
+// hasMiddleware reports whether any middleware is installed
+func (rc *RCONClient) hasMiddleware() bool {
+	rc.mwMu.Lock()
+	defer rc.mwMu.Unlock()
+	return len(rc.middleware) > 0
+}
@@ -1,9 +1,10 @@
 package rcon
 
 import (
-	"net"
 	"sync"
 	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/query"
 )
 
 type RCONClient struct {
@@ -11,82 +12,127 @@ type RCONClient struct {
 	Port     int
 	Password string
 	Timeout  time.Duration
-	Conn     *net.UDPConn
+	conn     Transport
+	closed   bool
 	mu       sync.Mutex
+	gate     commandGate
+	observer bool
+	dialer   Dialer
+	codec    Codec
+	game     Game
+	custom   *GameProfile
+
+	maxResponse    int
+	rejectedBase   uint64
+	queryChallenge bool
+	queryMaxAge    time.Duration
+	messagePrefix  string
+	codepage       *Codepage
+	commandOpts    []CommandOption
+	ipPrivacy      *IPPrivacy
+	optErr         error
+	learner        *timeoutLearner
+	offline        *offlineQueue
+	onOffline      func(OfflineResult)
+
+	dvarCache  *dvarCache
+	modMu      sync.Mutex
+	mod        string
+	modKnown   bool
+	modForced  bool
+	modMap     string
+	modFailed  time.Time
+	seenMu     sync.Mutex
+	seenMaps   map[string]bool
+	mwMu       sync.Mutex
+	middleware []Middleware
+
+	limiter   *rateLimiter
+	queueSize int
+	queue     chan queuedCommand
+	queueDone chan struct{}
+	queueMu   sync.RWMutex
+	queueOnce sync.Once
+	queueStop sync.Once
+
+	state             ConnState
+	timeouts          int
+	reconnectAfter    int
+	reconnectAttempts int
+	redialing         bool
+	backoffMin        time.Duration
+	backoffMax        time.Duration
+	onState           func(old, new ConnState)
 }
 
 type Player struct {
-	ClientNum int
-	Name      string
-	Ping      any
-	Score     int
-	IP        string
-	Port      int
-	QPort     int
-	GUID      string
-	LastMsg   int
-	Rate      int
+	ClientNum int    `json:"client_num"`
+	Name      string `json:"name"`
+	// Ping is 0 while the player is loading or a zombie
+	Ping int `json:"ping"`
+	// Loading is set while status shows LOAD or CNCT instead of a ping
+	Loading bool `json:"loading"`
+	// Zombie is set for a disconnecting slot (ZMBI)
+	Zombie  bool   `json:"zombie,omitempty"`
+	Score   int    `json:"score"`
+	IP      string `json:"ip"`
+	Port    int    `json:"port"`
+	QPort   int    `json:"qport"`
+	GUID    string `json:"guid"`
+	LastMsg int    `json:"last_msg"`
+	Rate    int    `json:"rate"`
 }
 
-type ServerStatus struct {
-	Map         string
-	Players     []Player
-	Raw         []string
-	RetrievedAt time.Time
+// UntypedPing returns the ping the way v1's Player.Ping held it: "LOAD" while the
+// player is loading, "ZMBI" for a disconnecting slot and the int ping otherwise.
+//
+// Deprecated: use Ping with Loading and Zombie
+func (p Player) UntypedPing() any {
+	switch {
+	case p.Loading:
+		return "LOAD"
+	case p.Zombie:
+		return "ZMBI"
+	}
+	return p.Ping
 }
 
-type ServerInfo struct {
-	NetFieldChk int64
-	Protocol    int
-	SessionMode int
-	Hostname    string
-	MapName     string
-	IsInGame    bool
-	MaxClients  int
-	GameType    string
-	HW          int
-	Mod         bool
-	Voice       bool
-	SecKey      string
-	SecID       string
-	HostAddr    string
-	RetrievedAt time.Time
+type ServerStatus struct {
+	Map     string   `json:"map"`
+	Players []Player `json:"players"`
+	Raw     []string `json:"raw,omitempty"`
+	// Truncated is set when the reply looked cut off, so Players may be incomplete
+	Truncated bool `json:"truncated"`
+	// RetrievedAt keeps its monotonic reading until serialized, for ordering against log events
+	RetrievedAt time.Time `json:"retrieved_at"`
 }
 
-type ServerStatusInfo struct {
-	ComMaxClients            int
-	GameType                 string
-	RandomSeed               int
-	GameName                 string
-	MapName                  string
-	PlaylistEnabled          bool
-	PlaylistEntry            int
-	Protocol                 int
-	ScrTeamFFType            int
-	ShortVersion             bool
-	SvAllowAimAssist         bool
-	SvAllowAnonymous         bool
-	SvClientFpsLimit         int
-	SvDisableClientConsole   bool
-	SvHostname               string
-	SvMaxClients             int
-	SvMaxPing                int
-	SvMinPing                int
-	SvPatchDSR50             bool
-	SvPrivateClients         int
-	SvPrivateClientsForUsers int
-	SvPure                   bool
-	SvVoice                  bool
-	PasswordEnabled          bool
-	ModEnabled               bool
-	RetrievedAt              time.Time
-}
+type ServerInfo = query.ServerInfo
+
+type ServerStatusInfo = query.ServerStatusInfo
+
+type StatusPlayer = query.StatusPlayer
+
+type QueryClient = query.Client
+
+type LatencyStats = query.LatencyStats
 
 type commandSettings struct {
 	retries        int
 	readTimeout    time.Duration
 	readExtension  time.Duration
+	timeoutSet     bool
+	extensionSet   bool
 	requireSuccess bool
+	truncated      *bool
+	backoff        Backoff
+	targetGUID     string
+	priority       Priority
 }
 
-type commandOption func(*commandSettings)
+type FullServerState struct {
+	Info        *ServerInfo       `json:"info"`
+	StatusInfo  *ServerStatusInfo `json:"status_info"`
+	Status      *ServerStatus     `json:"status"`
+	RetrievedAt time.Time         `json:"retrieved_at"`
+}
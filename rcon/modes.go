@@ -0,0 +1,73 @@
+package rcon
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/query"
+)
+
+// hardcoreDvars are, per title, the dvars that make up hardcore (index 1) and core
+// (index 0) rules. Only titles listed here can toggle hardcore
+var hardcoreDvars = map[Game]map[string][2]string{
+	GameT6: {
+		"scr_hardcore":               {"0", "1"},
+		"ui_hud_hardcore":            {"0", "1"},
+		"scr_team_fftype":            {"0", "1"},
+		"scr_player_maxhealth":       {"100", "30"},
+		"scr_player_healthregentime": {"5", "0"},
+	},
+}
+
+// Switch between hardcore and core rules, rolling back every dvar if one fails
+func (rc *RCONClient) SetHardcore(enabled bool) error {
+	dvars, ok := hardcoreDvars[rc.Game()]
+	if err := rc.requireCapability(ok && rc.profile().Capabilities.Hardcore, "hardcore toggle"); err != nil {
+		return err
+	}
+	idx := 0
+	if enabled {
+		idx = 1
+	}
+	values := make(map[string]string, len(dvars))
+	for name, v := range dvars {
+		values[name] = v[idx]
+	}
+	return rc.applyDvars(values)
+}
+
+type FriendlyFire = query.FriendlyFire
+
+const (
+	FriendlyFireOff     = query.FriendlyFireOff
+	FriendlyFireOn      = query.FriendlyFireOn
+	FriendlyFireReflect = query.FriendlyFireReflect
+	FriendlyFireShared  = query.FriendlyFireShared
+)
+
+// freeForAllGametypes have no teams, so friendly fire can only be off
+var freeForAllGametypes = map[string]bool{
+	"dm":   true,
+	"gun":  true,
+	"oic":  true,
+	"shrp": true,
+	"sas":  true,
+}
+
+// Set the friendly fire mode, validated against the running gametype
+func (rc *RCONClient) SetFriendlyFire(ff FriendlyFire) error {
+	if ff < FriendlyFireOff || ff > FriendlyFireShared {
+		return fmt.Errorf("invalid friendly fire mode %d", int(ff))
+	}
+
+	gametype, err := rc.GetDvar("g_gametype")
+	if err != nil {
+		return err
+	}
+	gametype = strings.ToLower(strings.TrimSpace(gametype))
+	if ff != FriendlyFireOff && freeForAllGametypes[gametype] {
+		return fmt.Errorf("friendly fire %s is not supported in %s", ff, gametype)
+	}
+	return rc.SetDvar("scr_team_fftype", strconv.Itoa(int(ff)))
+}
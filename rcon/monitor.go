@@ -0,0 +1,166 @@
+package rcon
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Monitor polls Status and fires callbacks when players join or leave, the map changes
+// or the server stops answering. Register callbacks before calling Run
+type Monitor struct {
+	Client   *RCONClient
+	Interval time.Duration
+
+	// LeaveAfter is how many polls a player must be missing before OnPlayerLeave fires (default 2),
+	// so a dropped reply or a quick reconnect doesn't look like a leave
+	LeaveAfter int
+	// DownAfter is how many failed polls in a row fire OnServerDown (default 3)
+	DownAfter int
+
+	OnPlayerJoin  func(p Player)
+	OnPlayerLeave func(p Player)
+	OnMapChange   func(old, new string)
+	OnServerDown  func(err error)
+	OnServerUp    func()
+	// OnStatus sees every successful poll, after the other callbacks
+	OnStatus func(st *ServerStatus)
+
+	mu       sync.Mutex
+	players  map[string]*seenPlayer
+	mapName  string
+	failures int
+	down     bool
+	primed   bool
+}
+
+type seenPlayer struct {
+	player  Player
+	missing int
+}
+
+// Run polls until ctx is done
+func (m *Monitor) Run(ctx context.Context) {
+	interval := m.Interval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		m.Poll()
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Poll runs one status poll and fires callbacks for what changed since the last one
+func (m *Monitor) Poll() {
+	st, err := m.Client.Status(WithPriority(PriorityPoll))
+
+	m.mu.Lock()
+	if err != nil {
+		m.failures++
+		fire := !m.down && m.failures >= defaultInt(m.DownAfter, 3)
+		if fire {
+			m.down = true
+		}
+		m.mu.Unlock()
+		if fire && m.OnServerDown != nil {
+			m.OnServerDown(err)
+		}
+		return
+	}
+
+	up := m.down
+	m.down, m.failures = false, 0
+	joined, left, oldMap, mapChanged := m.diff(st)
+	m.mu.Unlock()
+
+	if up && m.OnServerUp != nil {
+		m.OnServerUp()
+	}
+	if mapChanged && m.OnMapChange != nil {
+		m.OnMapChange(oldMap, st.Map)
+	}
+	for _, p := range left {
+		if m.OnPlayerLeave != nil {
+			m.OnPlayerLeave(p)
+		}
+	}
+	for _, p := range joined {
+		if m.OnPlayerJoin != nil {
+			m.OnPlayerJoin(p)
+		}
+	}
+	if m.OnStatus != nil {
+		m.OnStatus(st)
+	}
+}
+
+// Players returns the players the monitor currently considers connected, by ClientNum
+func (m *Monitor) Players() []Player {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Player, 0, len(m.players))
+	for _, sp := range m.players {
+		out = append(out, sp.player)
+	}
+	sortPlayers(out, ByClientNum)
+	return out
+}
+
+// diff updates the tracked players from a status snapshot; callers hold m.mu.
+// The first snapshot only primes the state so existing players aren't reported as joins
+func (m *Monitor) diff(st *ServerStatus) (joined, left []Player, oldMap string, mapChanged bool) {
+	if m.players == nil {
+		m.players = map[string]*seenPlayer{}
+	}
+	oldMap = m.mapName
+	mapChanged = m.primed && st.Map != m.mapName
+	m.mapName = st.Map
+
+	present := make(map[string]bool, len(st.Players))
+	for _, p := range st.Players {
+		key := playerKey(p)
+		present[key] = true
+		if sp, ok := m.players[key]; ok {
+			sp.player, sp.missing = p, 0
+			continue
+		}
+		m.players[key] = &seenPlayer{player: p}
+		if m.primed {
+			joined = append(joined, p)
+		}
+	}
+
+	after := defaultInt(m.LeaveAfter, 2)
+	for key, sp := range m.players {
+		if present[key] {
+			continue
+		}
+		sp.missing++
+		if sp.missing >= after {
+			delete(m.players, key)
+			left = append(left, sp.player)
+		}
+	}
+	m.primed = true
+	return joined, left, oldMap, mapChanged
+}
+
+// playerKey identifies a player across polls; bots share a GUID so the slot is part of it
+func playerKey(p Player) string {
+	return strconv.Itoa(p.ClientNum) + ":" + p.GUID
+}
+
+func defaultInt(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
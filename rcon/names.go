@@ -0,0 +1,204 @@
+package rcon
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+var (
+	namesMu sync.RWMutex
+	// mapNames are the names players know the maps of each title by
+	mapNames = map[Game]map[string]string{
+		GameT6: {
+			"mp_la": "Aftermath", "mp_dockside": "Cargo", "mp_carrier": "Carrier", "mp_drone": "Drone",
+			"mp_express": "Express", "mp_hijacked": "Hijacked", "mp_meltdown": "Meltdown",
+			"mp_overflow": "Overflow", "mp_nightclub": "Plaza", "mp_raid": "Raid", "mp_slums": "Slums",
+			"mp_village": "Standoff", "mp_turbine": "Turbine", "mp_socotra": "Yemen",
+			"mp_nuketown_2020": "Nuketown 2025", "mp_downhill": "Downhill", "mp_mirage": "Mirage",
+			"mp_hydro": "Hydro", "mp_skate": "Grind", "mp_concert": "Encore", "mp_magma": "Magma",
+			"mp_vertigo": "Vertigo", "mp_studio": "Studio", "mp_uplink": "Uplink", "mp_bridge": "Detour",
+			"mp_castaway": "Cove", "mp_paintball": "Rush", "mp_dig": "Dig", "mp_frostbite": "Frost",
+			"mp_pod": "Pod", "mp_takeoff": "Takeoff",
+			"zm_transit": "TranZit", "zm_nuked": "Nuketown Zombies", "zm_highrise": "Die Rise",
+			"zm_prison": "Mob of the Dead", "zm_buried": "Buried", "zm_tomb": "Origins",
+		},
+		GameIW5: {
+			"mp_alpha": "Lockdown", "mp_bootleg": "Bootleg", "mp_bravo": "Mission", "mp_carbon": "Carbon",
+			"mp_dome": "Dome", "mp_exchange": "Downturn", "mp_hardhat": "Hardhat",
+			"mp_interchange": "Interchange", "mp_lambeth": "Fallen", "mp_mogadishu": "Bakaara",
+			"mp_paris": "Resistance", "mp_plaza2": "Arkaden", "mp_radar": "Outpost",
+			"mp_seatown": "Seatown", "mp_underground": "Underground", "mp_village": "Village",
+			"mp_terminal_cls": "Terminal", "mp_rust": "Rust", "mp_highrise": "Highrise",
+			"mp_italy": "Piazza", "mp_park": "Liberation", "mp_overwatch": "Overwatch",
+			"mp_morningwood": "Black Box", "mp_meteora": "Sanctuary", "mp_cement": "Foundation",
+			"mp_qadeem": "Oasis", "mp_aground_ss": "Aground", "mp_courtyard_ss": "Erosion",
+			"mp_hillside_ss": "Getaway", "mp_restrepo_ss": "Lookout", "mp_burn_ss": "U-Turn",
+			"mp_crosswalk_ss": "Intersection", "mp_six_ss": "Vortex", "mp_shipbreaker": "Decommission",
+			"mp_roughneck": "Off Shore", "mp_nola": "Parish", "mp_boardwalk": "Boardwalk",
+			"mp_moab": "Gulch",
+		},
+		GameT4: {
+			"mp_airfield": "Airfield", "mp_asylum": "Asylum", "mp_castle": "Castle",
+			"mp_shrine": "Cliffside", "mp_courtyard": "Courtyard", "mp_dome": "Dome",
+			"mp_downfall": "Downfall", "mp_hangar": "Hangar", "mp_makin": "Makin",
+			"mp_makin_day": "Makin Day", "mp_outskirts": "Outskirts", "mp_roundhouse": "Roundhouse",
+			"mp_seelow": "Seelow", "mp_suburban": "Upheaval", "mp_kneedeep": "Knee Deep",
+			"mp_nachtfeuer": "Nightfire", "mp_subway": "Station", "mp_kwai": "Banzai",
+			"mp_stalingrad": "Corrosion", "mp_docks": "Sub Pens", "mp_drum": "Battery",
+			"mp_bgate": "Breach", "mp_vodka": "Revolution",
+			"nazi_zombie_prototype": "Nacht der Untoten", "nazi_zombie_asylum": "Verrückt",
+			"nazi_zombie_sumpf": "Shi No Numa", "nazi_zombie_factory": "Der Riese",
+		},
+		GameT5: {
+			"mp_array": "Array", "mp_cracked": "Cracked", "mp_crisis": "Crisis",
+			"mp_firingrange": "Firing Range", "mp_duga": "Grid", "mp_hanoi": "Hanoi",
+			"mp_cairo": "Havana", "mp_havoc": "Jungle", "mp_cosmodrome": "Launch",
+			"mp_nuked": "Nuketown", "mp_radiation": "Radiation", "mp_mountain": "Summit",
+			"mp_villa": "Villa", "mp_russianbase": "WMD", "mp_berlinwall2": "Berlin Wall",
+			"mp_discovery": "Discovery", "mp_kowloon": "Kowloon", "mp_stadium": "Stadium",
+			"mp_gridlock": "Convoy", "mp_hotel": "Hotel", "mp_outskirts": "Stockpile", "mp_zoo": "Zoo",
+			"mp_drivein": "Drive-In", "mp_area51": "Hangar 18", "mp_golfcourse": "Hazard",
+			"mp_silo": "Silo",
+
+			"zombie_theater": "Kino der Toten", "zombie_pentagon": "Five", "zombietron": "Dead Ops Arcade",
+			"zombie_cosmodrome": "Ascension", "zombie_coast": "Call of the Dead",
+			"zombie_temple": "Shangri-La", "zombie_moon": "Moon",
+			"zombie_cod5_prototype": "Nacht der Untoten", "zombie_cod5_asylum": "Verrückt",
+			"zombie_cod5_sumpf": "Shi No Numa", "zombie_cod5_factory": "Der Riese",
+		},
+		GameIW6: {
+			"mp_prisonbreak": "Prison Break", "mp_dart": "Octane", "mp_lonestar": "Tremor",
+			"mp_frag": "Freight", "mp_snow": "Whiteout", "mp_fahrenheit": "Stormfront",
+			"mp_hashima": "Siege", "mp_warhawk": "Warhawk", "mp_sovereign": "Sovereign",
+			"mp_zebra": "Overlord", "mp_skeleton": "Stonehaven", "mp_chasm": "Chasm",
+			"mp_flooded": "Flooded", "mp_strikezone": "Strikezone", "mp_descent_new": "Free Fall",
+			"mp_ca_red_river": "Containment", "mp_ca_rumble": "Bayview", "mp_swamp": "Fog",
+			"mp_boneyard_ns": "Ignition", "mp_ca_impact": "Collision", "mp_ca_behemoth": "Behemoth",
+			"mp_battery3": "Ruins", "mp_dig": "Pharaoh", "mp_favela_iw6": "Favela",
+			"mp_pirate": "Mutiny", "mp_zulu": "Departed", "mp_conflict": "Dynasty",
+			"mp_mine": "Goldrush", "mp_shipment_ns": "Showtime", "mp_zerosub": "Subzero",
+		},
+	}
+	// gametypeNames are the menu names of each title's gametypes
+	gametypeNames = map[Game]map[string]string{
+		GameT6: {
+			"tdm": "Team Deathmatch", "dm": "Free-for-All", "dom": "Domination", "koth": "Hardpoint",
+			"sd": "Search and Destroy", "dem": "Demolition", "ctf": "Capture the Flag",
+			"conf": "Kill Confirmed", "hq": "Headquarters", "oneflag": "One Flag CTF",
+			"sas": "Sticks and Stones", "gun": "Gun Game", "oic": "One in the Chamber",
+			"shrp": "Sharpshooter",
+
+			"zclassic": "Classic", "zstandard": "Survival", "zgrief": "Grief", "zcleansed": "Turned",
+		},
+		GameIW5: {
+			"war": "Team Deathmatch", "dm": "Free-for-All", "dom": "Domination", "koth": "Headquarters",
+			"sab": "Sabotage", "sd": "Search and Destroy", "dd": "Demolition", "ctf": "Capture the Flag",
+			"conf": "Kill Confirmed", "tdef": "Team Defender", "infect": "Infected", "gun": "Gun Game",
+			"jugg": "Juggernaut", "tjugg": "Team Juggernaut", "oic": "One in the Chamber",
+			"grnd": "Drop Zone",
+		},
+		GameT4: {
+			"tdm": "Team Deathmatch", "dm": "Free-for-All", "dom": "Domination", "koth": "Headquarters",
+			"sab": "Sabotage", "sd": "Search and Destroy", "ctf": "Capture the Flag", "twar": "War",
+		},
+		GameT5: {
+			"tdm": "Team Deathmatch", "dm": "Free-for-All", "dom": "Domination", "koth": "Headquarters",
+			"sab": "Sabotage", "sd": "Search and Destroy", "dem": "Demolition", "ctf": "Capture the Flag",
+			"hlnd": "Sticks and Stones", "gun": "Gun Game", "oic": "One in the Chamber",
+			"shrp": "Sharpshooter",
+		},
+		GameIW6: {
+			"war": "Team Deathmatch", "dm": "Free-for-All", "dom": "Domination", "conf": "Kill Confirmed",
+			"sd": "Search and Destroy", "sr": "Search and Rescue", "blitz": "Blitz", "cranked": "Cranked",
+			"grind": "Grind", "infect": "Infected", "gun": "Gun Game", "siege": "Reinforce",
+			"sotf": "Hunted", "sotf_ffa": "Free-for-All Hunted", "horde": "Safeguard",
+		},
+	}
+)
+
+// MapDisplayName returns the name players know a map by ("mp_nuketown_2020" is "Nuketown
+// 2025"), or name itself when the title has none for it
+func MapDisplayName(g Game, name string) string {
+	return displayName(mapNames, g, name)
+}
+
+// GametypeDisplayName returns the menu name of a gametype ("tdm" is "Team Deathmatch"),
+// or gametype itself when the title has none for it
+func GametypeDisplayName(g Game, gametype string) string {
+	return displayName(gametypeNames, g, gametype)
+}
+
+// MapByDisplayName resolves a display name, ignoring case, spaces and punctuation, to the
+// internal map name; internal names are accepted as they are
+func MapByDisplayName(g Game, display string) (string, bool) {
+	return internalName(mapNames, g, display)
+}
+
+// GametypeByDisplayName resolves a gametype's display name to its internal name
+func GametypeByDisplayName(g Game, display string) (string, bool) {
+	return internalName(gametypeNames, g, display)
+}
+
+// RegisterMapName sets the display name of a custom map; RegisterMap still decides which
+// maps changes accept
+func RegisterMapName(g Game, name, display string) {
+	registerName(mapNames, g, name, display)
+}
+
+// RegisterGametypeName sets the display name of a custom gametype
+func RegisterGametypeName(g Game, gametype, display string) {
+	registerName(gametypeNames, g, gametype, display)
+}
+
+func displayName(table map[Game]map[string]string, g Game, name string) string {
+	namesMu.RLock()
+	defer namesMu.RUnlock()
+	if d, ok := table[g][strings.ToLower(strings.TrimSpace(name))]; ok {
+		return d
+	}
+	return name
+}
+
+func internalName(table map[Game]map[string]string, g Game, display string) (string, bool) {
+	namesMu.RLock()
+	defer namesMu.RUnlock()
+	key := strings.ToLower(strings.TrimSpace(display))
+	if _, ok := table[g][key]; ok {
+		return key, true
+	}
+	want := foldName(display)
+	if want == "" {
+		return "", false
+	}
+	for name, d := range table[g] {
+		if foldName(d) == want {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func registerName(table map[Game]map[string]string, g Game, name, display string) {
+	namesMu.Lock()
+	defer namesMu.Unlock()
+	if table[g] == nil {
+		table[g] = map[string]string{}
+	}
+	table[g][strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(display)
+}
+
+// foldName lowercases a display name and drops everything but letters and digits, so
+// "free for all" matches "Free-for-All"
+func foldName(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if r == 'ü' {
+			r = 'u'
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,388 @@
+package rcon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ActionKind names an admin action the offline queue can hold
+type ActionKind string
+
+const (
+	ActionBan      ActionKind = "ban"
+	ActionTempBan  ActionKind = "tempban"
+	ActionUnban    ActionKind = "unban"
+	ActionSetDvar  ActionKind = "dvar"
+	ActionRotation ActionKind = "rotation"
+)
+
+// OfflineAction is an admin action held while the server was unreachable
+type OfflineAction struct {
+	ID   uint64     `json:"id"`
+	Kind ActionKind `json:"kind"`
+	// Target is the player name or GUID, or the dvar name
+	Target string `json:"target"`
+	// Value is the dvar value or the formatted rotation
+	Value    string        `json:"value,omitempty"`
+	Reason   string        `json:"reason,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+	// Base is what the dvar was last read as before queueing, when HasBase is set. If the
+	// server reports another value on reconnect, someone changed it meanwhile
+	Base     string    `json:"base,omitempty"`
+	HasBase  bool      `json:"has_base,omitempty"`
+	QueuedAt time.Time `json:"queued_at"`
+}
+
+// ActionOutcome is what happened to a queued action once the server was back
+type ActionOutcome int
+
+const (
+	OutcomeApplied ActionOutcome = iota
+	// OutcomeAlreadyApplied means the server already was in the wanted state, so nothing was sent
+	OutcomeAlreadyApplied
+	// OutcomeConflict means the target changed while the server was unreachable; the action is dropped
+	OutcomeConflict
+	// OutcomeExpired means a temp ban ran out before the server came back
+	OutcomeExpired
+	OutcomeFailed
+)
+
+func (o ActionOutcome) String() string {
+	switch o {
+	case OutcomeApplied:
+		return "applied"
+	case OutcomeAlreadyApplied:
+		return "already applied"
+	case OutcomeConflict:
+		return "conflict"
+	case OutcomeExpired:
+		return "expired"
+	case OutcomeFailed:
+		return "failed"
+	}
+	return "outcome(" + strconv.Itoa(int(o)) + ")"
+}
+
+// MarshalText reports the outcome by name
+func (o ActionOutcome) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+// OfflineResult reports a queued action taken off the queue
+type OfflineResult struct {
+	Action  OfflineAction `json:"action"`
+	Outcome ActionOutcome `json:"outcome"`
+	// Current is the value found on the server for a dvar or rotation conflict
+	Current string `json:"current,omitempty"`
+	Err     error  `json:"-"`
+}
+
+// MarshalJSON reports Err as its message
+func (r OfflineResult) MarshalJSON() ([]byte, error) {
+	type plain OfflineResult
+	return json.Marshal(struct {
+		plain
+		Err *string `json:"error,omitempty"`
+	}{plain(r), errString(r.Err)})
+}
+
+// ErrQueuedOffline is matched by the error of an action that was queued instead of sent
+var ErrQueuedOffline = errors.New("server unreachable, action queued until it is back")
+
+// QueuedError is returned when an action was queued instead of sent; Err is why the
+// server looked unreachable, nil when the client already knew
+type QueuedError struct {
+	Action OfflineAction
+	Err    error
+}
+
+func (e *QueuedError) Error() string {
+	msg := fmt.Sprintf("%s %s queued as action %d: server unreachable", e.Action.Kind, e.Action.Target, e.Action.ID)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *QueuedError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrQueuedOffline}
+	}
+	return []error{ErrQueuedOffline, e.Err}
+}
+
+// WithOfflineQueue holds up to size bans, unbans, dvar sets and rotation changes made
+// while the server is unreachable (the connection state isn't StateConnected, or the
+// send timed out) and applies them in order once it answers again. Such calls return
+// a *QueuedError. Before applying, each action is checked against the server: a ban
+// that is already in place is skipped, and a dvar or rotation that changed since it
+// was last read is a conflict and dropped. Bans by client number are never queued,
+// since the slot may hold someone else by then. Run HealthCheck so the client notices
+// the server is back
+func WithOfflineQueue(size int) Option {
+	return func(rc *RCONClient) {
+		if size <= 0 {
+			size = 64
+		}
+		rc.offline = &offlineQueue{size: size, seen: map[string]string{}}
+	}
+}
+
+// WithOfflineHook is called with the result of every queued action taken off the
+// queue. It runs without the client locked, so it may send commands
+func WithOfflineHook(fn func(OfflineResult)) Option {
+	return func(rc *RCONClient) {
+		rc.onOffline = fn
+	}
+}
+
+// PendingActions returns the actions waiting for the server, oldest first
+func (rc *RCONClient) PendingActions() []OfflineAction {
+	if rc == nil || rc.offline == nil {
+		return nil
+	}
+	rc.offline.mu.Lock()
+	defer rc.offline.mu.Unlock()
+	return append([]OfflineAction(nil), rc.offline.actions...)
+}
+
+// DiscardAction drops a pending action, reporting whether it was still queued
+func (rc *RCONClient) DiscardAction(id uint64) bool {
+	if rc == nil || rc.offline == nil {
+		return false
+	}
+	q := rc.offline
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, a := range q.actions {
+		if a.ID == id {
+			q.actions = append(q.actions[:i], q.actions[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyPending applies the queued actions in order, as the client does by itself on
+// reconnect. It stops at the first action the server doesn't answer, leaving it and
+// the rest queued, and returns the results of those taken off the queue
+func (rc *RCONClient) ApplyPending() []OfflineResult {
+	if rc == nil || rc.offline == nil {
+		return nil
+	}
+	q := rc.offline
+	if !q.flushing.TryLock() {
+		return nil
+	}
+	defer q.flushing.Unlock()
+
+	var results []OfflineResult
+	for {
+		a, ok := q.head()
+		if !ok {
+			return results
+		}
+		res := rc.applyAction(a)
+		if res.Outcome == OutcomeFailed && unreachable(res.Err) {
+			return results
+		}
+		q.remove(a.ID)
+		results = append(results, res)
+		if rc.onOffline != nil {
+			rc.onOffline(res)
+		}
+	}
+}
+
+// offlineQueue holds the actions waiting for the server, and the dvar values last read
+// from it to detect conflicts
+type offlineQueue struct {
+	size int
+
+	mu      sync.Mutex
+	nextID  uint64
+	actions []OfflineAction
+	seen    map[string]string
+
+	flushing sync.Mutex
+}
+
+// saw remembers a dvar value read from the server
+func (q *offlineQueue) saw(name, value string) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	q.seen[strings.ToLower(strings.TrimSpace(name))] = value
+	q.mu.Unlock()
+}
+
+// push queues a, replacing a pending dvar or rotation change of the same target but
+// keeping its base, so the conflict check still compares against the older value
+func (q *offlineQueue) push(a OfflineAction, cause error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	a.QueuedAt = time.Now()
+	if a.Kind == ActionSetDvar || a.Kind == ActionRotation {
+		for i, p := range q.actions {
+			if p.Kind == a.Kind && strings.EqualFold(p.Target, a.Target) {
+				q.actions[i].Value = a.Value
+				return &QueuedError{Action: q.actions[i], Err: cause}
+			}
+		}
+		a.Base, a.HasBase = q.seen[strings.ToLower(a.Target)]
+	}
+	if len(q.actions) >= q.size {
+		if cause == nil {
+			cause = ErrNotConnected
+		}
+		return fmt.Errorf("offline queue is full (%d actions): %w", q.size, cause)
+	}
+	q.nextID++
+	a.ID = q.nextID
+	q.actions = append(q.actions, a)
+	return &QueuedError{Action: a, Err: cause}
+}
+
+func (q *offlineQueue) pending() bool {
+	if q == nil {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.actions) > 0
+}
+
+func (q *offlineQueue) head() (OfflineAction, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.actions) == 0 {
+		return OfflineAction{}, false
+	}
+	return q.actions[0], true
+}
+
+func (q *offlineQueue) remove(id uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, a := range q.actions {
+		if a.ID == id {
+			q.actions = append(q.actions[:i], q.actions[i+1:]...)
+			return
+		}
+	}
+}
+
+// offlineOr runs send, or queues a when the offline queue is on and the server looks
+// unreachable before or during the send
+func (rc *RCONClient) offlineOr(a OfflineAction, send func() error) error {
+	if rc == nil {
+		return ErrNilClient
+	}
+	q := rc.offline
+	if q == nil || rc.Closed() {
+		return send()
+	}
+	var cause error
+	if rc.State() == StateConnected {
+		cause = send()
+		if !unreachable(cause) {
+			return cause
+		}
+	}
+	return q.push(a, cause)
+}
+
+// unreachable reports whether err means the server didn't answer, as opposed to
+// rejecting the action
+func unreachable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrNotConnected) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// applyAction checks a queued action against the server and applies it
+func (rc *RCONClient) applyAction(a OfflineAction) OfflineResult {
+	res := OfflineResult{Action: a}
+	switch a.Kind {
+	case ActionBan, ActionTempBan, ActionUnban:
+		// a ban list that can't be read only skips the check, since bans are idempotent
+		bans, err := rc.BanList()
+		if unreachable(err) {
+			res.Outcome, res.Err = OutcomeFailed, err
+			return res
+		}
+		banned := false
+		for _, b := range bans {
+			if strings.EqualFold(b.GUID, a.Target) || strings.EqualFold(stripColorCodes(b.Name), a.Target) {
+				banned = true
+				break
+			}
+		}
+		if err == nil && banned == (a.Kind != ActionUnban) {
+			res.Outcome = OutcomeAlreadyApplied
+			return res
+		}
+		cmds := rc.banCommands()
+		switch a.Kind {
+		case ActionBan:
+			err = rc.banCommand(a.Target, a.Reason, cmds.banClient, cmds.banUser, "", nil)
+		case ActionTempBan:
+			left := a.Duration - time.Since(a.QueuedAt)
+			if left < time.Minute {
+				res.Outcome = OutcomeExpired
+				return res
+			}
+			err = rc.tempBan(a.Target, left, a.Reason, nil)
+		case ActionUnban:
+			err = rc.unban(a.Target)
+		}
+		return appliedResult(res, err)
+
+	case ActionSetDvar, ActionRotation:
+		rc.forgetDvar(a.Target)
+		cur, err := rc.GetDvar(a.Target)
+		if err != nil {
+			res.Outcome, res.Err = OutcomeFailed, err
+			return res
+		}
+		res.Current = cur
+		if dvarValuesEqual(cur, a.Value) {
+			res.Outcome = OutcomeAlreadyApplied
+			return res
+		}
+		if a.HasBase && !dvarValuesEqual(cur, a.Base) {
+			res.Outcome = OutcomeConflict
+			return res
+		}
+		if a.Kind == ActionRotation {
+			err = rc.setMapRotation(a.Value)
+		} else {
+			_, err = rc.setDvar(a.Target, a.Value)
+		}
+		return appliedResult(res, err)
+	}
+	res.Outcome, res.Err = OutcomeFailed, fmt.Errorf("unknown action kind %q", a.Kind)
+	return res
+}
+
+// appliedResult fills in the outcome of a sent action
+func appliedResult(res OfflineResult, err error) OfflineResult {
+	if err != nil {
+		res.Outcome, res.Err = OutcomeFailed, err
+		return res
+	}
+	res.Outcome = OutcomeApplied
+	return res
+}
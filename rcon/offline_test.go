@@ -0,0 +1,139 @@
+package rcon
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOfflineQueuePush(t *testing.T) {
+	q := &offlineQueue{size: 2, seen: map[string]string{}}
+	q.saw("G_Speed", "190")
+
+	err := q.push(OfflineAction{Kind: ActionSetDvar, Target: "g_speed", Value: "200"}, nil)
+	var qe *QueuedError
+	if !errors.As(err, &qe) || !errors.Is(err, ErrQueuedOffline) {
+		t.Fatalf("push = %v, want a QueuedError", err)
+	}
+	if qe.Action.ID != 1 || !qe.Action.HasBase || qe.Action.Base != "190" {
+		t.Errorf("queued %+v, want id 1 with base 190", qe.Action)
+	}
+
+	// a later change of the same dvar replaces the value but keeps the older base
+	q.saw("g_speed", "200")
+	if err := q.push(OfflineAction{Kind: ActionSetDvar, Target: "G_SPEED", Value: "300"}, nil); !errors.As(err, &qe) {
+		t.Fatal(err)
+	}
+	if len(q.actions) != 1 || q.actions[0].Value != "300" || q.actions[0].Base != "190" {
+		t.Errorf("actions = %+v", q.actions)
+	}
+
+	cause := errors.New("timeout")
+	if err := q.push(OfflineAction{Kind: ActionBan, Target: "bob"}, cause); !errors.Is(err, cause) {
+		t.Errorf("push = %v, want it to wrap the cause", err)
+	}
+	if err := q.push(OfflineAction{Kind: ActionBan, Target: "alice"}, nil); err == nil || errors.Is(err, ErrQueuedOffline) {
+		t.Errorf("push on a full queue = %v", err)
+	}
+	if len(q.actions) != 2 {
+		t.Errorf("%d actions queued, want 2", len(q.actions))
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{ErrNotConnected, true},
+		{errTimeout{}, true},
+		{errors.New("Unknown command"), false},
+	}
+	for _, tt := range tests {
+		if got := unreachable(tt.err); got != tt.want {
+			t.Errorf("unreachable(%v) = %t, want %t", tt.err, got, tt.want)
+		}
+	}
+}
+
+type errTimeout struct{}
+
+func (errTimeout) Error() string   { return "i/o timeout" }
+func (errTimeout) Timeout() bool   { return true }
+func (errTimeout) Temporary() bool { return true }
+
+// An action made while the server is down waits for it and is applied once it answers
+func TestOfflineQueueApply(t *testing.T) {
+	srv := newFakeServer()
+	srv.setDvar("g_speed", "190")
+	results := make(chan OfflineResult, 4)
+	rc := srv.client(WithOfflineQueue(8), WithOfflineHook(func(r OfflineResult) { results <- r }))
+	defer rc.Close()
+
+	if v, err := rc.GetDvar("g_speed"); err != nil || v != "190" {
+		t.Fatalf("GetDvar = %q, %v", v, err)
+	}
+	srv.setDown(true)
+	if rc.IsAlive() {
+		t.Fatal("a down server looks alive")
+	}
+	if err := rc.SetDvar("g_speed", "250"); !errors.Is(err, ErrQueuedOffline) {
+		t.Fatalf("SetDvar while down = %v, want it queued", err)
+	}
+	if n := len(rc.PendingActions()); n != 1 {
+		t.Fatalf("%d pending actions, want 1", n)
+	}
+
+	srv.setDown(false)
+	if !rc.IsAlive() {
+		t.Fatal("server still looks down")
+	}
+	select {
+	case r := <-results:
+		if r.Outcome != OutcomeApplied || r.Err != nil {
+			t.Errorf("outcome %v, %v", r.Outcome, r.Err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("queued action not applied after reconnect")
+	}
+	if got := srv.dvar("g_speed"); got != "250" {
+		t.Errorf("g_speed = %q, want 250", got)
+	}
+	if n := len(rc.PendingActions()); n != 0 {
+		t.Errorf("%d actions still pending", n)
+	}
+}
+
+// A dvar someone else changed meanwhile is a conflict and left alone
+func TestOfflineQueueConflict(t *testing.T) {
+	srv := newFakeServer()
+	srv.setDvar("g_speed", "190")
+	rc := srv.client(WithOfflineQueue(8))
+	defer rc.Close()
+
+	rc.GetDvar("g_speed")
+	srv.setDown(true)
+	rc.IsAlive()
+	if err := rc.SetDvar("g_speed", "250"); !errors.Is(err, ErrQueuedOffline) {
+		t.Fatalf("SetDvar while down = %v", err)
+	}
+	srv.setDvar("g_speed", "400")
+	srv.setDown(false)
+
+	// the client still counts the server as down, so nothing runs in the background
+	results := rc.ApplyPending()
+	if len(results) != 1 || results[0].Outcome != OutcomeConflict || results[0].Current != "400" {
+		t.Fatalf("results = %+v, want one conflict with current 400", results)
+	}
+	if got := srv.dvar("g_speed"); got != "400" {
+		t.Errorf("g_speed = %q, the conflicting change was overwritten", got)
+	}
+}
+
+func TestOfflineOrNil(t *testing.T) {
+	var rc *RCONClient
+	if err := rc.SetDvar("g_speed", "1"); !errors.Is(err, ErrNilClient) {
+		t.Errorf("SetDvar on a nil client = %v", err)
+	}
+}
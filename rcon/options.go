@@ -0,0 +1,106 @@
+package rcon
+
+import (
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// CommandOption tunes a single SendCommand call, or every call through WithCommandDefaults
+type CommandOption func(*commandSettings)
+
+// Backoff returns how long to wait before retry attempt+1 (attempt counts from 0)
+type Backoff func(attempt int) time.Duration
+
+// LinearBackoff waits step, 2*step, 3*step, ... (the default, with a 150ms step)
+func LinearBackoff(step time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		return time.Duration(attempt+1) * step
+	}
+}
+
+// ExponentialBackoff doubles the wait from base up to max, picking each delay at random
+// between half and all of it so clients retrying together drift apart
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := base << min(attempt, 30)
+		if d > max || d <= 0 {
+			d = max
+		}
+		if d <= 1 {
+			return d
+		}
+		return d/2 + rand.N(d/2+1)
+	}
+}
+
+// WithCommandDefaults applies options to every command the client sends, ahead of the
+// options given to SendCommand
+func WithCommandDefaults(opts ...CommandOption) Option {
+	return func(rc *RCONClient) {
+		rc.commandOpts = append(rc.commandOpts, opts...)
+	}
+}
+
+// WithRetries sets how many times a command is resent after a failed write or, with
+// RequireResponse, an empty reply (default 3)
+func WithRetries(n int) CommandOption {
+	return func(s *commandSettings) {
+		s.retries = max(n, 0)
+	}
+}
+
+// WithBackoff sets the wait between retries
+func WithBackoff(b Backoff) CommandOption {
+	return func(s *commandSettings) {
+		if b != nil {
+			s.backoff = b
+		}
+	}
+}
+
+// WithTimeout sets how long to wait for the first datagram of the reply (default the client's Timeout)
+func WithTimeout(d time.Duration) CommandOption {
+	return func(s *commandSettings) {
+		if d > 0 {
+			s.readTimeout = d
+			s.timeoutSet = true
+		}
+	}
+}
+
+// RequireResponse makes SendCommand retry timeouts and fail when no reply arrives,
+// instead of returning an empty result
+func RequireResponse() CommandOption {
+	return func(s *commandSettings) {
+		s.requireSuccess = true
+	}
+}
+
+// WithReadExtension overrides how long to keep reading after a datagram for the rest of a long reply
+func WithReadExtension(d time.Duration) CommandOption {
+	return func(s *commandSettings) {
+		if d < 0 {
+			d = 0
+		}
+		s.readExtension = d
+		s.extensionSet = true
+	}
+}
+
+// WithTargetGUID makes an action on a client number (Tell, Kick, Ban, TempBan) first
+// check on a fresh status that the slot still holds this GUID, and fail with
+// ErrSlotReused instead of hitting whoever took it. SendCommand on its own ignores
+// it, since it can't know which slot a raw command targets
+func WithTargetGUID(guid string) CommandOption {
+	return func(s *commandSettings) {
+		s.targetGUID = strings.TrimSpace(guid)
+	}
+}
+
+// WithTruncatedFlag makes SendCommand report through t whether the reply looked cut off
+func WithTruncatedFlag(t *bool) CommandOption {
+	return func(s *commandSettings) {
+		s.truncated = t
+	}
+}
@@ -0,0 +1,176 @@
+package rcon
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+	"github.com/Yallamaztar/PlutoRCON/v2/query"
+)
+
+// modRetry is how long implicit mod detection waits after a failed getinfo
+const modRetry = time.Minute
+
+// ModParser parses the replies of a server mod whose status or commands differ from the
+// stock title. Nil fields keep the built-in parsing
+type ModParser struct {
+	// Status parses a status reply; returning nil falls back to ParseStatus
+	Status func(lines []string, game Game) *ServerStatus
+	// Info adjusts a parsed getinfo reply; kv holds all of its keys
+	Info func(info *ServerInfo, kv map[string]string)
+	// Commands parse the replies of mod commands, keyed by command name (see Parsed)
+	Commands map[string]ReplyParser
+	// Maps are maps the mod ships, accepted by map changes and rotation edits
+	Maps []string
+}
+
+// ReplyParser turns the reply lines of a command into a structured value
+type ReplyParser func(lines []string) (any, error)
+
+var modParsers = struct {
+	sync.RWMutex
+	m map[string]*ModParser
+}{m: map[string]*ModParser{}}
+
+// RegisterParser installs the parser of a mod, matched case-insensitively against the
+// fs_game (or its last path element, so "zombies" matches "mods/zombies") and gamename
+// keys of getinfo. Registering a name again replaces its parser
+func RegisterParser(mod string, p ModParser) {
+	modParsers.Lock()
+	defer modParsers.Unlock()
+	modParsers.m[strings.ToLower(mod)] = &p
+}
+
+// lookupParser returns the parser registered for mod
+func lookupParser(mod string) *ModParser {
+	if mod == "" {
+		return nil
+	}
+	modParsers.RLock()
+	defer modParsers.RUnlock()
+	return modParsers.m[strings.ToLower(mod)]
+}
+
+func haveParsers() bool {
+	modParsers.RLock()
+	defer modParsers.RUnlock()
+	return len(modParsers.m) > 0
+}
+
+// DetectMod returns the registered mod name that info identifies, or "" when none matches
+func DetectMod(info *ServerInfo) string {
+	if info == nil {
+		return ""
+	}
+	fs := strings.Trim(strings.ReplaceAll(info.FsGame, `\`, "/"), "/")
+	for _, name := range []string{fs, path.Base(fs), info.GameName} {
+		if name != "" && name != "." && lookupParser(name) != nil {
+			return strings.ToLower(name)
+		}
+	}
+	return ""
+}
+
+// WithMod selects the parser registered for mod instead of detecting it from getinfo
+func WithMod(mod string) Option {
+	return func(rc *RCONClient) {
+		rc.mod, rc.modKnown, rc.modForced = strings.ToLower(mod), true, true
+	}
+}
+
+// Mod returns the registered mod the server runs, detecting it with getinfo on first use
+// and again after a map change. It is "" when no registered parser matches
+func (rc *RCONClient) Mod() (string, error) {
+	if rc == nil {
+		return "", ErrNilClient
+	}
+	rc.modMu.Lock()
+	known, mod := rc.modKnown, rc.mod
+	rc.modMu.Unlock()
+	if known {
+		return mod, nil
+	}
+	if _, err := rc.GetInfo(); err != nil {
+		return "", err
+	}
+	rc.modMu.Lock()
+	defer rc.modMu.Unlock()
+	return rc.mod, nil
+}
+
+// noteMod records the mod of a getinfo reply unless WithMod fixed it
+func (rc *RCONClient) noteMod(info *ServerInfo) {
+	rc.modMu.Lock()
+	defer rc.modMu.Unlock()
+	if !rc.modForced {
+		rc.mod, rc.modKnown, rc.modMap, rc.modFailed = DetectMod(info), true, info.MapName, time.Time{}
+	}
+}
+
+// noteModMap forgets the detected mod when the server moved on from the map it was
+// detected on, since a map change is when a server can switch fs_game
+func (rc *RCONClient) noteModMap(mapName string) {
+	rc.modMu.Lock()
+	defer rc.modMu.Unlock()
+	if !rc.modForced && rc.modKnown && rc.modMap != "" && mapName != "" && !strings.EqualFold(mapName, rc.modMap) {
+		rc.modKnown = false
+	}
+}
+
+// modParser returns the parser of the server's mod, or nil. Detection only runs when
+// parsers are registered, and not again for modRetry after getinfo failed
+func (rc *RCONClient) modParser() *ModParser {
+	if rc == nil {
+		return nil
+	}
+	rc.modMu.Lock()
+	known, mod, failed := rc.modKnown, rc.mod, rc.modFailed
+	rc.modMu.Unlock()
+	if !known {
+		if !haveParsers() || time.Since(failed) < modRetry {
+			return nil
+		}
+		var err error
+		if mod, err = rc.Mod(); err != nil {
+			rc.modMu.Lock()
+			rc.modFailed = time.Now()
+			rc.modMu.Unlock()
+		}
+	}
+	return lookupParser(mod)
+}
+
+// parseInfo parses a getinfo reply and applies the mod's Info hook
+func (rc *RCONClient) parseInfo(lines []string) (*ServerInfo, error) {
+	info, err := query.ParseInfoResponseKeys(lines, rc.profile().InfoKeys)
+	if err != nil {
+		return nil, err
+	}
+	rc.noteMod(info)
+	rc.modMu.Lock()
+	p := lookupParser(rc.mod)
+	rc.modMu.Unlock()
+	if p != nil && p.Info != nil {
+		p.Info(info, wire.InfoString(lines, "inforesponse"))
+	}
+	return info, nil
+}
+
+// Parsed sends a command and parses its reply with the mod's parser for it
+func (rc *RCONClient) Parsed(cmd string, args *string, opts ...CommandOption) (any, error) {
+	if rc == nil {
+		return nil, ErrNilClient
+	}
+	p := rc.modParser()
+	if p == nil || p.Commands[cmd] == nil {
+		return nil, fmt.Errorf("no parser for %q: %w", cmd, ErrUnsupported)
+	}
+	res, err := rc.SendCommand(cmd, args, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return p.Commands[cmd](res)
+}
@@ -0,0 +1,102 @@
+package rcon
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// statusServer answers status with the map in mapName
+func statusServer(mapName *string, mu *sync.Mutex) *fakeServer {
+	srv := newFakeServer()
+	srv.handle = func(cmdline string) string {
+		if cmdline != "status" {
+			return ""
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return "map: " + *mapName + "\nnum score bot ping guid name lastmsg address qport rate\n"
+	}
+	return srv
+}
+
+// short keeps status from waiting the usual second for more reply packets
+var short = WithReadExtension(10 * time.Millisecond)
+
+func (s *fakeServer) getinfos() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.infos
+}
+
+func TestModDetectionRetry(t *testing.T) {
+	RegisterParser("retrymod", ModParser{})
+	var mu sync.Mutex
+	mapName := "mp_raid"
+	srv := statusServer(&mapName, &mu)
+	srv.noInfo = true
+	rc := srv.client()
+	defer rc.Close()
+
+	for range 3 {
+		if _, err := rc.Status(short); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := srv.getinfos(); n != 1 {
+		t.Errorf("%d getinfo requests, want 1 until the retry delay passed", n)
+	}
+
+	rc.modMu.Lock()
+	rc.modFailed = time.Now().Add(-modRetry)
+	rc.modMu.Unlock()
+	srv.mu.Lock()
+	srv.noInfo = false
+	srv.info = map[string]string{"mapname": "mp_raid", "fs_game": "mods/retrymod"}
+	srv.mu.Unlock()
+	rc.Status(short)
+	if mod, _ := rc.Mod(); mod != "retrymod" {
+		t.Errorf("Mod = %q after the retry, want retrymod", mod)
+	}
+	if n := srv.getinfos(); n != 2 {
+		t.Errorf("%d getinfo requests, want 2", n)
+	}
+}
+
+func TestModRedetectOnMapChange(t *testing.T) {
+	RegisterParser("mapmod", ModParser{})
+	var mu sync.Mutex
+	mapName := "mp_raid"
+	srv := statusServer(&mapName, &mu)
+	srv.info = map[string]string{"mapname": "mp_raid", "fs_game": "mods/mapmod"}
+	rc := srv.client()
+	defer rc.Close()
+
+	rc.Status(short)
+	rc.Status(short)
+	if n := srv.getinfos(); n != 1 {
+		t.Fatalf("%d getinfo requests on one map, want 1", n)
+	}
+
+	mu.Lock()
+	mapName = "mp_crash"
+	mu.Unlock()
+	srv.mu.Lock()
+	srv.info = map[string]string{"mapname": "mp_crash"}
+	srv.mu.Unlock()
+	rc.Status(short) // sees the new map
+	rc.Status(short) // detects again
+	if n := srv.getinfos(); n != 2 {
+		t.Errorf("%d getinfo requests after a map change, want 2", n)
+	}
+	if mod, _ := rc.Mod(); mod != "" {
+		t.Errorf("Mod = %q after the server dropped the mod", mod)
+	}
+
+	forced := srv.client(WithMod("mapmod"))
+	defer forced.Close()
+	forced.Status(short)
+	if n := srv.getinfos(); n != 2 {
+		t.Errorf("WithMod client sent getinfo")
+	}
+}
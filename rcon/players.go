@@ -0,0 +1,132 @@
+package rcon
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PlayerOrder is a sort key for ServerStatus.SortBy
+type PlayerOrder int
+
+const (
+	// ByClientNum orders by slot, the order Status returns
+	ByClientNum PlayerOrder = iota
+	// ByScore puts the highest score first
+	ByScore
+	// ByPing puts the lowest ping first, players without a ping (loading, zombie) last
+	ByPing
+	// ByName orders by name ignoring case and color codes
+	ByName
+)
+
+// SortBy reorders Players in place. Ties keep ClientNum order, so the result is the
+// same for the same players however the reply listed them
+func (st *ServerStatus) SortBy(order PlayerOrder) {
+	if st == nil {
+		return
+	}
+	sortPlayers(st.Players, order)
+}
+
+// sortPlayers sorts players by order, then by ClientNum
+func sortPlayers(players []Player, order PlayerOrder) {
+	slices.SortStableFunc(players, func(a, b Player) int {
+		var c int
+		switch order {
+		case ByScore:
+			c = cmp.Compare(b.Score, a.Score)
+		case ByPing:
+			c = cmp.Or(cmp.Compare(noPing(a), noPing(b)), cmp.Compare(a.Ping, b.Ping))
+		case ByName:
+			c = strings.Compare(strings.ToLower(stripColorCodes(a.Name)), strings.ToLower(stripColorCodes(b.Name)))
+		}
+		return cmp.Or(c, cmp.Compare(a.ClientNum, b.ClientNum))
+	})
+}
+
+// noPing ranks players whose ping isn't known after the others
+func noPing(p Player) int {
+	if p.Loading || p.Zombie {
+		return 1
+	}
+	return 0
+}
+
+// Find a player on a fresh status by exact GUID, exact IP, or name (exact first,
+// then partial), ignoring case and color codes
+func (rc *RCONClient) FindPlayer(query string) (*Player, error) {
+	st, err := rc.Status()
+	if err != nil {
+		return nil, err
+	}
+	return findPlayer(st.Players, query)
+}
+
+// findPlayer resolves a query against a player list
+func findPlayer(players []Player, query string) (*Player, error) {
+	q := strings.ToLower(strings.TrimSpace(query))
+	if q == "" {
+		return nil, &PlayerNotFoundError{Query: query}
+	}
+
+	var byIP, byName, partial []Player
+	for _, p := range players {
+		if strings.ToLower(p.GUID) == q {
+			return &p, nil
+		}
+		if p.IP == q {
+			byIP = append(byIP, p)
+		}
+		name := strings.ToLower(stripColorCodes(p.Name))
+		if name == q {
+			byName = append(byName, p)
+		} else if strings.Contains(name, q) {
+			partial = append(partial, p)
+		}
+	}
+
+	for _, set := range [][]Player{byIP, byName, partial} {
+		switch len(set) {
+		case 0:
+			continue
+		case 1:
+			return &set[0], nil
+		}
+		return nil, &AmbiguousPlayerError{Query: query, Candidates: set}
+	}
+	return nil, &PlayerNotFoundError{Query: query}
+}
+
+// Kick the player with a GUID
+func (rc *RCONClient) KickByGUID(guid, reason string) error {
+	st, err := rc.Status()
+	if err != nil {
+		return err
+	}
+	for _, p := range st.Players {
+		if strings.EqualFold(p.GUID, strings.TrimSpace(guid)) {
+			return rc.Kick(strconv.Itoa(p.ClientNum), reason)
+		}
+	}
+	return &PlayerNotFoundError{Query: guid}
+}
+
+// Kick the player whose name matches
+func (rc *RCONClient) KickByName(name, reason string) error {
+	p, err := rc.FindPlayer(name)
+	if err != nil {
+		return err
+	}
+	return rc.Kick(strconv.Itoa(p.ClientNum), reason)
+}
+
+// Private message the player whose name matches
+func (rc *RCONClient) TellByName(name, message string) error {
+	p, err := rc.FindPlayer(name)
+	if err != nil {
+		return err
+	}
+	return rc.Tell(p.ClientNum, message)
+}
@@ -0,0 +1,69 @@
+package rcon
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/query"
+)
+
+type PlaylistInfo = query.PlaylistInfo
+
+// PlaylistName returns the display name of a playlist entry
+func PlaylistName(entry int) (string, bool) { return query.PlaylistName(entry) }
+
+// RegisterPlaylist adds or renames a playlist entry, for servers running a custom playlists.info
+func RegisterPlaylist(entry int, name string) { query.RegisterPlaylist(entry, name) }
+
+// Playlists returns the known playlist entries in ascending order
+func Playlists() []PlaylistInfo { return query.Playlists() }
+
+// Get the active playlist
+func (rc *RCONClient) Playlist() (*PlaylistInfo, error) {
+	if err := rc.requireCapability(rc.profile().Capabilities.Playlists, "playlists"); err != nil {
+		return nil, err
+	}
+	enabled, err := rc.GetDvar("playlist_enabled")
+	if err != nil {
+		return nil, err
+	}
+	entry, err := rc.GetDvar("playlist_entry")
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(entry))
+	if err != nil {
+		return nil, fmt.Errorf("invalid playlist_entry %q", entry)
+	}
+	info := &PlaylistInfo{Enabled: boolSafe(enabled), Entry: n}
+	info.Name, _ = PlaylistName(n)
+	return info, nil
+}
+
+// Switch to a playlist entry registered with RegisterPlaylist and verify the server took it
+func (rc *RCONClient) SetPlaylist(entry int) error {
+	if err := rc.requireCapability(rc.profile().Capabilities.Playlists, "playlists"); err != nil {
+		return err
+	}
+	if _, ok := PlaylistName(entry); !ok {
+		return fmt.Errorf("unknown playlist entry %d; register the server's playlists with RegisterPlaylist", entry)
+	}
+
+	if err := rc.SetDvar("playlist_enabled", "1"); err != nil {
+		return err
+	}
+	if err := rc.SetDvar("playlist_entry", strconv.Itoa(entry)); err != nil {
+		return err
+	}
+
+	cur, err := rc.Playlist()
+	if err != nil {
+		return err
+	}
+	if cur.Entry != entry {
+		return fmt.Errorf("server kept playlist entry %d instead of %d", cur.Entry, entry)
+	}
+	return nil
+}
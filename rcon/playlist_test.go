@@ -0,0 +1,26 @@
+package rcon
+
+import "testing"
+
+func TestSetPlaylist(t *testing.T) {
+	RegisterPlaylist(42, "Custom TDM")
+	srv := newFakeServer()
+	srv.setDvar("playlist_enabled", "0")
+	srv.setDvar("playlist_entry", "1")
+	rc := srv.client(WithCommandDefaults(short))
+	defer rc.Close()
+
+	if err := rc.SetPlaylist(43); err == nil {
+		t.Error("SetPlaylist accepted an unregistered entry")
+	}
+	if err := rc.SetPlaylist(42); err != nil {
+		t.Fatal(err)
+	}
+	p, err := rc.Playlist()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Enabled || p.Entry != 42 || p.Name != "Custom TDM" {
+		t.Errorf("Playlist = %+v", p)
+	}
+}
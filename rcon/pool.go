@@ -0,0 +1,181 @@
+package rcon
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Pool holds named clients for managing several servers at once.
+//
+// Deprecated: use manager.Manager
+type Pool struct {
+	mu      sync.RWMutex
+	clients map[string]*RCONClient
+}
+
+// PoolError collects per-server failures of a broadcast operation.
+//
+// Deprecated: use manager.Error
+type PoolError struct {
+	Errors map[string]error
+}
+
+func (e *PoolError) Error() string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ": " + e.Errors[name].Error()
+	}
+	return fmt.Sprintf("%d server(s) failed: %s", len(names), strings.Join(parts, "; "))
+}
+
+// PoolStatus is the status of one pool member.
+//
+// Deprecated: use manager.Status
+type PoolStatus struct {
+	Server string        `json:"server"`
+	Status *ServerStatus `json:"status"`
+	Err    error         `json:"-"`
+}
+
+// MarshalJSON reports Err as its message
+func (s PoolStatus) MarshalJSON() ([]byte, error) {
+	type plain PoolStatus
+	return json.Marshal(struct {
+		plain
+		Err *string `json:"error,omitempty"`
+	}{plain(s), errString(s.Err)})
+}
+
+// NewPool returns an empty pool.
+//
+// Deprecated: use manager.New
+func NewPool() *Pool {
+	return &Pool{clients: map[string]*RCONClient{}}
+}
+
+// Add registers a client under a name, replacing any client with the same name
+func (p *Pool) Add(name string, rc *RCONClient) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.clients == nil {
+		p.clients = map[string]*RCONClient{}
+	}
+	p.clients[name] = rc
+}
+
+// Remove drops a client from the pool, returning it so the caller can close it
+func (p *Pool) Remove(name string) *RCONClient {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	rc := p.clients[name]
+	delete(p.clients, name)
+	return rc
+}
+
+// Get returns a named client
+func (p *Pool) Get(name string) (*RCONClient, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	rc, ok := p.clients[name]
+	return rc, ok
+}
+
+// Names returns the member names in sorted order
+func (p *Pool) Names() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	names := make([]string, 0, len(p.clients))
+	for name := range p.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Each runs fn against every client in parallel, returning a *PoolError for the ones that failed
+func (p *Pool) Each(fn func(name string, rc *RCONClient) error) error {
+	p.mu.RLock()
+	clients := make(map[string]*RCONClient, len(p.clients))
+	for name, rc := range p.clients {
+		clients[name] = rc
+	}
+	p.mu.RUnlock()
+
+	var (
+		mu   sync.Mutex
+		errs = map[string]error{}
+		wg   sync.WaitGroup
+	)
+	for name, rc := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(name, rc); err != nil {
+				mu.Lock()
+				errs[name] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return &PoolError{Errors: errs}
+	}
+	return nil
+}
+
+// Say broadcasts a message to every server
+func (p *Pool) Say(message string) error {
+	return p.Each(func(_ string, rc *RCONClient) error { return rc.Say(message) })
+}
+
+// SetDvar sets a dvar on every server
+func (p *Pool) SetDvar(dvar, value string) error {
+	return p.Each(func(_ string, rc *RCONClient) error { return rc.SetDvar(dvar, value) })
+}
+
+// Status polls every server in parallel, returning results sorted by server name
+func (p *Pool) Status() []PoolStatus {
+	var (
+		mu  sync.Mutex
+		out []PoolStatus
+	)
+	p.Each(func(name string, rc *RCONClient) error {
+		st, err := rc.Status()
+		mu.Lock()
+		out = append(out, PoolStatus{Server: name, Status: st, Err: err})
+		mu.Unlock()
+		return err
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].Server < out[j].Server })
+	return out
+}
+
+// FindGUID reports which server a player is currently on
+func (p *Pool) FindGUID(guid string) (string, *Player, bool) {
+	guid = strings.ToLower(strings.TrimSpace(guid))
+	for _, res := range p.Status() {
+		if res.Err != nil || res.Status == nil {
+			continue
+		}
+		for i := range res.Status.Players {
+			if strings.ToLower(res.Status.Players[i].GUID) == guid {
+				return res.Server, &res.Status.Players[i], true
+			}
+		}
+	}
+	return "", nil, false
+}
+
+// Close closes every client
+func (p *Pool) Close() error {
+	return p.Each(func(_ string, rc *RCONClient) error { return rc.Close() })
+}
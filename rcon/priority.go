@@ -0,0 +1,76 @@
+package rcon
+
+import "sync"
+
+// Priority orders commands waiting for the connection: a waiting admin action is
+// sent before automation, and automation before polling. Within one level commands go
+// in arrival order. A command already on the wire is never interrupted
+type Priority int
+
+const (
+	// PriorityPoll is for status polls of dashboards, metrics and monitors
+	PriorityPoll Priority = iota - 1
+	// PriorityAutomation is the default, for bots, policies and announcers
+	PriorityAutomation
+	// PriorityAdmin is for moderators acting by hand, e.g. through a proxy or a session
+	PriorityAdmin
+)
+
+// WithPriority sets the priority of a command; Status, GetInfo, GetStatus and
+// FullState take it too. Under a steady stream of higher priority commands, lower
+// ones wait
+func WithPriority(p Priority) CommandOption {
+	return func(s *commandSettings) {
+		s.priority = min(max(p, PriorityPoll), PriorityAdmin)
+	}
+}
+
+// priorityOf resolves the priority of opts over the client defaults
+func (rc *RCONClient) priorityOf(opts []CommandOption) Priority {
+	var s commandSettings
+	for _, opt := range rc.commandOpts {
+		opt(&s)
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s.priority
+}
+
+// commandGate hands the connection to one exchange at a time, highest priority first.
+// The zero value is ready to use
+type commandGate struct {
+	mu      sync.Mutex
+	busy    bool
+	waiting [PriorityAdmin - PriorityPoll + 1][]chan struct{}
+}
+
+// acquire waits for the connection; call the returned func when done with it
+func (g *commandGate) acquire(p Priority) func() {
+	g.mu.Lock()
+	if !g.busy {
+		g.busy = true
+		g.mu.Unlock()
+		return g.release
+	}
+	ch := make(chan struct{})
+	i := p - PriorityPoll
+	g.waiting[i] = append(g.waiting[i], ch)
+	g.mu.Unlock()
+	<-ch
+	return g.release
+}
+
+// release hands the connection to the next waiter, or frees it
+func (g *commandGate) release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i := len(g.waiting) - 1; i >= 0; i-- {
+		if q := g.waiting[i]; len(q) > 0 {
+			g.waiting[i] = q[1:]
+			close(q[0])
+			return
+		}
+	}
+	g.busy = false
+}
@@ -0,0 +1,97 @@
+package rcon
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// waiters counts the exchanges queued on g
+func waiters(g *commandGate) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	n := 0
+	for _, q := range g.waiting {
+		n += len(q)
+	}
+	return n
+}
+
+func TestCommandGateOrder(t *testing.T) {
+	var g commandGate
+	release := g.acquire(PriorityPoll)
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	for i, w := range []struct {
+		name string
+		p    Priority
+	}{
+		{"poll 1", PriorityPoll},
+		{"automation 1", PriorityAutomation},
+		{"admin 1", PriorityAdmin},
+		{"poll 2", PriorityPoll},
+		{"admin 2", PriorityAdmin},
+		{"automation 2", PriorityAutomation},
+	} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done := g.acquire(w.p)
+			mu.Lock()
+			order = append(order, w.name)
+			mu.Unlock()
+			done()
+		}()
+		// queue them one by one so arrival order is known
+		for waiters(&g) < i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	release()
+	wg.Wait()
+
+	want := []string{"admin 1", "admin 2", "automation 1", "automation 2", "poll 1", "poll 2"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("order = %q, want %q", order, want)
+		}
+	}
+	if g.busy {
+		t.Error("gate still busy after the last release")
+	}
+}
+
+func TestCommandGateFree(t *testing.T) {
+	var g commandGate
+	done := make(chan struct{})
+	go func() {
+		g.acquire(PriorityPoll)()
+		g.acquire(PriorityAdmin)()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("acquire blocked on a free gate")
+	}
+}
+
+func TestWithPriorityClamps(t *testing.T) {
+	rc := &RCONClient{commandOpts: []CommandOption{WithPriority(PriorityAdmin)}}
+	tests := []struct {
+		opts []CommandOption
+		want Priority
+	}{
+		{nil, PriorityAdmin},
+		{[]CommandOption{WithPriority(PriorityPoll)}, PriorityPoll},
+		{[]CommandOption{WithPriority(PriorityAdmin + 5)}, PriorityAdmin},
+		{[]CommandOption{WithPriority(PriorityPoll - 5)}, PriorityPoll},
+	}
+	for _, tt := range tests {
+		if got := rc.priorityOf(tt.opts); got != tt.want {
+			t.Errorf("priorityOf = %d, want %d", got, tt.want)
+		}
+	}
+}
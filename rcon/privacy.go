@@ -0,0 +1,176 @@
+package rcon
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// IPMode is how IPPrivacy rewrites player addresses
+type IPMode int
+
+const (
+	// IPKeep leaves addresses as the server reports them
+	IPKeep IPMode = iota
+	// IPHash replaces an address with a keyed hash ("ip-" and 16 hex digits). The same
+	// address and salt always give the same hash, so shared-IP lookups keep working.
+	// It needs a Salt: without one, addresses are dropped rather than hashed
+	IPHash
+	// IPTruncate zeroes the host part: IPv4 keeps its /24, IPv6 its /48. Country
+	// lookups still work on the result
+	IPTruncate
+)
+
+// IPPrivacy rewrites player IPs before anything else sees them
+type IPPrivacy struct {
+	Mode IPMode
+	// Salt keys IPHash. Keep it secret, and the same on every instance and importer
+	// writing to one store, or their hashes won't match
+	Salt string
+}
+
+// WithIPPrivacy rewrites the player IPs of status and ban list replies, so Status,
+// BanList, session transcripts and everything fed from them only hold the hashed or
+// truncated form. New fails with ErrNoSalt for IPHash without a Salt
+func WithIPPrivacy(p IPPrivacy) Option {
+	return func(rc *RCONClient) {
+		if p.Mode == IPKeep {
+			rc.ipPrivacy = nil
+			return
+		}
+		if err := p.Validate(); err != nil {
+			rc.optErr = err
+			return
+		}
+		rc.ipPrivacy = &p
+	}
+}
+
+// Validate reports a setting that would not protect addresses: IPHash without a Salt
+func (p *IPPrivacy) Validate() error {
+	if p != nil && p.Mode == IPHash && p.Salt == "" {
+		return ErrNoSalt
+	}
+	return nil
+}
+
+// Apply rewrites one address, with or without a port. Values that aren't addresses,
+// such as "bot", "loopback" or an address already rewritten, are returned unchanged
+func (p *IPPrivacy) Apply(ip string) string {
+	if p == nil || p.Mode == IPKeep || ip == "" {
+		return ip
+	}
+	if ap, err := netip.ParseAddrPort(ip); err == nil {
+		host := p.apply(ap.Addr())
+		if a, err := netip.ParseAddr(host); err == nil && a.Is6() {
+			host = "[" + host + "]"
+		}
+		return host + ":" + strconv.Itoa(int(ap.Port()))
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	return p.apply(addr)
+}
+
+func (p *IPPrivacy) apply(addr netip.Addr) string {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsUnspecified() {
+		return addr.String()
+	}
+	if p.Mode == IPHash {
+		if p.Salt == "" {
+			return ""
+		}
+		return HashIP(addr.String(), p.Salt)
+	}
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, _ := addr.Prefix(bits)
+	return prefix.Addr().String()
+}
+
+// HashIP is the IPHash form of an address, for looking up stored hashes by a known IP
+func HashIP(ip, salt string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(ip))
+	return "ip-" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// IsHashedIP reports whether s is an address rewritten by IPHash
+func IsHashedIP(s string) bool {
+	return hashedIPRx.MatchString(s)
+}
+
+// IsTruncatedIP reports whether s looks like an address rewritten by IPTruncate: an
+// IPv4 address ending in .0 or an IPv6 address with only its /48 set. Such an address
+// stands for a whole range, so it must not be matched like one player's IP
+func IsTruncatedIP(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	if err != nil || addr.IsUnspecified() {
+		return false
+	}
+	addr = addr.Unmap()
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, _ := addr.Prefix(bits)
+	return prefix.Addr() == addr
+}
+
+var (
+	hashedIPRx = regexp.MustCompile(`^ip-[0-9a-f]{16}$`)
+	fieldRx    = regexp.MustCompile(`\S+`)
+)
+
+// scrubIPs applies the client's IP privacy to the address column of status and ban
+// list replies. Other replies are left alone, since dvars such as net_ip, version
+// strings, names and chat may look like addresses without being a player's
+func (rc *RCONClient) scrubIPs(cmd string, lines []string) []string {
+	p := rc.ipPrivacy
+	if p == nil {
+		return lines
+	}
+	switch learnKey(cmd) {
+	case "status":
+		pattern := rc.profile().StatusPattern
+		group := pattern.SubexpIndex("ipport")
+		for i, line := range lines {
+			lead := len(line) - len(strings.TrimLeft(line, " \t"))
+			m := pattern.FindStringSubmatchIndex(strings.TrimSpace(line))
+			if group < 0 || m == nil || m[2*group] < 0 {
+				continue
+			}
+			start, end := lead+m[2*group], lead+m[2*group+1]
+			lines[i] = line[:start] + p.Apply(line[start:end]) + line[end:]
+		}
+	case learnKey(rc.banCommands().banList):
+		for i, line := range lines {
+			lines[i] = scrubBanLine(p, line)
+		}
+	}
+	return lines
+}
+
+// scrubBanLine rewrites the address field parseBanList reads: the first one before
+// the quoted player name
+func scrubBanLine(p *IPPrivacy, line string) string {
+	head := line
+	if m := banNameRx.FindStringIndex(line); m != nil {
+		head = line[:m[0]]
+	}
+	for _, f := range fieldRx.FindAllStringIndex(head, -1) {
+		if tok := line[f[0]:f[1]]; isIP(tok) {
+			return line[:f[0]] + p.Apply(tok) + line[f[1]:]
+		}
+	}
+	return line
+}
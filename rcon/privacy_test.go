@@ -0,0 +1,140 @@
+package rcon
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestIPPrivacyApply(t *testing.T) {
+	hash := IPPrivacy{Mode: IPHash, Salt: "pepper"}
+	trunc := IPPrivacy{Mode: IPTruncate}
+	tests := []struct {
+		name string
+		p    *IPPrivacy
+		in   string
+		want string
+	}{
+		{"nil keeps", nil, "203.0.113.5", "203.0.113.5"},
+		{"keep", &IPPrivacy{}, "203.0.113.5", "203.0.113.5"},
+		{"hash", &hash, "203.0.113.5", HashIP("203.0.113.5", "pepper")},
+		{"hash with port", &hash, "203.0.113.5:28960", HashIP("203.0.113.5", "pepper") + ":28960"},
+		{"hash of a mapped address", &hash, "::ffff:203.0.113.5", HashIP("203.0.113.5", "pepper")},
+		{"hash without salt", &IPPrivacy{Mode: IPHash}, "203.0.113.5", ""},
+		{"truncate v4", &trunc, "203.0.113.5", "203.0.113.0"},
+		{"truncate v4 with port", &trunc, "203.0.113.5:28960", "203.0.113.0:28960"},
+		{"truncate v6", &trunc, "2001:db8:1:2::5", "2001:db8:1::"},
+		{"truncate v6 with port", &trunc, "[2001:db8:1:2::5]:28960", "[2001:db8:1::]:28960"},
+		{"loopback", &hash, "127.0.0.1:28960", "127.0.0.1:28960"},
+		{"bot", &hash, "bot", "bot"},
+		{"already hashed", &hash, "ip-0123456789abcdef", "ip-0123456789abcdef"},
+		{"empty", &hash, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.Apply(tt.in); got != tt.want {
+				t.Errorf("Apply(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashIP(t *testing.T) {
+	a := HashIP("203.0.113.5", "pepper")
+	if !IsHashedIP(a) {
+		t.Errorf("%q is not recognized as a hash", a)
+	}
+	if a != HashIP("203.0.113.5", "pepper") {
+		t.Error("hash is not stable")
+	}
+	if a == HashIP("203.0.113.5", "salt") || a == HashIP("203.0.113.6", "pepper") {
+		t.Error("hash ignores its input")
+	}
+}
+
+func TestIsTruncatedIP(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"203.0.113.0", true},
+		{"203.0.113.5", false},
+		{"2001:db8:1::", true},
+		{"2001:db8:1:2::5", false},
+		{"0.0.0.0", false},
+		{"bot", false},
+		{"ip-0123456789abcdef", false},
+	}
+	for _, tt := range tests {
+		if got := IsTruncatedIP(tt.in); got != tt.want {
+			t.Errorf("IsTruncatedIP(%q) = %t, want %t", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIPPrivacyValidate(t *testing.T) {
+	var nilPrivacy *IPPrivacy
+	tests := []struct {
+		p    *IPPrivacy
+		want error
+	}{
+		{nilPrivacy, nil},
+		{&IPPrivacy{Mode: IPHash}, ErrNoSalt},
+		{&IPPrivacy{Mode: IPHash, Salt: "pepper"}, nil},
+		{&IPPrivacy{Mode: IPTruncate}, nil},
+	}
+	for _, tt := range tests {
+		if err := tt.p.Validate(); !errors.Is(err, tt.want) {
+			t.Errorf("Validate(%+v) = %v, want %v", tt.p, err, tt.want)
+		}
+	}
+
+	rc := &RCONClient{}
+	WithIPPrivacy(IPPrivacy{Mode: IPHash})(rc)
+	if !errors.Is(rc.optErr, ErrNoSalt) || rc.ipPrivacy != nil {
+		t.Errorf("WithIPPrivacy without a salt: optErr %v, privacy %+v", rc.optErr, rc.ipPrivacy)
+	}
+}
+
+func TestScrubIPs(t *testing.T) {
+	rc := &RCONClient{ipPrivacy: &IPPrivacy{Mode: IPTruncate}}
+	tests := []struct {
+		name string
+		cmd  string
+		in   string
+		want string
+	}{
+		{
+			name: "status address column",
+			cmd:  "status",
+			in:   "  0  1200   0   45 1022834                          Name 10.1.2.3^7     0 203.0.113.5:28960     12345 25000",
+			want: "  0  1200   0   45 1022834                          Name 10.1.2.3^7     0 203.0.113.0:28960     12345 25000",
+		},
+		{
+			name: "ban list address before the name",
+			cmd:  "banlist",
+			in:   `0110000100000001 203.0.113.5 "Name 10.1.2.3" aimbot from 198.51.100.7`,
+			want: `0110000100000001 203.0.113.0 "Name 10.1.2.3" aimbot from 198.51.100.7`,
+		},
+		{
+			name: "dvar replies untouched",
+			cmd:  "net_ip",
+			in:   `"net_ip" is:"203.0.113.5^7"`,
+			want: `"net_ip" is:"203.0.113.5^7"`,
+		},
+		{
+			name: "chat untouched",
+			cmd:  "say",
+			in:   "join 203.0.113.5:28960",
+			want: "join 203.0.113.5:28960",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rc.scrubIPs(tt.cmd, []string{tt.in})
+			if strings.Join(got, "\n") != tt.want {
+				t.Errorf("scrubIPs = %q, want %q", got[0], tt.want)
+			}
+		})
+	}
+}
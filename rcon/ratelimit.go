@@ -0,0 +1,167 @@
+package rcon
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket refilled at one token per interval
+type rateLimiter struct {
+	interval time.Duration
+	burst    int
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// WithRateLimit paces outbound packets to at most one per interval, allowing bursts of
+// up to burst packets after a quiet period. Plutonium servers drop or garble replies
+// when commands arrive back to back, so bots sending bursts should set this
+func WithRateLimit(interval time.Duration, burst int) Option {
+	return func(rc *RCONClient) {
+		if interval <= 0 {
+			return
+		}
+		if burst < 1 {
+			burst = 1
+		}
+		rc.limiter = &rateLimiter{interval: interval, burst: burst, tokens: float64(burst)}
+	}
+}
+
+// wait blocks until a packet may be sent. Callers wait before taking rc.mu, so a
+// paced command doesn't hold up State, Close or commands of other goroutines
+func (l *rateLimiter) wait() {
+	if d := l.reserve(); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// reserve takes a token and returns how long to wait before sending. The bucket may
+// go into debt, so concurrent callers get consecutive slots
+func (l *rateLimiter) reserve() time.Duration {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens += float64(now.Sub(l.last)) / float64(l.interval)
+		if l.tokens > float64(l.burst) {
+			l.tokens = float64(l.burst)
+		}
+	}
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens * float64(l.interval))
+}
+
+// Result is the outcome of a queued command
+type Result struct {
+	Command  string   `json:"command"`
+	Response []string `json:"response"`
+	Err      error    `json:"-"`
+}
+
+// MarshalJSON reports Err as its message
+func (r Result) MarshalJSON() ([]byte, error) {
+	type plain Result
+	return json.Marshal(struct {
+		plain
+		Err *string `json:"error,omitempty"`
+	}{plain(r), errString(r.Err)})
+}
+
+// ErrQueueClosed is returned for commands queued on a closed client
+var ErrQueueClosed = errors.New("command queue is closed")
+
+type queuedCommand struct {
+	cmd  string
+	args *string
+	res  chan Result
+}
+
+// WithQueueSize sets how many commands Enqueue buffers before blocking (default 64)
+func WithQueueSize(n int) Option {
+	return func(rc *RCONClient) {
+		rc.queueSize = n
+	}
+}
+
+// Enqueue sends a command in the background, in order with other queued commands,
+// and delivers its result on the returned channel
+func (rc *RCONClient) Enqueue(cmd string, args *string) <-chan Result {
+	res := make(chan Result, 1)
+	if rc == nil {
+		res <- Result{Command: cmd, Err: ErrNilClient}
+		return res
+	}
+	rc.queueOnce.Do(rc.startQueue)
+	// stopQueue closes queueDone under the write lock, so nothing lands in the buffer
+	// after the worker drained it
+	rc.queueMu.RLock()
+	defer rc.queueMu.RUnlock()
+	select {
+	case <-rc.queueDone:
+		res <- Result{Command: cmd, Err: ErrQueueClosed}
+		return res
+	default:
+	}
+	rc.queue <- queuedCommand{cmd: cmd, args: args, res: res}
+	return res
+}
+
+// startQueue starts the worker draining the command queue
+func (rc *RCONClient) startQueue() {
+	size := rc.queueSize
+	if size <= 0 {
+		size = 64
+	}
+	rc.queue = make(chan queuedCommand, size)
+	rc.queueDone = make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-rc.queueDone:
+				rc.drainQueue()
+				return
+			case q := <-rc.queue:
+				resp, err := rc.SendCommand(q.cmd, q.args)
+				q.res <- Result{Command: q.cmd, Response: resp, Err: err}
+			}
+		}
+	}()
+}
+
+// drainQueue fails every command still waiting in the queue
+func (rc *RCONClient) drainQueue() {
+	for {
+		select {
+		case q := <-rc.queue:
+			q.res <- Result{Command: q.cmd, Err: ErrQueueClosed}
+		default:
+			return
+		}
+	}
+}
+
+// stopQueue stops the queue worker, if one was started
+func (rc *RCONClient) stopQueue() {
+	// A queue that never started still needs a closed done channel for later Enqueue calls
+	rc.queueOnce.Do(func() {
+		rc.queue = make(chan queuedCommand)
+		rc.queueDone = make(chan struct{})
+	})
+	rc.queueStop.Do(func() {
+		rc.queueMu.Lock()
+		close(rc.queueDone)
+		rc.queueMu.Unlock()
+	})
+}
@@ -0,0 +1,88 @@
+package rcon
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	l := &rateLimiter{interval: time.Hour, burst: 3, tokens: 3}
+	for i := 0; i < 3; i++ {
+		if d := l.reserve(); d != 0 {
+			t.Fatalf("packet %d of the burst waits %v", i+1, d)
+		}
+	}
+	// past the burst the bucket goes into debt, one interval per packet
+	for i := 1; i <= 3; i++ {
+		d := l.reserve()
+		if want := time.Duration(i) * time.Hour; d < want-time.Second || d > want {
+			t.Errorf("packet %d past the burst waits %v, want about %v", i, d, want)
+		}
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	l := &rateLimiter{interval: time.Minute, burst: 2, tokens: 0, last: time.Now().Add(-time.Hour)}
+	// an hour of refill is capped at the burst
+	for i := 0; i < 2; i++ {
+		if d := l.reserve(); d != 0 {
+			t.Fatalf("packet %d after a quiet period waits %v", i+1, d)
+		}
+	}
+	if d := l.reserve(); d == 0 {
+		t.Error("the refill went past the burst")
+	}
+}
+
+func TestRateLimiterNil(t *testing.T) {
+	var l *rateLimiter
+	if d := l.reserve(); d != 0 {
+		t.Errorf("nil limiter waits %v", d)
+	}
+	l.wait()
+}
+
+func TestWithRateLimit(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		burst    int
+		want     *rateLimiter
+	}{
+		{0, 5, nil},
+		{-time.Second, 5, nil},
+		{time.Second, 0, &rateLimiter{interval: time.Second, burst: 1, tokens: 1}},
+		{time.Second, 4, &rateLimiter{interval: time.Second, burst: 4, tokens: 4}},
+	}
+	for _, tt := range tests {
+		rc := &RCONClient{}
+		WithRateLimit(tt.interval, tt.burst)(rc)
+		if (rc.limiter == nil) != (tt.want == nil) {
+			t.Errorf("WithRateLimit(%v, %d) limiter = %v", tt.interval, tt.burst, rc.limiter)
+			continue
+		}
+		if tt.want != nil && (rc.limiter.burst != tt.want.burst || rc.limiter.tokens != tt.want.tokens) {
+			t.Errorf("WithRateLimit(%v, %d) = burst %d, tokens %v", tt.interval, tt.burst, rc.limiter.burst, rc.limiter.tokens)
+		}
+	}
+}
+
+func TestEnqueueAfterClose(t *testing.T) {
+	rc := &RCONClient{}
+	rc.stopQueue()
+	select {
+	case res := <-rc.Enqueue("status", nil):
+		if !errors.Is(res.Err, ErrQueueClosed) {
+			t.Errorf("err = %v, want ErrQueueClosed", res.Err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Enqueue on a closed queue blocked")
+	}
+}
+
+func TestEnqueueNil(t *testing.T) {
+	var rc *RCONClient
+	if res := <-rc.Enqueue("status", nil); !errors.Is(res.Err, ErrNilClient) {
+		t.Errorf("err = %v, want ErrNilClient", res.Err)
+	}
+}
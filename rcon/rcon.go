@@ -1,95 +1,173 @@
 package rcon
 
 import (
-	"bytes"
 	"errors"
-	"fmt"
 	"net"
 	"strconv"
 	"sync"
 	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+	"github.com/Yallamaztar/PlutoRCON/v2/query"
 )
 
 const (
-	readBufferSize       = 4096
 	defaultReadTimeout   = time.Second
 	defaultReadExtension = 350 * time.Millisecond
 )
 
-func New(ip, port, password string) (*RCONClient, error) {
+func New(ip, port, password string, opts ...Option) (*RCONClient, error) {
 	if password == "" {
 		return nil, errors.New("RCON password cannot be empty")
 	}
+	return dial(ip, port, password, false, opts)
+}
 
-	portNum, err := strconv.Atoi(port)
-	if err != nil {
-		return nil, errors.New("invalid port number")
-	}
+// NewObserver creates a read-only client without the RCON password.
+// It can only run connectionless queries such as GetInfo and GetStatus
+func NewObserver(ip, port string, opts ...Option) (*RCONClient, error) {
+	return dial(ip, port, "", true, opts)
+}
 
-	addr, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", ip, portNum))
+// NewQueryClient creates a lightweight client for getinfo, getstatus and latency
+// probes that needs no RCON password and reads the given title's info keys
+func NewQueryClient(ip, port string, game Game) (*QueryClient, error) {
+	qc, err := query.New(ip, port)
 	if err != nil {
-		return nil, errors.New("failed to resolve UDP address")
+		return nil, err
 	}
+	qc.Keys = builtinProfile(game).InfoKeys
+	qc.Codepage = builtinProfile(game).Codepage
+	return qc, nil
+}
 
-	conn, err := net.DialUDP("udp", nil, addr)
+// dial builds the client, applies its options and opens the transport
+func dial(ip, port, password string, observer bool, opts []Option) (*RCONClient, error) {
+	portNum, err := strconv.Atoi(port)
 	if err != nil {
-		return nil, errors.New("failed to establish UDP connection")
+		return nil, errors.New("invalid port number")
 	}
 
-	return &RCONClient{
+	rc := &RCONClient{
 		IP:       ip,
 		Port:     portNum,
 		Password: password,
 		Timeout:  defaultReadTimeout,
-		Conn:     conn,
 		mu:       sync.Mutex{},
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(rc)
+	}
+	if rc.optErr != nil {
+		return nil, rc.optErr
+	}
+	rc.observer = observer
+
+	if err := rc.Connect(); err != nil {
+		return nil, err
+	}
+	return rc, nil
+}
+
+// ReadOnly reports whether the client was created with NewObserver
+func (rc *RCONClient) ReadOnly() bool {
+	return rc != nil && rc.observer
 }
 
-// Close the RCONClient UDP connection
+// Close the RCONClient UDP connection. Later calls return ErrClosed until Connect
 func (rc *RCONClient) Close() error {
-	return rc.Conn.Close()
+	if rc == nil {
+		return ErrNilClient
+	}
+	rc.stopQueue()
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.closed {
+		return ErrClosed
+	}
+	rc.closed = true
+	rc.setStateLocked(StateDisconnected)
+	if rc.conn == nil {
+		return nil
+	}
+	return rc.conn.Close()
 }
 
-// readResponse reads the response from the RCON
-func (rc *RCONClient) readResponse(readTimeout, readExtension time.Duration) ([]string, error) {
+// Closed reports whether Close was called (and Connect wasn't since)
+func (rc *RCONClient) Closed() bool {
+	if rc == nil {
+		return true
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.closed
+}
+
+// Transport returns the connection the client talks through, or nil before it connected
+func (rc *RCONClient) Transport() Transport {
+	if rc == nil {
+		return nil
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.conn
+}
+
+// Conn returns the UDP socket, or nil before the client connected or when it talks
+// through another transport.
+//
+// Deprecated: v1 exposed the socket as the Conn field; use Transport
+func (rc *RCONClient) Conn() *net.UDPConn {
+	conn, _ := rc.Transport().(*net.UDPConn)
+	return conn
+}
+
+// ready returns the typed error that keeps the client from sending: nil, closed or not
+// connected
+func (rc *RCONClient) ready() error {
+	if rc == nil {
+		return ErrNilClient
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.readyLocked()
+}
+
+// readyLocked is ready for callers holding rc.mu
+func (rc *RCONClient) readyLocked() error {
+	switch {
+	case rc.closed:
+		return ErrClosed
+	case rc.conn == nil:
+		return ErrNotConnected
+	}
+	return nil
+}
+
+// readResponse reads the response from the RCON, reporting whether it looks truncated.
+// expect names the reply type to keep ("print" for commands), dropping stray replies of other types
+func (rc *RCONClient) readResponse(readTimeout, readExtension time.Duration, expect string) ([]string, bool, error) {
+	lines, res, err := rc.readFrames(readTimeout, readExtension, expect, rc.codecOrDefault().Decode)
+	if err != nil {
+		return nil, false, err
+	}
+	return lines, res.Truncated, nil
+}
+
+// readFrames is readResponse with a custom decoder, also returning the reassembled reply.
+// Text is converted from the game's codepage so names and chat are valid UTF-8
+func (rc *RCONClient) readFrames(readTimeout, readExtension time.Duration, expect string, decode wire.DecodeFunc) ([]string, *wire.Response, error) {
 	if readTimeout <= 0 {
 		readTimeout = defaultReadTimeout
 	}
-	if readExtension < 0 {
-		readExtension = 0
-	}
-
-	var buf bytes.Buffer
-	deadline := time.Now().Add(readTimeout)
-
-	for {
-		if err := rc.Conn.SetReadDeadline(deadline); err != nil {
-			return nil, err
-		}
-		tmp := make([]byte, readBufferSize)
-		n, err := rc.Conn.Read(tmp)
-		if err != nil {
-			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-				if buf.Len() == 0 {
-					return nil, err
-				}
-				break
-			}
-			return nil, err
-		}
-		if n > 0 {
-			buf.Write(tmp[:n])
-			if readExtension > 0 {
-				deadline = time.Now().Add(readExtension)
-			}
-		}
-	}
-
-	raw := normalizeRCON(buf.String())
+	res, err := wire.ReadFrames(rc.conn, readTimeout, readExtension, expect, decode, rc.maxResponse)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	raw := wire.ToUTF8(normalizeRCON(string(res.Data)), rc.textCodepage())
 	if raw == "" {
-		return nil, nil
+		return nil, res, nil
 	}
-	lines := splitNonEmptyLines(raw)
-	return lines, nil
+	return splitNonEmptyLines(raw), res, nil
 }
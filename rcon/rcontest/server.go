@@ -0,0 +1,340 @@
+// Package rcontest provides an in-memory Plutonium-style UDP server for testing code built on rcon
+package rcontest
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+var oobHeader = []byte{0xFF, 0xFF, 0xFF, 0xFF}
+
+// HandlerFunc answers an rcon command; the returned text is sent as a print reply
+type HandlerFunc func(args string) string
+
+type Command struct {
+	Name          string
+	Args          string
+	Raw           string
+	From          net.Addr
+	Authenticated bool
+	At            time.Time
+}
+
+// Server is a fake game server answering rcon, getinfo and getstatus requests
+type Server struct {
+	Password string
+	// FragmentSize splits replies into datagrams of at most this many payload bytes (0 = one datagram)
+	FragmentSize int
+	// Delay is applied before every reply
+	Delay time.Duration
+
+	conn      net.PacketConn
+	wg        sync.WaitGroup
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu       sync.Mutex
+	info     map[string]string
+	status   map[string]string
+	mapName  string
+	players  []rcon.Player
+	dvars    map[string]string
+	handlers map[string]HandlerFunc
+	scripts  map[string][]string
+	commands []Command
+}
+
+// NewServer starts a fake server on a random loopback port
+func NewServer(password string) *Server {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("rcontest: failed to listen: %v", err))
+	}
+	s := &Server{
+		Password: password,
+		conn:     conn,
+		done:     make(chan struct{}),
+		info:     map[string]string{"hostname": "rcontest", "mapname": "mp_raid", "gametype": "tdm", "protocol": "6", "sv_maxclients": "18"},
+		status:   map[string]string{"sv_hostname": "rcontest", "mapname": "mp_raid", "g_gametype": "tdm", "sv_maxclients": "18", "protocol": "6"},
+		mapName:  "mp_raid",
+		dvars:    map[string]string{},
+		handlers: map[string]HandlerFunc{},
+		scripts:  map[string][]string{},
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Addr returns the host:port the server listens on
+func (s *Server) Addr() string { return s.conn.LocalAddr().String() }
+
+// Host returns the listening IP, for rcon.New
+func (s *Server) Host() string {
+	h, _, _ := net.SplitHostPort(s.Addr())
+	return h
+}
+
+// Port returns the listening port, for rcon.New
+func (s *Server) Port() string {
+	_, p, _ := net.SplitHostPort(s.Addr())
+	return p
+}
+
+// Client returns an rcon client connected to the server with its password
+func (s *Server) Client(opts ...rcon.Option) (*rcon.RCONClient, error) {
+	return rcon.New(s.Host(), s.Port(), s.Password, opts...)
+}
+
+// Close stops the server along with its heartbeats and simulations
+func (s *Server) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+	s.conn.Close()
+	s.wg.Wait()
+}
+
+// Handle registers a canned reply for an rcon command
+func (s *Server) Handle(cmd, reply string) {
+	s.HandleFunc(cmd, func(string) string { return reply })
+}
+
+// HandleFunc registers a handler for an rcon command
+func (s *Server) HandleFunc(cmd string, fn HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[strings.ToLower(cmd)] = fn
+}
+
+// Script queues replies returned one by one for a command before falling back to its handler
+func (s *Server) Script(cmd string, replies ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := strings.ToLower(cmd)
+	s.scripts[key] = append(s.scripts[key], replies...)
+}
+
+// SetDvar sets a dvar the server reports for "<dvar>" queries
+func (s *Server) SetDvar(name, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dvars[strings.ToLower(name)] = value
+}
+
+// Dvar returns a dvar as set by the client or SetDvar
+func (s *Server) Dvar(name string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.dvars[strings.ToLower(name)]
+	return v, ok
+}
+
+// SetInfo sets a key of the getinfo reply
+func (s *Server) SetInfo(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.info[key] = value
+}
+
+// SetStatusVar sets a key of the getstatus reply
+func (s *Server) SetStatusVar(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status[key] = value
+}
+
+// SetPlayers sets the map and players reported by status and getstatus
+func (s *Server) SetPlayers(mapName string, players []rcon.Player) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mapName = mapName
+	s.info["mapname"] = mapName
+	s.status["mapname"] = mapName
+	s.players = append([]rcon.Player(nil), players...)
+}
+
+// Players returns the players currently reported
+func (s *Server) Players() []rcon.Player {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]rcon.Player(nil), s.players...)
+}
+
+// Commands returns every rcon command received so far
+func (s *Server) Commands() []Command {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Command(nil), s.commands...)
+}
+
+// Reset forgets the received commands
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands = nil
+}
+
+// serve reads requests until the connection is closed
+func (s *Server) serve() {
+	defer s.wg.Done()
+	buf := make([]byte, 65536)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if !bytes.HasPrefix(buf[:n], oobHeader) {
+			continue
+		}
+		req := strings.TrimRight(string(buf[4:n]), "\n\x00")
+		if reply, header, ok := s.handle(req, addr); ok {
+			if s.Delay > 0 {
+				time.Sleep(s.Delay)
+			}
+			s.send(addr, header, reply)
+		}
+	}
+}
+
+// handle builds the reply for one request
+func (s *Server) handle(req string, from net.Addr) (reply, header string, ok bool) {
+	word, rest, _ := strings.Cut(req, " ")
+	switch strings.ToLower(word) {
+	case "getinfo":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.info["clients"] = strconv.Itoa(len(s.players))
+		return infoString(s.info) + challenge(rest), "infoResponse\n", true
+	case "getstatus":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		var b strings.Builder
+		b.WriteString(infoString(s.status) + challenge(rest))
+		for _, p := range s.players {
+			fmt.Fprintf(&b, "\n%d %d %q", p.Score, p.Ping, p.Name)
+		}
+		return b.String(), "statusResponse\n", true
+	case "rcon":
+		pass, cmdline, _ := strings.Cut(rest, " ")
+		reply := s.rcon(pass, strings.TrimSpace(cmdline), req, from)
+		// Real servers end every print with a newline; the client treats a missing one as truncation
+		if reply != "" && !strings.HasSuffix(reply, "\n") {
+			reply += "\n"
+		}
+		return reply, "print\n", true
+	}
+	return "", "", false
+}
+
+// rcon records and answers an rcon command
+func (s *Server) rcon(pass, cmdline, raw string, from net.Addr) string {
+	name, args, _ := strings.Cut(cmdline, " ")
+	key := strings.ToLower(name)
+
+	s.mu.Lock()
+	authed := pass == s.Password
+	s.commands = append(s.commands, Command{Name: name, Args: args, Raw: raw, From: from, Authenticated: authed, At: time.Now()})
+	if !authed {
+		s.mu.Unlock()
+		return "Invalid password."
+	}
+	if queued := s.scripts[key]; len(queued) > 0 {
+		s.scripts[key] = queued[1:]
+		s.mu.Unlock()
+		return queued[0]
+	}
+	if fn, ok := s.handlers[key]; ok {
+		s.mu.Unlock()
+		return fn(args)
+	}
+	defer s.mu.Unlock()
+
+	switch key {
+	case "status":
+		return s.statusText()
+	case "set", "seta", "sets":
+		dvar, val, _ := strings.Cut(args, " ")
+		s.dvars[strings.ToLower(dvar)] = strings.Trim(strings.TrimSpace(val), `"`)
+		return ""
+	}
+	if v, ok := s.dvars[key]; ok {
+		return fmt.Sprintf("%q is:\"%s^7\" default:\"^7\"", name, v)
+	}
+	return ""
+}
+
+// statusText renders the rcon status table; callers hold s.mu
+func (s *Server) statusText() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "map: %s\n", s.mapName)
+	b.WriteString("num score bot ping guid                             name             lastmsg address               qport rate\n")
+	b.WriteString("--- ----- --- ---- -------------------------------- ---------------- ------- --------------------- ----- -----\n")
+	players := append([]rcon.Player(nil), s.players...)
+	sort.Slice(players, func(i, j int) bool { return players[i].ClientNum < players[j].ClientNum })
+	for _, p := range players {
+		addr := p.IP
+		if p.Port != 0 {
+			addr = fmt.Sprintf("%s:%d", p.IP, p.Port)
+		}
+		fmt.Fprintf(&b, "%3d %5d %3d %4s %-32s %-16s %7d %-21s %5d %5d\n",
+			p.ClientNum, p.Score, 0, pingString(p), p.GUID, p.Name+"^7", p.LastMsg, addr, p.QPort, p.Rate)
+	}
+	return b.String()
+}
+
+// send writes a reply, splitting it into fragments each carrying its own header
+func (s *Server) send(to net.Addr, header, reply string) {
+	size := s.FragmentSize
+	if size <= 0 || len(reply) <= size {
+		s.conn.WriteTo(append(append([]byte{}, oobHeader...), header+reply...), to)
+		return
+	}
+	for len(reply) > 0 {
+		n := size
+		if n > len(reply) {
+			n = len(reply)
+		}
+		s.conn.WriteTo(append(append([]byte{}, oobHeader...), header+reply[:n]...), to)
+		reply = reply[n:]
+	}
+}
+
+// infoString renders a \key\value string with sorted keys
+func infoString(kv map[string]string) string {
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString("\\" + k + "\\" + kv[k])
+	}
+	return b.String()
+}
+
+// challenge echoes a getinfo/getstatus nonce the way real servers do
+func challenge(nonce string) string {
+	if nonce = strings.TrimSpace(nonce); nonce == "" {
+		return ""
+	}
+	return "\\challenge\\" + nonce
+}
+
+// pingString formats a Player ping the way status prints it
+func pingString(p rcon.Player) string {
+	switch {
+	case p.Loading:
+		return "LOAD"
+	case p.Zombie:
+		return "ZMBI"
+	}
+	return strconv.Itoa(p.Ping)
+}
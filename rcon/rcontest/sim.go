@@ -0,0 +1,158 @@
+package rcontest
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// Heartbeat sends a master-server heartbeat followed by an unsolicited infoResponse to addr
+// every interval, like a public server announcing itself. It runs until stop or Close
+func (s *Server) Heartbeat(addr string, interval time.Duration) (stop func(), err error) {
+	to, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return s.every(interval, func() {
+		s.conn.WriteTo(append(append([]byte{}, oobHeader...), "heartbeat PlutoRCON\n"...), to)
+		reply, header, _ := s.handle("getinfo", to)
+		s.send(to, header, reply)
+	}), nil
+}
+
+// Churn describes simulated player activity for Simulate
+type Churn struct {
+	// Interval between steps; 0 only steps when Step is called
+	Interval time.Duration
+	// JoinChance and LeaveChance are the per-step odds of one player joining or leaving
+	JoinChance  float64
+	LeaveChance float64
+	// MaxPlayers caps the simulated population (default 18)
+	MaxPlayers int
+	// Maps are rotated through every MapEvery steps (0 keeps the current map)
+	Maps     []string
+	MapEvery int
+	// Seed makes the simulation repeatable
+	Seed int64
+}
+
+// Simulation changes the server's players and map over time
+type Simulation struct {
+	s    *Server
+	c    Churn
+	rng  *rand.Rand
+	mu   sync.Mutex
+	step int
+	next int
+	stop func()
+}
+
+// Simulate starts player churn on the server. Joining players show LOAD for a step
+// before getting a ping, scores grow while they play, and maps rotate
+func (s *Server) Simulate(c Churn) *Simulation {
+	if c.MaxPlayers <= 0 {
+		c.MaxPlayers = 18
+	}
+	sim := &Simulation{s: s, c: c, rng: rand.New(rand.NewSource(c.Seed))}
+	if c.Interval > 0 {
+		sim.stop = s.every(c.Interval, sim.Step)
+	}
+	return sim
+}
+
+// Step advances the simulation once
+func (sim *Simulation) Step() {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	sim.step++
+
+	s := sim.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.players {
+		p := &s.players[i]
+		if p.Loading {
+			p.Loading, p.Ping = false, 20+sim.rng.Intn(80)
+			continue
+		}
+		p.Score += sim.rng.Intn(3) * 100
+		p.Ping = max(5, p.Ping+sim.rng.Intn(11)-5)
+	}
+
+	if len(s.players) > 0 && sim.rng.Float64() < sim.c.LeaveChance {
+		i := sim.rng.Intn(len(s.players))
+		s.players = append(s.players[:i], s.players[i+1:]...)
+	}
+	if len(s.players) < sim.c.MaxPlayers && sim.rng.Float64() < sim.c.JoinChance {
+		s.players = append(s.players, sim.newPlayerLocked())
+	}
+
+	if sim.c.MapEvery > 0 && len(sim.c.Maps) > 0 && sim.step%sim.c.MapEvery == 0 {
+		m := sim.c.Maps[(sim.step/sim.c.MapEvery-1)%len(sim.c.Maps)]
+		s.mapName, s.info["mapname"], s.status["mapname"] = m, m, m
+		for i := range s.players {
+			s.players[i].Score = 0
+		}
+	}
+}
+
+// Stop ends timed stepping; Step still works afterwards
+func (sim *Simulation) Stop() {
+	if sim.stop != nil {
+		sim.stop()
+	}
+}
+
+// newPlayerLocked builds a joining player in the lowest free slot; callers hold both locks
+func (sim *Simulation) newPlayerLocked() rcon.Player {
+	used := map[int]bool{}
+	for _, p := range sim.s.players {
+		used[p.ClientNum] = true
+	}
+	num := 0
+	for used[num] {
+		num++
+	}
+	sim.next++
+	return rcon.Player{
+		ClientNum: num,
+		Name:      fmt.Sprintf("player%d", sim.next),
+		Loading:   true,
+		IP:        fmt.Sprintf("203.0.113.%d", 1+sim.next%254),
+		Port:      28960,
+		QPort:     sim.rng.Intn(65536),
+		GUID:      fmt.Sprintf("%016x", sim.rng.Uint64()),
+		Rate:      25000,
+	}
+}
+
+// every calls fn each interval until the returned stop or Close
+func (s *Server) every(interval time.Duration, fn func()) (stop func()) {
+	quit := make(chan struct{})
+	var once sync.Once
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-quit:
+				return
+			case <-t.C:
+				fn()
+			}
+		}
+	}()
+	return func() { once.Do(func() { close(quit) }) }
+}
@@ -0,0 +1,156 @@
+package rcon
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RotationEntry is one map of sv_maprotation; an empty GameType keeps the previous one
+type RotationEntry struct {
+	GameType string `json:"game_type"`
+	Map      string `json:"map"`
+}
+
+// ParseMapRotation parses the "gametype tdm map mp_raid map mp_slums" dvar format
+func ParseMapRotation(rot string) []RotationEntry {
+	var out []RotationEntry
+	gametype := ""
+	f := strings.Fields(rot)
+	for i := 0; i+1 < len(f); i++ {
+		switch strings.ToLower(f[i]) {
+		case "gametype":
+			gametype = f[i+1]
+			i++
+		case "map":
+			out = append(out, RotationEntry{GameType: gametype, Map: f[i+1]})
+			i++
+		}
+	}
+	return out
+}
+
+// FormatMapRotation builds the sv_maprotation value, only repeating gametype when it changes
+func FormatMapRotation(entries []RotationEntry) string {
+	var b strings.Builder
+	gametype := ""
+	for _, e := range entries {
+		if e.GameType != "" && e.GameType != gametype {
+			gametype = e.GameType
+			b.WriteString("gametype " + gametype + " ")
+		}
+		b.WriteString("map " + e.Map + " ")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Get the map rotation
+func (rc *RCONClient) GetMapRotation() ([]RotationEntry, error) {
+	rot, err := rc.GetDvar("sv_maprotation")
+	if err != nil {
+		return nil, err
+	}
+	entries := ParseMapRotation(rot)
+	rc.noteRotation(entries)
+	return entries, nil
+}
+
+// Get the next map of the running rotation: the head of sv_maprotationcurrent, or the
+// first entry of sv_maprotation once the current pass is used up
+func (rc *RCONClient) NextMap() (*RotationEntry, error) {
+	cur, err := rc.GetDvar("sv_maprotationcurrent")
+	if err != nil {
+		return nil, err
+	}
+	entries := ParseMapRotation(cur)
+	rc.noteRotation(entries)
+	if len(entries) == 0 {
+		if entries, err = rc.GetMapRotation(); err != nil {
+			return nil, err
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("map rotation is empty")
+	}
+	return &entries[0], nil
+}
+
+// Replace the map rotation, validating every map against the available maps
+func (rc *RCONClient) SetMapRotation(entries []RotationEntry) error {
+	if len(entries) == 0 {
+		return fmt.Errorf("rotation cannot be empty")
+	}
+	for i, e := range entries {
+		if err := rc.validateMap(e.Map); err != nil {
+			return fmt.Errorf("rotation entry %d: %w", i+1, err)
+		}
+		if strings.ContainsAny(e.GameType, " \t\"") {
+			return fmt.Errorf("rotation entry %d: invalid gametype %q", i+1, e.GameType)
+		}
+	}
+	value := FormatMapRotation(entries)
+	return rc.offlineOr(OfflineAction{Kind: ActionRotation, Target: "sv_maprotation", Value: value}, func() error {
+		return rc.setMapRotation(value)
+	})
+}
+
+// setMapRotation sets a formatted rotation and restarts it
+func (rc *RCONClient) setMapRotation(value string) error {
+	if _, err := rc.setDvar("sv_maprotation", value); err != nil {
+		return err
+	}
+	// sv_maprotationcurrent holds what's left of the running rotation; reset it so the new one starts over
+	return rc.setDvarRaw("sv_maprotationcurrent", "")
+}
+
+// Change map, optionally switching gametype first
+func (rc *RCONClient) ChangeMap(mapName, gametype string) error {
+	if err := rc.validateMap(mapName); err != nil {
+		return err
+	}
+	if gametype != "" {
+		if err := rc.SetDvar("g_gametype", gametype); err != nil {
+			return err
+		}
+	}
+	rc.InvalidateDvars()
+	_, err := rc.SendCommand("map", &mapName)
+	return err
+}
+
+// Restart the current map, reloading latched dvars
+func (rc *RCONClient) MapRestart() error {
+	rc.InvalidateDvars()
+	_, err := rc.SendCommand("map_restart", nil)
+	return err
+}
+
+// Restart the current round without reloading the map
+func (rc *RCONClient) FastRestart() error {
+	_, err := rc.SendCommand("fast_restart", nil)
+	return err
+}
+
+// validateMap rejects empty names and maps that aren't among the available ones
+func (rc *RCONClient) validateMap(name string) error {
+	if rc == nil {
+		return ErrNilClient
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, " \t\"") {
+		return fmt.Errorf("invalid map name %q", name)
+	}
+	if IsKnownMap(rc.Game(), name) {
+		return nil
+	}
+	maps := rc.availableMaps()
+	if !maps[strings.ToLower(name)] && !rc.ReadOnly() {
+		// the live rotation may list custom maps this client hasn't seen yet
+		if _, err := rc.GetMapRotation(); err == nil {
+			maps = rc.availableMaps()
+		}
+	}
+	if maps[strings.ToLower(name)] {
+		return nil
+	}
+	return &UnknownMapError{Game: rc.Game(), Map: name, Suggestions: suggestMaps(name, maps)}
+}
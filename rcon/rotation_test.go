@@ -0,0 +1,32 @@
+package rcon
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMapRotation(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []RotationEntry
+	}{
+		{"", nil},
+		{"map mp_raid", []RotationEntry{{Map: "mp_raid"}}},
+		{"gametype tdm map mp_raid map mp_slums gametype dom map mp_hijacked", []RotationEntry{
+			{GameType: "tdm", Map: "mp_raid"}, {GameType: "tdm", Map: "mp_slums"}, {GameType: "dom", Map: "mp_hijacked"},
+		}},
+		{"GAMETYPE tdm  MAP mp_raid map", []RotationEntry{{GameType: "tdm", Map: "mp_raid"}}},
+	}
+	for _, tt := range tests {
+		if got := ParseMapRotation(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseMapRotation(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatMapRotation(t *testing.T) {
+	rot := "gametype tdm map mp_raid map mp_slums gametype dom map mp_hijacked"
+	if got := FormatMapRotation(ParseMapRotation(rot)); got != rot {
+		t.Errorf("round trip = %q, want %q", got, rot)
+	}
+}
@@ -0,0 +1,70 @@
+package rcon
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type DvarSnapshot struct {
+	Values  map[string]string `json:"values"`
+	TakenAt time.Time         `json:"taken_at"`
+	rc      *RCONClient
+}
+
+// Snapshot the current values of a set of dvars so they can be restored later
+func (rc *RCONClient) SnapshotDvars(names ...string) (*DvarSnapshot, error) {
+	snap := &DvarSnapshot{Values: make(map[string]string, len(names)), TakenAt: time.Now(), rc: rc}
+	for _, name := range names {
+		val, err := rc.GetDvar(name)
+		if err != nil {
+			return nil, fmt.Errorf("snapshot %s: %w", name, err)
+		}
+		snap.Values[name] = val
+	}
+	return snap, nil
+}
+
+// Restore writes every value of the snapshot back to the server
+func (s *DvarSnapshot) Restore() error {
+	var errs []error
+	for name, val := range s.Values {
+		if err := s.rc.setDvarRaw(name, val); err != nil {
+			errs = append(errs, fmt.Errorf("restore %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyDvars sets several dvars, restoring all of them if any set fails
+func (rc *RCONClient) applyDvars(values map[string]string) error {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	snap, err := rc.SnapshotDvars(names...)
+	if err != nil {
+		return err
+	}
+
+	for name, val := range values {
+		if err := rc.setDvarRaw(name, val); err != nil {
+			if rerr := snap.Restore(); rerr != nil {
+				return errors.Join(fmt.Errorf("set %s: %w", name, err), rerr)
+			}
+			return fmt.Errorf("set %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setDvarRaw is SetDvar that also accepts empty values
+func (rc *RCONClient) setDvarRaw(name, value string) error {
+	if value == "" {
+		arg := name + ` ""`
+		_, err := rc.SendCommand("set", &arg)
+		rc.forgetDvar(name)
+		return err
+	}
+	return rc.SetDvar(name, value)
+}
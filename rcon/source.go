@@ -0,0 +1,189 @@
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+)
+
+const (
+	sourceAuth          = 3
+	sourceAuthResponse  = 2
+	sourceExecCommand   = 2
+	sourceResponseValue = 0
+	sourceMaxPacket     = 4096 + 10
+)
+
+// ErrSourceAuth is returned when a Source RCON server rejects the password
+var ErrSourceAuth = errors.New("source rcon authentication failed")
+
+// SourceDialer returns a Dialer for servers speaking Source (Valve) TCP RCON
+func SourceDialer(password string, timeout time.Duration) Dialer {
+	return func(ip string, port int) (Transport, error) {
+		return DialSource(net.JoinHostPort(ip, strconv.Itoa(port)), password, timeout)
+	}
+}
+
+// SourceTransport adapts Source TCP RCON to the command datagrams the client writes,
+// so the high-level API works unchanged against Source servers
+type SourceTransport struct {
+	conn   net.Conn
+	nextID int32
+	// want is the id of the last command written; replies carrying another id answer
+	// a command that timed out and are dropped
+	want    int32
+	pending []byte
+	// partial holds the bytes of a packet whose read was cut off by the deadline, so
+	// the next read finishes it instead of taking its body for a length
+	partial []byte
+	broken  error
+}
+
+// DialSource connects and authenticates to a Source RCON server
+func DialSource(addr, password string, timeout time.Duration) (*SourceTransport, error) {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to establish TCP connection: %w", err)
+	}
+	t := &SourceTransport{conn: conn, nextID: 1}
+	if err := t.auth(password, timeout); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// auth sends SERVERDATA_AUTH and waits for the matching auth response
+func (t *SourceTransport) auth(password string, timeout time.Duration) error {
+	id := t.id()
+	if err := t.writePacket(id, sourceAuth, password); err != nil {
+		return err
+	}
+	t.conn.SetReadDeadline(time.Now().Add(timeout))
+	defer t.conn.SetReadDeadline(time.Time{})
+	for {
+		rid, typ, _, err := t.readPacket()
+		if err != nil {
+			return err
+		}
+		if typ != sourceAuthResponse {
+			continue
+		}
+		if rid == -1 || rid != id {
+			return ErrSourceAuth
+		}
+		return nil
+	}
+}
+
+// Write translates an out-of-band rcon datagram into SERVERDATA_EXECCOMMAND
+func (t *SourceTransport) Write(b []byte) (int, error) {
+	payload := strings.TrimSpace(string(bytes.TrimPrefix(b, wire.OOBHeader)))
+	if !strings.HasPrefix(payload, "rcon ") {
+		return 0, fmt.Errorf("source rcon does not support %q requests", strings.Fields(payload + " ")[0])
+	}
+	// Drop "rcon <password> " since the connection is already authenticated
+	parts := strings.SplitN(payload, " ", 3)
+	if len(parts) < 3 {
+		return 0, fmt.Errorf("empty rcon command")
+	}
+	id := t.id()
+	if err := t.writePacket(id, sourceExecCommand, parts[2]); err != nil {
+		return 0, err
+	}
+	t.want, t.pending = id, nil
+	return len(b), nil
+}
+
+// Read returns the body of the next response packet to the last command written,
+// skipping what is left of the replies to earlier commands
+func (t *SourceTransport) Read(b []byte) (int, error) {
+	for len(t.pending) == 0 {
+		id, typ, body, err := t.readPacket()
+		if err != nil {
+			return 0, err
+		}
+		if id == t.want && typ == sourceResponseValue && len(body) > 0 {
+			t.pending = body
+		}
+	}
+	n := copy(b, t.pending)
+	t.pending = t.pending[n:]
+	return n, nil
+}
+
+// Close implements Transport
+func (t *SourceTransport) Close() error { return t.conn.Close() }
+
+// SetReadDeadline implements Transport
+func (t *SourceTransport) SetReadDeadline(d time.Time) error { return t.conn.SetReadDeadline(d) }
+
+// id returns the next request id
+func (t *SourceTransport) id() int32 {
+	id := t.nextID
+	t.nextID++
+	return id
+}
+
+// writePacket frames and sends a Source RCON packet
+func (t *SourceTransport) writePacket(id, typ int32, body string) error {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, int32(len(body)+10))
+	binary.Write(&buf, binary.LittleEndian, id)
+	binary.Write(&buf, binary.LittleEndian, typ)
+	buf.WriteString(body)
+	buf.Write([]byte{0, 0})
+	_, err := t.conn.Write(buf.Bytes())
+	return err
+}
+
+// readPacket reads one Source RCON packet. A read cut off mid-packet keeps what
+// arrived for the next call; a packet with an impossible size leaves no way to find
+// the next one, so the connection is closed and every later read fails
+func (t *SourceTransport) readPacket() (id, typ int32, body []byte, err error) {
+	if t.broken != nil {
+		return 0, 0, nil, t.broken
+	}
+	if err = t.fill(4); err != nil {
+		return
+	}
+	size := int32(binary.LittleEndian.Uint32(t.partial[:4]))
+	if size < 10 || size > sourceMaxPacket {
+		t.broken = fmt.Errorf("invalid source rcon packet size %d", size)
+		t.partial = nil
+		t.conn.Close()
+		return 0, 0, nil, t.broken
+	}
+	if err = t.fill(4 + int(size)); err != nil {
+		return
+	}
+	data := t.partial[4 : 4+size]
+	t.partial = t.partial[4+size:]
+	id = int32(binary.LittleEndian.Uint32(data[0:4]))
+	typ = int32(binary.LittleEndian.Uint32(data[4:8]))
+	body = bytes.TrimRight(data[8:], "\x00")
+	return
+}
+
+// fill reads until partial holds at least n bytes
+func (t *SourceTransport) fill(n int) error {
+	buf := make([]byte, sourceMaxPacket)
+	for len(t.partial) < n {
+		m, err := t.conn.Read(buf)
+		t.partial = append(t.partial, buf[:m]...)
+		if err != nil && len(t.partial) < n {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// sourcePacket frames a Source RCON response value packet
+func sourcePacket(id int32, body string) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, int32(len(body)+10))
+	binary.Write(&buf, binary.LittleEndian, id)
+	binary.Write(&buf, binary.LittleEndian, int32(sourceResponseValue))
+	buf.WriteString(body)
+	buf.Write([]byte{0, 0})
+	return buf.Bytes()
+}
+
+func TestSourcePartialRead(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	tr := &SourceTransport{conn: client, nextID: 2, want: 1}
+	defer tr.Close()
+
+	pkt := sourcePacket(1, "map: mp_raid")
+	go server.Write(pkt[:7])
+	tr.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	buf := make([]byte, 64)
+	if _, err := tr.Read(buf); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("Read = %v, want a timeout", err)
+	}
+
+	go server.Write(append(pkt[7:], sourcePacket(1, "next")...))
+	tr.SetReadDeadline(time.Now().Add(time.Second))
+	for _, want := range []string{"map: mp_raid", "next"} {
+		n, err := tr.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(buf[:n]); got != want {
+			t.Errorf("Read = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestSourceBadSize(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	tr := &SourceTransport{conn: client, nextID: 1}
+
+	go server.Write([]byte{0xff, 0xff, 0xff, 0x7f})
+	tr.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 64)
+	_, err := tr.Read(buf)
+	if err == nil {
+		t.Fatal("Read accepted an impossible packet size")
+	}
+	if _, again := tr.Read(buf); again == nil || again.Error() != err.Error() {
+		t.Errorf("second Read = %v, want %v", again, err)
+	}
+}
+
+func TestSourceStaleReply(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	tr := &SourceTransport{conn: client, nextID: 1}
+	defer tr.Close()
+
+	// the server answers each command only after the client gave up on it
+	go func() {
+		hdr := make([]byte, 4)
+		for id := int32(1); ; id++ {
+			if _, err := io.ReadFull(server, hdr); err != nil {
+				return
+			}
+			io.CopyN(io.Discard, server, int64(binary.LittleEndian.Uint32(hdr)))
+			if id > 1 {
+				server.Write(sourcePacket(id-1, fmt.Sprintf("reply %d", id-1)))
+			}
+			server.Write(sourcePacket(id, fmt.Sprintf("reply %d", id)))
+		}
+	}()
+	buf := make([]byte, 64)
+	for _, cmd := range []string{"status", "serverinfo"} {
+		if _, err := tr.Write([]byte("\xff\xff\xff\xffrcon secret " + cmd)); err != nil {
+			t.Fatal(err)
+		}
+		tr.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := tr.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("reply %d", tr.want); string(buf[:n]) != want {
+			t.Errorf("%s read %q, want %q", cmd, buf[:n], want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package rcon
+
+import "time"
+
+// Get the combined getinfo, getstatus and (for non read-only clients) status
+// snapshot; opts apply to the status command, and their priority to all three
+func (rc *RCONClient) FullState(opts ...CommandOption) (*FullServerState, error) {
+	info, err := rc.GetInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+	statusInfo, err := rc.GetStatus(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &FullServerState{Info: info, StatusInfo: statusInfo}
+	if !rc.ReadOnly() {
+		if state.Status, err = rc.Status(opts...); err != nil {
+			return nil, err
+		}
+	}
+	state.RetrievedAt = time.Now()
+	return state, nil
+}
@@ -0,0 +1,144 @@
+package rcon
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const udpReadBuffer = 1 << 20
+
+// Transport carries command datagrams to the server. *net.UDPConn satisfies it
+type Transport interface {
+	Read(b []byte) (int, error)
+	Write(b []byte) (int, error)
+	Close() error
+	SetReadDeadline(t time.Time) error
+}
+
+// Dialer opens a transport to a server
+type Dialer func(ip string, port int) (Transport, error)
+
+// WithMaxResponseSize bounds the bytes kept for one reply (default 512 KiB, negative for
+// no limit). Larger replies are discarded and the command fails with ErrResponseTooLarge
+func WithMaxResponseSize(n int) Option {
+	return func(rc *RCONClient) {
+		rc.maxResponse = n
+	}
+}
+
+// WithQueryChallenge sends a fresh nonce with every getinfo/getstatus and drops replies
+// that don't echo it; with maxAge > 0, replies arriving later than that after their
+// request fail with ErrStale, so duplicated or delayed datagrams can't poison polled state
+func WithQueryChallenge(maxAge time.Duration) Option {
+	return func(rc *RCONClient) {
+		rc.queryChallenge = true
+		rc.queryMaxAge = maxAge
+	}
+}
+
+// WithDialer replaces the default Quake-style UDP transport, e.g. with SourceDialer or a test fake
+func WithDialer(d Dialer) Option {
+	return func(rc *RCONClient) {
+		rc.dialer = d
+	}
+}
+
+// DialUDP opens the default Quake-style UDP transport
+func DialUDP(ip string, port int) (Transport, error) {
+	addr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(ip, strconv.Itoa(port)))
+	if err != nil {
+		return nil, errors.New("failed to resolve UDP address")
+	}
+	conn, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		return nil, errors.New("failed to establish UDP connection")
+	}
+	// Long replies such as dvar dumps arrive as a burst of datagrams; a larger socket
+	// buffer keeps the kernel from dropping the tail before we read it
+	conn.SetReadBuffer(udpReadBuffer)
+	return conn, nil
+}
+
+// WithUnconnectedUDP reads replies on an unconnected socket, for servers whose replies
+// don't come back through a connected socket. Datagrams from any address other than the
+// server's are dropped and counted in RejectedDatagrams
+func WithUnconnectedUDP() Option {
+	return WithDialer(DialUDPUnconnected)
+}
+
+// UnconnectedUDP is a UDP transport that filters replies by source address itself
+type UnconnectedUDP struct {
+	conn     *net.UDPConn
+	server   *net.UDPAddr
+	rejected atomic.Uint64
+}
+
+// DialUDPUnconnected opens an unconnected UDP socket that writes to and reads from the server
+func DialUDPUnconnected(ip string, port int) (Transport, error) {
+	addr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(ip, strconv.Itoa(port)))
+	if err != nil {
+		return nil, errors.New("failed to resolve UDP address")
+	}
+	network := "udp4"
+	if addr.IP.To4() == nil {
+		network = "udp6"
+	}
+	conn, err := net.ListenUDP(network, nil)
+	if err != nil {
+		return nil, errors.New("failed to open UDP socket")
+	}
+	conn.SetReadBuffer(udpReadBuffer)
+	return &UnconnectedUDP{conn: conn, server: addr}, nil
+}
+
+// Write implements Transport
+func (t *UnconnectedUDP) Write(b []byte) (int, error) { return t.conn.WriteToUDP(b, t.server) }
+
+// Read implements Transport, skipping datagrams that didn't come from the server
+func (t *UnconnectedUDP) Read(b []byte) (int, error) {
+	for {
+		n, from, err := t.conn.ReadFromUDP(b)
+		if err != nil {
+			return n, err
+		}
+		if from.Port == t.server.Port && from.IP.Equal(t.server.IP) {
+			return n, nil
+		}
+		t.rejected.Add(1)
+	}
+}
+
+// LocalAddr returns the address replies are read on
+func (t *UnconnectedUDP) LocalAddr() net.Addr { return t.conn.LocalAddr() }
+
+// Rejected returns how many datagrams from other addresses were dropped
+func (t *UnconnectedUDP) Rejected() uint64 { return t.rejected.Load() }
+
+// Close implements Transport
+func (t *UnconnectedUDP) Close() error { return t.conn.Close() }
+
+// SetReadDeadline implements Transport
+func (t *UnconnectedUDP) SetReadDeadline(d time.Time) error { return t.conn.SetReadDeadline(d) }
+
+// rejectCounter is implemented by transports that validate the source of replies
+type rejectCounter interface {
+	Rejected() uint64
+}
+
+// RejectedDatagrams returns how many datagrams were dropped for coming from the wrong
+// address, across reconnects. Connected sockets are filtered by the kernel and report 0
+func (rc *RCONClient) RejectedDatagrams() uint64 {
+	if rc == nil {
+		return 0
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	n := rc.rejectedBase
+	if c, ok := rc.conn.(rejectCounter); ok {
+		n += c.Rejected()
+	}
+	return n
+}
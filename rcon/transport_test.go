@@ -0,0 +1,155 @@
+package rcon
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeServer answers rcon commands and getinfo over fakeConns, without a socket. While
+// down it drops every request, the way an unreachable server looks to the client
+type fakeServer struct {
+	mu    sync.Mutex
+	down  bool
+	dvars map[string]string
+	// info replaces the getinfo keys; noInfo drops getinfo requests, which infos counts
+	info   map[string]string
+	noInfo bool
+	infos  int
+	// handle answers commands other than set and dvar reads; nil replies nothing
+	handle func(cmdline string) string
+	sent   []string
+}
+
+func newFakeServer() *fakeServer {
+	return &fakeServer{dvars: map[string]string{}}
+}
+
+// client returns a client talking to s
+func (s *fakeServer) client(opts ...Option) *RCONClient {
+	opts = append([]Option{
+		WithDialer(func(string, int) (Transport, error) { return &fakeConn{srv: s, replies: make(chan []byte, 64)}, nil }),
+		WithCommandDefaults(WithTimeout(50 * time.Millisecond)),
+	}, opts...)
+	rc, err := New("127.0.0.1", "28960", "secret", opts...)
+	if err != nil {
+		panic(err)
+	}
+	rc.Timeout = 50 * time.Millisecond
+	return rc
+}
+
+func (s *fakeServer) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+func (s *fakeServer) dvar(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dvars[strings.ToLower(name)]
+}
+
+func (s *fakeServer) setDvar(name, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dvars[strings.ToLower(name)] = value
+}
+
+// commands returns the rcon command lines received while up
+func (s *fakeServer) commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.sent...)
+}
+
+// reply builds the datagram answering pkt, or nil
+func (s *fakeServer) reply(pkt []byte) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		return nil
+	}
+	line := strings.TrimSpace(strings.TrimPrefix(string(pkt), "\xFF\xFF\xFF\xFF"))
+	word, rest, _ := strings.Cut(line, " ")
+	switch strings.ToLower(word) {
+	case "getinfo":
+		s.infos++
+		if s.noInfo {
+			return nil
+		}
+		info := s.info
+		if info == nil {
+			info = map[string]string{"hostname": "fake", "mapname": "mp_raid", "clients": "0", "sv_maxclients": "18"}
+		}
+		var b strings.Builder
+		for k, v := range info {
+			b.WriteString(`\` + k + `\` + v)
+		}
+		return []byte("\xFF\xFF\xFF\xFFinfoResponse\n" + b.String() + "\n")
+	case "rcon":
+	default:
+		return nil
+	}
+	_, cmdline, _ := strings.Cut(rest, " ")
+	s.sent = append(s.sent, cmdline)
+	name, args, _ := strings.Cut(cmdline, " ")
+	var out string
+	switch {
+	case strings.EqualFold(name, "set"):
+		dvar, val, _ := strings.Cut(args, " ")
+		s.dvars[strings.ToLower(dvar)] = strings.Trim(val, `"`)
+	case args == "" && s.dvars[strings.ToLower(name)] != "":
+		out = fmt.Sprintf("%q is:\"%s^7\" default:\"^7\"\n", name, s.dvars[strings.ToLower(name)])
+	case s.handle != nil:
+		s.mu.Unlock()
+		out = s.handle(cmdline)
+		s.mu.Lock()
+	}
+	if out == "" {
+		return nil
+	}
+	return []byte("\xFF\xFF\xFF\xFFprint\n" + out)
+}
+
+// fakeConn is one client connection to a fakeServer
+type fakeConn struct {
+	srv     *fakeServer
+	replies chan []byte
+
+	mu       sync.Mutex
+	deadline time.Time
+}
+
+func (c *fakeConn) Write(b []byte) (int, error) {
+	if r := c.srv.reply(b); r != nil {
+		c.replies <- r
+	}
+	return len(b), nil
+}
+
+func (c *fakeConn) Read(b []byte) (int, error) {
+	c.mu.Lock()
+	deadline := c.deadline
+	c.mu.Unlock()
+	t := time.NewTimer(time.Until(deadline))
+	defer t.Stop()
+	select {
+	case r := <-c.replies:
+		return copy(b, r), nil
+	case <-t.C:
+		return 0, os.ErrDeadlineExceeded
+	}
+}
+
+func (c *fakeConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deadline = t
+	return nil
+}
+
+func (c *fakeConn) Close() error { return nil }
@@ -0,0 +1,233 @@
+package report
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+	"github.com/Yallamaztar/PlutoRCON/v2/session"
+	"github.com/Yallamaztar/PlutoRCON/v2/timeline"
+)
+
+// aggregateBucket is how much time one Aggregator bucket covers
+const aggregateBucket = time.Hour
+
+// Aggregator sums up status polls and admin actions per server and hour as they
+// arrive, so reports cover their whole period without keeping every entry: a week of
+// 5s polls is about 120k status entries per server, far more than a Recorder holds.
+// Reports start and end on whole hours of the buckets they cover
+type Aggregator struct {
+	// Retention drops buckets older than this (default 8 days, enough for weekly reports)
+	Retention time.Duration
+	// MaxGap is the longest time between two polls that counts as up (default 2m)
+	MaxGap time.Duration
+
+	mu      sync.Mutex
+	servers map[string]*serverAggregate
+}
+
+type serverAggregate struct {
+	buckets  map[int64]*bucket
+	lastPoll time.Time
+	lastMap  string
+}
+
+// bucket holds the figures of one server for one hour
+type bucket struct {
+	polls     int
+	up        time.Duration
+	players   map[string]bool
+	peak      int
+	peakAt    time.Time
+	maps      map[string]*MapStat
+	actions   int
+	operators map[string]int
+}
+
+// Add records a timeline entry; entries other than status polls and admin actions
+// are ignored
+func (a *Aggregator) Add(e timeline.Entry) {
+	if e.Kind != timeline.KindStatus && e.Kind != timeline.KindAction || e.Kind == timeline.KindStatus && e.Status == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s := a.serverLocked(e.Server)
+	b := s.bucketAt(e.At)
+	if e.Kind == timeline.KindAction {
+		name := e.Operator
+		if name == "" {
+			name = "unknown"
+		}
+		b.actions++
+		b.operators[name]++
+		return
+	}
+
+	st := e.Status
+	b.polls++
+	for _, p := range st.Players {
+		if p.GUID != "" {
+			b.players[strings.ToLower(p.GUID)] = true
+		}
+	}
+	if n := len(st.Players); n > b.peak {
+		b.peak, b.peakAt = n, e.At
+	}
+	ms := b.maps[st.Map]
+	if ms == nil {
+		ms = &MapStat{Map: st.Map}
+		b.maps[st.Map] = ms
+	}
+	if st.Map != s.lastMap {
+		ms.Plays++
+	}
+	// a poll older than the last one can't be placed between two others, so it only counts as seen
+	if e.At.After(s.lastPoll) {
+		if !s.lastPoll.IsZero() {
+			if gap := e.At.Sub(s.lastPoll); gap <= a.maxGap() {
+				b.up += gap
+				prev := b.maps[s.lastMap]
+				if prev == nil {
+					prev = &MapStat{Map: s.lastMap}
+					b.maps[s.lastMap] = prev
+				}
+				prev.Time += gap
+			}
+		}
+		s.lastPoll, s.lastMap = e.At, st.Map
+	}
+	a.pruneLocked(s, e.At)
+}
+
+// AddStatus records a status poll
+func (a *Aggregator) AddStatus(server string, st *rcon.ServerStatus) {
+	a.Add(timeline.StatusEntry(server, st))
+}
+
+// AddAction records an admin command
+func (a *Aggregator) AddAction(server, operator string, e session.Entry) {
+	a.Add(timeline.ActionEntry(server, operator, e))
+}
+
+// Attach records every successful poll of a Monitor, keeping an OnStatus that is already set
+func (a *Aggregator) Attach(server string, m *rcon.Monitor) {
+	prev := m.OnStatus
+	m.OnStatus = func(st *rcon.ServerStatus) {
+		if prev != nil {
+			prev(st)
+		}
+		a.AddStatus(server, st)
+	}
+}
+
+// Middleware records the commands a client sends as admin actions, like
+// timeline.Recorder.Middleware
+func (a *Aggregator) Middleware(server, operator string) rcon.Middleware {
+	return timeline.ActionMiddleware(server, operator, a.Add)
+}
+
+// Servers returns the servers with recorded figures
+func (a *Aggregator) Servers() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := make([]string, 0, len(a.servers))
+	for name := range a.servers {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Report summarizes server over the hours from from up to to
+func (a *Aggregator) Report(server string, from, to time.Time) *Report {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	sum := newBucket()
+	if s := a.servers[server]; s != nil {
+		first, last := bucketKey(from), bucketKey(to.Add(-time.Nanosecond))
+		for key, b := range s.buckets {
+			if key < first || key > last {
+				continue
+			}
+			sum.polls += b.polls
+			sum.up += b.up
+			sum.actions += b.actions
+			for guid := range b.players {
+				sum.players[guid] = true
+			}
+			if b.peak > sum.peak || b.peak == sum.peak && b.peak > 0 && b.peakAt.Before(sum.peakAt) {
+				sum.peak, sum.peakAt = b.peak, b.peakAt
+			}
+			for name, ms := range b.maps {
+				m := sum.maps[name]
+				if m == nil {
+					m = &MapStat{Map: name}
+					sum.maps[name] = m
+				}
+				m.Time += ms.Time
+				m.Plays += ms.Plays
+			}
+			for name, n := range b.operators {
+				sum.operators[name] += n
+			}
+		}
+	}
+	r := &Report{Server: server, From: from, To: to, Polls: sum.polls, PeakPlayers: sum.peak, PeakAt: sum.peakAt, Actions: sum.actions}
+	finish(r, sum.players, sum.maps, sum.operators, sum.up)
+	return r
+}
+
+func (a *Aggregator) maxGap() time.Duration {
+	if a.MaxGap <= 0 {
+		return 2 * time.Minute
+	}
+	return a.MaxGap
+}
+
+func (a *Aggregator) serverLocked(name string) *serverAggregate {
+	if a.servers == nil {
+		a.servers = map[string]*serverAggregate{}
+	}
+	s := a.servers[name]
+	if s == nil {
+		s = &serverAggregate{buckets: map[int64]*bucket{}}
+		a.servers[name] = s
+	}
+	return s
+}
+
+// pruneLocked drops the buckets of s past the retention
+func (a *Aggregator) pruneLocked(s *serverAggregate, now time.Time) {
+	retention := a.Retention
+	if retention <= 0 {
+		retention = 8 * 24 * time.Hour
+	}
+	cut := bucketKey(now.Add(-retention))
+	for key := range s.buckets {
+		if key < cut {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func (s *serverAggregate) bucketAt(t time.Time) *bucket {
+	key := bucketKey(t)
+	b := s.buckets[key]
+	if b == nil {
+		b = newBucket()
+		s.buckets[key] = b
+	}
+	return b
+}
+
+func newBucket() *bucket {
+	return &bucket{players: map[string]bool{}, maps: map[string]*MapStat{}, operators: map[string]int{}}
+}
+
+// bucketKey numbers the hour t falls in
+func bucketKey(t time.Time) int64 {
+	return t.Unix() / int64(aggregateBucket/time.Second)
+}
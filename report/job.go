@@ -0,0 +1,159 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+	"github.com/Yallamaztar/PlutoRCON/v2/timeline"
+)
+
+// Period is how much time one report covers
+type Period int
+
+const (
+	Daily Period = iota
+	Weekly
+)
+
+func (p Period) String() string {
+	if p == Weekly {
+		return "weekly"
+	}
+	return "daily"
+}
+
+// Job builds reports on a schedule and hands them to its sinks. With an Aggregator the
+// figures come from it; otherwise they are built from Sources, which must then keep
+// every entry of the period. A timeline.Recorder doesn't for weekly reports, as its
+// entry limit fills up within days of 5s polls
+type Job struct {
+	Aggregator *Aggregator
+	Sources    timeline.Sources
+	// Servers limits the report to these servers (nil = every server with entries)
+	Servers []string
+	Every   Period
+	// At is the time of day reports are due, e.g. 6*time.Hour for 06:00; a report
+	// covers the period ending then
+	At time.Duration
+	// Weekday is the day weekly reports are due (default Sunday)
+	Weekday time.Weekday
+	// Location is the zone of At and of the times shown (default time.Local)
+	Location *time.Location
+	// Title defaults to "Daily report" or "Weekly report", in the i18n language
+	Title string
+	// MaxGap is passed to Build; an Aggregator has its own
+	MaxGap  time.Duration
+	Sinks   []Sink
+	OnError func(error)
+}
+
+func (j *Job) location() *time.Location {
+	if j.Location == nil {
+		return time.Local
+	}
+	return j.Location
+}
+
+// Next returns when the first report after t is due
+func (j *Job) Next(t time.Time) time.Time {
+	t = t.In(j.location())
+	// Built from the wall clock so a DST change doesn't shift the hour
+	h, m, sec := int(j.At/time.Hour), int(j.At%time.Hour/time.Minute), int(j.At%time.Minute/time.Second)
+	for i := 0; ; i++ {
+		due := time.Date(t.Year(), t.Month(), t.Day()+i, h, m, sec, 0, t.Location())
+		if !due.After(t) || j.Every == Weekly && due.Weekday() != j.Weekday {
+			continue
+		}
+		return due
+	}
+}
+
+// span returns the period covered by the report due at due
+func (j *Job) span(due time.Time) (time.Time, time.Time) {
+	if j.Every == Weekly {
+		return due.AddDate(0, 0, -7), due
+	}
+	return due.AddDate(0, 0, -1), due
+}
+
+// Run delivers reports until ctx is done
+func (j *Job) Run(ctx context.Context) {
+	for {
+		due := j.Next(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(due)):
+		}
+		from, to := j.span(due)
+		if err := j.Deliver(ctx, from, to); err != nil && j.OnError != nil {
+			j.OnError(err)
+		}
+	}
+}
+
+// Generate builds and renders the reports of a range without delivering them
+func (j *Job) Generate(ctx context.Context, from, to time.Time) (*Document, error) {
+	reports, err := j.build(ctx, from, to)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	title := j.Title
+	if title == "" {
+		title = i18n.T("Daily report")
+		if j.Every == Weekly {
+			title = i18n.T("Weekly report")
+		}
+	}
+	d, rerr := Render(title, from, to, reports, j.location())
+	return d, errors.Join(err, rerr)
+}
+
+// build summarizes every server of the job from the aggregator or the sources
+func (j *Job) build(ctx context.Context, from, to time.Time) ([]*Report, error) {
+	var all map[string][]timeline.Entry
+	var err error
+	servers := j.Servers
+	if j.Aggregator != nil {
+		if servers == nil {
+			servers = j.Aggregator.Servers()
+		}
+	} else {
+		all, err = j.Sources.Timeline(ctx, from, to)
+		if servers == nil {
+			for name := range all {
+				servers = append(servers, name)
+			}
+			sort.Strings(servers)
+		}
+	}
+	reports := make([]*Report, 0, len(servers))
+	for _, s := range servers {
+		if j.Aggregator != nil {
+			reports = append(reports, j.Aggregator.Report(s, from, to))
+			continue
+		}
+		reports = append(reports, Build(s, from, to, all[s], j.MaxGap))
+	}
+	return reports, err
+}
+
+// Deliver generates the reports of a range and sends them to every sink, returning
+// the joined errors of the sources and sinks that failed
+func (j *Job) Deliver(ctx context.Context, from, to time.Time) error {
+	d, err := j.Generate(ctx, from, to)
+	if d == nil {
+		return err
+	}
+	errs := []error{err}
+	for _, s := range j.Sinks {
+		if serr := s.Deliver(ctx, d); serr != nil {
+			errs = append(errs, fmt.Errorf("%T: %w", s, serr))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,111 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/i18n"
+)
+
+// Document is a rendered set of reports, one per server
+type Document struct {
+	Title    string    `json:"title"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Reports  []*Report `json:"reports"`
+	Markdown string    `json:"markdown"`
+	HTML     string    `json:"html"`
+}
+
+// Render builds the Markdown and HTML of reports; times are shown in loc (default time.Local)
+// and labels in the i18n language
+func Render(title string, from, to time.Time, reports []*Report, loc *time.Location) (*Document, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	d := &Document{Title: title, From: from, To: to, Reports: reports, Markdown: markdown(title, from, to, reports, loc)}
+	var b bytes.Buffer
+	err := htmlTemplate.Execute(&b, map[string]any{"Title": title, "Range": period(from, to, loc), "Reports": reports, "Loc": loc})
+	d.HTML = b.String()
+	return d, err
+}
+
+// markdown sticks to headings and lists, which Discord renders too
+func markdown(title string, from, to time.Time, reports []*Report, loc *time.Location) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n%s\n", title, period(from, to, loc))
+	for _, r := range reports {
+		fmt.Fprintf(&b, "\n## %s\n", r.Server)
+		fmt.Fprintf(&b, "- %s\n", i18n.Sprintf("Uptime: %.1f%% (down %s)", r.Uptime*100, r.Downtime.Round(time.Minute)))
+		fmt.Fprintf(&b, "- %s\n", i18n.Sprintf("Unique players: %d", r.UniquePlayers))
+		if r.PeakPlayers > 0 {
+			fmt.Fprintf(&b, "- %s\n", i18n.Sprintf("Peak: %d players at %s", r.PeakPlayers, r.PeakAt.In(loc).Format("Mon 15:04")))
+		} else {
+			fmt.Fprintf(&b, "- %s\n", i18n.T("Peak: 0 players"))
+		}
+		if len(r.TopMaps) > 0 {
+			maps := make([]string, len(r.TopMaps))
+			for i, m := range r.TopMaps {
+				maps[i] = fmt.Sprintf("%s (%s, %dx)", m.Map, m.Time.Round(time.Minute), m.Plays)
+			}
+			fmt.Fprintf(&b, "- %s\n", i18n.Sprintf("Top maps: %s", strings.Join(maps, ", ")))
+		}
+		fmt.Fprintf(&b, "- %s", i18n.Sprintf("Admin actions: %d", r.Actions))
+		if len(r.Operators) > 0 {
+			ops := make([]string, len(r.Operators))
+			for i, o := range r.Operators {
+				ops[i] = fmt.Sprintf("%s %d", o.Name, o.Count)
+			}
+			fmt.Fprintf(&b, " (%s)", strings.Join(ops, ", "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func period(from, to time.Time, loc *time.Location) string {
+	return from.In(loc).Format("Mon 2 Jan 2006 15:04") + " – " + to.In(loc).Format("Mon 2 Jan 2006 15:04 MST")
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(f float64) string { return fmt.Sprintf("%.1f%%", f*100) },
+	"minutes": func(d time.Duration) string { return d.Round(time.Minute).String() },
+	"clock":   func(t time.Time, loc *time.Location) string { return t.In(loc).Format("Mon 15:04") },
+	"t":       i18n.T,
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Title}}</title>
+<style>body{font-family:sans-serif;max-width:46em;margin:2em auto}table{border-collapse:collapse}td,th{padding:.2em .8em;text-align:left;border-bottom:1px solid #ddd}</style>
+</head><body>
+<h1>{{.Title}}</h1>
+<p>{{.Range}}</p>
+{{- $loc := .Loc}}
+{{- range .Reports}}
+<h2>{{.Server}}</h2>
+<table>
+<tr><th>{{t "Uptime"}}</th><td>{{percent .Uptime}} ({{t "down"}} {{minutes .Downtime}})</td></tr>
+<tr><th>{{t "Unique players"}}</th><td>{{.UniquePlayers}}</td></tr>
+<tr><th>{{t "Peak"}}</th><td>{{.PeakPlayers}} {{t "players"}}{{if .PeakPlayers}} {{t "at"}} {{clock .PeakAt $loc}}{{end}}</td></tr>
+<tr><th>{{t "Admin actions"}}</th><td>{{.Actions}}</td></tr>
+</table>
+{{- if .TopMaps}}
+<h3>{{t "Top maps"}}</h3>
+<table><tr><th>{{t "Map"}}</th><th>{{t "Time"}}</th><th>{{t "Plays"}}</th></tr>
+{{- range .TopMaps}}
+<tr><td>{{.Map}}</td><td>{{minutes .Time}}</td><td>{{.Plays}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Operators}}
+<h3>{{t "Admin actions by operator"}}</h3>
+<table><tr><th>{{t "Operator"}}</th><th>{{t "Actions"}}</th></tr>
+{{- range .Operators}}
+<tr><td>{{.Name}}</td><td>{{.Count}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+</body></html>
+`))
@@ -0,0 +1,133 @@
+// Package report summarizes the timelines of servers into daily or weekly reports and
+// delivers them as Markdown or HTML
+package report
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/timeline"
+)
+
+// Report summarizes one server over a period
+type Report struct {
+	Server        string        `json:"server"`
+	From          time.Time     `json:"from"`
+	To            time.Time     `json:"to"`
+	UniquePlayers int           `json:"unique_players"`
+	PeakPlayers   int           `json:"peak_players"`
+	PeakAt        time.Time     `json:"peak_at"`
+	TopMaps       []MapStat     `json:"top_maps"`
+	Actions       int           `json:"actions"`
+	Operators     []Count       `json:"operators"`
+	Uptime        float64       `json:"uptime"`
+	Downtime      time.Duration `json:"downtime"`
+	Polls         int           `json:"polls"`
+}
+
+// MapStat is how long a map was played and how often it came up
+type MapStat struct {
+	Map   string        `json:"map"`
+	Time  time.Duration `json:"time"`
+	Plays int           `json:"plays"`
+}
+
+// Count is a name with a number, e.g. the admin actions of one operator
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// topMaps bounds Report.TopMaps
+const topMaps = 5
+
+// Build summarizes the timeline entries of server between from and to. Time between
+// two status polls counts as up when they are at most maxGap apart (default 2m)
+func Build(server string, from, to time.Time, entries []timeline.Entry, maxGap time.Duration) *Report {
+	if maxGap <= 0 {
+		maxGap = 2 * time.Minute
+	}
+	r := &Report{Server: server, From: from, To: to}
+	players := map[string]bool{}
+	maps := map[string]*MapStat{}
+	operators := map[string]int{}
+	var up time.Duration
+	var prev *timeline.Entry
+	lastMap := ""
+
+	for i := range entries {
+		e := &entries[i]
+		switch e.Kind {
+		case timeline.KindAction:
+			r.Actions++
+			name := e.Operator
+			if name == "" {
+				name = "unknown"
+			}
+			operators[name]++
+		case timeline.KindStatus:
+			st := e.Status
+			r.Polls++
+			for _, p := range st.Players {
+				if p.GUID != "" {
+					players[strings.ToLower(p.GUID)] = true
+				}
+			}
+			if n := len(st.Players); n > r.PeakPlayers {
+				r.PeakPlayers, r.PeakAt = n, e.At
+			}
+			ms := maps[st.Map]
+			if ms == nil {
+				ms = &MapStat{Map: st.Map}
+				maps[st.Map] = ms
+			}
+			if st.Map != lastMap {
+				ms.Plays++
+				lastMap = st.Map
+			}
+			if prev != nil {
+				if gap := e.At.Sub(prev.At); gap <= maxGap {
+					up += gap
+					maps[prev.Status.Map].Time += gap
+				}
+			}
+			prev = e
+		}
+	}
+
+	finish(r, players, maps, operators, up)
+	return r
+}
+
+// finish fills in the figures of r that come from its totals
+func finish(r *Report, players map[string]bool, maps map[string]*MapStat, operators map[string]int, up time.Duration) {
+	r.UniquePlayers = len(players)
+	if span := r.To.Sub(r.From); span > 0 {
+		r.Uptime = min(float64(up)/float64(span), 1)
+		r.Downtime = max(span-up, 0)
+	}
+	for _, ms := range maps {
+		if ms.Map != "" {
+			r.TopMaps = append(r.TopMaps, *ms)
+		}
+	}
+	sort.Slice(r.TopMaps, func(i, j int) bool {
+		if r.TopMaps[i].Time != r.TopMaps[j].Time {
+			return r.TopMaps[i].Time > r.TopMaps[j].Time
+		}
+		return r.TopMaps[i].Map < r.TopMaps[j].Map
+	})
+	if len(r.TopMaps) > topMaps {
+		r.TopMaps = r.TopMaps[:topMaps]
+	}
+	for name, n := range operators {
+		r.Operators = append(r.Operators, Count{Name: name, Count: n})
+	}
+	sort.Slice(r.Operators, func(i, j int) bool {
+		if r.Operators[i].Count != r.Operators[j].Count {
+			return r.Operators[i].Count > r.Operators[j].Count
+		}
+		return r.Operators[i].Name < r.Operators[j].Name
+	})
+}
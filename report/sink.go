@@ -0,0 +1,121 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/webhook"
+	"github.com/Yallamaztar/PlutoRCON/v2/notify"
+)
+
+// Sink delivers rendered reports
+type Sink interface {
+	Deliver(ctx context.Context, d *Document) error
+}
+
+// FileSink writes each document as <date>.md and <date>.html into Dir
+type FileSink struct {
+	Dir string
+	// NoHTML skips the HTML file
+	NoHTML bool
+}
+
+// Deliver implements Sink
+func (f *FileSink) Deliver(_ context.Context, d *Document) error {
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return err
+	}
+	base := filepath.Join(f.Dir, d.From.Format("2006-01-02")+"_"+fileName(d.Title))
+	if err := os.WriteFile(base+".md", []byte(d.Markdown), 0o644); err != nil {
+		return err
+	}
+	if f.NoHTML {
+		return nil
+	}
+	return os.WriteFile(base+".html", []byte(d.HTML), 0o644)
+}
+
+// WebhookSink POSTs the document as JSON (title, range, reports, markdown and html), e.g.
+// to a mail relay that turns it into an email
+type WebhookSink struct {
+	URL     string
+	Headers map[string]string
+	Client  *http.Client
+}
+
+// Deliver implements Sink
+func (w *WebhookSink) Deliver(ctx context.Context, d *Document) error {
+	body, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return webhook.PostJSON(ctx, w.Client, w.URL, w.Headers, body)
+}
+
+// discordLimit is Discord's limit on the content of one message
+const discordLimit = 2000
+
+// DiscordSink posts the Markdown, one message per server, splitting a section that is
+// still longer than Discord's 2000 characters at line breaks
+type DiscordSink struct {
+	Webhook *notify.Webhook
+}
+
+// Deliver implements Sink
+func (s *DiscordSink) Deliver(ctx context.Context, d *Document) error {
+	if s.Webhook == nil {
+		return errors.New("discord sink has no webhook")
+	}
+	// Sections start with "## "; the first carries the title
+	parts := strings.Split(d.Markdown, "\n## ")
+	for i, p := range parts {
+		if i > 0 {
+			p = "## " + p
+		}
+		for _, msg := range splitMessage(strings.TrimSpace(p), discordLimit) {
+			if err := s.Webhook.Send(ctx, msg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// splitMessage cuts text into messages of at most limit runes, at line breaks where
+// it can and inside a line that alone is too long
+func splitMessage(text string, limit int) []string {
+	var out []string
+	var cur []rune
+	for _, line := range strings.SplitAfter(text, "\n") {
+		r := []rune(line)
+		if len(cur)+len(r) > limit && len(cur) > 0 {
+			out = append(out, strings.TrimSpace(string(cur)))
+			cur = nil
+		}
+		for len(r) > limit {
+			out = append(out, string(r[:limit]))
+			r = r[limit:]
+		}
+		cur = append(cur, r...)
+	}
+	if s := strings.TrimSpace(string(cur)); s != "" {
+		out = append(out, s)
+	}
+	return out
+}
+
+// fileName makes a title usable inside a file name
+func fileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,241 @@
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Purged replaces what RedactEntry removes
+const Purged = "[purged]"
+
+// Subject is the player a purge removes: their GUID and the names they played under.
+// Names shorter than three characters are left alone, since they would match too much
+type Subject struct {
+	GUID  string
+	Names []string
+}
+
+// slotCommands take a client slot as their first argument
+var slotCommands = map[string]bool{
+	"clientkick": true, "clientkick_for_reason": true, "tell": true,
+	"banclient": true, "tempbanclient": true,
+}
+
+// Terms returns what RedactEntry looks for: the GUID and the names long enough
+func (s Subject) Terms() []string {
+	var terms []string
+	if guid := strings.TrimSpace(s.GUID); guid != "" {
+		terms = append(terms, guid)
+	}
+	for _, name := range s.Names {
+		if name = strings.TrimSpace(name); utf8.RuneCountInString(name) >= 3 {
+			terms = append(terms, name)
+		}
+	}
+	return terms
+}
+
+// matcher matches the terms of s case-insensitively, or is nil when there are none
+func (s Subject) matcher() *regexp.Regexp {
+	terms := s.Terms()
+	if len(terms) == 0 {
+		return nil
+	}
+	for i, t := range terms {
+		terms[i] = regexp.QuoteMeta(t)
+	}
+	return regexp.MustCompile(`(?i)` + strings.Join(terms, "|"))
+}
+
+// RedactEntry replaces the GUID and names of s in the arguments, and every response
+// line mentioning them, with Purged. It reports whether anything changed. Commands
+// aimed at the player's slot need the rest of the transcript; see RedactTranscript
+func RedactEntry(e *Entry, s Subject) bool {
+	rx := s.matcher()
+	if e == nil || rx == nil {
+		return false
+	}
+	return redactEntry(e, rx)
+}
+
+func redactEntry(e *Entry, rx *regexp.Regexp) bool {
+	changed := false
+	if rx.MatchString(e.Args) {
+		e.Args, changed = rx.ReplaceAllLiteralString(e.Args, Purged), true
+	}
+	for i, line := range e.Response {
+		if rx.MatchString(line) {
+			e.Response[i], changed = Purged, true
+		}
+	}
+	return changed
+}
+
+// RedactTranscript redacts s from the entries of one session, given in order, and
+// returns the indices of the entries it changed. Besides what RedactEntry covers, the
+// arguments of kicks, tells and bans aimed at a slot are replaced while the latest
+// status reply before them shows the player in that slot
+func RedactTranscript(entries []Entry, s Subject) []int {
+	rx := s.matcher()
+	guid := strings.ToLower(strings.TrimSpace(s.GUID))
+	if rx == nil {
+		return nil
+	}
+	var changed []int
+	slots := map[string]bool{}
+	for i := range entries {
+		e := &entries[i]
+		cmd := strings.ToLower(e.Command)
+		if cmd == "status" && guid != "" {
+			slots = playerSlots(e.Response, guid)
+		}
+		hit := redactEntry(e, rx)
+		slot, _, _ := strings.Cut(strings.TrimSpace(e.Args), " ")
+		if slotCommands[cmd] && slots[slot] {
+			e.Args, hit = Purged, true
+		}
+		if hit {
+			changed = append(changed, i)
+		}
+	}
+	return changed
+}
+
+// playerSlots returns the slots of the status rows that carry guid
+func playerSlots(lines []string, guid string) map[string]bool {
+	slots := map[string]bool{}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.Trim(fields[0], "0123456789") != "" {
+			continue
+		}
+		for _, f := range fields[1:] {
+			if strings.ToLower(f) == guid {
+				slots[fields[0]] = true
+			}
+		}
+	}
+	return slots
+}
+
+// errBackendPurge is returned by the purge methods when a Backend keeps the transcripts
+var errBackendPurge = errors.New("session backend keeps the transcripts; purge through the backend")
+
+// Purge redacts a player from every transcript file with RedactTranscript. Sessions
+// this Store opened keep recording to their redacted files; sessions another process
+// opened on the same directory would not, so purge through the Store that records them
+func (st *Store) Purge(s Subject) error {
+	if st.Backend != nil {
+		return errBackendPurge
+	}
+	files, err := filepath.Glob(filepath.Join(st.Dir, "*.jsonl"))
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		id := strings.TrimSuffix(filepath.Base(path), ".jsonl")
+		open := st.openSession(id)
+		if open != nil {
+			open.mu.Lock()
+		}
+		err := redactFile(path, s, open)
+		if open != nil {
+			open.mu.Unlock()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// redactFile redacts one transcript file; open is the session still writing it, locked
+// by the caller, which is pointed at the new file
+func redactFile(path string, s Subject, open *Session) error {
+	if open != nil && open.w != nil {
+		if err := open.w.Flush(); err != nil {
+			return err
+		}
+	}
+	t, err := readTranscript(path, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if len(RedactTranscript(t.Entries, s)) == 0 {
+		return nil
+	}
+	if err := writeTranscript(path, t); err != nil {
+		return err
+	}
+	if open == nil || open.f == nil {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	open.f.Close()
+	open.f, open.w = f, bufio.NewWriter(f)
+	return nil
+}
+
+// PurgeOlderThan deletes the transcripts whose last command is older than d and
+// returns how many
+func (st *Store) PurgeOlderThan(d time.Duration) (int, error) {
+	if st.Backend != nil {
+		return 0, errBackendPurge
+	}
+	cutoff := time.Now().Add(-d)
+	files, err := filepath.Glob(filepath.Join(st.Dir, "*.jsonl"))
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, path := range files {
+		t, err := readTranscript(path, filepath.Base(path))
+		if err != nil {
+			return n, err
+		}
+		last := t.Meta.Started
+		if len(t.Entries) > 0 {
+			last = t.Entries[len(t.Entries)-1].At
+		}
+		if !last.Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// writeTranscript replaces a transcript file through a temporary file, so a crash
+// never leaves half of it
+func writeTranscript(path string, t *Transcript) error {
+	var b []byte
+	for i := -1; i < len(t.Entries); i++ {
+		var v any = t.Meta
+		if i >= 0 {
+			v = t.Entries[i]
+		}
+		line, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		b = append(append(b, line...), '\n')
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
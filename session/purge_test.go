@@ -0,0 +1,68 @@
+package session
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRedactTranscript(t *testing.T) {
+	s := Subject{GUID: "ABCDEF01", Names: []string{"Sniper", "xy"}}
+	entries := []Entry{
+		{Command: "status", Response: []string{
+			"num score ping guid name",
+			"  3    10   50 abcdef01 Sniper^7",
+			"  4     0   60 12345678 xy",
+		}},
+		{Command: "tell", Args: "3 stop camping"},
+		{Command: "clientkick", Args: "4"},
+		{Command: "say", Args: "bye sniper"},
+		{Command: "status", Response: []string{"  3     0   50 12345678 Other"}},
+		{Command: "clientkick", Args: "3"},
+	}
+	changed := RedactTranscript(entries, s)
+	if want := []int{0, 1, 3}; !slices.Equal(changed, want) {
+		t.Fatalf("changed %v, want %v", changed, want)
+	}
+	if entries[0].Response[1] != Purged || entries[0].Response[2] == Purged {
+		t.Fatalf("status reply %q", entries[0].Response)
+	}
+	if entries[1].Args != Purged {
+		t.Fatalf("tell to the player's slot kept %q", entries[1].Args)
+	}
+	if entries[2].Args != "4" || entries[5].Args != "3" {
+		t.Fatalf("kicks of other players were redacted: %q, %q", entries[2].Args, entries[5].Args)
+	}
+	if entries[3].Args != "bye "+Purged {
+		t.Fatalf("say kept the name: %q", entries[3].Args)
+	}
+}
+
+func TestPurgeOpenSession(t *testing.T) {
+	st := &Store{Dir: t.TempDir()}
+	sess, err := st.Open("admin", "cli", "srv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sess.Close()
+	if err := sess.Record(Entry{Command: "say", Args: "hello sniper"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Purge(Subject{GUID: "abcdef01", Names: []string{"Sniper"}}); err != nil {
+		t.Fatal(err)
+	}
+	// the session keeps appending to the redacted file
+	if err := sess.Record(Entry{Command: "status"}); err != nil {
+		t.Fatal(err)
+	}
+	tr, err := st.Load(sess.Meta.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tr.Entries) != 2 || tr.Entries[1].Command != "status" {
+		t.Fatalf("entries %+v", tr.Entries)
+	}
+	if strings.Contains(strings.ToLower(tr.Entries[0].Args), "sniper") {
+		t.Fatalf("name kept: %q", tr.Entries[0].Args)
+	}
+}
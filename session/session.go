@@ -0,0 +1,296 @@
+// Package session records command/response transcripts of admin sessions
+package session
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// Meta describes a session; it is the first line of every transcript file
+type Meta struct {
+	ID       string    `json:"id"`
+	Operator string    `json:"operator"`
+	Source   string    `json:"source,omitempty"`
+	Server   string    `json:"server,omitempty"`
+	Started  time.Time `json:"started"`
+}
+
+// Entry is one command and its response
+type Entry struct {
+	At       time.Time     `json:"at"`
+	Command  string        `json:"command"`
+	Args     string        `json:"args,omitempty"`
+	Response []string      `json:"response,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Took     time.Duration `json:"took,omitempty"`
+}
+
+type Transcript struct {
+	Meta    Meta    `json:"meta"`
+	Entries []Entry `json:"entries"`
+}
+
+// Backend keeps transcripts somewhere other than files, e.g. a database
+type Backend interface {
+	Create(m Meta) error
+	Append(id string, e Entry) error
+	// List returns the metadata of every session, newest first
+	List() ([]Meta, error)
+	// Load returns a full transcript; id may be a unique prefix
+	Load(id string) (*Transcript, error)
+}
+
+// Store keeps transcripts as JSON lines files, one per session, in Dir, or in Backend when set
+type Store struct {
+	Dir     string
+	Backend Backend
+
+	mu   sync.Mutex
+	open map[string]*Session
+}
+
+// Session appends entries to one transcript file
+type Session struct {
+	Meta Meta
+
+	mu      sync.Mutex
+	f       *os.File
+	w       *bufio.Writer
+	backend Backend
+	store   *Store
+}
+
+// Open starts a new session for an operator; source says where it came from ("repl", "proxy", ...)
+func (st *Store) Open(operator, source, server string) (*Session, error) {
+	now := time.Now()
+	meta := Meta{ID: newID(now), Operator: operator, Source: source, Server: server, Started: now}
+	if st.Backend != nil {
+		if err := st.Backend.Create(meta); err != nil {
+			return nil, err
+		}
+		return &Session{Meta: meta, backend: st.Backend}, nil
+	}
+	if err := os.MkdirAll(st.Dir, 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(st.path(meta.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	s := &Session{Meta: meta, f: f, w: bufio.NewWriter(f), store: st}
+	if err := s.writeLine(meta); err != nil {
+		f.Close()
+		return nil, err
+	}
+	st.mu.Lock()
+	if st.open == nil {
+		st.open = map[string]*Session{}
+	}
+	st.open[meta.ID] = s
+	st.mu.Unlock()
+	return s, nil
+}
+
+// openSession returns the session with id this Store has open, or nil
+func (st *Store) openSession(id string) *Session {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.open[id]
+}
+
+// Record appends an entry, stamping it if At is zero
+func (s *Session) Record(e Entry) error {
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.backend != nil {
+		return s.backend.Append(s.Meta.ID, e)
+	}
+	return s.writeLine(e)
+}
+
+// Run sends a command through rc at admin priority and records it with its response
+func (s *Session) Run(rc *rcon.RCONClient, cmd string, args *string) ([]string, error) {
+	start := time.Now()
+	res, err := rc.SendCommand(cmd, args, rcon.WithPriority(rcon.PriorityAdmin))
+	e := Entry{At: start, Command: cmd, Response: res, Took: time.Since(start)}
+	if args != nil {
+		e.Args = *args
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	if rerr := s.Record(e); rerr != nil && err == nil {
+		err = rerr
+	}
+	return res, err
+}
+
+// Middleware records every command the client sends, including the ones issued by helpers
+func (s *Session) Middleware() rcon.Middleware {
+	return func(next rcon.CommandFunc) rcon.CommandFunc {
+		return func(cmd rcon.Command) ([]string, error) {
+			start := time.Now()
+			res, err := next(cmd)
+			e := Entry{At: start, Command: cmd.Name, Response: res, Took: time.Since(start)}
+			if cmd.Args != nil {
+				e.Args = *cmd.Args
+			}
+			if err != nil {
+				e.Error = err.Error()
+			}
+			s.Record(e)
+			return res, err
+		}
+	}
+}
+
+// Close flushes and closes the transcript file
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.backend = nil
+	if s.f == nil {
+		return nil
+	}
+	err := errors.Join(s.w.Flush(), s.f.Close())
+	s.f = nil
+	if st := s.store; st != nil {
+		st.mu.Lock()
+		delete(st.open, s.Meta.ID)
+		st.mu.Unlock()
+	}
+	return err
+}
+
+// writeLine writes one JSON line and flushes so transcripts survive crashes
+func (s *Session) writeLine(v any) error {
+	if s.f == nil {
+		return fmt.Errorf("session %s is closed", s.Meta.ID)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	s.w.Write(append(data, '\n'))
+	return s.w.Flush()
+}
+
+// List returns the metadata of every stored session, newest first
+func (st *Store) List() ([]Meta, error) {
+	if st.Backend != nil {
+		return st.Backend.List()
+	}
+	files, err := filepath.Glob(filepath.Join(st.Dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	var out []Meta
+	for _, path := range files {
+		meta, err := readMeta(path)
+		if err != nil {
+			continue
+		}
+		out = append(out, meta)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Started.After(out[j].Started) })
+	return out, nil
+}
+
+// Load reads a full transcript; id may be a unique prefix
+func (st *Store) Load(id string) (*Transcript, error) {
+	if st.Backend != nil {
+		return st.Backend.Load(id)
+	}
+	path, err := st.resolve(id)
+	if err != nil {
+		return nil, err
+	}
+	return readTranscript(path, id)
+}
+
+// readTranscript reads a transcript file; id names it in errors
+func readTranscript(path, id string) (*Transcript, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t := &Transcript{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 0; sc.Scan(); n++ {
+		if n == 0 {
+			if err := json.Unmarshal(sc.Bytes(), &t.Meta); err != nil {
+				return nil, fmt.Errorf("session %s: bad header: %w", id, err)
+			}
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("session %s line %d: %w", id, n+1, err)
+		}
+		t.Entries = append(t.Entries, e)
+	}
+	return t, sc.Err()
+}
+
+// resolve maps a session id or unique id prefix to its file
+func (st *Store) resolve(id string) (string, error) {
+	id = strings.TrimSpace(id)
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return "", fmt.Errorf("invalid session id %q", id)
+	}
+	matches, err := filepath.Glob(filepath.Join(st.Dir, id+"*.jsonl"))
+	if err != nil {
+		return "", err
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("session %q not found", id)
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("session id %q is ambiguous (%d matches)", id, len(matches))
+}
+
+func (st *Store) path(id string) string {
+	return filepath.Join(st.Dir, id+".jsonl")
+}
+
+// readMeta reads the header line of a transcript
+func readMeta(path string) (Meta, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Meta{}, err
+	}
+	defer f.Close()
+	line, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return Meta{}, err
+	}
+	var m Meta
+	return m, json.Unmarshal(line, &m)
+}
+
+// newID returns a sortable session id such as 20261014T101530-3fa2c1
+func newID(t time.Time) string {
+	var b [3]byte
+	rand.Read(b[:])
+	return t.UTC().Format("20060102T150405") + "-" + hex.EncodeToString(b[:])
+}
@@ -0,0 +1,40 @@
+// Package storage is the old name of package store.
+//
+// Deprecated: import github.com/Yallamaztar/PlutoRCON/v2/store instead
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/store"
+)
+
+// SQLite is store.SQLite.
+//
+// Deprecated: use store.SQLite
+type SQLite = store.SQLite
+
+// Postgres is store.Postgres.
+//
+// Deprecated: use store.Postgres
+type Postgres = store.Postgres
+
+// OpenSQLite calls store.OpenSQLite.
+//
+// Deprecated: use store.OpenSQLite
+func OpenSQLite(dsn string) (*SQLite, error) { return store.OpenSQLite(dsn) }
+
+// NewSQLite calls store.NewSQLite.
+//
+// Deprecated: use store.NewSQLite
+func NewSQLite(db *sql.DB) (*SQLite, error) { return store.NewSQLite(db) }
+
+// OpenPostgres calls store.OpenPostgres.
+//
+// Deprecated: use store.OpenPostgres
+func OpenPostgres(dsn string) (*Postgres, error) { return store.OpenPostgres(dsn) }
+
+// NewPostgres calls store.NewPostgres.
+//
+// Deprecated: use store.NewPostgres
+func NewPostgres(db *sql.DB) (*Postgres, error) { return store.NewPostgres(db) }
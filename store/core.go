@@ -0,0 +1,253 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/policy"
+	"github.com/Yallamaztar/PlutoRCON/v2/session"
+	"github.com/Yallamaztar/PlutoRCON/v2/timeline"
+)
+
+// core keeps bans, sessions and the audit trail, which SQLite and Postgres store
+// alike. Queries are written with ? placeholders and rebound to $n for Postgres
+type core struct {
+	db     *sql.DB
+	dollar bool
+}
+
+func (c *core) rebind(query string) string {
+	if !c.dollar {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (c *core) exec(query string, args ...any) (sql.Result, error) {
+	return c.db.Exec(c.rebind(query), args...)
+}
+
+func (c *core) execContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return c.db.ExecContext(ctx, c.rebind(query), args...)
+}
+
+func (c *core) query(query string, args ...any) (*sql.Rows, error) {
+	return c.db.Query(c.rebind(query), args...)
+}
+
+func (c *core) queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return c.db.QueryContext(ctx, c.rebind(query), args...)
+}
+
+func (c *core) queryRow(query string, args ...any) *sql.Row {
+	return c.db.QueryRow(c.rebind(query), args...)
+}
+
+// SaveBan implements policy.BanStore
+func (c *core) SaveBan(b policy.Ban) error {
+	guid := strings.ToLower(strings.TrimSpace(b.GUID))
+	if guid == "" {
+		return errors.New("ban needs a guid")
+	}
+	var expires int64
+	if !b.Expires.IsZero() {
+		expires = b.Expires.UnixNano()
+	}
+	_, err := c.exec(`INSERT INTO bans (guid, ip, name, reason, operator, server, at, expires) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (guid) DO UPDATE SET ip = excluded.ip, name = excluded.name, reason = excluded.reason,
+			operator = excluded.operator, server = excluded.server, at = excluded.at, expires = excluded.expires`,
+		guid, b.IP, b.Name, b.Reason, b.Operator, b.Server, b.At.UnixNano(), expires)
+	return err
+}
+
+// RemoveBan implements policy.BanStore
+func (c *core) RemoveBan(guid string) error {
+	_, err := c.exec(`DELETE FROM bans WHERE guid = ?`, strings.ToLower(strings.TrimSpace(guid)))
+	return err
+}
+
+// Bans implements policy.BanStore
+func (c *core) Bans() ([]policy.Ban, error) {
+	rows, err := c.query(`SELECT guid, ip, name, reason, operator, server, at, expires FROM bans ORDER BY at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []policy.Ban
+	for rows.Next() {
+		b, err := scanBan(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *b)
+	}
+	return out, rows.Err()
+}
+
+// FindBan implements policy.BanStore
+func (c *core) FindBan(guid, ip string, at time.Time) (*policy.Ban, error) {
+	row := c.queryRow(`SELECT guid, ip, name, reason, operator, server, at, expires FROM bans
+		WHERE (guid = ? OR (? <> '' AND ip = ?)) AND (expires = 0 OR expires > ?) ORDER BY at DESC LIMIT 1`,
+		strings.ToLower(guid), ip, ip, at.UnixNano())
+	b, err := scanBan(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return b, err
+}
+
+func scanBan(row interface{ Scan(...any) error }) (*policy.Ban, error) {
+	var b policy.Ban
+	var at, expires int64
+	if err := row.Scan(&b.GUID, &b.IP, &b.Name, &b.Reason, &b.Operator, &b.Server, &at, &expires); err != nil {
+		return nil, err
+	}
+	b.At = time.Unix(0, at)
+	if expires != 0 {
+		b.Expires = time.Unix(0, expires)
+	}
+	return &b, nil
+}
+
+// Create implements session.Backend
+func (c *core) Create(m session.Meta) error {
+	_, err := c.exec(`INSERT INTO sessions (id, operator, source, server, started) VALUES (?, ?, ?, ?, ?)`,
+		m.ID, m.Operator, m.Source, m.Server, m.Started.UnixNano())
+	return err
+}
+
+// Append implements session.Backend
+func (c *core) Append(id string, e session.Entry) error {
+	response, err := encodeResponse(e.Response)
+	if err != nil {
+		return err
+	}
+	_, err = c.exec(`INSERT INTO session_entries (session_id, at, command, args, response, error, took) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		id, e.At.UnixNano(), e.Command, e.Args, response, e.Error, int64(e.Took))
+	return err
+}
+
+// encodeResponse is the JSON form of response lines kept in session_entries, empty
+// for none
+func encodeResponse(lines []string) (string, error) {
+	if len(lines) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(lines)
+	return string(data), err
+}
+
+// List implements session.Backend
+func (c *core) List() ([]session.Meta, error) {
+	return c.metas(`SELECT id, operator, source, server, started FROM sessions ORDER BY started DESC`)
+}
+
+// Load implements session.Backend
+func (c *core) Load(id string) (*session.Transcript, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, fmt.Errorf("invalid session id %q", id)
+	}
+	metas, err := c.metas(`SELECT id, operator, source, server, started FROM sessions WHERE id LIKE ? ESCAPE '\' LIMIT 2`, likePrefix(id))
+	if err != nil {
+		return nil, err
+	}
+	switch len(metas) {
+	case 0:
+		return nil, fmt.Errorf("session %q not found", id)
+	case 2:
+		return nil, fmt.Errorf("session id %q is ambiguous", id)
+	}
+	t := &session.Transcript{Meta: metas[0]}
+	rows, err := c.query(`SELECT at, command, args, response, error, took FROM session_entries WHERE session_id = ? ORDER BY at`, t.Meta.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		t.Entries = append(t.Entries, e)
+	}
+	return t, rows.Err()
+}
+
+func (c *core) metas(query string, args ...any) ([]session.Meta, error) {
+	rows, err := c.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []session.Meta
+	for rows.Next() {
+		var m session.Meta
+		var started int64
+		if err := rows.Scan(&m.ID, &m.Operator, &m.Source, &m.Server, &started); err != nil {
+			return nil, err
+		}
+		m.Started = time.Unix(0, started)
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+func scanEntry(row interface{ Scan(...any) error }, dest ...any) (session.Entry, error) {
+	var e session.Entry
+	var at, took int64
+	var response string
+	if err := row.Scan(append(dest, &at, &e.Command, &e.Args, &response, &e.Error, &took)...); err != nil {
+		return e, err
+	}
+	e.At, e.Took = time.Unix(0, at), time.Duration(took)
+	if response != "" {
+		if err := json.Unmarshal([]byte(response), &e.Response); err != nil {
+			return e, err
+		}
+	}
+	return e, nil
+}
+
+// Entries implements timeline.Source with the admin actions of every session, the
+// audit trail that timeline.SessionSource reads from transcript files
+func (c *core) Entries(ctx context.Context, from, to time.Time) ([]timeline.Entry, error) {
+	rows, err := c.queryContext(ctx, `SELECT s.server, s.operator, e.at, e.command, e.args, e.response, e.error, e.took
+		FROM session_entries e JOIN sessions s ON s.id = e.session_id WHERE e.at >= ? AND e.at <= ? ORDER BY e.at`,
+		from.UnixNano(), to.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []timeline.Entry
+	for rows.Next() {
+		var server, operator string
+		e, err := scanEntry(rows, &server, &operator)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, timeline.ActionEntry(server, operator, e))
+	}
+	return out, rows.Err()
+}
+
+// likePrefix escapes s for a LIKE 's%' match
+func likePrefix(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s) + "%"
+}
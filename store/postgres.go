@@ -0,0 +1,185 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/chatlog"
+	"github.com/Yallamaztar/PlutoRCON/v2/match"
+	"github.com/Yallamaztar/PlutoRCON/v2/tracker"
+)
+
+// Postgres keeps everything in a PostgreSQL database that several daemons can share,
+// e.g. so a policy.BanPolicy on every instance enforces bans issued by any of them.
+// It implements the same interfaces as SQLite
+type Postgres struct {
+	DB *sql.DB
+
+	core
+	players tracker.PostgresStore
+	chat    chatlog.PostgresStore
+	stats   match.SQLSink
+}
+
+// postgresDrivers are the names the common Postgres drivers register as
+// (github.com/jackc/pgx/v5/stdlib, github.com/lib/pq)
+var postgresDrivers = []string{"pgx", "postgres"}
+
+// migration is one step of the schema; applied versions are kept in schema_migrations
+type migration struct {
+	version int
+	name    string
+	stmts   []string
+}
+
+// postgresMigrations must only ever be appended to
+var postgresMigrations = []migration{
+	{1, "players and notes", tracker.PostgresSchema},
+	{2, "chat index", chatlog.PostgresSchema},
+	{3, "bans, match results and sessions", []string{
+		`CREATE TABLE bans (
+			guid TEXT PRIMARY KEY, ip TEXT NOT NULL DEFAULT '', name TEXT NOT NULL DEFAULT '',
+			reason TEXT NOT NULL DEFAULT '', operator TEXT NOT NULL DEFAULT '', server TEXT NOT NULL DEFAULT '',
+			at BIGINT NOT NULL, expires BIGINT NOT NULL DEFAULT 0)`,
+		`CREATE INDEX bans_ip ON bans (ip)`,
+		`CREATE TABLE match_results (
+			id BIGSERIAL PRIMARY KEY, map TEXT NOT NULL, gametype TEXT NOT NULL,
+			started_at TIMESTAMPTZ, ended_at TIMESTAMPTZ, document JSONB NOT NULL)`,
+		`CREATE INDEX match_results_ended ON match_results (ended_at)`,
+		`CREATE TABLE sessions (
+			id TEXT PRIMARY KEY, operator TEXT NOT NULL, source TEXT NOT NULL DEFAULT '',
+			server TEXT NOT NULL DEFAULT '', started BIGINT NOT NULL)`,
+		`CREATE TABLE session_entries (
+			session_id TEXT NOT NULL REFERENCES sessions (id) ON DELETE CASCADE, at BIGINT NOT NULL,
+			command TEXT NOT NULL, args TEXT NOT NULL DEFAULT '', response TEXT NOT NULL DEFAULT '',
+			error TEXT NOT NULL DEFAULT '', took BIGINT NOT NULL DEFAULT 0)`,
+		`CREATE INDEX session_entries_session ON session_entries (session_id, at)`,
+		`CREATE INDEX session_entries_at ON session_entries (at)`,
+	}},
+}
+
+// migrationLock is the advisory lock key held while migrating, so daemons starting
+// at the same time don't apply a migration twice
+const migrationLock = 0x706c75746f72636f // "plutorco"
+
+// OpenPostgres opens the database at dsn with whichever Postgres driver the program
+// imports and applies pending migrations
+func OpenPostgres(dsn string) (*Postgres, error) {
+	registered := sql.Drivers()
+	for _, name := range postgresDrivers {
+		if !slices.Contains(registered, name) {
+			continue
+		}
+		db, err := sql.Open(name, dsn)
+		if err != nil {
+			return nil, err
+		}
+		s, err := NewPostgres(db)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf(`%w for Postgres; import one, e.g. _ "github.com/jackc/pgx/v5/stdlib"`, ErrNoDriver)
+}
+
+// NewPostgres uses an already open database and applies pending migrations
+func NewPostgres(db *sql.DB) (*Postgres, error) {
+	if db == nil {
+		return nil, errors.New("storage has no database")
+	}
+	s := &Postgres{DB: db, core: core{db: db, dollar: true}}
+	s.players.DB, s.chat.DB, s.stats.DB = db, db, db
+	s.stats.Query = "INSERT INTO match_results (map, gametype, started_at, ended_at, document) VALUES ($1, $2, $3, $4, $5)"
+	if err := migrate(context.Background(), db); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// migrate applies the missing migrations in one transaction
+func migrate(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, int64(migrationLock)); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at TIMESTAMPTZ NOT NULL DEFAULT now())`); err != nil {
+		return err
+	}
+	var current int
+	if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+	if last := postgresMigrations[len(postgresMigrations)-1].version; current > last {
+		return fmt.Errorf("database schema version %d is newer than this build (%d)", current, last)
+	}
+	for _, m := range postgresMigrations {
+		if m.version <= current {
+			continue
+		}
+		for _, stmt := range m.stmts {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.version, m.name); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Close closes the database
+func (s *Postgres) Close() error {
+	return s.DB.Close()
+}
+
+// SaveNote implements tracker.Store
+func (s *Postgres) SaveNote(n tracker.Note) error { return s.players.SaveNote(n) }
+
+// Notes implements tracker.Store
+func (s *Postgres) Notes(guid string) ([]tracker.Note, error) { return s.players.Notes(guid) }
+
+// NotesBetween implements tracker.Store
+func (s *Postgres) NotesBetween(from, to time.Time) ([]tracker.Note, error) {
+	return s.players.NotesBetween(from, to)
+}
+
+// Record implements tracker.Store
+func (s *Postgres) Record(server string, at time.Time, seen []tracker.Sighting) error {
+	return s.players.Record(server, at, seen)
+}
+
+// RecordPolls implements tracker.BatchRecorder
+func (s *Postgres) RecordPolls(polls []tracker.Poll) error { return s.players.RecordPolls(polls) }
+
+// Lookup implements tracker.Store
+func (s *Postgres) Lookup(guid string) (*tracker.Player, error) { return s.players.Lookup(guid) }
+
+// ByNameEver implements tracker.Store
+func (s *Postgres) ByNameEver(name string) ([]tracker.Player, error) {
+	return s.players.ByNameEver(name)
+}
+
+// Add implements chatlog.Store
+func (s *Postgres) Add(ctx context.Context, msgs ...chatlog.Message) error {
+	return s.chat.Add(ctx, msgs...)
+}
+
+// Search implements chatlog.Store
+func (s *Postgres) Search(ctx context.Context, q chatlog.Query) ([]chatlog.Message, error) {
+	return s.chat.Search(ctx, q)
+}
+
+// Write implements match.Sink
+func (s *Postgres) Write(ctx context.Context, r *match.Result) error { return s.stats.Write(ctx, r) }
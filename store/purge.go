@@ -0,0 +1,310 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/chatlog"
+	"github.com/Yallamaztar/PlutoRCON/v2/match"
+	"github.com/Yallamaztar/PlutoRCON/v2/session"
+	"github.com/Yallamaztar/PlutoRCON/v2/tracker"
+)
+
+// Retention is how long each kind of data is kept; zero keeps it forever. Bans are
+// not covered: they last until they expire or RemoveBan lifts them
+type Retention struct {
+	// Players are deleted when not seen for this long, and the names, IPs and notes
+	// of the others once they are this old
+	Players time.Duration
+	// Chat deletes chat lines
+	Chat time.Duration
+	// Stats deletes match results by when the match ended
+	Stats time.Duration
+	// Audit deletes session entries, and sessions once they have none left
+	Audit time.Duration
+}
+
+// Purged counts what Enforce deleted
+type Purged struct {
+	Players, Chat, Stats, Audit int
+}
+
+// Total is the sum of all counts
+func (p Purged) Total() int {
+	return p.Players + p.Chat + p.Stats + p.Audit
+}
+
+// Purge deletes a player, their chat lines and notes, removes them from match results
+// and redacts them from the audit trail with session.RedactTranscript, all in one
+// transaction, e.g. to honor a deletion request. Their bans are kept
+func (s *SQLite) Purge(ctx context.Context, guid string) error {
+	return purge(ctx, guid, &s.players, &s.chat, &s.core)
+}
+
+// Enforce deletes what is older than the retention allows
+func (s *SQLite) Enforce(ctx context.Context, r Retention) (Purged, error) {
+	return enforce(ctx, r, &s.players, &s.chat, &s.core)
+}
+
+// Purge is SQLite.Purge
+func (s *Postgres) Purge(ctx context.Context, guid string) error {
+	return purge(ctx, guid, &s.players, &s.chat, &s.core)
+}
+
+// Enforce is SQLite.Enforce
+func (s *Postgres) Enforce(ctx context.Context, r Retention) (Purged, error) {
+	return enforce(ctx, r, &s.players, &s.chat, &s.core)
+}
+
+// txPurger deletes a player's rows inside a transaction
+type txPurger interface {
+	PurgeTx(ctx context.Context, tx *sql.Tx, guid string) error
+}
+
+func purge(ctx context.Context, guid string, players interface {
+	txPurger
+	Lookup(guid string) (*tracker.Player, error)
+}, chat txPurger, c *core) error {
+	guid = strings.ToLower(strings.TrimSpace(guid))
+	if guid == "" {
+		return fmt.Errorf("purge needs a guid")
+	}
+	// the names are read first, as the audit trail is redacted after they are gone
+	subject := session.Subject{GUID: guid}
+	p, err := players.Lookup(guid)
+	if err != nil {
+		return fmt.Errorf("purge players: %w", err)
+	}
+	if p != nil {
+		for _, n := range p.Names {
+			subject.Names = append(subject.Names, n.Value)
+		}
+	}
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := players.PurgeTx(ctx, tx, guid); err != nil {
+		return fmt.Errorf("purge players: %w", err)
+	}
+	if err := chat.PurgeTx(ctx, tx, guid); err != nil {
+		return fmt.Errorf("purge chat: %w", err)
+	}
+	if err := c.purgeStats(ctx, tx, guid); err != nil {
+		return fmt.Errorf("purge match results: %w", err)
+	}
+	if err := c.purgeAudit(ctx, tx, subject); err != nil {
+		return fmt.Errorf("purge audit trail: %w", err)
+	}
+	return tx.Commit()
+}
+
+func enforce(ctx context.Context, r Retention, players tracker.Purger, chat chatlog.Purger, c *core) (Purged, error) {
+	var p Purged
+	var err error
+	now := time.Now()
+	if r.Players > 0 {
+		if p.Players, err = players.PurgeOlderThan(r.Players); err != nil {
+			return p, fmt.Errorf("purge players: %w", err)
+		}
+	}
+	if r.Chat > 0 {
+		if p.Chat, err = chat.PurgeOlderThan(ctx, r.Chat); err != nil {
+			return p, fmt.Errorf("purge chat: %w", err)
+		}
+	}
+	if r.Stats > 0 {
+		if p.Stats, err = c.purgeStatsBefore(ctx, now.Add(-r.Stats)); err != nil {
+			return p, fmt.Errorf("purge match results: %w", err)
+		}
+	}
+	if r.Audit > 0 {
+		if p.Audit, err = c.purgeAuditBefore(ctx, now.Add(-r.Audit)); err != nil {
+			return p, fmt.Errorf("purge audit trail: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// Run enforces r every interval until ctx is done, starting right away. Failures go
+// to onError when set and don't stop the loop
+func (r Retention) Run(ctx context.Context, db interface {
+	Enforce(context.Context, Retention) (Purged, error)
+}, every time.Duration, onError func(error)) {
+	if every <= 0 {
+		every = time.Hour
+	}
+	tick := time.NewTicker(every)
+	defer tick.Stop()
+	for {
+		if _, err := db.Enforce(ctx, r); err != nil && onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+// purgeStats removes a player from the stored match results
+func (c *core) purgeStats(ctx context.Context, tx *sql.Tx, guid string) error {
+	rows, err := tx.QueryContext(ctx, c.rebind(`SELECT id, document FROM match_results WHERE LOWER(CAST(document AS TEXT)) LIKE ? ESCAPE '\'`), "%"+likePrefix(guid))
+	if err != nil {
+		return err
+	}
+	type doc struct {
+		id   int64
+		data string
+	}
+	var docs []doc
+	for rows.Next() {
+		var d doc
+		if err := rows.Scan(&d.id, &d.data); err != nil {
+			rows.Close()
+			return err
+		}
+		docs = append(docs, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	// updated after the rows are closed: SQLite runs on one connection
+	for _, d := range docs {
+		var r match.Result
+		if err := json.Unmarshal([]byte(d.data), &r); err != nil {
+			return fmt.Errorf("match result %d: %w", d.id, err)
+		}
+		if !match.RedactPlayer(&r, guid) {
+			continue
+		}
+		data, err := json.Marshal(&r)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, c.rebind(`UPDATE match_results SET document = ? WHERE id = ?`), string(data), d.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *core) purgeStatsBefore(ctx context.Context, cutoff time.Time) (int, error) {
+	res, err := c.execContext(ctx, `DELETE FROM match_results WHERE ended_at < ?`, cutoff.UTC())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// purgeAudit redacts a player from the sessions that mention them. Whole sessions are
+// read, since a kick or tell aimed at the player's slot only names them in an earlier
+// status reply
+func (c *core) purgeAudit(ctx context.Context, tx *sql.Tx, s session.Subject) error {
+	var where []string
+	var args []any
+	for _, term := range s.Terms() {
+		pattern := "%" + likePrefix(strings.ToLower(term))
+		where = append(where, `LOWER(args) LIKE ? ESCAPE '\' OR LOWER(response) LIKE ? ESCAPE '\'`)
+		args = append(args, pattern, pattern)
+	}
+	if len(where) == 0 {
+		return nil
+	}
+	rows, err := tx.QueryContext(ctx, c.rebind(`SELECT DISTINCT session_id FROM session_entries WHERE `+strings.Join(where, " OR ")), args...)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := c.redactSession(ctx, tx, id, s); err != nil {
+			return fmt.Errorf("session %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// redactSession runs session.RedactTranscript over one session and writes back the
+// entries it changed
+func (c *core) redactSession(ctx context.Context, tx *sql.Tx, id string, s session.Subject) error {
+	rows, err := tx.QueryContext(ctx, c.rebind(`SELECT at, command, args, response FROM session_entries WHERE session_id = ? ORDER BY at`), id)
+	if err != nil {
+		return err
+	}
+	type row struct {
+		at                      int64
+		command, args, response string
+	}
+	var found []row
+	var entries []session.Entry
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.at, &r.command, &r.args, &r.response); err != nil {
+			rows.Close()
+			return err
+		}
+		e := session.Entry{Command: r.command, Args: r.args}
+		if r.response != "" {
+			if err := json.Unmarshal([]byte(r.response), &e.Response); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		found, entries = append(found, r), append(entries, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, i := range session.RedactTranscript(entries, s) {
+		response, err := encodeResponse(entries[i].Response)
+		if err != nil {
+			return err
+		}
+		r := found[i]
+		if _, err := tx.ExecContext(ctx, c.rebind(`UPDATE session_entries SET args = ?, response = ?
+			WHERE session_id = ? AND at = ? AND command = ? AND args = ? AND response = ?`),
+			entries[i].Args, response, id, r.at, r.command, r.args, r.response); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeAuditBefore deletes the entries older than cutoff and the sessions left empty,
+// returning how many entries
+func (c *core) purgeAuditBefore(ctx context.Context, cutoff time.Time) (int, error) {
+	ts := cutoff.UnixNano()
+	res, err := c.execContext(ctx, `DELETE FROM session_entries WHERE at < ?`, ts)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	_, err = c.execContext(ctx, `DELETE FROM sessions WHERE started < ?
+		AND NOT EXISTS (SELECT 1 FROM session_entries e WHERE e.session_id = sessions.id)`, ts)
+	return int(n), err
+}
@@ -0,0 +1,151 @@
+// Package store bundles the stores of players, notes, bans, match stats, session
+// transcripts, the admin audit trail and the chat index behind one database
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/chatlog"
+	"github.com/Yallamaztar/PlutoRCON/v2/match"
+	"github.com/Yallamaztar/PlutoRCON/v2/tracker"
+)
+
+// SQLite keeps everything in one SQLite database. It implements tracker.Store,
+// tracker.BatchRecorder, policy.BanStore, match.Sink, session.Backend, chatlog.Store
+// and, for the audit trail of admin actions, timeline.Source
+type SQLite struct {
+	DB *sql.DB
+
+	core
+	players tracker.SQLiteStore
+	chat    chatlog.SQLiteStore
+	stats   match.SQLSink
+}
+
+// ErrNoDriver is returned by OpenSQLite and OpenPostgres when the program imports no
+// matching database/sql driver
+var ErrNoDriver = errors.New("no database driver is registered")
+
+// sqliteDrivers are the names the common SQLite drivers register as
+// (modernc.org/sqlite, github.com/mattn/go-sqlite3)
+var sqliteDrivers = []string{"sqlite", "sqlite3"}
+
+var sqliteSchema = []string{
+	`CREATE TABLE IF NOT EXISTS bans (
+		guid TEXT PRIMARY KEY, ip TEXT NOT NULL DEFAULT '', name TEXT NOT NULL DEFAULT '',
+		reason TEXT NOT NULL DEFAULT '', operator TEXT NOT NULL DEFAULT '', server TEXT NOT NULL DEFAULT '',
+		at INTEGER NOT NULL, expires INTEGER NOT NULL DEFAULT 0)`,
+	`CREATE INDEX IF NOT EXISTS bans_ip ON bans (ip)`,
+	`CREATE TABLE IF NOT EXISTS match_results (
+		id INTEGER PRIMARY KEY, map TEXT NOT NULL, gametype TEXT NOT NULL,
+		started_at TIMESTAMP, ended_at TIMESTAMP, document TEXT NOT NULL)`,
+	`CREATE INDEX IF NOT EXISTS match_results_ended ON match_results (ended_at)`,
+	`CREATE TABLE IF NOT EXISTS sessions (
+		id TEXT PRIMARY KEY, operator TEXT NOT NULL, source TEXT NOT NULL DEFAULT '',
+		server TEXT NOT NULL DEFAULT '', started INTEGER NOT NULL)`,
+	`CREATE TABLE IF NOT EXISTS session_entries (
+		session_id TEXT NOT NULL, at INTEGER NOT NULL, command TEXT NOT NULL, args TEXT NOT NULL DEFAULT '',
+		response TEXT NOT NULL DEFAULT '', error TEXT NOT NULL DEFAULT '', took INTEGER NOT NULL DEFAULT 0)`,
+	`CREATE INDEX IF NOT EXISTS session_entries_session ON session_entries (session_id, at)`,
+	`CREATE INDEX IF NOT EXISTS session_entries_at ON session_entries (at)`,
+}
+
+// OpenSQLite opens the database at dsn with whichever SQLite driver the program
+// imports and creates the tables. The driver needs FTS5 for the chat index
+func OpenSQLite(dsn string) (*SQLite, error) {
+	registered := sql.Drivers()
+	for _, name := range sqliteDrivers {
+		if !slices.Contains(registered, name) {
+			continue
+		}
+		db, err := sql.Open(name, dsn)
+		if err != nil {
+			return nil, err
+		}
+		// one connection serializes writers instead of failing them with SQLITE_BUSY,
+		// and keeps :memory: databases from splitting into one per connection
+		db.SetMaxOpenConns(1)
+		if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil {
+			db.Close()
+			return nil, err
+		}
+		s, err := NewSQLite(db)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf(`%w for SQLite; import one, e.g. _ "modernc.org/sqlite"`, ErrNoDriver)
+}
+
+// NewSQLite uses an already open database and creates the tables if they don't exist
+func NewSQLite(db *sql.DB) (*SQLite, error) {
+	if db == nil {
+		return nil, errors.New("storage has no database")
+	}
+	s := &SQLite{DB: db, core: core{db: db}}
+	s.players.DB, s.chat.DB, s.stats.DB = db, db, db
+	if err := s.players.Init(); err != nil {
+		return nil, fmt.Errorf("player tables: %w", err)
+	}
+	if err := s.chat.Init(context.Background()); err != nil {
+		return nil, fmt.Errorf("chat table: %w", err)
+	}
+	for _, stmt := range sqliteSchema {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Close closes the database
+func (s *SQLite) Close() error {
+	return s.DB.Close()
+}
+
+// SaveNote implements tracker.Store
+func (s *SQLite) SaveNote(n tracker.Note) error { return s.players.SaveNote(n) }
+
+// Notes implements tracker.Store
+func (s *SQLite) Notes(guid string) ([]tracker.Note, error) { return s.players.Notes(guid) }
+
+// NotesBetween implements tracker.Store
+func (s *SQLite) NotesBetween(from, to time.Time) ([]tracker.Note, error) {
+	return s.players.NotesBetween(from, to)
+}
+
+// Record implements tracker.Store
+func (s *SQLite) Record(server string, at time.Time, seen []tracker.Sighting) error {
+	return s.players.Record(server, at, seen)
+}
+
+// RecordPolls implements tracker.BatchRecorder
+func (s *SQLite) RecordPolls(polls []tracker.Poll) error { return s.players.RecordPolls(polls) }
+
+// Lookup implements tracker.Store
+func (s *SQLite) Lookup(guid string) (*tracker.Player, error) { return s.players.Lookup(guid) }
+
+// ByNameEver implements tracker.Store
+func (s *SQLite) ByNameEver(name string) ([]tracker.Player, error) {
+	return s.players.ByNameEver(name)
+}
+
+// Add implements chatlog.Store
+func (s *SQLite) Add(ctx context.Context, msgs ...chatlog.Message) error {
+	return s.chat.Add(ctx, msgs...)
+}
+
+// Search implements chatlog.Store
+func (s *SQLite) Search(ctx context.Context, q chatlog.Query) ([]chatlog.Message, error) {
+	return s.chat.Search(ctx, q)
+}
+
+// Write implements match.Sink
+func (s *SQLite) Write(ctx context.Context, r *match.Result) error { return s.stats.Write(ctx, r) }
@@ -0,0 +1,171 @@
+package timeline
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/capture"
+	"github.com/Yallamaztar/PlutoRCON/v2/session"
+)
+
+// Incident gathers the evidence of one server for a time window
+type Incident struct {
+	Sources Sources
+	// Captures are pcap files whose datagrams inside the window go into captures.pcap,
+	// with rcon and BattlEye login passwords masked. Datagrams of other protocols are
+	// left out, since their passwords can't be found
+	Captures []string
+	// Addr, when set, keeps only captured datagrams to or from this server address
+	Addr netip.AddrPort
+}
+
+// IncidentManifest is the manifest.json of an incident bundle
+type IncidentManifest struct {
+	Server    string    `json:"server"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Generated time.Time `json:"generated"`
+	Entries   int       `json:"entries"`
+	Snapshots int       `json:"snapshots"`
+	Actions   int       `json:"actions"`
+	Datagrams int       `json:"datagrams"`
+	Errors    []string  `json:"errors,omitempty"`
+}
+
+type bundleFile struct {
+	name  string
+	write func(io.Writer) error
+}
+
+// auditLine is one admin command in audit.jsonl
+type auditLine struct {
+	Operator string `json:"operator,omitempty"`
+	session.Entry
+}
+
+// ExportIncident writes a zip with the server's merged timeline (timeline.json and a
+// readable timeline.txt), its status snapshots, the admin audit log, moderators' notes and
+// the raw captures of the window. Sources or captures that fail are listed in manifest.json instead of
+// aborting the export
+func (in *Incident) ExportIncident(ctx context.Context, w io.Writer, server string, from, to time.Time) error {
+	entries, err := in.Sources.Server(ctx, server, from, to)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	m := IncidentManifest{Server: server, From: from, To: to, Generated: time.Now(), Entries: len(entries)}
+	if err != nil {
+		m.Errors = append(m.Errors, err.Error())
+	}
+
+	var snapshots, actions, notes []Entry
+	for _, e := range entries {
+		switch e.Kind {
+		case KindStatus:
+			snapshots = append(snapshots, e)
+		case KindAction:
+			actions = append(actions, e)
+		case KindNote:
+			notes = append(notes, e)
+		}
+	}
+	m.Snapshots, m.Actions = len(snapshots), len(actions)
+
+	dgrams, cerrs := in.captured(from, to)
+	m.Datagrams = len(dgrams)
+	m.Errors = append(m.Errors, cerrs...)
+
+	zw := zip.NewWriter(w)
+	files := []bundleFile{
+		{"manifest.json", func(w io.Writer) error { return writeJSON(w, m) }},
+		{"timeline.json", func(w io.Writer) error { return writeJSON(w, nonNil(entries)) }},
+		{"timeline.txt", func(w io.Writer) error { return writeText(w, entries) }},
+		{"status.jsonl", func(w io.Writer) error { return writeLines(w, snapshots, func(e Entry) any { return e.Status }) }},
+		{"audit.jsonl", func(w io.Writer) error {
+			return writeLines(w, actions, func(e Entry) any { return auditLine{Operator: e.Operator, Entry: *e.Action} })
+		}},
+	}
+	if len(notes) > 0 {
+		files = append(files, bundleFile{"notes.jsonl", func(w io.Writer) error { return writeLines(w, notes, func(e Entry) any { return e.Note }) }})
+	}
+	if len(dgrams) > 0 {
+		files = append(files, bundleFile{"captures.pcap", func(w io.Writer) error { return capture.WritePcap(w, dgrams) }})
+	}
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: m.Generated})
+		if err != nil {
+			return err
+		}
+		if err := f.write(fw); err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+	}
+	return zw.Close()
+}
+
+// captured reads the datagrams of the capture files that fall inside the window
+func (in *Incident) captured(from, to time.Time) ([]capture.Datagram, []string) {
+	var out []capture.Datagram
+	var errs []string
+	for _, path := range in.Captures {
+		f, err := os.Open(path)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		dgrams, err := capture.ReadPcap(f)
+		f.Close()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", path, err))
+		}
+		for _, d := range dgrams {
+			if d.At.Before(from) || d.At.After(to) {
+				continue
+			}
+			if in.Addr.IsValid() && d.Src != in.Addr && d.Dst != in.Addr {
+				continue
+			}
+			if d, ok := capture.Redact(d); ok {
+				out = append(out, d)
+			}
+		}
+	}
+	return out, errs
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeLines(w io.Writer, entries []Entry, line func(Entry) any) error {
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(line(e)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeText(w io.Writer, entries []Entry) error {
+	for _, e := range entries {
+		if _, err := fmt.Fprintf(w, "%s  %-6s  %s\n", e.At.Format("2006-01-02 15:04:05.000 MST"), e.Kind, e.Summary); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func nonNil(entries []Entry) []Entry {
+	if entries == nil {
+		return []Entry{}
+	}
+	return entries
+}
@@ -0,0 +1,204 @@
+package timeline
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/events"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+	"github.com/Yallamaztar/PlutoRCON/v2/session"
+	"github.com/Yallamaztar/PlutoRCON/v2/tracker"
+)
+
+// readOnlyCommands are left out of the admin actions recorded by Recorder.Middleware
+var readOnlyCommands = map[string]bool{
+	"status": true, "serverinfo": true, "cmdlist": true, "dvarlist": true, "dvardump": true,
+}
+
+// Recorder keeps recent entries of several servers in memory and is itself a Source
+type Recorder struct {
+	// Retention drops entries older than this (default 24h)
+	Retention time.Duration
+	// MaxEntries bounds the entries kept per server (default 50000)
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries map[string][]Entry
+}
+
+// Add records an entry
+func (r *Recorder) Add(e Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.entries == nil {
+		r.entries = map[string][]Entry{}
+	}
+	list := r.entries[e.Server]
+	// Sources deliver nearly in order, so inserting from the back is cheap
+	i := sort.Search(len(list), func(i int) bool { return list[i].At.After(e.At) })
+	list = append(list, Entry{})
+	copy(list[i+1:], list[i:])
+	list[i] = e
+	r.entries[e.Server] = r.pruneLocked(list)
+}
+
+// pruneLocked drops entries past the retention and size limits. It reslices instead of
+// copying the rest of the list, so a full list costs no more per Add than a short one;
+// append moves the live entries to a new array now and then, freeing the dropped ones
+func (r *Recorder) pruneLocked(list []Entry) []Entry {
+	retention := r.Retention
+	if retention <= 0 {
+		retention = 24 * time.Hour
+	}
+	max := r.MaxEntries
+	if max <= 0 {
+		max = 50000
+	}
+	cut := time.Now().Add(-retention)
+	drop := sort.Search(len(list), func(i int) bool { return !list[i].At.Before(cut) })
+	if n := len(list) - drop; n > max {
+		drop = len(list) - max
+	}
+	clear(list[:drop])
+	return list[drop:]
+}
+
+// AddStatus records a status snapshot
+func (r *Recorder) AddStatus(server string, st *rcon.ServerStatus) {
+	r.Add(StatusEntry(server, st))
+}
+
+// AddEvent records a log event
+func (r *Recorder) AddEvent(server string, ev events.Event) {
+	r.Add(EventEntry(server, ev))
+}
+
+// AddAction records an admin command
+func (r *Recorder) AddAction(server, operator string, a session.Entry) {
+	r.Add(ActionEntry(server, operator, a))
+}
+
+// Entries implements Source
+func (r *Recorder) Entries(ctx context.Context, from, to time.Time) ([]Entry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []Entry
+	for _, list := range r.entries {
+		start := sort.Search(len(list), func(i int) bool { return !list[i].At.Before(from) })
+		for _, e := range list[start:] {
+			if e.At.After(to) {
+				break
+			}
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// Follow records the events of a stream until ctx is done or the stream closes
+func (r *Recorder) Follow(ctx context.Context, server string, s *events.Stream) {
+	evs, cancel := s.Subscribe(256)
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-evs:
+			if !ok {
+				return
+			}
+			r.AddEvent(server, ev)
+		}
+	}
+}
+
+// Attach records every successful poll of a Monitor, keeping an OnStatus that is already set
+func (r *Recorder) Attach(server string, m *rcon.Monitor) {
+	prev := m.OnStatus
+	m.OnStatus = func(st *rcon.ServerStatus) {
+		if prev != nil {
+			prev(st)
+		}
+		r.AddStatus(server, st)
+	}
+}
+
+// Middleware records the commands a client sends as admin actions, skipping read-only
+// polls such as status
+func (r *Recorder) Middleware(server, operator string) rcon.Middleware {
+	return ActionMiddleware(server, operator, r.Add)
+}
+
+// ActionMiddleware passes the commands a client sends to add as admin action entries,
+// skipping read-only polls such as status
+func ActionMiddleware(server, operator string, add func(Entry)) rcon.Middleware {
+	return func(next rcon.CommandFunc) rcon.CommandFunc {
+		return func(cmd rcon.Command) ([]string, error) {
+			start := time.Now()
+			res, err := next(cmd)
+			if readOnlyCommands[strings.ToLower(cmd.Name)] {
+				return res, err
+			}
+			a := session.Entry{At: start, Command: cmd.Name, Response: res, Took: time.Since(start)}
+			if cmd.Args != nil {
+				a.Args = *cmd.Args
+			}
+			if err != nil {
+				a.Error = err.Error()
+			}
+			add(ActionEntry(server, operator, a))
+			return res, err
+		}
+	}
+}
+
+// SessionSource reads admin actions from stored session transcripts
+type SessionSource struct {
+	Store *session.Store
+}
+
+// Entries implements Source
+func (s SessionSource) Entries(ctx context.Context, from, to time.Time) ([]Entry, error) {
+	metas, err := s.Store.List()
+	if err != nil {
+		return nil, err
+	}
+	var out []Entry
+	for _, m := range metas {
+		if m.Started.After(to) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		t, err := s.Store.Load(m.ID)
+		if err != nil {
+			continue
+		}
+		for _, e := range t.Entries {
+			if e.At.Before(from) || e.At.After(to) {
+				continue
+			}
+			out = append(out, ActionEntry(m.Server, m.Operator, e))
+		}
+	}
+	return out, nil
+}
+
+// NoteSource reads moderators' notes on players, placed on the server they were written for
+type NoteSource struct {
+	Store tracker.Store
+}
+
+// Entries implements Source
+func (s NoteSource) Entries(_ context.Context, from, to time.Time) ([]Entry, error) {
+	notes, err := s.Store.NotesBetween(from, to)
+	out := make([]Entry, 0, len(notes))
+	for _, n := range notes {
+		out = append(out, NoteEntry(n))
+	}
+	return out, err
+}
@@ -0,0 +1,138 @@
+// Package timeline merges status snapshots, log events and admin actions of servers
+// into one ordered history per server, for "what happened at 21:35?" investigations
+package timeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/events"
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+	"github.com/Yallamaztar/PlutoRCON/v2/session"
+	"github.com/Yallamaztar/PlutoRCON/v2/tracker"
+)
+
+// Kind says which source an entry came from
+type Kind string
+
+const (
+	KindStatus Kind = "status"
+	KindEvent  Kind = "event"
+	KindAction Kind = "action"
+	KindNote   Kind = "note"
+)
+
+// Entry is one thing that happened on a server; exactly one of Status, Event, Action and Note is set
+type Entry struct {
+	Server  string    `json:"server"`
+	At      time.Time `json:"at"`
+	Kind    Kind      `json:"kind"`
+	Summary string    `json:"summary"`
+
+	Status *rcon.ServerStatus `json:"status,omitempty"`
+	// EventType is the Go type name of Event, e.g. "Kill"
+	EventType string         `json:"event_type,omitempty"`
+	Event     events.Event   `json:"event,omitempty"`
+	Operator  string         `json:"operator,omitempty"`
+	Action    *session.Entry `json:"action,omitempty"`
+	Note      *tracker.Note  `json:"note,omitempty"`
+}
+
+// Source returns the entries of every server it knows between from and to
+type Source interface {
+	Entries(ctx context.Context, from, to time.Time) ([]Entry, error)
+}
+
+// Sources merges several sources
+type Sources []Source
+
+// Timeline returns the entries between from and to grouped by server, each in time order.
+// A failing source doesn't hide the others; its error is returned alongside the result
+func (s Sources) Timeline(ctx context.Context, from, to time.Time) (map[string][]Entry, error) {
+	out := map[string][]Entry{}
+	var errs []error
+	for _, src := range s {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		entries, err := src.Entries(ctx, from, to)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, e := range entries {
+			if e.At.Before(from) || e.At.After(to) {
+				continue
+			}
+			out[e.Server] = append(out[e.Server], e)
+		}
+	}
+	for _, entries := range out {
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].At.Before(entries[j].At) })
+	}
+	return out, errors.Join(errs...)
+}
+
+// Server is Timeline for a single server
+func (s Sources) Server(ctx context.Context, server string, from, to time.Time) ([]Entry, error) {
+	all, err := s.Timeline(ctx, from, to)
+	return all[server], err
+}
+
+// StatusEntry builds the entry of a status snapshot
+func StatusEntry(server string, st *rcon.ServerStatus) Entry {
+	at := st.RetrievedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+	return Entry{Server: server, At: at, Kind: KindStatus, Status: st, Summary: fmt.Sprintf("%s, %d players", st.Map, len(st.Players))}
+}
+
+// EventEntry builds the entry of a log event, placed at its Timestamp
+func EventEntry(server string, ev events.Event) Entry {
+	name := reflect.TypeOf(ev).String()
+	name = name[strings.LastIndex(name, ".")+1:]
+	return Entry{Server: server, At: ev.Timestamp(), Kind: KindEvent, EventType: name, Event: ev, Summary: eventSummary(ev)}
+}
+
+// ActionEntry builds the entry of an admin command
+func ActionEntry(server, operator string, a session.Entry) Entry {
+	summary := strings.TrimSpace(a.Command + " " + a.Args)
+	if operator != "" {
+		summary = operator + ": " + summary
+	}
+	if a.Error != "" {
+		summary += " (failed: " + a.Error + ")"
+	}
+	return Entry{Server: server, At: a.At, Kind: KindAction, Operator: operator, Action: &a, Summary: summary}
+}
+
+// NoteEntry builds the entry of a moderator's note on a player
+func NoteEntry(n tracker.Note) Entry {
+	return Entry{Server: n.Server, At: n.At, Kind: KindNote, Operator: n.Author, Note: &n, Summary: fmt.Sprintf("%s noted on %s: %s", n.Author, n.GUID, n.Text)}
+}
+
+func eventSummary(ev events.Event) string {
+	name := func(a events.Actor) string { return wire.StripColors(a.Name) }
+	switch e := ev.(type) {
+	case *events.PlayerJoin:
+		return name(e.Player) + " joined"
+	case *events.PlayerQuit:
+		return name(e.Player) + " left"
+	case *events.Kill:
+		if e.Suicide() {
+			return fmt.Sprintf("%s died (%s)", name(e.Victim), e.MeansOfDeath)
+		}
+		return fmt.Sprintf("%s killed %s with %s", name(e.Attacker), name(e.Victim), e.Weapon)
+	case *events.ChatMessage:
+		return fmt.Sprintf("%s: %s", name(e.Player), wire.StripColors(e.Message))
+	case *events.MapChange:
+		return fmt.Sprintf("map %s (%s)", e.Map, e.GameType)
+	}
+	return fmt.Sprintf("%T", ev)
+}
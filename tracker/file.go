@@ -0,0 +1,291 @@
+package tracker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// FileStore keeps everything in one JSON file. Sightings are written at most every
+// FlushEvery, notes and purges at once. Several processes may share the file, like a
+// daemon and `plutorcon notes add`: every write happens under a lock file and first
+// re-reads the file if another process changed it. It suits a handful of servers; use
+// SQLiteStore for large communities
+type FileStore struct {
+	Path string
+	// FlushEvery is how often Record writes the file (default 30s); Flush writes it now
+	FlushEvery time.Duration
+
+	mu      sync.Mutex
+	loaded  bool
+	stamp   fileStamp
+	data    fileData
+	pending []recordBatch
+	saved   time.Time
+}
+
+type fileData struct {
+	Players map[string]*Player `json:"players"`
+	Notes   []Note             `json:"notes"`
+}
+
+// fileStamp identifies the file version last read or written
+type fileStamp struct {
+	mod  time.Time
+	size int64
+}
+
+// recordBatch is a Record call not yet written, replayed when the file is re-read
+type recordBatch struct {
+	server string
+	at     time.Time
+	seen   []Sighting
+}
+
+const (
+	defaultFileFlush = 30 * time.Second
+	// fileLockWait is how long a write waits for another process's lock, and
+	// fileLockStale how old a lock file is before it counts as left by a crash
+	fileLockWait  = 5 * time.Second
+	fileLockStale = 30 * time.Second
+)
+
+// loadLocked reads the file unless the copy in memory is current; a missing file is an
+// empty store. Sightings not yet written are merged into a fresh read again
+func (f *FileStore) loadLocked() error {
+	st, err := os.Stat(f.Path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	var stamp fileStamp
+	if err == nil {
+		stamp = fileStamp{mod: st.ModTime(), size: st.Size()}
+	}
+	if f.loaded && stamp == f.stamp {
+		return nil
+	}
+	data, err := os.ReadFile(f.Path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	var fresh fileData
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &fresh); err != nil {
+			return err
+		}
+	}
+	f.data, f.stamp, f.loaded = fresh, stamp, true
+	for _, b := range f.pending {
+		f.mergeLocked(b)
+	}
+	return nil
+}
+
+// updateLocked applies change to the current file contents and writes them, holding
+// the lock file so another process's write in between isn't lost
+func (f *FileStore) updateLocked(change func()) error {
+	unlock, err := f.lockFile()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := f.loadLocked(); err != nil {
+		return err
+	}
+	change()
+	if err := f.saveLocked(); err != nil {
+		// memory is ahead of the file now; read it again next time
+		f.loaded = false
+		return err
+	}
+	return nil
+}
+
+// saveLocked replaces the file through a rename so a crash never leaves half of it
+func (f *FileStore) saveLocked() error {
+	data, err := json.MarshalIndent(f.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := f.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, f.Path); err != nil {
+		return err
+	}
+	if st, err := os.Stat(f.Path); err == nil {
+		f.stamp = fileStamp{mod: st.ModTime(), size: st.Size()}
+	}
+	f.pending, f.saved = nil, time.Now()
+	return nil
+}
+
+// lockFile takes the lock file next to Path, waiting for another process to finish
+func (f *FileStore) lockFile() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0o700); err != nil {
+		return nil, err
+	}
+	lock := f.Path + ".lock"
+	deadline := time.Now().Add(fileLockWait)
+	for {
+		fh, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			fh.Close()
+			return func() { os.Remove(lock) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if st, err := os.Stat(lock); err == nil && time.Since(st.ModTime()) > fileLockStale {
+			os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("player store %s is locked by another process (remove %s if none is running)", f.Path, lock)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// Flush writes sightings that Record is still holding back
+func (f *FileStore) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.pending) == 0 {
+		return nil
+	}
+	return f.updateLocked(func() {})
+}
+
+// SaveNote implements Store
+func (f *FileStore) SaveNote(n Note) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.updateLocked(func() {
+		f.data.Notes = append(f.data.Notes, n)
+	})
+}
+
+// Notes implements Store
+func (f *FileStore) Notes(guid string) ([]Note, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.loadLocked(); err != nil {
+		return nil, err
+	}
+	var out []Note
+	for _, n := range f.data.Notes {
+		if n.GUID == guid {
+			out = append(out, n)
+		}
+	}
+	return out, nil
+}
+
+// NotesBetween implements Store
+func (f *FileStore) NotesBetween(from, to time.Time) ([]Note, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.loadLocked(); err != nil {
+		return nil, err
+	}
+	var out []Note
+	for _, n := range f.data.Notes {
+		if !n.At.Before(from) && !n.At.After(to) {
+			out = append(out, n)
+		}
+	}
+	return out, nil
+}
+
+// Record implements Store. The file is written at most every FlushEvery, so a crash
+// loses at most that much playtime
+func (f *FileStore) Record(server string, at time.Time, seen []Sighting) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.loadLocked(); err != nil {
+		return err
+	}
+	b := recordBatch{server: server, at: at, seen: slices.Clone(seen)}
+	f.mergeLocked(b)
+	f.pending = append(f.pending, b)
+	every := f.FlushEvery
+	if every <= 0 {
+		every = defaultFileFlush
+	}
+	if time.Since(f.saved) < every {
+		return nil
+	}
+	return f.updateLocked(func() {})
+}
+
+// RecordPolls implements BatchRecorder, writing the file once
+func (f *FileStore) RecordPolls(polls []Poll) error {
+	if len(polls) == 0 {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.updateLocked(func() {
+		for _, p := range polls {
+			f.mergeLocked(recordBatch{server: p.Server, at: p.At, seen: p.Seen})
+		}
+	})
+}
+
+// mergeLocked applies one poll to the players in memory
+func (f *FileStore) mergeLocked(b recordBatch) {
+	if f.data.Players == nil {
+		f.data.Players = map[string]*Player{}
+	}
+	for _, s := range b.seen {
+		p := f.data.Players[s.GUID]
+		if p == nil {
+			p = &Player{GUID: s.GUID}
+			f.data.Players[s.GUID] = p
+		}
+		p.merge(b.server, b.at, s)
+	}
+}
+
+// Lookup implements Store
+func (f *FileStore) Lookup(guid string) (*Player, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.loadLocked(); err != nil {
+		return nil, err
+	}
+	p := f.data.Players[guid]
+	if p == nil {
+		return nil, nil
+	}
+	out := *p
+	out.Names, out.IPs = slices.Clone(p.Names), slices.Clone(p.IPs)
+	return &out, nil
+}
+
+// ByNameEver implements Store
+func (f *FileStore) ByNameEver(name string) ([]Player, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.loadLocked(); err != nil {
+		return nil, err
+	}
+	q := normalizeName(name)
+	var out []Player
+	for _, p := range f.data.Players {
+		if p.nameMatches(q) {
+			c := *p
+			c.Names, c.IPs = slices.Clone(p.Names), slices.Clone(p.IPs)
+			out = append(out, c)
+		}
+	}
+	sortByLastSeen(out)
+	return out, nil
+}
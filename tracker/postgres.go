@@ -0,0 +1,165 @@
+package tracker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// PostgresStore keeps players and notes in PostgreSQL through database/sql, so several
+// daemons can share them. Open DB with any Postgres driver, e.g. github.com/jackc/pgx/v5/stdlib
+type PostgresStore struct {
+	DB *sql.DB
+}
+
+// PostgresSchema creates the tables of PostgresStore; store.Postgres runs it as a migration
+var PostgresSchema = []string{
+	`CREATE TABLE IF NOT EXISTS players (
+		guid TEXT PRIMARY KEY, first_seen BIGINT NOT NULL, last_seen BIGINT NOT NULL,
+		last_server TEXT NOT NULL DEFAULT '', playtime BIGINT NOT NULL DEFAULT 0)`,
+	`CREATE TABLE IF NOT EXISTS player_names (
+		guid TEXT NOT NULL, name TEXT NOT NULL, clean TEXT NOT NULL,
+		first_seen BIGINT NOT NULL, last_seen BIGINT NOT NULL, PRIMARY KEY (guid, name))`,
+	`CREATE INDEX IF NOT EXISTS player_names_clean ON player_names (clean)`,
+	`CREATE TABLE IF NOT EXISTS player_ips (
+		guid TEXT NOT NULL, ip TEXT NOT NULL,
+		first_seen BIGINT NOT NULL, last_seen BIGINT NOT NULL, PRIMARY KEY (guid, ip))`,
+	`CREATE TABLE IF NOT EXISTS player_notes (
+		guid TEXT NOT NULL, author TEXT NOT NULL, text TEXT NOT NULL,
+		server TEXT NOT NULL DEFAULT '', at BIGINT NOT NULL)`,
+	`CREATE INDEX IF NOT EXISTS player_notes_guid ON player_notes (guid)`,
+	`CREATE INDEX IF NOT EXISTS player_notes_at ON player_notes (at)`,
+}
+
+// Init creates the tables if they don't exist
+func (s *PostgresStore) Init(ctx context.Context) error {
+	if s.DB == nil {
+		return errors.New("player store has no database")
+	}
+	for _, stmt := range PostgresSchema {
+		if _, err := s.DB.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Record implements Store
+func (s *PostgresStore) Record(server string, at time.Time, seen []Sighting) error {
+	return s.RecordPolls([]Poll{{Server: server, At: at, Seen: seen}})
+}
+
+// RecordPolls implements BatchRecorder in one transaction
+func (s *PostgresStore) RecordPolls(polls []Poll) error {
+	if s.DB == nil {
+		return errors.New("player store has no database")
+	}
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, poll := range polls {
+		if err := s.recordTx(tx, poll); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// recordTx merges one poll inside tx
+func (s *PostgresStore) recordTx(tx *sql.Tx, poll Poll) error {
+	server, ts := poll.Server, poll.At.UnixNano()
+	for _, p := range poll.Seen {
+		if _, err := tx.Exec(`INSERT INTO players (guid, first_seen, last_seen, last_server, playtime) VALUES ($1, $2, $2, $3, $4)
+			ON CONFLICT (guid) DO UPDATE SET
+				first_seen = LEAST(players.first_seen, excluded.first_seen),
+				last_server = CASE WHEN excluded.last_seen >= players.last_seen THEN excluded.last_server ELSE players.last_server END,
+				last_seen = GREATEST(players.last_seen, excluded.last_seen),
+				playtime = players.playtime + excluded.playtime`,
+			p.GUID, ts, server, int64(p.Played)); err != nil {
+			return err
+		}
+		if p.Name != "" {
+			if _, err := tx.Exec(`INSERT INTO player_names (guid, name, clean, first_seen, last_seen) VALUES ($1, $2, $3, $4, $4)
+				ON CONFLICT (guid, name) DO UPDATE SET
+					first_seen = LEAST(player_names.first_seen, excluded.first_seen), last_seen = GREATEST(player_names.last_seen, excluded.last_seen)`,
+				p.GUID, p.Name, normalizeName(p.Name), ts); err != nil {
+				return err
+			}
+		}
+		if p.IP != "" {
+			if _, err := tx.Exec(`INSERT INTO player_ips (guid, ip, first_seen, last_seen) VALUES ($1, $2, $3, $3)
+				ON CONFLICT (guid, ip) DO UPDATE SET
+					first_seen = LEAST(player_ips.first_seen, excluded.first_seen), last_seen = GREATEST(player_ips.last_seen, excluded.last_seen)`,
+				p.GUID, p.IP, ts); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Lookup implements Store
+func (s *PostgresStore) Lookup(guid string) (*Player, error) {
+	if s.DB == nil {
+		return nil, errors.New("player store has no database")
+	}
+	p := &Player{GUID: guid}
+	var first, last, played int64
+	err := s.DB.QueryRow(`SELECT first_seen, last_seen, last_server, playtime FROM players WHERE guid = $1`, guid).
+		Scan(&first, &last, &p.LastServer, &played)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.FirstSeen, p.LastSeen, p.Playtime = time.Unix(0, first), time.Unix(0, last), time.Duration(played)
+	if p.Names, err = querySeen(s.DB, `SELECT name, first_seen, last_seen FROM player_names WHERE guid = $1 ORDER BY first_seen`, guid); err != nil {
+		return nil, err
+	}
+	if p.IPs, err = querySeen(s.DB, `SELECT ip, first_seen, last_seen FROM player_ips WHERE guid = $1 ORDER BY first_seen`, guid); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ByNameEver implements Store
+func (s *PostgresStore) ByNameEver(name string) ([]Player, error) {
+	if s.DB == nil {
+		return nil, errors.New("player store has no database")
+	}
+	rows, err := s.DB.Query(`SELECT DISTINCT guid FROM player_names WHERE clean LIKE $1 ESCAPE '\'`, likePattern(name))
+	if err != nil {
+		return nil, err
+	}
+	return lookupAll(rows, s.Lookup)
+}
+
+// SaveNote implements Store
+func (s *PostgresStore) SaveNote(n Note) error {
+	if s.DB == nil {
+		return errors.New("player store has no database")
+	}
+	_, err := s.DB.Exec(`INSERT INTO player_notes (guid, author, text, server, at) VALUES ($1, $2, $3, $4, $5)`,
+		n.GUID, n.Author, n.Text, n.Server, n.At.UnixNano())
+	return err
+}
+
+// Notes implements Store
+func (s *PostgresStore) Notes(guid string) ([]Note, error) {
+	if s.DB == nil {
+		return nil, errors.New("player store has no database")
+	}
+	return queryNotes(s.DB, `SELECT guid, author, text, server, at FROM player_notes WHERE guid = $1 ORDER BY at`, guid)
+}
+
+// NotesBetween implements Store
+func (s *PostgresStore) NotesBetween(from, to time.Time) ([]Note, error) {
+	if s.DB == nil {
+		return nil, errors.New("player store has no database")
+	}
+	return queryNotes(s.DB, `SELECT guid, author, text, server, at FROM player_notes WHERE at >= $1 AND at <= $2 ORDER BY at`, from.UnixNano(), to.UnixNano())
+}
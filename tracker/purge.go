@@ -0,0 +1,169 @@
+package tracker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+)
+
+// Purger is a store that can delete player data, for deletion requests and to keep
+// the store bounded. FileStore, SQLiteStore and PostgresStore implement it
+type Purger interface {
+	// Purge deletes a player with their names, IPs and notes
+	Purge(guid string) error
+	// PurgeOlderThan deletes the players not seen for d, and the names, IPs and notes
+	// last used or written before then. It returns how many players were deleted
+	PurgeOlderThan(d time.Duration) (int, error)
+}
+
+// Purge implements Purger
+func (f *FileStore) Purge(guid string) error {
+	guid = normalizeGUID(guid)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// sightings held back for the player must not come back on the next re-read
+	pending := f.pending[:0]
+	for _, b := range f.pending {
+		b.seen = slices.DeleteFunc(b.seen, func(s Sighting) bool { return normalizeGUID(s.GUID) == guid })
+		pending = append(pending, b)
+	}
+	f.pending = pending
+	return f.updateLocked(func() {
+		for key := range f.data.Players {
+			if normalizeGUID(key) == guid {
+				delete(f.data.Players, key)
+			}
+		}
+		notes := f.data.Notes[:0]
+		for _, n := range f.data.Notes {
+			if normalizeGUID(n.GUID) != guid {
+				notes = append(notes, n)
+			}
+		}
+		f.data.Notes = notes
+	})
+}
+
+// PurgeOlderThan implements Purger
+func (f *FileStore) PurgeOlderThan(d time.Duration) (int, error) {
+	cutoff := time.Now().Add(-d)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	err := f.updateLocked(func() {
+		for guid, p := range f.data.Players {
+			if p.LastSeen.Before(cutoff) {
+				delete(f.data.Players, guid)
+				n++
+				continue
+			}
+			p.Names, p.IPs = seenSince(p.Names, cutoff), seenSince(p.IPs, cutoff)
+		}
+		notes := f.data.Notes[:0]
+		for _, note := range f.data.Notes {
+			if !note.At.Before(cutoff) {
+				notes = append(notes, note)
+			}
+		}
+		f.data.Notes = notes
+	})
+	return n, err
+}
+
+// seenSince drops the entries last seen before cutoff
+func seenSince(list []Seen, cutoff time.Time) []Seen {
+	out := list[:0]
+	for _, s := range list {
+		if !s.LastSeen.Before(cutoff) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Purge implements Purger
+func (s *SQLiteStore) Purge(guid string) error {
+	return purgeSQL(s.DB, normalizeGUID(guid), "?")
+}
+
+// PurgeTx is Purge inside tx, for callers that delete the player from other tables in
+// the same transaction
+func (s *SQLiteStore) PurgeTx(ctx context.Context, tx *sql.Tx, guid string) error {
+	return purgeTx(ctx, tx, normalizeGUID(guid), "?")
+}
+
+// PurgeOlderThan implements Purger
+func (s *SQLiteStore) PurgeOlderThan(d time.Duration) (int, error) {
+	return purgeSQLBefore(s.DB, time.Now().Add(-d), "?")
+}
+
+// Purge implements Purger
+func (s *PostgresStore) Purge(guid string) error {
+	return purgeSQL(s.DB, normalizeGUID(guid), "$1")
+}
+
+// PurgeTx is SQLiteStore.PurgeTx
+func (s *PostgresStore) PurgeTx(ctx context.Context, tx *sql.Tx, guid string) error {
+	return purgeTx(ctx, tx, normalizeGUID(guid), "$1")
+}
+
+// PurgeOlderThan implements Purger
+func (s *PostgresStore) PurgeOlderThan(d time.Duration) (int, error) {
+	return purgeSQLBefore(s.DB, time.Now().Add(-d), "$1")
+}
+
+// purgeSQL deletes a player from the tables SQLiteStore and PostgresStore share;
+// ph is the dialect's first placeholder
+func purgeSQL(db *sql.DB, guid, ph string) error {
+	if db == nil {
+		return errors.New("player store has no database")
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := purgeTx(context.Background(), tx, guid, ph); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func purgeTx(ctx context.Context, tx *sql.Tx, guid, ph string) error {
+	for _, table := range []string{"players", "player_names", "player_ips", "player_notes"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE guid = "+ph, guid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeSQLBefore deletes players and history entries older than cutoff
+func purgeSQLBefore(db *sql.DB, cutoff time.Time, ph string) (int, error) {
+	if db == nil {
+		return 0, errors.New("player store has no database")
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	ts := cutoff.UnixNano()
+	res, err := tx.Exec("DELETE FROM players WHERE last_seen < "+ph, ts)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	for _, stmt := range []string{
+		"DELETE FROM player_names WHERE last_seen < " + ph,
+		"DELETE FROM player_ips WHERE last_seen < " + ph,
+		"DELETE FROM player_notes WHERE at < " + ph,
+	} {
+		if _, err := tx.Exec(stmt, ts); err != nil {
+			return 0, err
+		}
+	}
+	return int(n), tx.Commit()
+}
@@ -0,0 +1,166 @@
+package tracker
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/internal/wire"
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// Player is everything recorded about one GUID
+type Player struct {
+	GUID      string    `json:"guid"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	// LastServer is where the player was seen last
+	LastServer string        `json:"last_server,omitempty"`
+	Playtime   time.Duration `json:"playtime"`
+	Names      []Seen        `json:"names"`
+	IPs        []Seen        `json:"ips"`
+}
+
+// Seen is a name or address with when it was first and last used
+type Seen struct {
+	Value     string    `json:"value"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Sighting is one player in one poll; Played is the playtime earned since the previous poll
+type Sighting struct {
+	GUID   string
+	Name   string
+	IP     string
+	Played time.Duration
+}
+
+// Attach records every successful poll of a Monitor, keeping an OnStatus that is already set
+func (t *Tracker) Attach(server string, m *rcon.Monitor) {
+	prev := m.OnStatus
+	m.OnStatus = func(st *rcon.ServerStatus) {
+		if prev != nil {
+			prev(st)
+		}
+		if err := t.Observe(server, st); err != nil && t.OnError != nil {
+			t.OnError(err)
+		}
+	}
+}
+
+// Observe records the players of a status poll. Bots and loading slots are skipped
+func (t *Tracker) Observe(server string, st *rcon.ServerStatus) error {
+	if st == nil {
+		return nil
+	}
+	if err := t.Privacy.Validate(); err != nil {
+		return err
+	}
+	at := st.RetrievedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+	gap := t.MaxGap
+	if gap <= 0 {
+		gap = 5 * time.Minute
+	}
+
+	t.mu.Lock()
+	if t.last == nil {
+		t.last = map[string]map[string]time.Time{}
+	}
+	prev := t.last[server]
+	cur := map[string]time.Time{}
+	var seen []Sighting
+	for _, p := range st.Players {
+		guid := normalizeGUID(p.GUID)
+		if p.Loading || p.Zombie || isBot(guid, p.IP) {
+			continue
+		}
+		s := Sighting{GUID: guid, Name: wire.StripColors(p.Name), IP: t.Privacy.Apply(p.IP)}
+		if before, ok := prev[guid]; ok && at.After(before) && at.Sub(before) <= gap {
+			s.Played = at.Sub(before)
+		}
+		cur[guid] = at
+		seen = append(seen, s)
+	}
+	t.last[server] = cur
+	t.mu.Unlock()
+
+	if len(seen) == 0 {
+		return nil
+	}
+	return t.Store.Record(server, at, seen)
+}
+
+// Lookup returns a player, or nil if the GUID was never seen
+func (t *Tracker) Lookup(guid string) (*Player, error) {
+	return t.Store.Lookup(normalizeGUID(guid))
+}
+
+// ByNameEver returns the players who ever used a name containing name, most recently seen first
+func (t *Tracker) ByNameEver(name string) ([]Player, error) {
+	return t.Store.ByNameEver(name)
+}
+
+// isBot reports whether a status row is a bot rather than a person
+func isBot(guid, ip string) bool {
+	switch strings.ToLower(ip) {
+	case "bot", "loopback", "localhost":
+		return true
+	}
+	return strings.Trim(guid, "0") == ""
+}
+
+// merge folds a sighting into a player record
+func (p *Player) merge(server string, at time.Time, s Sighting) {
+	if p.FirstSeen.IsZero() || at.Before(p.FirstSeen) {
+		p.FirstSeen = at
+	}
+	if !at.Before(p.LastSeen) {
+		p.LastSeen, p.LastServer = at, server
+	}
+	p.Playtime += s.Played
+	p.Names = mergeSeen(p.Names, s.Name, at)
+	if s.IP != "" {
+		p.IPs = mergeSeen(p.IPs, s.IP, at)
+	}
+}
+
+func mergeSeen(list []Seen, value string, at time.Time) []Seen {
+	if value == "" {
+		return list
+	}
+	for i := range list {
+		if list[i].Value == value {
+			if at.After(list[i].LastSeen) {
+				list[i].LastSeen = at
+			}
+			if at.Before(list[i].FirstSeen) {
+				list[i].FirstSeen = at
+			}
+			return list
+		}
+	}
+	return append(list, Seen{Value: value, FirstSeen: at, LastSeen: at})
+}
+
+// nameMatches reports whether any name of p contains the lower-cased, color-free query q
+func (p *Player) nameMatches(q string) bool {
+	for _, n := range p.Names {
+		if strings.Contains(strings.ToLower(wire.StripColors(n.Value)), q) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeName prepares a ByNameEver query
+func normalizeName(name string) string {
+	return strings.ToLower(wire.StripColors(strings.TrimSpace(name)))
+}
+
+func sortByLastSeen(players []Player) {
+	sort.Slice(players, func(i, j int) bool { return players[i].LastSeen.After(players[j].LastSeen) })
+}
@@ -0,0 +1,238 @@
+package tracker
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// SQLiteStore keeps players and notes in SQLite through database/sql. Open DB with any
+// SQLite driver (3.24 or later for upserts), e.g. modernc.org/sqlite, and call Init once
+type SQLiteStore struct {
+	DB *sql.DB
+}
+
+var sqliteSchema = []string{
+	`CREATE TABLE IF NOT EXISTS players (
+		guid TEXT PRIMARY KEY, first_seen INTEGER NOT NULL, last_seen INTEGER NOT NULL,
+		last_server TEXT NOT NULL DEFAULT '', playtime INTEGER NOT NULL DEFAULT 0)`,
+	`CREATE TABLE IF NOT EXISTS player_names (
+		guid TEXT NOT NULL, name TEXT NOT NULL, clean TEXT NOT NULL,
+		first_seen INTEGER NOT NULL, last_seen INTEGER NOT NULL, PRIMARY KEY (guid, name))`,
+	`CREATE INDEX IF NOT EXISTS player_names_clean ON player_names (clean)`,
+	`CREATE TABLE IF NOT EXISTS player_ips (
+		guid TEXT NOT NULL, ip TEXT NOT NULL,
+		first_seen INTEGER NOT NULL, last_seen INTEGER NOT NULL, PRIMARY KEY (guid, ip))`,
+	`CREATE TABLE IF NOT EXISTS player_notes (
+		guid TEXT NOT NULL, author TEXT NOT NULL, text TEXT NOT NULL,
+		server TEXT NOT NULL DEFAULT '', at INTEGER NOT NULL)`,
+	`CREATE INDEX IF NOT EXISTS player_notes_guid ON player_notes (guid)`,
+	`CREATE INDEX IF NOT EXISTS player_notes_at ON player_notes (at)`,
+}
+
+// Init creates the tables if they don't exist
+func (s *SQLiteStore) Init() error {
+	if s.DB == nil {
+		return errors.New("player store has no database")
+	}
+	for _, stmt := range sqliteSchema {
+		if _, err := s.DB.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Record implements Store
+func (s *SQLiteStore) Record(server string, at time.Time, seen []Sighting) error {
+	return s.RecordPolls([]Poll{{Server: server, At: at, Seen: seen}})
+}
+
+// RecordPolls implements BatchRecorder in one transaction
+func (s *SQLiteStore) RecordPolls(polls []Poll) error {
+	if s.DB == nil {
+		return errors.New("player store has no database")
+	}
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, poll := range polls {
+		if err := s.recordTx(tx, poll); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// recordTx merges one poll inside tx
+func (s *SQLiteStore) recordTx(tx *sql.Tx, poll Poll) error {
+	server, ts := poll.Server, poll.At.UnixNano()
+	for _, p := range poll.Seen {
+		if _, err := tx.Exec(`INSERT INTO players (guid, first_seen, last_seen, last_server, playtime) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (guid) DO UPDATE SET
+				first_seen = min(first_seen, excluded.first_seen),
+				last_server = CASE WHEN excluded.last_seen >= last_seen THEN excluded.last_server ELSE last_server END,
+				last_seen = max(last_seen, excluded.last_seen),
+				playtime = playtime + excluded.playtime`,
+			p.GUID, ts, ts, server, int64(p.Played)); err != nil {
+			return err
+		}
+		if p.Name != "" {
+			if _, err := tx.Exec(`INSERT INTO player_names (guid, name, clean, first_seen, last_seen) VALUES (?, ?, ?, ?, ?)
+				ON CONFLICT (guid, name) DO UPDATE SET
+					first_seen = min(first_seen, excluded.first_seen), last_seen = max(last_seen, excluded.last_seen)`,
+				p.GUID, p.Name, normalizeName(p.Name), ts, ts); err != nil {
+				return err
+			}
+		}
+		if p.IP != "" {
+			if _, err := tx.Exec(`INSERT INTO player_ips (guid, ip, first_seen, last_seen) VALUES (?, ?, ?, ?)
+				ON CONFLICT (guid, ip) DO UPDATE SET
+					first_seen = min(first_seen, excluded.first_seen), last_seen = max(last_seen, excluded.last_seen)`,
+				p.GUID, p.IP, ts, ts); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Lookup implements Store
+func (s *SQLiteStore) Lookup(guid string) (*Player, error) {
+	if s.DB == nil {
+		return nil, errors.New("player store has no database")
+	}
+	p := &Player{GUID: guid}
+	var first, last, played int64
+	err := s.DB.QueryRow(`SELECT first_seen, last_seen, last_server, playtime FROM players WHERE guid = ?`, guid).
+		Scan(&first, &last, &p.LastServer, &played)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.FirstSeen, p.LastSeen, p.Playtime = time.Unix(0, first), time.Unix(0, last), time.Duration(played)
+	if p.Names, err = querySeen(s.DB, `SELECT name, first_seen, last_seen FROM player_names WHERE guid = ? ORDER BY first_seen`, guid); err != nil {
+		return nil, err
+	}
+	if p.IPs, err = querySeen(s.DB, `SELECT ip, first_seen, last_seen FROM player_ips WHERE guid = ? ORDER BY first_seen`, guid); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// querySeen reads the name or IP history rows of one player
+func querySeen(db *sql.DB, query, guid string) ([]Seen, error) {
+	rows, err := db.Query(query, guid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Seen
+	for rows.Next() {
+		var v Seen
+		var first, last int64
+		if err := rows.Scan(&v.Value, &first, &last); err != nil {
+			return nil, err
+		}
+		v.FirstSeen, v.LastSeen = time.Unix(0, first), time.Unix(0, last)
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
+
+// ByNameEver implements Store
+func (s *SQLiteStore) ByNameEver(name string) ([]Player, error) {
+	if s.DB == nil {
+		return nil, errors.New("player store has no database")
+	}
+	rows, err := s.DB.Query(`SELECT DISTINCT guid FROM player_names WHERE clean LIKE ? ESCAPE '\'`, likePattern(name))
+	if err != nil {
+		return nil, err
+	}
+	return lookupAll(rows, s.Lookup)
+}
+
+// lookupAll looks up every GUID of rows, most recently seen first
+func lookupAll(rows *sql.Rows, lookup func(string) (*Player, error)) ([]Player, error) {
+	var guids []string
+	for rows.Next() {
+		var g string
+		if err := rows.Scan(&g); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		guids = append(guids, g)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	out := make([]Player, 0, len(guids))
+	for _, g := range guids {
+		p, err := lookup(g)
+		if err != nil {
+			return out, err
+		}
+		if p != nil {
+			out = append(out, *p)
+		}
+	}
+	sortByLastSeen(out)
+	return out, nil
+}
+
+// SaveNote implements Store
+func (s *SQLiteStore) SaveNote(n Note) error {
+	if s.DB == nil {
+		return errors.New("player store has no database")
+	}
+	_, err := s.DB.Exec(`INSERT INTO player_notes (guid, author, text, server, at) VALUES (?, ?, ?, ?, ?)`,
+		n.GUID, n.Author, n.Text, n.Server, n.At.UnixNano())
+	return err
+}
+
+// Notes implements Store
+func (s *SQLiteStore) Notes(guid string) ([]Note, error) {
+	return s.notes(`SELECT guid, author, text, server, at FROM player_notes WHERE guid = ? ORDER BY at`, guid)
+}
+
+// NotesBetween implements Store
+func (s *SQLiteStore) NotesBetween(from, to time.Time) ([]Note, error) {
+	return s.notes(`SELECT guid, author, text, server, at FROM player_notes WHERE at >= ? AND at <= ? ORDER BY at`, from.UnixNano(), to.UnixNano())
+}
+
+func (s *SQLiteStore) notes(query string, args ...any) ([]Note, error) {
+	if s.DB == nil {
+		return nil, errors.New("player store has no database")
+	}
+	return queryNotes(s.DB, query, args...)
+}
+
+func queryNotes(db *sql.DB, query string, args ...any) ([]Note, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Note
+	for rows.Next() {
+		var n Note
+		var at int64
+		if err := rows.Scan(&n.GUID, &n.Author, &n.Text, &n.Server, &at); err != nil {
+			return nil, err
+		}
+		n.At = time.Unix(0, at)
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+// likePattern matches names containing name, ignoring case and color codes
+func likePattern(name string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(normalizeName(name)) + "%"
+}
@@ -0,0 +1,115 @@
+// Package tracker keeps what is known about players across sessions, keyed by GUID:
+// when and where they were seen, under which names and addresses, for how long, and
+// the notes moderators leave on them
+package tracker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Yallamaztar/PlutoRCON/v2/rcon"
+)
+
+// Note is a moderator's remark about a player
+type Note struct {
+	GUID   string    `json:"guid"`
+	Author string    `json:"author"`
+	Text   string    `json:"text"`
+	Server string    `json:"server,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// ErrInvalidNote is returned for a note without a GUID or text
+var ErrInvalidNote = errors.New("invalid note")
+
+// Store persists player data. GUIDs reach it lower-cased
+type Store interface {
+	SaveNote(n Note) error
+	// Notes returns the notes of a player, oldest first
+	Notes(guid string) ([]Note, error)
+	// NotesBetween returns the notes of every player written between from and to
+	NotesBetween(from, to time.Time) ([]Note, error)
+
+	// Record merges the players seen by one poll of server
+	Record(server string, at time.Time, seen []Sighting) error
+	// Lookup returns a player, or nil if the GUID was never seen
+	Lookup(guid string) (*Player, error)
+	// ByNameEver returns the players who ever used a name containing name, ignoring
+	// case and color codes, most recently seen first
+	ByNameEver(name string) ([]Player, error)
+}
+
+// Poll is the players seen by one poll of a server, as passed to Record
+type Poll struct {
+	Server string
+	At     time.Time
+	Seen   []Sighting
+}
+
+// BatchRecorder is a store that merges many polls in one write, for bulk imports.
+// FileStore, SQLiteStore and PostgresStore implement it
+type BatchRecorder interface {
+	RecordPolls(polls []Poll) error
+}
+
+// RecordPolls records polls in one write when s is a BatchRecorder, and one Record
+// call at a time otherwise
+func RecordPolls(s Store, polls []Poll) error {
+	if b, ok := s.(BatchRecorder); ok {
+		return b.RecordPolls(polls)
+	}
+	for _, p := range polls {
+		if err := s.Record(p.Server, p.At, p.Seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Tracker is the player store used by the CLI, gateway and timelines
+type Tracker struct {
+	Store Store
+	// MaxGap is the longest time between two polls that still counts as continuous
+	// playtime (default 5m); longer gaps are treated as the player having left
+	MaxGap time.Duration
+	// OnError receives failed writes of Attach
+	OnError func(error)
+	// Privacy rewrites IPs before they are stored, for statuses of clients without
+	// rcon.WithIPPrivacy; already rewritten addresses are kept as they are
+	Privacy *rcon.IPPrivacy
+
+	mu   sync.Mutex
+	last map[string]map[string]time.Time
+}
+
+// AddNote records a note on a player
+func (t *Tracker) AddNote(guid, author, text string) (Note, error) {
+	return t.SaveNote(Note{GUID: guid, Author: author, Text: text})
+}
+
+// SaveNote records a note with every field set by the caller, stamping it if At is zero
+func (t *Tracker) SaveNote(n Note) (Note, error) {
+	n.GUID, n.Text = normalizeGUID(n.GUID), strings.TrimSpace(n.Text)
+	switch {
+	case n.GUID == "":
+		return n, fmt.Errorf("%w: missing player guid", ErrInvalidNote)
+	case n.Text == "":
+		return n, fmt.Errorf("%w: empty text", ErrInvalidNote)
+	}
+	if n.At.IsZero() {
+		n.At = time.Now()
+	}
+	return n, t.Store.SaveNote(n)
+}
+
+// Notes returns the notes of a player, oldest first
+func (t *Tracker) Notes(guid string) ([]Note, error) {
+	return t.Store.Notes(normalizeGUID(guid))
+}
+
+func normalizeGUID(guid string) string {
+	return strings.ToLower(strings.TrimSpace(guid))
+}
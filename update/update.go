@@ -0,0 +1,301 @@
+// Package update checks for new releases and replaces the running binary with a
+// signed one, so long-running daemons pick up parser fixes for new Plutonium builds
+package update
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultURL is the manifest of the latest release on GitHub
+const DefaultURL = "https://github.com/Yallamaztar/PlutoRCON/releases/latest/download/release.json"
+
+// ErrBadSignature is returned when a manifest isn't signed by the checker's key
+var ErrBadSignature = errors.New("release manifest signature does not verify")
+
+// Release is the manifest published with every release. Its signature is the
+// base64 ed25519 signature of the manifest bytes, served next to it with a .sig suffix
+type Release struct {
+	Version   string    `json:"version"`
+	Published time.Time `json:"published"`
+	Notes     string    `json:"notes,omitempty"`
+	Assets    []Asset   `json:"assets"`
+}
+
+// Asset is one binary of a release
+type Asset struct {
+	OS     string `json:"os"`
+	Arch   string `json:"arch"`
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+}
+
+// Asset returns the binary for this platform
+func (r *Release) Asset() (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.OS == runtime.GOOS && a.Arch == runtime.GOARCH {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// Checker looks for releases newer than Current. Nothing is checked unless asked:
+// call Check, or Watch from a daemon
+type Checker struct {
+	// URL of the release manifest (default DefaultURL)
+	URL string
+	// Current is the running version, e.g. v2.1.0
+	Current string
+	// PublicKey verifies manifests; without it nothing is trusted
+	PublicKey ed25519.PublicKey
+	Client    *http.Client
+}
+
+// Check returns the latest release when it is newer than Current, or nil
+func (c *Checker) Check(ctx context.Context) (*Release, error) {
+	if len(c.PublicKey) != ed25519.PublicKeySize {
+		return nil, errors.New("update checker has no release public key")
+	}
+	url := c.URL
+	if url == "" {
+		url = DefaultURL
+	}
+	manifest, err := c.get(ctx, url, 1<<20)
+	if err != nil {
+		return nil, fmt.Errorf("release manifest: %w", err)
+	}
+	sig, err := c.get(ctx, url+".sig", 4<<10)
+	if err != nil {
+		return nil, fmt.Errorf("release signature: %w", err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil || !ed25519.Verify(c.PublicKey, manifest, raw) {
+		return nil, ErrBadSignature
+	}
+	var r Release
+	if err := json.Unmarshal(manifest, &r); err != nil {
+		return nil, fmt.Errorf("release manifest: %w", err)
+	}
+	if Compare(r.Version, c.Current) <= 0 {
+		return nil, nil
+	}
+	return &r, nil
+}
+
+// Watch checks every interval (default a day) until ctx is done and calls onRelease
+// once per new version found; errors go to onError when set
+func (c *Checker) Watch(ctx context.Context, every time.Duration, onRelease func(*Release), onError func(error)) {
+	if every <= 0 {
+		every = 24 * time.Hour
+	}
+	seen := ""
+	tick := time.NewTicker(every)
+	defer tick.Stop()
+	for {
+		r, err := c.Check(ctx)
+		switch {
+		case err != nil:
+			if onError != nil && ctx.Err() == nil {
+				onError(err)
+			}
+		case r != nil && r.Version != seen:
+			seen = r.Version
+			onRelease(r)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+// Apply downloads the release's binary for this platform, checks it against the
+// signed hash and replaces the file at exe with it, e.g. os.Executable(). The running
+// process keeps the old code until restarted
+func (c *Checker) Apply(ctx context.Context, r *Release, exe string) error {
+	a, ok := r.Asset()
+	if !ok {
+		return fmt.Errorf("release %s has no binary for %s/%s", r.Version, runtime.GOOS, runtime.GOARCH)
+	}
+	want, err := hex.DecodeString(a.SHA256)
+	if err != nil || len(want) != sha256.Size {
+		return fmt.Errorf("release %s: bad sha256 for %s/%s", r.Version, a.OS, a.Arch)
+	}
+	data, err := c.get(ctx, a.URL, 256<<20)
+	if err != nil {
+		return fmt.Errorf("download %s: %w", a.URL, err)
+	}
+	if sum := sha256.Sum256(data); !bytes.Equal(sum[:], want) {
+		return fmt.Errorf("download %s: sha256 mismatch", a.URL)
+	}
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return err
+	}
+	mode := os.FileMode(0o755)
+	if fi, err := os.Stat(exe); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	next, old := exe+".new", exe+".old"
+	if err := os.WriteFile(next, data, mode); err != nil {
+		return err
+	}
+	// Windows can't overwrite a running binary but can rename it
+	os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		os.Remove(next)
+		return err
+	}
+	if err := os.Rename(next, exe); err != nil {
+		os.Rename(old, exe)
+		return err
+	}
+	os.Remove(old)
+	return nil
+}
+
+// Sign returns the .sig file contents for a manifest, for release tooling
+func Sign(manifest []byte, key ed25519.PrivateKey) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, manifest)) + "\n")
+}
+
+func (c *Checker) get(ctx context.Context, url string, limit int64) ([]byte, error) {
+	client := c.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Minute}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err == nil && int64(len(data)) > limit {
+		err = fmt.Errorf("%s is larger than %d bytes", url, limit)
+	}
+	return data, err
+}
+
+// Compare orders versions such as v2.1.0 and 2.1.0-rc.1: negative when a is older
+// than b, zero when equal, positive when newer. Versions that don't parse, such as
+// "dev", sort before every release
+func Compare(a, b string) int {
+	va, oka := parse(a)
+	vb, okb := parse(b)
+	switch {
+	case !oka && !okb:
+		return 0
+	case !oka:
+		return -1
+	case !okb:
+		return 1
+	}
+	for i := range 3 {
+		if va.n[i] != vb.n[i] {
+			if va.n[i] < vb.n[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	// a pre-release comes before its release
+	switch {
+	case va.pre == vb.pre:
+		return 0
+	case va.pre == "":
+		return 1
+	case vb.pre == "":
+		return -1
+	}
+	return comparePre(va.pre, vb.pre)
+}
+
+// comparePre orders pre-releases as semver does: field by field, numeric fields
+// by value and before alphanumeric ones, and a shorter list of equal fields first,
+// so rc.2 < rc.10 and alpha < alpha.1 < beta
+func comparePre(a, b string) int {
+	fa, fb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range min(len(fa), len(fb)) {
+		x, y := fa[i], fb[i]
+		if x == y {
+			continue
+		}
+		nx, ny := numeric(x), numeric(y)
+		switch {
+		case nx && ny:
+			// no leading zeros, so the longer number is the larger one
+			if len(x) != len(y) {
+				return cmp.Compare(len(x), len(y))
+			}
+			return strings.Compare(x, y)
+		case nx:
+			return -1
+		case ny:
+			return 1
+		}
+		return strings.Compare(x, y)
+	}
+	return cmp.Compare(len(fa), len(fb))
+}
+
+// numeric reports whether a pre-release field is a number
+func numeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+type version struct {
+	n   [3]int
+	pre string
+}
+
+func parse(s string) (version, bool) {
+	var v version
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, v.pre, _ = strings.Cut(s, "-")
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return v, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v.n[i] = n
+	}
+	return v, true
+}
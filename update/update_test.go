@@ -0,0 +1,110 @@
+package update
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v2.1.0", "2.1.0", 0},
+		{"v2.1.0", "v2.0.9", 1},
+		{"v2.9.0", "v2.10.0", -1},
+		{"v2.1.0-rc.1", "v2.1.0", -1},
+		{"v2.1.0+build.5", "v2.1.0", 0},
+		{"dev", "v0.0.1", -1},
+		{"dev", "unknown", 0},
+
+		// the precedence example of the semver spec
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-alpha.beta", "1.0.0-beta", -1},
+		{"1.0.0-beta", "1.0.0-beta.2", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-beta.11", "1.0.0-rc.1", -1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+
+		{"v2.1.0-rc.10", "v2.1.0-rc.2", 1},
+		{"v2.1.0-rc.2", "v2.1.0-rc.2", 0},
+	}
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := Compare(tt.b, tt.a); got != -tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestCheckAndApply(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary := []byte("new plutorcon")
+	sum := sha256.Sum256(binary)
+	files := map[string][]byte{"/bin": binary}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	manifest, _ := json.Marshal(Release{Version: "v2.2.0", Assets: []Asset{
+		{OS: runtime.GOOS, Arch: runtime.GOARCH, URL: srv.URL + "/bin", SHA256: hex.EncodeToString(sum[:])},
+	}})
+	files["/latest.json"], files["/latest.json.sig"] = manifest, Sign(manifest, priv)
+	c := &Checker{URL: srv.URL + "/latest.json", Current: "v2.1.0", PublicKey: pub}
+
+	r, err := c.Check(context.Background())
+	if err != nil || r == nil || r.Version != "v2.2.0" {
+		t.Fatalf("Check = %+v, %v, want v2.2.0", r, err)
+	}
+	exe := filepath.Join(t.TempDir(), "plutorcon")
+	if err := os.WriteFile(exe, []byte("old plutorcon"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Apply(context.Background(), r, exe); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(exe); !bytes.Equal(got, binary) {
+		t.Errorf("binary after Apply %q, want %q", got, binary)
+	}
+
+	files["/bin"] = []byte("tampered")
+	if err := c.Apply(context.Background(), r, exe); err == nil || !strings.Contains(err.Error(), "sha256 mismatch") {
+		t.Errorf("Apply of a tampered binary = %v, want a sha256 mismatch", err)
+	}
+
+	c.Current = "v2.2.0"
+	if r, err := c.Check(context.Background()); r != nil || err != nil {
+		t.Errorf("Check when up to date = %+v, %v, want nothing", r, err)
+	}
+
+	files["/latest.json"] = bytes.Replace(manifest, []byte("v2.2.0"), []byte("v9.9.9"), 1)
+	if _, err := c.Check(context.Background()); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Check of a tampered manifest = %v, want ErrBadSignature", err)
+	}
+}